@@ -0,0 +1,24 @@
+package api
+
+import "time"
+
+// Function that returns the day of an all day date as its midnight in UTC.
+// All day dates are converted between calendars as these floating dates, which do not depend
+// on any time zone, not even the one of the server, so the days of multi-day events are kept.
+// Dates given as an instant are the day they start on the given location: outlook gives them
+// as the midnight of its time zone, or the first hour of the day when DST starts at midnight
+func floatingDate(t time.Time, location *time.Location) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	day := t.In(timeZoneOrUTC(location))
+	if !isMidnight(day) && isMidnight(t.UTC()) {
+		day = t.UTC()
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Function that returns whether the time is the midnight of its location
+func isMidnight(t time.Time) bool {
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+}
@@ -0,0 +1,112 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/TetAlius/GoSyncMyCalendars/api"
+)
+
+// Function that sets the local time zone of the server to one far from UTC, returning how to restore it
+func farLocal(t *testing.T) func() {
+	local := time.Local
+	location, err := time.LoadLocation("Pacific/Kiritimati")
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	time.Local = location
+	return func() {
+		time.Local = local
+	}
+}
+
+func TestAllDay_GoogleToOutlook(t *testing.T) {
+	defer farLocal(t)()
+	testCases := []struct {
+		timeZone string
+		start    time.Time
+		end      time.Time
+		windows  string
+	}{
+		// leap day
+		{"UTC", time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC), time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC), "UTC"},
+		// multi-day events across the start and the end of DST
+		{"Europe/Madrid", time.Date(2018, 3, 24, 0, 0, 0, 0, time.UTC), time.Date(2018, 3, 27, 0, 0, 0, 0, time.UTC), "Romance Standard Time"},
+		{"America/Los_Angeles", time.Date(2018, 11, 3, 0, 0, 0, 0, time.UTC), time.Date(2018, 11, 5, 0, 0, 0, 0, time.UTC), "Pacific Standard Time"},
+	}
+	for _, testCase := range testCases {
+		location, _ := time.LoadLocation(testCase.timeZone)
+		googleEvent, outlookEvent := reminderEvents()
+		googleEvent.IsAllDay = true
+		googleEvent.Start = &api.GoogleTime{Date: testCase.start, IsAllDay: true, TimeZone: location}
+		googleEvent.End = &api.GoogleTime{Date: testCase.end, IsAllDay: true, TimeZone: location}
+		err := api.ConvertEvent(googleEvent, outlookEvent)
+		if err != nil {
+			t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+		}
+		if !outlookEvent.IsAllDay {
+			t.Fatalf("something went wrong. Expected all day event found %v", outlookEvent.IsAllDay)
+		}
+		for date, expected := range map[*api.OutlookDateTimeTimeZone]time.Time{outlookEvent.Start: testCase.start, outlookEvent.End: testCase.end} {
+			contents, err := json.Marshal(date)
+			if err != nil {
+				t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+			}
+			// midnight to midnight on the time zone of the calendar
+			expectedJSON := fmt.Sprintf(`{"DateTime":"%s","TimeZone":"%s"}`, expected.Format("2006-01-02T15:04:05"), testCase.windows)
+			if string(contents) != expectedJSON {
+				t.Fatalf("something went wrong. Expected %s found %s", expectedJSON, contents)
+			}
+		}
+	}
+}
+
+func TestAllDay_OutlookToGoogle(t *testing.T) {
+	defer farLocal(t)()
+	testCases := []struct {
+		timeZone string
+		start    string
+		end      string
+		date     string
+		endDate  string
+	}{
+		// given as floating dates
+		{"Pacific Standard Time", "2018-03-25T00:00:00", "2018-03-26T00:00:00", "2018-03-25", "2018-03-26"},
+		{"Tokyo Standard Time", "2020-02-28T00:00:00", "2020-03-02T00:00:00", "2020-02-28", "2020-03-02"},
+		// given as the midnight of their time zone, across the start of DST
+		{"Romance Standard Time", "2018-03-24T23:00:00", "2018-03-25T22:00:00", "2018-03-25", "2018-03-26"},
+		// DST started at midnight, so the day started at 01:00
+		{"E. South America Standard Time", "2018-11-04T03:00:00", "2018-11-05T02:00:00", "2018-11-04", "2018-11-05"},
+	}
+	for _, testCase := range testCases {
+		_, teardown := setupStandIn(map[string]string{"outlook/events/id": "/events/%s"}, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(fmt.Sprintf(`{"Id":"event","Type":"SingleInstance","Subject":"Holidays","Body":{"ContentType":"Text","Content":""},"IsAllDay":true,"OriginalStartTimeZone":"%[1]s","OriginalEndTimeZone":"%[1]s","Start":{"DateTime":"%[2]s","TimeZone":"UTC"},"End":{"DateTime":"%[3]s","TimeZone":"UTC"}}`, testCase.timeZone, testCase.start, testCase.end)))
+		})
+		calendar := api.RetrieveOutlookCalendar("calendar", "", &api.OutlookAccount{TokenType: "Bearer", AccessToken: "token", AnchorMailbox: "travis@example.com"})
+		outlookEvent, err := calendar.GetEvent("event")
+		teardown()
+		if err != nil {
+			t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+		}
+		googleEvent := new(api.GoogleEvent)
+		googleEvent.SetCalendar(api.RetrieveGoogleCalendar("primary", "", &api.GoogleAccount{}))
+		err = api.ConvertEvent(outlookEvent, googleEvent)
+		if err != nil {
+			t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+		}
+		for date, expected := range map[*api.GoogleTime]string{googleEvent.Start: testCase.date, googleEvent.End: testCase.endDate} {
+			contents, err := json.Marshal(date)
+			if err != nil {
+				t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+			}
+			// exclusive dates without time
+			expectedJSON := fmt.Sprintf(`{"date":"%s"}`, expected)
+			if string(contents) != expectedJSON {
+				t.Fatalf("something went wrong. Expected %s found %s on %s", expectedJSON, contents, testCase.timeZone)
+			}
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -8,6 +9,7 @@ import (
 
 	"os"
 
+	"github.com/TetAlius/GoSyncMyCalendars/convert"
 	"github.com/getsentry/raven-go"
 	"github.com/google/uuid"
 )
@@ -19,9 +21,12 @@ const (
 	Updated
 	Deleted
 
-	// Different kinds of accounts used
+	// Different kinds of accounts used, each one registered by its provider
 	GOOGLE  = 1
 	OUTLOOK = 2
+	CALDAV  = 3
+	ICS     = 4
+	GRAPH   = 5
 
 	// maximum number of wrong requests in synchronization
 	maxBackoff = 5
@@ -44,6 +49,8 @@ func parseTag(tag string) (string, tagOptions) {
 type AccountManager interface {
 	// Method to refresh the access to the account
 	Refresh() error
+	// Method like Refresh that is stopped once the given context is done
+	RefreshContext(context.Context) error
 
 	// Method that retrieves all calendars from account
 	GetAllCalendars() ([]CalendarManager, error)
@@ -51,6 +58,10 @@ type AccountManager interface {
 	GetCalendar(string) (CalendarManager, error)
 	// Method that returns the principal calendar from the account
 	GetPrimaryCalendar() (CalendarManager, error)
+	// Methods like GetAllCalendars, GetCalendar and GetPrimaryCalendar that are stopped once the given context is done
+	GetAllCalendarsContext(context.Context) ([]CalendarManager, error)
+	GetCalendarContext(context.Context, string) (CalendarManager, error)
+	GetPrimaryCalendarContext(context.Context) (CalendarManager, error)
 	// Method that format the authorization request
 	AuthorizationRequest() string
 	// Method that returns the mail associated with the account
@@ -90,11 +101,18 @@ type CalendarManager interface {
 	Delete() error
 	// Method that creates the calendar
 	Create() error
+	// Methods like Update, Delete and Create that are stopped once the given context is done
+	UpdateContext(context.Context) error
+	DeleteContext(context.Context) error
+	CreateContext(context.Context) error
 
 	// Method that returns all events inside the calendar
 	GetAllEvents() ([]EventManager, error)
 	// Method that returns a single event given the ID
 	GetEvent(string) (EventManager, error)
+	// Methods like GetAllEvents and GetEvent that are stopped once the given context is done
+	GetAllEventsContext(context.Context) ([]EventManager, error)
+	GetEventContext(context.Context, string) (EventManager, error)
 
 	// Method that returns the ID of the calendar
 	GetID() string
@@ -110,6 +128,66 @@ type CalendarManager interface {
 	SetUUID(string)
 	// Method that creates an empty event
 	CreateEmptyEvent(string) EventManager
+	// Method that sets the window of days whose events are synchronized
+	SetSyncWindow(SyncWindow)
+	// Method that returns the window of days whose events are synchronized
+	GetSyncWindow() SyncWindow
+	// Method that sets the options of the relation of the calendar
+	SetSyncOptions(SyncOptions)
+	// Method that returns the options of the relation of the calendar
+	GetSyncOptions() SyncOptions
+}
+
+// Interface for calendars that can return only the events changed
+// since a previous synchronization
+type IncrementalCalendarManager interface {
+	CalendarManager
+	// Method that returns the events changed since the given token and the token for the next call.
+	// With an empty token all events are returned, or the ones inside a range of dates
+	// if the calendar is a BoundedIncrementalCalendarManager.
+	// Deleted events are returned with the Deleted state.
+	// If the token is no longer valid a SyncTokenExpiredError is returned
+	GetChangedEvents(string) ([]EventManager, string, error)
+	// Method like GetChangedEvents that is stopped once the given context is done
+	GetChangedEventsContext(context.Context, string) ([]EventManager, string, error)
+}
+
+// Interface for incremental calendars whose changed events, asked without a token,
+// are only the ones inside a range of dates. Events missing from them may still exist
+type BoundedIncrementalCalendarManager interface {
+	IncrementalCalendarManager
+	// Method that returns whether the changed events asked without a token are bounded
+	HasBoundedChanges() bool
+}
+
+// Interface for calendars that return their events split in pages
+type PagedCalendarManager interface {
+	CalendarManager
+	// Method that calls the given function with every page of events of the calendar.
+	// No more pages are retrieved once the function returns an error
+	ForEachEventPage(func([]EventManager) error) error
+	// Method like ForEachEventPage that is stopped once the given context is done
+	ForEachEventPageContext(context.Context, func([]EventManager) error) error
+}
+
+// Interface for calendars whose recurring series can have instances
+// changed or cancelled on their own
+type InstanceCalendarManager interface {
+	CalendarManager
+	// Method that returns the instance of the given series that originally started at the given time.
+	// If the instance does not exist or it is cancelled a NotFoundError is returned
+	GetInstance(string, time.Time) (EventManager, error)
+	// Method like GetInstance that is stopped once the given context is done
+	GetInstanceContext(context.Context, string, time.Time) (EventManager, error)
+}
+
+// Interface for events that can be an instance of a recurring series
+type InstanceEventManager interface {
+	EventManager
+	// Method that returns the ID of the series master. It is empty if the event is not an instance
+	GetSeriesID() string
+	// Method that returns the start that the instance had inside its series
+	GetOriginalStart() time.Time
 }
 
 // Interface for event that defines the needed method to work inside the project
@@ -125,6 +203,10 @@ type EventManager interface {
 	Update() error
 	// Method that deletes the event
 	Delete() error
+	// Methods like Create, Update and Delete that are stopped once the given context is done
+	CreateContext(context.Context) error
+	UpdateContext(context.Context) error
+	DeleteContext(context.Context) error
 	// Method that returns the ID of the event
 	GetID() string
 
@@ -136,6 +218,9 @@ type EventManager interface {
 
 	// Method that returns the last update date
 	GetUpdatedAt() (time.Time, error)
+	// Method that returns the start and end dates of the event.
+	// The end is zero for recurring events
+	GetTimeRange() (time.Time, time.Time, error)
 	// Method that returns the state of the event
 	GetState() int
 	// Method that sets the state of the event
@@ -161,6 +246,10 @@ type SubscriptionManager interface {
 	Renew() error
 	// Method that deletes subscription
 	Delete() error
+	// Methods like Subscribe, Renew and Delete that are stopped once the given context is done
+	SubscribeContext(context.Context, CalendarManager) error
+	RenewContext(context.Context) error
+	DeleteContext(context.Context) error
 	// Method that returns the ID of the subscription
 	GetID() string
 	// Method that returns the UUID of the subscription
@@ -186,6 +275,86 @@ func (err RefreshError) Error() string {
 	return fmt.Sprintf("code: %s. message: %s", err.Code, err.Message)
 }
 
+// Specific error for a sync token that is no longer valid.
+// A full synchronization must be done to get a new one
+type SyncTokenExpiredError struct {
+	ID string
+}
+
+// Method implementing error interface
+func (err *SyncTokenExpiredError) Error() string {
+	return fmt.Sprintf("sync token of calendar %s is no longer valid", err.ID)
+}
+
+// Specific error for a write on a calendar that can only be read
+type ReadOnlyError struct {
+	ID string
+}
+
+// Method implementing error interface
+func (err ReadOnlyError) Error() string {
+	return fmt.Sprintf("%s is read-only and can not be written", err.ID)
+}
+
+// Function that calls the given function with every page of events of the calendar.
+// Calendars that are not paged give all their events as a single page
+func ForEachEventPage(calendar CalendarManager, fn func([]EventManager) error) error {
+	return ForEachEventPageContext(context.Background(), calendar, fn)
+}
+
+// Function like ForEachEventPage that stops retrieving pages once the given context is done
+func ForEachEventPageContext(ctx context.Context, calendar CalendarManager, fn func([]EventManager) error) error {
+	if paged, ok := calendar.(PagedCalendarManager); ok {
+		return paged.ForEachEventPageContext(ctx, fn)
+	}
+	events, err := calendar.GetAllEventsContext(ctx)
+	if err != nil {
+		return err
+	}
+	return fn(events)
+}
+
+// Function that converts an event to the model of another one, writing it
+// as the options of the relation of the calendar of the origin say
+func ConvertEvent(from EventManager, to EventManager) (err error) {
+	return ConvertEventContext(context.Background(), from, to)
+}
+
+// Function like ConvertEvent that stops the requests done to read the origin once the given context is done
+func ConvertEventContext(ctx context.Context, from EventManager, to EventManager) (err error) {
+	err = convert.Convert(from, to)
+	if err != nil {
+		return
+	}
+	options := from.GetCalendar().GetSyncOptions()
+	if writer, ok := to.(notificationsWriter); ok {
+		writer.notifyAttendees(options.NotifyAttendees)
+	}
+	if writer, ok := to.(attendeesWriter); ok {
+		writer.writeAttendees(options.Attendees)
+	}
+	writeReminders(ctx, from, to)
+	writeFreeBusy(from, to, options.FreeBusyFallbacks)
+	writeCategories(from, to, options.CategoryColors)
+	writeMeetingLinks(from, to)
+	writeAttachments(ctx, from, to, options.Attachments)
+	return
+}
+
+// Function that deletes an event synced with another one, notifying its
+// attendees only if the options of the relation of the calendar of the origin say so
+func DeleteEvent(from EventManager, to EventManager) (err error) {
+	return DeleteEventContext(context.Background(), from, to)
+}
+
+// Function like DeleteEvent that is stopped once the given context is done
+func DeleteEventContext(ctx context.Context, from EventManager, to EventManager) (err error) {
+	if writer, ok := to.(notificationsWriter); ok {
+		writer.notifyAttendees(from.GetCalendar().GetSyncOptions().NotifyAttendees)
+	}
+	return to.DeleteContext(ctx)
+}
+
 // Function to know in which state the event is
 func GetChangeType(onCloud bool, onDB bool) int {
 	if onCloud && !onDB {
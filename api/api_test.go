@@ -1,11 +1,21 @@
 package api_test
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"time"
 
 	"github.com/TetAlius/GoSyncMyCalendars/api"
+	"github.com/TetAlius/GoSyncMyCalendars/util"
 )
 
+func init() {
+	// retries of the tests do not wait as long as the ones to the providers
+	util.DefaultProviderClient.BaseDelay = time.Millisecond
+	util.DefaultProviderClient.MaxDelay = 10 * time.Millisecond
+}
+
 func setup() (outAcc *api.OutlookAccount, gooAcc *api.GoogleAccount) {
 	outAcc = &api.OutlookAccount{
 		TokenType:         os.Getenv("OUTLOOK_TOKEN_TYPE"),
@@ -28,6 +38,30 @@ func setup() (outAcc *api.OutlookAccount, gooAcc *api.GoogleAccount) {
 	return
 }
 
-func setupApiRoot() {
-	os.Setenv("API_ROOT", os.Getenv("API_ROOT_TEST"))
+func setupRoutes() {
+	util.ResetRoutes()
+}
+
+// Function that starts a stand-in server that answers the requests to the provider,
+// so the API can be tested without network.
+// Routes map the name of the route to the path that the handler will receive,
+// the rest of routes of the providers point to an unreachable address
+func setupStandIn(routes map[string]string, handler http.HandlerFunc) (server *httptest.Server, teardown func()) {
+	server = httptest.NewServer(handler)
+	unreachableRoutes()
+	for name, path := range routes {
+		util.SetRoute(name, server.URL+path)
+	}
+	return server, func() {
+		util.ResetRoutes()
+		server.Close()
+	}
+}
+
+// Function that points the base URLs of all providers to an unreachable address,
+// so the requests to them fail
+func unreachableRoutes() {
+	for _, base := range util.RouteBases() {
+		util.SetBaseURL(base, "http://127.0.0.1:0")
+	}
 }
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Name of the block of the description where the attachments of the synced events are listed
+const attachmentsBlock = "Attachments"
+
+// Attachment of an event, synced as a link that can be opened from the other calendars
+type Attachment struct {
+	Name string
+	// Link to open or download the attachment. Empty if it is only listed by its name
+	URL string
+	// Content type of the attachment, empty if not known
+	ContentType string
+}
+
+// Method that returns the line that describes the attachment inside a list
+func (attachment Attachment) String() string {
+	if len(attachment.URL) == 0 {
+		return fmt.Sprintf("- %s", attachment.Name)
+	}
+	return fmt.Sprintf("- %s: %s", attachment.Name, attachment.URL)
+}
+
+// Method that returns whether the attachment is a file of google drive,
+// which google events hold as attachments instead of links
+func (attachment Attachment) isDriveFile() bool {
+	link, err := url.Parse(attachment.URL)
+	if err != nil {
+		return false
+	}
+	return link.Scheme == "https" && (link.Host == "drive.google.com" || link.Host == "docs.google.com")
+}
+
+// Limits of the attachments synced by a relation.
+// Attachments out of them are only listed by their name on the synced events
+type AttachmentLimits struct {
+	// Content types synced, like application/pdf or image/*. None means all of them
+	ContentTypes []string
+}
+
+// Method that returns whether the attachment is inside the limits.
+// Content types not known are not limited
+func (limits AttachmentLimits) allow(attachment Attachment) bool {
+	if len(limits.ContentTypes) == 0 || len(attachment.ContentType) == 0 {
+		return true
+	}
+	contentType := strings.ToLower(strings.TrimSpace(strings.Split(attachment.ContentType, ";")[0]))
+	for _, allowed := range limits.ContentTypes {
+		allowed = strings.ToLower(allowed)
+		if allowed == contentType || allowed == "*/*" {
+			return true
+		}
+		if strings.HasSuffix(allowed, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(allowed, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Function that returns the content types given separated by commas, ignoring the empty ones
+func ParseContentTypes(value string) (contentTypes []string) {
+	for _, contentType := range strings.Split(value, ",") {
+		contentType = strings.TrimSpace(contentType)
+		if len(contentType) != 0 {
+			contentTypes = append(contentTypes, contentType)
+		}
+	}
+	return
+}
+
+// Interface for events whose attachments are written on the events synced with them
+type attachmentsReader interface {
+	// Method that returns the attachments of the event
+	readAttachments(context.Context) []Attachment
+}
+
+// Interface for events that are written with the attachments of the events synced with them
+type attachmentsWriter interface {
+	// Method that writes the attachments. No attachments removes the ones written before
+	writeAttachments(context.Context, []Attachment)
+}
+
+// Function that writes the attachments of an event on another one, if both have them.
+// Attachments out of the limits lose their link, so they are only listed by their name
+func writeAttachments(ctx context.Context, from EventManager, to EventManager, limits AttachmentLimits) {
+	reader, ok := from.(attachmentsReader)
+	if !ok {
+		return
+	}
+	writer, ok := to.(attachmentsWriter)
+	if !ok {
+		return
+	}
+	attachments := reader.readAttachments(ctx)
+	for i, attachment := range attachments {
+		if !limits.allow(attachment) {
+			attachments[i].URL = ""
+		}
+	}
+	writer.writeAttachments(ctx, attachments)
+}
+
+// Function that returns the description with the block of the attachments.
+// The attachments replace the ones already listed, so a changed attachment is not repeated
+func describeAttachments(description string, attachments []Attachment) string {
+	lines := make([]string, len(attachments))
+	for i, attachment := range attachments {
+		lines[i] = attachment.String()
+	}
+	return setDescriptionBlock(description, attachmentsBlock, strings.Join(lines, "\n"))
+}
+
+// Function that returns the attachments listed on the block of the description,
+// so attachments of calendars that can not hold them are passed on to the next ones
+func attachmentsFromDescription(description string) (attachments []Attachment) {
+	for _, line := range strings.Split(descriptionBlock(description, attachmentsBlock), "\n") {
+		line = strings.TrimPrefix(strings.TrimSpace(line), "- ")
+		if len(line) == 0 {
+			continue
+		}
+		attachment := Attachment{Name: line}
+		if i := strings.LastIndex(line, ": "); i != -1 && strings.Contains(line[i+2:], "://") {
+			attachment = Attachment{Name: line[:i], URL: line[i+2:]}
+		}
+		attachments = append(attachments, attachment)
+	}
+	return
+}
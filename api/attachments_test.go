@@ -0,0 +1,136 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/TetAlius/GoSyncMyCalendars/api"
+)
+
+const outlookEventLink = "https://outlook.office.com/owa/?itemid=event"
+
+func TestAttachments_OutlookToGoogle(t *testing.T) {
+	googleEvent, outlookEvent := reminderEvents()
+	outlookEvent.GetCalendar().SetSyncOptions(api.SyncOptions{Attachments: api.AttachmentLimits{ContentTypes: []string{"application/pdf", "image/*"}}})
+	outlookEvent.Body = &api.OutlookItemBody{Description: "Agenda"}
+	outlookEvent.Link = outlookEventLink
+	outlookEvent.Attachments = []api.OutlookAttachment{
+		{Name: "agenda.pdf", ContentType: "application/pdf", Size: 1 << 20},
+		{Name: "logo.png", ContentType: "image/png", Size: 1 << 10, IsInline: true},
+		{Name: "Budget", OdataType: "#Microsoft.OutlookServices.ReferenceAttachment", SourceURL: "https://drive.google.com/file/d/budget/view"},
+		{Name: "scan.jpg", ContentType: "image/jpeg", Size: 20 << 20},
+		// out of the limits
+		{Name: "photos.zip", ContentType: "application/zip", Size: 1 << 20},
+	}
+	err := api.ConvertEvent(outlookEvent, googleEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	expectedAttachments := []api.GoogleAttachment{{FileURL: "https://drive.google.com/file/d/budget/view", Title: "Budget"}}
+	if !reflect.DeepEqual(googleEvent.Attachments, expectedAttachments) {
+		t.Fatalf("something went wrong. Expected %v found %v", expectedAttachments, googleEvent.Attachments)
+	}
+	// files of outlook have no link to download them, so they are listed by their name
+	expected := "Agenda\n\n--- Attachments ---\n- agenda.pdf\n- scan.jpg\n- photos.zip\n--- End of attachments ---"
+	if googleEvent.Description != expected {
+		t.Fatalf("something went wrong. Expected %q found %q", expected, googleEvent.Description)
+	}
+
+	// attachments removed from the origin are removed from the synced event
+	outlookEvent.Attachments = []api.OutlookAttachment{}
+	outlookEvent.Body.Description = "Agenda"
+	err = api.ConvertEvent(outlookEvent, googleEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if googleEvent.Attachments == nil || len(googleEvent.Attachments) != 0 || googleEvent.Description != "Agenda" {
+		t.Fatalf("something went wrong. Expected no attachments found %v and description %q", googleEvent.Attachments, googleEvent.Description)
+	}
+}
+
+func TestAttachments_GoogleToOutlook(t *testing.T) {
+	googleEvent, outlookEvent := reminderEvents()
+	googleEvent.Description = "Agenda\n\n--- Attachments ---\n- agenda.pdf\n- report.pdf: " + outlookEventLink + "\n- Notes: minutes.txt\n--- End of attachments ---"
+	googleEvent.Attachments = []api.GoogleAttachment{{FileURL: "https://docs.google.com/document/d/notes/edit", Title: "Meeting notes", MimeType: "application/vnd.google-apps.document"}}
+	outlookEvent.Link = outlookEventLink
+	outlookEvent.Attachments = []api.OutlookAttachment{{Name: "agenda.pdf", ContentType: "application/pdf", Size: 1 << 20}}
+	err := api.ConvertEvent(googleEvent, outlookEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	// the files of the outlook event itself are not listed on it
+	expected := "Agenda\n\n--- Attachments ---\n- Meeting notes: https://docs.google.com/document/d/notes/edit\n- Notes: minutes.txt\n--- End of attachments ---"
+	if outlookEvent.Body.Description != expected {
+		t.Fatalf("something went wrong. Expected %q found %q", expected, outlookEvent.Body.Description)
+	}
+
+	// the links listed on outlook are google attachments again
+	googleEvent, _ = reminderEvents()
+	err = api.ConvertEvent(outlookEvent, googleEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(googleEvent.Attachments) != 1 || googleEvent.Attachments[0].FileURL != "https://docs.google.com/document/d/notes/edit" || googleEvent.Attachments[0].Title != "Meeting notes" {
+		t.Fatalf("something went wrong. Expected the document as attachment found %v", googleEvent.Attachments)
+	}
+	expected = "Agenda\n\n--- Attachments ---\n- agenda.pdf\n- Notes: minutes.txt\n--- End of attachments ---"
+	if googleEvent.Description != expected {
+		t.Fatalf("something went wrong. Expected %q found %q", expected, googleEvent.Description)
+	}
+}
+
+func TestAttachments_OutlookRetrieved(t *testing.T) {
+	var requests int
+	_, teardown := setupStandIn(map[string]string{"outlook/events/id/attachments": "/events/%s/attachments"}, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/events/event/attachments" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// the contents of the files are not downloaded
+		if r.URL.Query().Get("$select") != "Name,ContentType,Size,IsInline,SourceUrl" {
+			t.Errorf("something went wrong. Expected properties of the attachments selected found %q", r.URL.Query().Get("$select"))
+		}
+		w.Write([]byte(`{"value":[{"@odata.type":"#Microsoft.OutlookServices.FileAttachment","Id":"file","Name":"agenda.pdf","ContentType":"application/pdf","Size":2048,"IsInline":false}]}`))
+	})
+	defer teardown()
+	googleEvent, outlookEvent := reminderEvents()
+	outlookEvent.ID = "event"
+	outlookEvent.Link = outlookEventLink
+	outlookEvent.HasAttachments = true
+	err := api.ConvertEvent(outlookEvent, googleEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if requests != 1 {
+		t.Fatalf("something went wrong. Expected 1 request found %d", requests)
+	}
+	expected := "--- Attachments ---\n- agenda.pdf\n--- End of attachments ---"
+	if googleEvent.Description != expected {
+		t.Fatalf("something went wrong. Expected %q found %q", expected, googleEvent.Description)
+	}
+
+	// the files of the event are retrieved to know which of the names listed are its own
+	_, outlookEvent = reminderEvents()
+	outlookEvent.ID = "event"
+	googleEvent.Description = "Agenda\n\n--- Attachments ---\n- agenda.pdf\n- minutes.txt\n--- End of attachments ---"
+	err = api.ConvertEvent(googleEvent, outlookEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if requests != 2 {
+		t.Fatalf("something went wrong. Expected 2 requests found %d", requests)
+	}
+	expected = "Agenda\n\n--- Attachments ---\n- minutes.txt\n--- End of attachments ---"
+	if outlookEvent.Body.Description != expected {
+		t.Fatalf("something went wrong. Expected %q found %q", expected, outlookEvent.Body.Description)
+	}
+	// the files retrieved are not written back with the event
+	data, _ := json.Marshal(outlookEvent)
+	if strings.Contains(string(data), "agenda.pdf") {
+		t.Fatalf("something went wrong. Expected event written without its files found %s", data)
+	}
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Types of attendee
+const (
+	AttendeeRequired = "required"
+	AttendeeOptional = "optional"
+	AttendeeResource = "resource"
+)
+
+// Responses of an attendee to an invitation
+const (
+	ResponseNeedsAction = "needsAction"
+	ResponseAccepted    = "accepted"
+	ResponseTentative   = "tentative"
+	ResponseDeclined    = "declined"
+)
+
+// Name of the block of the description where attendees are listed
+const attendeesBlock = "Attendees"
+
+// Attendee of an event, as given by the Deconverter of the attendees of each calendar
+type Attendee struct {
+	Email     string
+	Name      string
+	Type      string
+	Response  string
+	Organizer bool
+}
+
+// Method that returns the line that describes the attendee inside a list
+func (attendee Attendee) String() string {
+	buffer := bytes.NewBufferString("- ")
+	if len(attendee.Name) != 0 {
+		buffer.WriteString(fmt.Sprintf("%s <%s>", attendee.Name, attendee.Email))
+	} else {
+		buffer.WriteString(attendee.Email)
+	}
+	details := []string{attendee.Type}
+	if attendee.Organizer {
+		details = append(details, "organizer")
+	}
+	details = append(details, attendee.Response)
+	buffer.WriteString(fmt.Sprintf(" (%s)", strings.Join(details, ", ")))
+	return buffer.String()
+}
+
+// Function that returns the attendees given by a Deconverter
+func attendeesFrom(m interface{}) ([]Attendee, error) {
+	switch x := m.(type) {
+	case nil:
+		return nil, nil
+	case []Attendee:
+		return x, nil
+	default:
+		return nil, fmt.Errorf("incorrect type of field attendees: %T", x)
+	}
+}
+
+// Function that returns the description with the attendees as the mode says.
+// Listed attendees replace the ones already listed, so lists synced back are not repeated
+func describeAttendees(description string, attendees []Attendee, mode AttendeesMode) string {
+	if mode != AttendeesInBody || len(attendees) == 0 {
+		return setDescriptionBlock(description, attendeesBlock, "")
+	}
+	lines := make([]string, len(attendees))
+	for i, attendee := range attendees {
+		lines[i] = attendee.String()
+	}
+	return setDescriptionBlock(description, attendeesBlock, strings.Join(lines, "\n"))
+}
+
+// Function that returns the lines that start and end the block of the description with the given name
+func descriptionBlockMarkers(name string) (start string, end string) {
+	return fmt.Sprintf("--- %s ---", name), fmt.Sprintf("--- End of %s ---", strings.ToLower(name))
+}
+
+// Function that returns the content of the block of the description with the given name
+func descriptionBlock(description string, name string) string {
+	start, end := descriptionBlockMarkers(name)
+	i := strings.Index(description, start)
+	if i < 0 {
+		return ""
+	}
+	content := description[i+len(start):]
+	if j := strings.Index(content, end); j >= 0 {
+		content = content[:j]
+	}
+	return strings.Trim(content, "\n")
+}
+
+// Function that replaces the block of the description with the given name by the content.
+// Empty content removes the block
+func setDescriptionBlock(description string, name string, content string) string {
+	start, end := descriptionBlockMarkers(name)
+	if i := strings.Index(description, start); i >= 0 {
+		rest := description[i:]
+		if j := strings.Index(rest, end); j >= 0 {
+			rest = rest[j+len(end):]
+		} else {
+			rest = ""
+		}
+		description = strings.TrimRight(description[:i], "\n") + rest
+	}
+	if len(content) == 0 {
+		return description
+	}
+	block := fmt.Sprintf("%s\n%s\n%s", start, content, end)
+	if len(description) == 0 {
+		return block
+	}
+	return fmt.Sprintf("%s\n\n%s", strings.TrimRight(description, "\n"), block)
+}
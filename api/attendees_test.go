@@ -0,0 +1,118 @@
+package api_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TetAlius/GoSyncMyCalendars/api"
+	"github.com/TetAlius/GoSyncMyCalendars/convert"
+)
+
+func meetingGoogleEvent(options api.SyncOptions) *api.GoogleEvent {
+	calendar := api.RetrieveGoogleCalendar("primary", "", &api.GoogleAccount{TokenType: "Bearer", AccessToken: "token"})
+	calendar.SetSyncOptions(options)
+	event := &api.GoogleEvent{
+		Subject:     "Meeting",
+		Description: "Agenda",
+		Start:       &api.GoogleTime{DateTime: recurrenceStart, TimeZone: time.UTC},
+		End:         &api.GoogleTime{DateTime: recurrenceStart.Add(time.Hour), TimeZone: time.UTC},
+		Attendees: api.GoogleAttendees{
+			{Email: "organizer@example.com", Name: "Organizer", Organizer: true, ResponseStatus: "accepted"},
+			{Email: "optional@example.com", Optional: true, ResponseStatus: "tentative"},
+			{Email: "room@example.com", Name: "Room", Resource: true, ResponseStatus: "declined"},
+			{Email: "required@example.com"},
+		},
+	}
+	event.SetCalendar(calendar)
+	return event
+}
+
+func TestAttendees_Convert(t *testing.T) {
+	outlookEvent := new(api.OutlookEvent)
+	err := convert.Convert(meetingGoogleEvent(api.SyncOptions{}), outlookEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	expected := api.OutlookAttendees{
+		{EmailAddress: &api.OutlookEmailAddress{Address: "organizer@example.com", Name: "Organizer"}, Status: &api.OutlookStatus{Response: "Organizer"}, Type: "Required"},
+		{EmailAddress: &api.OutlookEmailAddress{Address: "optional@example.com"}, Status: &api.OutlookStatus{Response: "TentativelyAccepted"}, Type: "Optional"},
+		{EmailAddress: &api.OutlookEmailAddress{Address: "room@example.com", Name: "Room"}, Status: &api.OutlookStatus{Response: "Declined"}, Type: "Resource"},
+		{EmailAddress: &api.OutlookEmailAddress{Address: "required@example.com"}, Status: &api.OutlookStatus{Response: "NotResponded"}, Type: "Required"},
+	}
+	if !reflect.DeepEqual(outlookEvent.Attendees, expected) {
+		t.Fatalf("something went wrong. Expected %+v found %+v", expected, outlookEvent.Attendees)
+	}
+
+	googleEvent := new(api.GoogleEvent)
+	err = convert.Convert(outlookEvent, googleEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	original := meetingGoogleEvent(api.SyncOptions{}).Attendees
+	original[3].ResponseStatus = "needsAction"
+	if !reflect.DeepEqual(googleEvent.Attendees, original) {
+		t.Fatalf("something went wrong. Expected %+v found %+v", original, googleEvent.Attendees)
+	}
+}
+
+func TestAttendees_ConvertEvent(t *testing.T) {
+	// attendees copied are real attendees of the synced event
+	outlookEvent := new(api.OutlookEvent)
+	err := api.ConvertEvent(meetingGoogleEvent(api.SyncOptions{Attendees: api.AttendeesCopied, NotifyAttendees: true}), outlookEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(outlookEvent.Attendees) != 4 || outlookEvent.Body.Description != "Agenda" {
+		t.Fatalf("something went wrong. Expected 4 attendees and the description found %d and %s", len(outlookEvent.Attendees), outlookEvent.Body.Description)
+	}
+
+	// outlook can not copy attendees without sending them meeting requests
+	outlookEvent = new(api.OutlookEvent)
+	err = api.ConvertEvent(meetingGoogleEvent(api.SyncOptions{Attendees: api.AttendeesCopied}), outlookEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(outlookEvent.Attendees) != 0 || !strings.Contains(outlookEvent.Body.Description, "--- Attendees ---") {
+		t.Fatalf("something went wrong. Expected attendees listed on the body found %d attendees and %s", len(outlookEvent.Attendees), outlookEvent.Body.Description)
+	}
+
+	// attendees listed on the body are not attendees of the synced event
+	outlookEvent = new(api.OutlookEvent)
+	err = api.ConvertEvent(meetingGoogleEvent(api.SyncOptions{}), outlookEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(outlookEvent.Attendees) != 0 {
+		t.Fatalf("something went wrong. Expected no attendees found %d", len(outlookEvent.Attendees))
+	}
+	description := outlookEvent.Body.Description
+	for _, line := range []string{"Agenda", "- Organizer <organizer@example.com> (required, organizer, accepted)", "- optional@example.com (optional, tentative)", "- Room <room@example.com> (resource, declined)", "- required@example.com (required, needsAction)"} {
+		if !strings.Contains(description, line) {
+			t.Fatalf("something went wrong. Expected %s on description found %s", line, description)
+		}
+	}
+
+	// the list synced back is removed, so it is not repeated
+	outlookEvent.SetCalendar(api.RetrieveOutlookCalendar("calendar", "", &api.OutlookAccount{}))
+	googleEvent := new(api.GoogleEvent)
+	err = api.ConvertEvent(outlookEvent, googleEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if googleEvent.Description != "Agenda" {
+		t.Fatalf("something went wrong. Expected only the agenda found %s", googleEvent.Description)
+	}
+	googleEvent.SetCalendar(meetingGoogleEvent(api.SyncOptions{}).GetCalendar())
+	googleEvent.Attendees = meetingGoogleEvent(api.SyncOptions{}).Attendees
+	googleEvent.Description = description
+	outlookEvent = new(api.OutlookEvent)
+	err = api.ConvertEvent(googleEvent, outlookEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if outlookEvent.Body.Description != description {
+		t.Fatalf("something went wrong. Expected %s found %s", description, outlookEvent.Body.Description)
+	}
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
+	log "github.com/TetAlius/GoSyncMyCalendars/logger"
+	"github.com/TetAlius/GoSyncMyCalendars/util"
+)
+
+// Interface for calendars whose events can be written with batch requests
+type batchCalendar interface {
+	// Method that does the requests given as a single batch request, returning their responses in the same order
+	doBatch(context.Context, []util.BatchRequest) ([]util.BatchResponse, error)
+	// Method that returns the maximum number of requests inside a batch
+	batchSize() int
+}
+
+// Interface for events that can be written inside a batch request
+type batchEvent interface {
+	// Method that returns the request that writes the event as the operation says: Created, Updated or Deleted
+	batchRequest(int) (util.BatchRequest, error)
+	// Method that reads the event back from the contents of the response of its request
+	readBatchResponse(int, []byte) error
+}
+
+// Function that converts each event given to the model of the one on the same position, and
+// writes them as the operation says: creating, updating or deleting them.
+// Events that can not be converted are not written. Returns the error of each event, nil if it was written
+func SyncEvents(ctx context.Context, operation int, from []EventManager, to []EventManager) (errs []error) {
+	errs = make([]error, len(to))
+	var toWrite []EventManager
+	var positions []int
+	for i := range to {
+		if operation == Deleted {
+			if writer, ok := to[i].(notificationsWriter); ok {
+				writer.notifyAttendees(from[i].GetCalendar().GetSyncOptions().NotifyAttendees)
+			}
+		} else if errs[i] = ConvertEventContext(ctx, from[i], to[i]); errs[i] != nil {
+			continue
+		}
+		toWrite = append(toWrite, to[i])
+		positions = append(positions, i)
+	}
+	for i, err := range WriteEvents(ctx, operation, toWrite) {
+		errs[positions[i]] = err
+	}
+	return
+}
+
+// Function that writes the events given as the operation says: creating, updating or deleting them.
+// Events of calendars that take batch requests are written together with as few requests as possible.
+// Returns the error of each event on the same position, nil if it was written
+func WriteEvents(ctx context.Context, operation int, events []EventManager) (errs []error) {
+	errs = make([]error, len(events))
+	// events are grouped by their calendar, as a batch only writes on one account
+	groups := make(map[string][]int)
+	var order []string
+	for i, event := range events {
+		key := event.GetCalendar().GetUUID()
+		if len(key) == 0 {
+			key = fmt.Sprintf("%s/%s", event.GetCalendar().GetAccount().Mail(), event.GetCalendar().GetID())
+		}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+	for _, key := range order {
+		positions := groups[key]
+		batch, ok := events[positions[0]].GetCalendar().(batchCalendar)
+		if !ok {
+			for _, i := range positions {
+				errs[i] = writeEvent(ctx, operation, events[i])
+			}
+			continue
+		}
+		for start := 0; start < len(positions); start += batch.batchSize() {
+			end := start + batch.batchSize()
+			if end > len(positions) {
+				end = len(positions)
+			}
+			writeBatch(ctx, batch, operation, events, positions[start:end], errs)
+		}
+	}
+	return
+}
+
+// Function that writes the events on the given positions with a single batch request,
+// leaving the error of each one on its position
+func writeBatch(ctx context.Context, batch batchCalendar, operation int, events []EventManager, positions []int, errs []error) {
+	var requests []util.BatchRequest
+	var batched []int
+	for _, i := range positions {
+		event, ok := events[i].(batchEvent)
+		if !ok {
+			errs[i] = writeEvent(ctx, operation, events[i])
+			continue
+		}
+		request, err := event.batchRequest(operation)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		requests = append(requests, request)
+		batched = append(batched, i)
+	}
+	if len(requests) == 0 {
+		return
+	}
+	responses, err := batch.doBatch(ctx, requests)
+	if err != nil {
+		if ctx.Err() != nil || !batchNotProcessed(err) {
+			// the provider may have written the events already, so writing them
+			// again could create them twice
+			for _, i := range batched {
+				errs[i] = err
+			}
+			return
+		}
+		// events are written on their own if the batch was rejected before being done
+		log.Warningf("batch of %d events could not be written, writing them one by one: %s", len(batched), err.Error())
+		for _, i := range batched {
+			errs[i] = writeEvent(ctx, operation, events[i])
+		}
+		return
+	}
+	for n, i := range batched {
+		response := responses[n]
+		switch {
+		case response.Err != nil && response.Retry:
+			// the request can succeed later, so it is done again with the retries of the client
+			errs[i] = writeEvent(ctx, operation, events[i])
+		case response.Err != nil:
+			errs[i] = response.Err
+		default:
+			errs[i] = events[i].(batchEvent).readBatchResponse(operation, response.Contents)
+		}
+	}
+}
+
+// Function that returns if the error of a batch request means that none of its requests were done:
+// the batch was rejected by the rate limits or the service was unavailable
+func batchNotProcessed(err error) bool {
+	switch err := err.(type) {
+	case *customErrors.RateLimitError:
+		return true
+	case *customErrors.ServerError:
+		return err.Status == http.StatusServiceUnavailable
+	}
+	return false
+}
+
+// Function that writes a single event as the operation says
+func writeEvent(ctx context.Context, operation int, event EventManager) error {
+	switch operation {
+	case Created:
+		return event.CreateContext(ctx)
+	case Updated:
+		return event.UpdateContext(ctx)
+	case Deleted:
+		return event.DeleteContext(ctx)
+	}
+	return errors.New(fmt.Sprintf("operation %d not supported for event %s", operation, event.GetID()))
+}
@@ -0,0 +1,179 @@
+package api_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"testing"
+
+	"github.com/TetAlius/GoSyncMyCalendars/api"
+	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
+)
+
+func TestWriteEvents_GoogleBatch(t *testing.T) {
+	var batches int
+	var single []string
+	_, teardown := setupStandIn(map[string]string{"google/batch": "/batch/calendar/v3", "google/calendars/id/events": "/calendars/%s/events"}, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/batch/calendar/v3" {
+			single = append(single, r.Method+" "+r.URL.Path)
+			w.Write([]byte(`{"id":"single"}`))
+			return
+		}
+		batches++
+		_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		reader := multipart.NewReader(r.Body, params["boundary"])
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		for i := 0; ; i++ {
+			part, err := reader.NextPart()
+			if err != nil {
+				break
+			}
+			request, err := http.ReadRequest(bufio.NewReader(part))
+			if err != nil || request.Method != http.MethodPost || request.URL.Path != "/calendars/calendar/events" || request.URL.Query().Get("sendUpdates") != "none" {
+				t.Fatalf("something went wrong. Expected create inside batch found %v with error %v", request, err)
+			}
+			response, _ := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {"application/http"}, "Content-ID": {"<response-" + part.Header.Get("Content-ID")[1:]}})
+			// responses are given in the order of the requests
+			switch i {
+			case 0:
+				fmt.Fprint(response, "HTTP/1.1 200 OK\r\nContent-Type: application/json\r\n\r\n{\"id\":\"batched\"}")
+			case 1:
+				fmt.Fprint(response, "HTTP/1.1 400 Bad Request\r\nContent-Type: application/json\r\n\r\n{\"error\":{\"code\":400,\"message\":\"Invalid\"}}")
+			default:
+				fmt.Fprint(response, "HTTP/1.1 503 Service Unavailable\r\n\r\n")
+			}
+		}
+		writer.Close()
+		w.Header().Set("Content-Type", "multipart/mixed; boundary="+writer.Boundary())
+		w.Write(body.Bytes())
+	})
+	defer teardown()
+	calendar := api.RetrieveGoogleCalendar("calendar", "uuid", &api.GoogleAccount{TokenType: "Bearer", AccessToken: "token"})
+	var events []api.EventManager
+	for i := 0; i < 3; i++ {
+		event := &api.GoogleEvent{Subject: fmt.Sprintf("event %d", i)}
+		event.SetCalendar(calendar)
+		events = append(events, event)
+	}
+
+	errs := api.WriteEvents(context.Background(), api.Created, events)
+	if batches != 1 {
+		t.Fatalf("something went wrong. Expected 1 batch request found %d", batches)
+	}
+	if errs[0] != nil || events[0].GetID() != "batched" {
+		t.Fatalf("something went wrong. Expected event created inside batch found %s with error %v", events[0].GetID(), errs[0])
+	}
+	if _, ok := errs[1].(*customErrors.StatusError); !ok {
+		t.Fatalf("something went wrong. Expected StatusError found %v", errs[1])
+	}
+	// events that can succeed later are written again on their own
+	if errs[2] != nil || events[2].GetID() != "single" || len(single) != 1 || single[0] != "POST /calendars/calendar/events" {
+		t.Fatalf("something went wrong. Expected event created on its own found %s with error %v after %v", events[2].GetID(), errs[2], single)
+	}
+}
+
+func TestWriteEvents_FailedBatch(t *testing.T) {
+	for status, expectedSingle := range map[int]int{http.StatusServiceUnavailable: 2, http.StatusInternalServerError: 0, http.StatusGatewayTimeout: 0} {
+		var single int
+		_, teardown := setupStandIn(map[string]string{"google/batch": "/batch/calendar/v3", "google/calendars/id/events": "/calendars/%s/events"}, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/batch/calendar/v3" {
+				single++
+				w.Write([]byte(`{"id":"single"}`))
+				return
+			}
+			w.WriteHeader(status)
+		})
+		calendar := api.RetrieveGoogleCalendar("calendar", "uuid", &api.GoogleAccount{TokenType: "Bearer", AccessToken: "token"})
+		var events []api.EventManager
+		for i := 0; i < 2; i++ {
+			event := &api.GoogleEvent{Subject: fmt.Sprintf("event %d", i)}
+			event.SetCalendar(calendar)
+			events = append(events, event)
+		}
+
+		// events are only created on their own if the batch was surely not done
+		errs := api.WriteEvents(context.Background(), api.Created, events)
+		teardown()
+		if single != expectedSingle {
+			t.Fatalf("something went wrong. Expected %d single requests after %d found %d", expectedSingle, status, single)
+		}
+		for _, err := range errs {
+			if _, ok := err.(*customErrors.ServerError); expectedSingle == 0 && !ok || expectedSingle > 0 && err != nil {
+				t.Fatalf("something went wrong. Expected result of %d single requests after %d found %v", expectedSingle, status, err)
+			}
+		}
+	}
+}
+
+func TestWriteEvents_GraphBatch(t *testing.T) {
+	var batches []int
+	_, teardown := setupStandIn(map[string]string{"graph/batch": "/v1.0/$batch", "graph/events/id": "/v1.0/me/events/%s"}, func(w http.ResponseWriter, r *http.Request) {
+		batch := struct {
+			Requests []struct {
+				ID     string          `json:"id"`
+				Method string          `json:"method"`
+				URL    string          `json:"url"`
+				Body   json.RawMessage `json:"body"`
+			} `json:"requests"`
+		}{}
+		contents, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(contents, &batch)
+		batches = append(batches, len(batch.Requests))
+		var responses []map[string]interface{}
+		// responses can come in any order
+		for i := len(batch.Requests) - 1; i >= 0; i-- {
+			request := batch.Requests[i]
+			if request.Method != http.MethodPatch || request.URL != "/me/events/event-"+request.ID {
+				t.Fatalf("something went wrong. Expected update of event-%s found %s %s", request.ID, request.Method, request.URL)
+			}
+			var body map[string]interface{}
+			json.Unmarshal(request.Body, &body)
+			body["id"] = "event-" + request.ID
+			responses = append(responses, map[string]interface{}{"id": request.ID, "status": 200, "body": body})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"responses": responses})
+	})
+	defer teardown()
+	calendar := api.RetrieveGraphCalendar("calendar", "uuid", api.RetrieveGraphAccount("Bearer", "refresh", "travis@example.com", api.GRAPH, "token"))
+	var events []api.EventManager
+	for i := 0; i < 25; i++ {
+		event := &api.GraphEvent{ID: fmt.Sprintf("event-%d", i%20+1), Subject: fmt.Sprintf("event %d", i)}
+		event.SetCalendar(calendar)
+		events = append(events, event)
+	}
+
+	// graph takes up to 20 requests on each batch
+	errs := api.WriteEvents(context.Background(), api.Updated, events)
+	if len(batches) != 2 || batches[0] != 20 || batches[1] != 5 {
+		t.Fatalf("something went wrong. Expected batches of 20 and 5 requests found %v", batches)
+	}
+	for i, err := range errs {
+		if err != nil || events[i].(*api.GraphEvent).Subject != fmt.Sprintf("event %d", i) {
+			t.Fatalf("something went wrong. Expected event %d updated found %v with error %v", i, events[i], err)
+		}
+	}
+}
+
+func TestSyncEvents_ReadOnly(t *testing.T) {
+	unreachableRoutes()
+	defer setupRoutes()
+	from := new(api.GoogleEvent)
+	json.Unmarshal([]byte(`{"id":"event","summary":"event","start":{"dateTime":"2018-03-08T10:00:00Z"},"end":{"dateTime":"2018-03-08T11:00:00Z"}}`), from)
+	from.SetCalendar(api.RetrieveGoogleCalendar("primary", "uuid", &api.GoogleAccount{}))
+	to := new(api.ICSEvent)
+	to.SetCalendar(api.RetrieveICSCalendar("feed", "uuid", api.RetrieveICSAccount("", "http://127.0.0.1:0/feed.ics", "feed", api.ICS, "")))
+
+	// calendars without batches write each event on its own
+	errs := api.SyncEvents(context.Background(), api.Created, []api.EventManager{from}, []api.EventManager{to})
+	if _, ok := errs[0].(api.ReadOnlyError); len(errs) != 1 || !ok {
+		t.Fatalf("something went wrong. Expected ReadOnlyError found %v", errs)
+	}
+}
@@ -0,0 +1,328 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	log "github.com/TetAlius/GoSyncMyCalendars/logger"
+	"github.com/TetAlius/GoSyncMyCalendars/util"
+	"github.com/google/uuid"
+)
+
+// CalDAV accounts are added with their credentials and their calendars are polled
+func init() {
+	RegisterProvider(&Provider{
+		Kind: CALDAV,
+		Name: "caldav",
+		RetrieveAccount: func(tokenType string, refreshToken string, email string, kind int, accessToken string, serverURL string, credential string) AccountManager {
+			return RetrieveCalDAVAccount(email, kind, serverURL, credential)
+		},
+		RetrieveCalendar: func(ID string, uid string, account AccountManager) CalendarManager {
+			return RetrieveCalDAVCalendar(ID, uid, account.(*CalDAVAccount))
+		},
+		NewEvent: func(ID string) EventManager {
+			return &CalDAVEvent{ID: ID}
+		},
+		NewSubscription: func() SubscriptionManager {
+			return NewPollingSubscription(uuid.New().String())
+		},
+		RetrieveSubscription: func(ID string, uid uuid.UUID, calendar CalendarManager, typ string, resourceID string) SubscriptionManager {
+			return RetrievePollingSubscription(ID, uid, calendar, typ)
+		},
+	})
+}
+
+const (
+	caldavPrincipalBody = `<?xml version="1.0" encoding="utf-8"?><d:propfind xmlns:d="DAV:"><d:prop><d:current-user-principal/></d:prop></d:propfind>`
+	caldavHomeSetBody   = `<?xml version="1.0" encoding="utf-8"?><d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:prop><c:calendar-home-set/></d:prop></d:propfind>`
+	caldavCalendarsBody = `<?xml version="1.0" encoding="utf-8"?><d:propfind xmlns:d="DAV:" xmlns:cs="http://calendarserver.org/ns/"><d:prop><d:resourcetype/><d:displayname/><cs:getctag/></d:prop></d:propfind>`
+)
+
+// Function that creates a CalDAVAccount discovering the calendar home set
+// of the user from the server URL given
+//
+// PROPFIND {server}
+//
+// PROPFIND {principal}
+func NewCalDAVAccount(server string, username string, password string) (a *CalDAVAccount, err error) {
+	return NewCalDAVAccountContext(context.Background(), server, username, password)
+}
+
+// Function like NewCalDAVAccount that stops the discovery once the given context is done
+func NewCalDAVAccountContext(ctx context.Context, server string, username string, password string) (a *CalDAVAccount, err error) {
+	if len(server) == 0 || len(username) == 0 {
+		return nil, errors.New("server and username must be given for a caldav account")
+	}
+	a = new(CalDAVAccount)
+	a.TokenType = "Basic"
+	a.AccessToken = base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", username, password)))
+	a.Email = username
+	if !strings.Contains(username, "@") {
+		serverURL, err := url.Parse(server)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("error parsing caldav server url: %s", err.Error()))
+		}
+		a.Email = fmt.Sprintf("%s@%s", username, serverURL.Hostname())
+	}
+	a.Kind = CALDAV
+
+	principal, err := a.propfindHref(ctx, server, caldavPrincipalBody, func(prop caldavProp) string {
+		return prop.CurrentUserPrincipal.Href
+	})
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error discovering caldav principal: %s", err.Error()))
+	}
+	if len(principal) == 0 {
+		principal = server
+	}
+	home, err := a.propfindHref(ctx, principal, caldavHomeSetBody, func(prop caldavProp) string {
+		return prop.CalendarHomeSet.Href
+	})
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error discovering caldav calendar home: %s", err.Error()))
+	}
+	if len(home) == 0 {
+		home = principal
+	}
+	a.HomeURL = home
+	return
+}
+
+// Function that returns a CalDAVAccount given specific info.
+// The credential is the encrypted one stored on DB. If it can not be decrypted
+// the account has no credentials, so it fails once it is refreshed
+func RetrieveCalDAVAccount(email string, kind int, serverURL string, credential string) (a *CalDAVAccount) {
+	a = new(CalDAVAccount)
+	a.TokenType = "Basic"
+	a.HomeURL = serverURL
+	a.Email = email
+	a.Kind = kind
+	if len(credential) == 0 {
+		return
+	}
+	accessToken, err := util.DecryptCredential(credential)
+	if err != nil {
+		log.Errorf("error decrypting credential of caldav account %s: %s", email, err.Error())
+		return
+	}
+	a.AccessToken = accessToken
+	return
+}
+
+// Method that does a PROPFIND with depth 0 and returns the absolute URL
+// of the href selected from the response
+func (a *CalDAVAccount) propfindHref(ctx context.Context, route string, body string, selectHref func(caldavProp) string) (href string, err error) {
+	responses, err := a.propfind(ctx, route, "0", body)
+	if err != nil {
+		return
+	}
+	for _, response := range responses {
+		prop, ok := response.prop()
+		if !ok || len(selectHref(prop)) == 0 {
+			continue
+		}
+		return a.resolve(route, selectHref(prop))
+	}
+	return
+}
+
+// Method that does a PROPFIND request to the given route
+//
+// PROPFIND {route}
+func (a *CalDAVAccount) propfind(ctx context.Context, route string, depth string, body string) (responses []caldavResponse, err error) {
+	headers := make(map[string]string)
+	headers["Authorization"] = a.AuthorizationRequest()
+	headers["Depth"] = depth
+	headers["Content-Type"] = "application/xml; charset=utf-8"
+
+	contents, status, _, err := util.DoRawRequestContext(ctx, "PROPFIND", route, strings.NewReader(body), headers, nil)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error doing propfind for email %s. %s", a.Mail(), err.Error()))
+	}
+	if err = createCalDAVResponseError(status, contents); err != nil {
+		return nil, err
+	}
+	multistatus := new(caldavMultistatus)
+	err = xml.Unmarshal(contents, multistatus)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error unmarshalling caldav response: %s", err.Error()))
+	}
+	return multistatus.Responses, nil
+}
+
+// Method that resolves an href against the given base
+func (a *CalDAVAccount) resolve(base string, href string) (string, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("error parsing caldav url: %s", err.Error()))
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("error parsing caldav href: %s", err.Error()))
+	}
+	return baseURL.ResolveReference(ref).String(), nil
+}
+
+// Method to refresh the access to the caldav account.
+// CalDAV uses basic authentication, so there is nothing to refresh
+func (a *CalDAVAccount) Refresh() (err error) {
+	return a.RefreshContext(context.Background())
+}
+
+// Method like Refresh that is stopped once the given context is done
+func (a *CalDAVAccount) RefreshContext(ctx context.Context) (err error) {
+	if len(a.AccessToken) == 0 || len(a.HomeURL) == 0 {
+		return errors.New(fmt.Sprintf("caldav account %s has no credentials", a.Mail()))
+	}
+	return
+}
+
+// Method that retrieves all calendars from account
+//
+// PROPFIND {home}
+func (a *CalDAVAccount) GetAllCalendars() (calendars []CalendarManager, err error) {
+	return a.GetAllCalendarsContext(context.Background())
+}
+
+// Method like GetAllCalendars that is stopped once the given context is done
+func (a *CalDAVAccount) GetAllCalendarsContext(ctx context.Context) (calendars []CalendarManager, err error) {
+	log.Debugln("getAllCalendars caldav")
+	responses, err := a.propfind(ctx, a.HomeURL, "1", caldavCalendarsBody)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error getting all calendars for email %s. %s", a.Mail(), err.Error()))
+	}
+	for _, response := range responses {
+		calendar, ok := a.calendarFromResponse(response)
+		if ok {
+			calendars = append(calendars, calendar)
+		}
+	}
+	return
+}
+
+// Method that retrieves one calendar given an ID
+//
+// PROPFIND {calendarID}
+func (a *CalDAVAccount) GetCalendar(calendarID string) (calendar CalendarManager, err error) {
+	return a.GetCalendarContext(context.Background(), calendarID)
+}
+
+// Method like GetCalendar that is stopped once the given context is done
+func (a *CalDAVAccount) GetCalendarContext(ctx context.Context, calendarID string) (calendar CalendarManager, err error) {
+	log.Debugln("getCalendar caldav")
+	if len(calendarID) == 0 {
+		return nil, errors.New("no ID for calendar was given")
+	}
+	route, err := a.resolve(a.HomeURL, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	responses, err := a.propfind(ctx, route, "0", caldavCalendarsBody)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error getting calendar for email %s. %s", a.Mail(), err.Error()))
+	}
+	for _, response := range responses {
+		cal, ok := a.calendarFromResponse(response)
+		if ok {
+			return cal, nil
+		}
+	}
+	return nil, CalDAVError{Code: 404, Message: fmt.Sprintf("%s is not a calendar", calendarID)}
+}
+
+// Method that returns the principal calendar from the account.
+// CalDAV has no principal calendar, so the first one is used
+func (a *CalDAVAccount) GetPrimaryCalendar() (calendar CalendarManager, err error) {
+	return a.GetPrimaryCalendarContext(context.Background())
+}
+
+// Method like GetPrimaryCalendar that is stopped once the given context is done
+func (a *CalDAVAccount) GetPrimaryCalendarContext(ctx context.Context) (calendar CalendarManager, err error) {
+	log.Debugln("getPrimaryCalendar caldav")
+	calendars, err := a.GetAllCalendarsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(calendars) == 0 {
+		return nil, errors.New(fmt.Sprintf("no calendars found for email %s", a.Mail()))
+	}
+	return calendars[0], nil
+}
+
+// Method that creates a calendar from a PROPFIND response if it is one
+func (a *CalDAVAccount) calendarFromResponse(response caldavResponse) (calendar *CalDAVCalendar, ok bool) {
+	prop, ok := response.prop()
+	if !ok || prop.ResourceType.Calendar == nil {
+		return nil, false
+	}
+	calendar = &CalDAVCalendar{ID: response.Href, Name: prop.DisplayName, CTag: prop.CTag}
+	calendar.SetAccount(a)
+	return calendar, true
+}
+
+// Method that format the authorization request
+func (a *CalDAVAccount) AuthorizationRequest() string {
+	return fmt.Sprintf("%s %s", a.TokenType, a.AccessToken)
+}
+
+// Method that returns the mail associated with the account
+func (a *CalDAVAccount) Mail() string {
+	return a.Email
+}
+
+// Method that sets which kind of account is
+func (a *CalDAVAccount) SetKind(kind int) {
+	a.Kind = kind
+}
+
+// Method that returns the token type
+func (a *CalDAVAccount) GetTokenType() string {
+	return a.TokenType
+}
+
+// Method that returns the refresh token.
+// CalDAV has no refresh tokens, so it is always empty
+func (a *CalDAVAccount) GetRefreshToken() string {
+	return ""
+}
+
+// Method that returns the kind of the account
+func (a *CalDAVAccount) GetKind() int {
+	return a.Kind
+}
+
+// Method that returns the access token.
+// The credentials of CalDAV are not stored as a token, so it is always empty
+func (a *CalDAVAccount) GetAccessToken() string {
+	return ""
+}
+
+// Method that returns the URL of the calendar home set
+func (a *CalDAVAccount) GetServerURL() string {
+	return a.HomeURL
+}
+
+// Method that returns the credentials of the account encrypted, to be stored on DB
+func (a *CalDAVAccount) GetCredential() (credential string, err error) {
+	return util.EncryptCredential(a.AccessToken)
+}
+
+// Method that returns the internal ID given to the account on DB
+func (a *CalDAVAccount) GetInternalID() int {
+	return a.InternID
+}
+
+// Method that sets all synced calendars associated with the account
+func (a *CalDAVAccount) SetCalendars(calendars []CalendarManager) {
+	a.calendars = calendars
+}
+
+// Method that returns all synced calendars associated with the account
+func (a *CalDAVAccount) GetSyncCalendars() []CalendarManager {
+	return a.calendars
+}
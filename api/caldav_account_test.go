@@ -0,0 +1,146 @@
+package api_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/TetAlius/GoSyncMyCalendars/api"
+)
+
+func TestNewCalDAVAccount(t *testing.T) {
+	standIn := newCalDAVStandIn()
+	defer standIn.Close()
+
+	// good call discovering the calendar home
+	account, err := api.NewCalDAVAccount(standIn.URL(), caldavUser, caldavPassword)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if account.GetServerURL() != standIn.URL()+caldavHome {
+		t.Fatalf("something went wrong. Expected %s found %s", standIn.URL()+caldavHome, account.GetServerURL())
+	}
+	// credentials are not given as tokens, as they would be stored as they are
+	if len(account.GetAccessToken()) != 0 || len(account.GetRefreshToken()) != 0 {
+		t.Fatalf("something went wrong. Expected empty tokens found %s and %s", account.GetAccessToken(), account.GetRefreshToken())
+	}
+	if account.GetKind() != api.CALDAV {
+		t.Fatalf("something went wrong. Expected kind %d found %d", api.CALDAV, account.GetKind())
+	}
+	if account.Mail() != caldavUser+"@127.0.0.1" {
+		t.Fatalf("something went wrong. Expected mail %s found %s", caldavUser+"@127.0.0.1", account.Mail())
+	}
+
+	// wrong call with bad credentials
+	_, err = api.NewCalDAVAccount(standIn.URL(), caldavUser, "wrong")
+	if err == nil {
+		t.Fatal("something went wrong. Expected error found nil")
+	}
+	// wrong call without server
+	_, err = api.NewCalDAVAccount("", caldavUser, caldavPassword)
+	if err == nil {
+		t.Fatal("something went wrong. Expected error found nil")
+	}
+}
+
+func TestCalDAVAccount_Refresh(t *testing.T) {
+	standIn := newCalDAVStandIn()
+	defer standIn.Close()
+	account, err := api.NewCalDAVAccount(standIn.URL(), caldavUser, caldavPassword)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	key := os.Getenv("CREDENTIALS_KEY")
+	defer os.Setenv("CREDENTIALS_KEY", key)
+	os.Setenv("CREDENTIALS_KEY", "credentials-secret")
+	credential, err := account.GetCredential()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if strings.Contains(credential, account.AccessToken) {
+		t.Fatalf("something went wrong. Expected credential encrypted found %s", credential)
+	}
+	retrieved := api.RetrieveCalDAVAccount(account.Mail(), account.GetKind(), account.GetServerURL(), credential)
+	err = retrieved.Refresh()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+
+	// credential encrypted with another key
+	os.Setenv("CREDENTIALS_KEY", "other-secret")
+	err = api.RetrieveCalDAVAccount(account.Mail(), account.GetKind(), account.GetServerURL(), credential).Refresh()
+	if err == nil {
+		t.Fatal("something went wrong. Expected error found nil")
+	}
+
+	err = (&api.CalDAVAccount{}).Refresh()
+	if err == nil {
+		t.Fatal("something went wrong. Expected error found nil")
+	}
+}
+
+func TestCalDAVAccount_GetAllCalendars(t *testing.T) {
+	standIn := newCalDAVStandIn()
+	defer standIn.Close()
+	account, err := api.NewCalDAVAccount(standIn.URL(), caldavUser, caldavPassword)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+
+	calendars, err := account.GetAllCalendars()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	// home collection must be ignored
+	if len(calendars) != 1 {
+		t.Fatalf("something went wrong. Expected 1 calendar found %d", len(calendars))
+	}
+	if calendars[0].GetName() != "Default" {
+		t.Fatalf("something went wrong. Expected name Default found %s", calendars[0].GetName())
+	}
+}
+
+func TestCalDAVAccount_GetPrimaryCalendar(t *testing.T) {
+	standIn := newCalDAVStandIn()
+	defer standIn.Close()
+	account, err := api.NewCalDAVAccount(standIn.URL(), caldavUser, caldavPassword)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+
+	calendar, err := account.GetPrimaryCalendar()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if calendar.GetID() != caldavHome+"default/" {
+		t.Fatalf("something went wrong. Expected %s found %s", caldavHome+"default/", calendar.GetID())
+	}
+}
+
+func TestCalDAVAccount_GetCalendar(t *testing.T) {
+	standIn := newCalDAVStandIn()
+	defer standIn.Close()
+	account, err := api.NewCalDAVAccount(standIn.URL(), caldavUser, caldavPassword)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+
+	// good call to get calendar
+	calendar, err := account.GetCalendar(caldavHome + "default/")
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if calendar.GetName() != "Default" {
+		t.Fatalf("something went wrong. Expected name Default found %s", calendar.GetName())
+	}
+
+	// wrong call to get calendar
+	_, err = account.GetCalendar(caldavHome + "wrong/")
+	if err == nil {
+		t.Fatal("something went wrong. Expected error found nil")
+	}
+	_, err = account.GetCalendar("")
+	if err == nil {
+		t.Fatal("something went wrong. Expected error found nil")
+	}
+}
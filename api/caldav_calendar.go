@@ -0,0 +1,287 @@
+package api
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
+	log "github.com/TetAlius/GoSyncMyCalendars/logger"
+	"github.com/TetAlius/GoSyncMyCalendars/util"
+	"github.com/google/uuid"
+)
+
+const (
+	caldavEventsBody = `<?xml version="1.0" encoding="utf-8"?><c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:prop><d:getetag/><c:calendar-data/></d:prop><c:filter><c:comp-filter name="VCALENDAR"><c:comp-filter name="VEVENT">%s</c:comp-filter></c:comp-filter></c:filter></c:calendar-query>`
+	caldavRangeBody  = `<c:time-range%s%s/>`
+	caldavEventBody  = `<?xml version="1.0" encoding="utf-8"?><c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:prop><d:getetag/><c:calendar-data/></d:prop><c:filter><c:comp-filter name="VCALENDAR"><c:comp-filter name="VEVENT"><c:prop-filter name="UID"><c:text-match collation="i;octet">%s</c:text-match></c:prop-filter></c:comp-filter></c:comp-filter></c:filter></c:calendar-query>`
+	caldavMkBody     = `<?xml version="1.0" encoding="utf-8"?><c:mkcalendar xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:set><d:prop><d:displayname>%s</d:displayname></d:prop></d:set></c:mkcalendar>`
+	caldavPatchBody  = `<?xml version="1.0" encoding="utf-8"?><d:propertyupdate xmlns:d="DAV:"><d:set><d:prop><d:displayname>%s</d:displayname></d:prop></d:set></d:propertyupdate>`
+)
+
+// Method that returns a CalDAVCalendar given specific info
+func RetrieveCalDAVCalendar(ID string, uid string, account *CalDAVAccount) *CalDAVCalendar {
+	cal := new(CalDAVCalendar)
+	cal.ID = ID
+	cal.account = account
+	cal.uuid = uid
+	return cal
+}
+
+// Method that updates the calendar
+//
+// PROPPATCH {calendarID}
+func (calendar *CalDAVCalendar) Update() (err error) {
+	return calendar.UpdateContext(context.Background())
+}
+
+// Method like Update that is stopped once the given context is done
+func (calendar *CalDAVCalendar) UpdateContext(ctx context.Context) (err error) {
+	log.Debugln("updateCalendar caldav")
+	_, err = calendar.request(ctx, "PROPPATCH", calendar.GetID(), fmt.Sprintf(caldavPatchBody, xmlEscape(calendar.Name)), nil)
+	if err != nil {
+		return errors.New(fmt.Sprintf("error updating a calendar for email %s. %s", calendar.GetAccount().Mail(), err.Error()))
+	}
+	return
+}
+
+// Method that deletes the calendar
+//
+// DELETE {calendarID}
+func (calendar *CalDAVCalendar) Delete() (err error) {
+	return calendar.DeleteContext(context.Background())
+}
+
+// Method like Delete that is stopped once the given context is done
+func (calendar *CalDAVCalendar) DeleteContext(ctx context.Context) (err error) {
+	log.Debugln("Delete calendar caldav")
+	_, err = calendar.request(ctx, http.MethodDelete, calendar.GetID(), "", nil)
+	if err != nil {
+		return errors.New(fmt.Sprintf("error deleting a calendar for email %s. %s", calendar.GetAccount().Mail(), err.Error()))
+	}
+	return
+}
+
+// Method that creates the calendar
+//
+// MKCALENDAR {home}/{uuid}/
+func (calendar *CalDAVCalendar) Create() (err error) {
+	return calendar.CreateContext(context.Background())
+}
+
+// Method like Create that is stopped once the given context is done
+func (calendar *CalDAVCalendar) CreateContext(ctx context.Context) (err error) {
+	log.Debugln("createCalendar caldav")
+	if len(calendar.ID) == 0 {
+		calendar.ID = fmt.Sprintf("%s/%s/", strings.TrimSuffix(calendar.account.HomeURL, "/"), uuid.New().String())
+	}
+	_, err = calendar.request(ctx, "MKCALENDAR", calendar.GetID(), fmt.Sprintf(caldavMkBody, xmlEscape(calendar.Name)), nil)
+	if err != nil {
+		return errors.New(fmt.Sprintf("error creating a calendar for email %s. %s", calendar.GetAccount().Mail(), err.Error()))
+	}
+	return
+}
+
+// Method that returns all events inside the calendar
+//
+// REPORT {calendarID}
+func (calendar *CalDAVCalendar) GetAllEvents() (events []EventManager, err error) {
+	return calendar.GetAllEventsContext(context.Background())
+}
+
+// Method like GetAllEvents that is stopped once the given context is done
+func (calendar *CalDAVCalendar) GetAllEventsContext(ctx context.Context) (events []EventManager, err error) {
+	log.Debugln("getAllEvents caldav")
+	caldavEvents, err := calendar.report(ctx, fmt.Sprintf(caldavEventsBody, calendar.timeRange(time.Now())))
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error getting all events of caldav calendar for email %s. %s", calendar.GetAccount().Mail(), err.Error()))
+	}
+	for _, event := range caldavEvents {
+		// ignore cancelled events
+		if event.Status != "CANCELLED" {
+			events = append(events, event)
+		}
+	}
+	return
+}
+
+// Method that returns a single event given the ID
+//
+// REPORT {calendarID}
+func (calendar *CalDAVCalendar) GetEvent(eventID string) (event EventManager, err error) {
+	return calendar.GetEventContext(context.Background(), eventID)
+}
+
+// Method like GetEvent that is stopped once the given context is done
+func (calendar *CalDAVCalendar) GetEventContext(ctx context.Context, eventID string) (event EventManager, err error) {
+	log.Debugln("getEvent caldav")
+	caldavEvents, err := calendar.report(ctx, fmt.Sprintf(caldavEventBody, xmlEscape(eventID)))
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error getting an event of caldav calendar for email %s. %s", calendar.GetAccount().Mail(), err.Error()))
+	}
+	for _, caldavEvent := range caldavEvents {
+		if caldavEvent.ID == eventID && caldavEvent.Status != "CANCELLED" {
+			return caldavEvent, nil
+		}
+	}
+	return nil, &customErrors.NotFoundError{Message: fmt.Sprintf("event with id: %s not found", eventID)}
+}
+
+// Method that returns the time-range filter for the sync window. It is empty if not limited
+func (calendar *CalDAVCalendar) timeRange(now time.Time) string {
+	if !calendar.window.IsLimited() {
+		return ""
+	}
+	var start, end string
+	if windowStart := calendar.window.Start(now); !windowStart.IsZero() {
+		start = fmt.Sprintf(` start="%s"`, windowStart.Format(icalUTCFormat))
+	}
+	if windowEnd := calendar.window.End(now); !windowEnd.IsZero() {
+		end = fmt.Sprintf(` end="%s"`, windowEnd.Format(icalUTCFormat))
+	}
+	return fmt.Sprintf(caldavRangeBody, start, end)
+}
+
+// Method that does a calendar-query REPORT and parses the events returned
+func (calendar *CalDAVCalendar) report(ctx context.Context, body string) (events []*CalDAVEvent, err error) {
+	headers := map[string]string{"Depth": "1"}
+	contents, err := calendar.request(ctx, "REPORT", calendar.GetID(), body, headers)
+	if err != nil {
+		return
+	}
+	multistatus := new(caldavMultistatus)
+	err = xml.Unmarshal(contents, multistatus)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error unmarshalling caldav response: %s", err.Error()))
+	}
+	for _, response := range multistatus.Responses {
+		prop, ok := response.prop()
+		if !ok || len(prop.CalendarData) == 0 {
+			continue
+		}
+		event, err := parseCalDAVEvent(prop.CalendarData)
+		if err != nil {
+			log.Errorf("error parsing caldav event %s: %s", response.Href, err.Error())
+			continue
+		}
+		event.Href = response.Href
+		event.ETag = prop.ETag
+		event.SetCalendar(calendar)
+		events = append(events, event)
+	}
+	return
+}
+
+// Method that does a request to the given route of the calendar server
+func (calendar *CalDAVCalendar) request(ctx context.Context, method string, route string, body string, headers map[string]string) (contents []byte, err error) {
+	a := calendar.GetAccount()
+	route, err = calendar.account.resolve(calendar.account.HomeURL, route)
+	if err != nil {
+		return
+	}
+	if headers == nil {
+		headers = make(map[string]string)
+	}
+	headers["Authorization"] = a.AuthorizationRequest()
+	if _, ok := headers["Content-Type"]; !ok {
+		headers["Content-Type"] = "application/xml; charset=utf-8"
+	}
+	var status int
+	if len(body) == 0 {
+		contents, status, _, err = util.DoRawRequestContext(ctx, method, route, nil, headers, nil)
+	} else {
+		contents, status, _, err = util.DoRawRequestContext(ctx, method, route, strings.NewReader(body), headers, nil)
+	}
+	if err != nil {
+		return
+	}
+	err = createCalDAVResponseError(status, contents)
+	return
+}
+
+// Method that sets the account which the calendar belongs
+func (calendar *CalDAVCalendar) SetAccount(a AccountManager) (err error) {
+	switch x := a.(type) {
+	case *CalDAVAccount:
+		calendar.account = x
+	default:
+		return errors.New(fmt.Sprintf("type of account not valid for caldav: %T", x))
+	}
+	return
+}
+
+// Method that returns the ID formatted for a query request
+func (calendar *CalDAVCalendar) GetQueryID() string {
+	return calendar.GetID()
+}
+
+// Method that returns the ID of the calendar
+func (calendar *CalDAVCalendar) GetID() string {
+	return calendar.ID
+}
+
+// Method that returns the name of the calendar
+func (calendar *CalDAVCalendar) GetName() string {
+	return calendar.Name
+}
+
+// Method that returns the account
+func (calendar *CalDAVCalendar) GetAccount() AccountManager {
+	return calendar.account
+}
+
+// Method that returns the internal UUID given to the calendar
+func (calendar *CalDAVCalendar) GetUUID() string {
+	return calendar.uuid
+}
+
+// Method that sets the internal UUID for the calendar
+func (calendar *CalDAVCalendar) SetUUID(id string) {
+	calendar.uuid = id
+}
+
+// Method that sets the synced calendars
+func (calendar *CalDAVCalendar) SetCalendars(calendars []CalendarManager) {
+	calendar.calendars = calendars
+}
+
+// Method that returns the synced calendar
+func (calendar *CalDAVCalendar) GetCalendars() []CalendarManager {
+	return calendar.calendars
+}
+
+// Method that creates an empty event
+func (calendar *CalDAVCalendar) CreateEmptyEvent(ID string) EventManager {
+	return &CalDAVEvent{ID: ID, calendar: calendar}
+}
+
+// Function that escapes a text to be placed inside a xml body
+func xmlEscape(text string) string {
+	var builder strings.Builder
+	xml.EscapeText(&builder, []byte(text))
+	return builder.String()
+}
+
+// Method that sets the window of days whose events are synchronized
+func (calendar *CalDAVCalendar) SetSyncWindow(window SyncWindow) {
+	calendar.window = window
+}
+
+// Method that returns the window of days whose events are synchronized
+func (calendar *CalDAVCalendar) GetSyncWindow() SyncWindow {
+	return calendar.window
+}
+
+// Method that sets the options of the relation of the calendar
+func (calendar *CalDAVCalendar) SetSyncOptions(options SyncOptions) {
+	calendar.options = options
+}
+
+// Method that returns the options of the relation of the calendar
+func (calendar *CalDAVCalendar) GetSyncOptions() SyncOptions {
+	return calendar.options
+}
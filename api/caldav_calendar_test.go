@@ -0,0 +1,163 @@
+package api_test
+
+import (
+	"testing"
+
+	"time"
+
+	"github.com/TetAlius/GoSyncMyCalendars/api"
+	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
+)
+
+func TestCalDAVCalendar_CalendarLifeCycle(t *testing.T) {
+	standIn := newCalDAVStandIn()
+	defer standIn.Close()
+	account, err := api.NewCalDAVAccount(standIn.URL(), caldavUser, caldavPassword)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+
+	calendar := api.CalDAVCalendar{Name: "Travis"}
+	calendar.SetAccount(account)
+
+	// good call to create calendar
+	err = calendar.Create()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+
+	// good call to get calendar
+	_, err = account.GetCalendar(calendar.GetID())
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+
+	// good call to update calendar
+	calendar.Name = "TravisRenamed"
+	err = calendar.Update()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	retrieved, err := account.GetCalendar(calendar.GetID())
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if retrieved.GetName() != "TravisRenamed" {
+		t.Fatalf("something went wrong. Expected name TravisRenamed found %s", retrieved.GetName())
+	}
+
+	// wrong call to create an existing calendar
+	err = calendar.Create()
+	if err == nil {
+		t.Fatal("something went wrong. Expected error found nil")
+	}
+
+	// good call to delete calendar
+	err = calendar.Delete()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+
+	// wrong call to update calendar
+	err = calendar.Update()
+	if err == nil {
+		t.Fatal("something went wrong. Expected error found nil")
+	}
+	// wrong call to delete calendar
+	err = calendar.Delete()
+	if err == nil {
+		t.Fatal("something went wrong. Expected error found nil")
+	}
+}
+
+func TestCalDAVCalendar_GetAllEvents(t *testing.T) {
+	standIn := newCalDAVStandIn()
+	defer standIn.Close()
+	account, err := api.NewCalDAVAccount(standIn.URL(), caldavUser, caldavPassword)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	calendar, err := account.GetPrimaryCalendar()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+
+	events, err := calendar.GetAllEvents()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(events) != 0 {
+		t.Fatalf("something went wrong. Expected no events found %d", len(events))
+	}
+
+	start := time.Date(2018, 6, 14, 10, 0, 0, 0, time.UTC)
+	for _, subject := range []string{"First", "Second"} {
+		event := &api.CalDAVEvent{
+			Subject: subject,
+			Start:   &api.CalDAVTime{DateTime: start, TimeZone: time.UTC},
+			End:     &api.CalDAVTime{DateTime: start.Add(time.Hour), TimeZone: time.UTC},
+		}
+		event.SetCalendar(calendar)
+		if err = event.Create(); err != nil {
+			t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+		}
+	}
+
+	events, err = calendar.GetAllEvents()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(events) != 2 {
+		t.Fatalf("something went wrong. Expected 2 events found %d", len(events))
+	}
+	for _, event := range events {
+		if event.GetCalendar() != calendar {
+			t.Fatal("something went wrong. Expected event to have the calendar set")
+		}
+	}
+}
+
+func TestCalDAVCalendar_GetEvent(t *testing.T) {
+	standIn := newCalDAVStandIn()
+	defer standIn.Close()
+	account, err := api.NewCalDAVAccount(standIn.URL(), caldavUser, caldavPassword)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	calendar, err := account.GetPrimaryCalendar()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+
+	start := time.Date(2018, 6, 14, 0, 0, 0, 0, time.UTC)
+	event := &api.CalDAVEvent{
+		Subject:     "All day",
+		Description: "A description, with; special chars\nand lines",
+		Start:       &api.CalDAVTime{DateTime: start, TimeZone: time.UTC, IsAllDay: true},
+		End:         &api.CalDAVTime{DateTime: start.AddDate(0, 0, 1), TimeZone: time.UTC, IsAllDay: true},
+		IsAllDay:    true,
+	}
+	event.SetCalendar(calendar)
+	if err = event.Create(); err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+
+	// good call to get event
+	retrieved, err := calendar.GetEvent(event.GetID())
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	caldavEvent := retrieved.(*api.CalDAVEvent)
+	if caldavEvent.Description != event.Description {
+		t.Fatalf("something went wrong. Expected description %q found %q", event.Description, caldavEvent.Description)
+	}
+	if !caldavEvent.IsAllDay || !caldavEvent.Start.DateTime.Equal(start) || !caldavEvent.End.DateTime.Equal(start.AddDate(0, 0, 1)) {
+		t.Fatalf("something went wrong. Expected all day event on %s found %s - %s", start, caldavEvent.Start.DateTime, caldavEvent.End.DateTime)
+	}
+
+	// wrong call to get event
+	_, err = calendar.GetEvent("wrong")
+	if _, ok := err.(*customErrors.NotFoundError); !ok {
+		t.Fatalf("something went wrong. Expected NotFoundError found %v", err)
+	}
+}
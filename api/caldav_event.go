@@ -0,0 +1,462 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"reflect"
+
+	"github.com/TetAlius/GoSyncMyCalendars/convert"
+	log "github.com/TetAlius/GoSyncMyCalendars/logger"
+	"github.com/TetAlius/GoSyncMyCalendars/util"
+	"github.com/google/uuid"
+)
+
+// Function that parses the calendar data of a CalDAV resource into an event.
+// Only the master component of the resource is read
+func parseCalDAVEvent(data string) (event *CalDAVEvent, err error) {
+	calendars, err := parseICal(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, calendar := range calendars {
+		for _, component := range calendar.components("VEVENT") {
+			if _, ok := component.property("RECURRENCE-ID"); ok {
+				continue
+			}
+			return newCalDAVEventFromComponent(component)
+		}
+	}
+	return nil, errors.New("no event found on calendar data")
+}
+
+// Function that creates a CalDAVEvent from a VEVENT component
+func newCalDAVEventFromComponent(component *icalComponent) (event *CalDAVEvent, err error) {
+	event = new(CalDAVEvent)
+	event.ID = component.value("UID")
+	if len(event.ID) == 0 {
+		return nil, errors.New("event without UID")
+	}
+	event.Subject = unescapeICalText(component.value("SUMMARY"))
+	event.Description = unescapeICalText(component.value("DESCRIPTION"))
+	event.Location = unescapeICalText(component.value("LOCATION"))
+	event.Status = strings.ToUpper(component.value("STATUS"))
+	event.Class = CalDAVClass(strings.ToUpper(component.value("CLASS")))
+	event.Transparency = strings.ToUpper(component.value("TRANSP"))
+	for _, property := range component.properties("RRULE") {
+		event.Recurrences = append(event.Recurrences, "RRULE:"+property.Value)
+	}
+	if sequence := component.value("SEQUENCE"); len(sequence) != 0 {
+		event.Sequence, _ = strconv.Atoi(sequence)
+	}
+	if property, ok := component.property("LAST-MODIFIED"); ok {
+		event.LastModified, _, _, _ = parseICalTime(property)
+	}
+	if property, ok := component.property("DTSTAMP"); ok {
+		event.Stamp, _, _, _ = parseICalTime(property)
+	}
+
+	property, ok := component.property("DTSTART")
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("event %s without DTSTART", event.ID))
+	}
+	start, isDate, location, err := parseICalTime(property)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error parsing DTSTART of event %s: %s", event.ID, err.Error()))
+	}
+	event.Start = &CalDAVTime{DateTime: start, TimeZone: location, IsAllDay: isDate}
+
+	end := start
+	if property, ok := component.property("DTEND"); ok {
+		end, _, location, err = parseICalTime(property)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("error parsing DTEND of event %s: %s", event.ID, err.Error()))
+		}
+	} else if duration := component.value("DURATION"); len(duration) != 0 {
+		d, err := parseICalDuration(duration)
+		if err != nil {
+			return nil, err
+		}
+		end = start.Add(d)
+	} else if isDate {
+		// RFC 5545: an all day event without end lasts a single day
+		end = start.AddDate(0, 0, 1)
+	}
+	event.End = &CalDAVTime{DateTime: end, TimeZone: location, IsAllDay: isDate}
+	event.setAllDay()
+	return
+}
+
+// Method that returns the event as an iCalendar object
+func (event *CalDAVEvent) iCal() string {
+	vevent := &icalComponent{Name: "VEVENT"}
+	vevent.add("UID", event.ID, nil)
+	vevent.add("DTSTAMP", time.Now().UTC().Format(icalUTCFormat), nil)
+	vevent.add("LAST-MODIFIED", event.LastModified.UTC().Format(icalUTCFormat), nil)
+	vevent.add("SEQUENCE", strconv.Itoa(event.Sequence), nil)
+	vevent.add("SUMMARY", escapeICalText(event.Subject), nil)
+	if len(event.Description) != 0 {
+		vevent.add("DESCRIPTION", escapeICalText(event.Description), nil)
+	}
+	if len(event.Location) != 0 {
+		vevent.add("LOCATION", escapeICalText(event.Location), nil)
+	}
+	if len(event.Status) != 0 {
+		vevent.add("STATUS", event.Status, nil)
+	}
+	if len(event.Class) != 0 {
+		vevent.add("CLASS", string(event.Class), nil)
+	}
+	if len(event.Transparency) != 0 {
+		vevent.add("TRANSP", event.Transparency, nil)
+	}
+	names := []string{"DTSTART", "DTEND"}
+	for i, date := range []*CalDAVTime{event.Start, event.End} {
+		if date == nil {
+			continue
+		}
+		if event.IsAllDay {
+			vevent.add(names[i], date.DateTime.UTC().Format(icalDateFormat), map[string]string{"VALUE": "DATE"})
+		} else {
+			vevent.add(names[i], date.DateTime.UTC().Format(icalUTCFormat), nil)
+		}
+	}
+	for _, recurrence := range event.Recurrences {
+		vevent.add("RRULE", strings.TrimPrefix(recurrence, "RRULE:"), nil)
+	}
+
+	vcalendar := &icalComponent{Name: "VCALENDAR"}
+	vcalendar.add("VERSION", "2.0", nil)
+	vcalendar.add("PRODID", "-//GoSyncMyCalendars//EN", nil)
+	vcalendar.Components = append(vcalendar.Components, vevent)
+	return vcalendar.String()
+}
+
+// Method that creates the event
+//
+// PUT {calendarID}/{eventID}.ics
+func (event *CalDAVEvent) Create() (err error) {
+	return event.CreateContext(context.Background())
+}
+
+// Method like Create that is stopped once the given context is done
+func (event *CalDAVEvent) CreateContext(ctx context.Context) (err error) {
+	a := event.GetCalendar().GetAccount()
+	log.Debugln("createEvent caldav")
+	if len(event.ID) == 0 {
+		event.ID = uuid.New().String()
+	}
+	event.Href = fmt.Sprintf("%s/%s.ics", strings.TrimSuffix(event.calendar.GetID(), "/"), event.ID)
+
+	err = event.put(ctx, map[string]string{"If-None-Match": "*"})
+	if err != nil {
+		return errors.New(fmt.Sprintf("error creating event in caldav calendar for email %s. %s", a.Mail(), err.Error()))
+	}
+	return
+}
+
+// Method that updates the event
+//
+// PUT {eventHref}
+func (event *CalDAVEvent) Update() (err error) {
+	return event.UpdateContext(context.Background())
+}
+
+// Method like Update that is stopped once the given context is done
+func (event *CalDAVEvent) UpdateContext(ctx context.Context) (err error) {
+	a := event.GetCalendar().GetAccount()
+	log.Debugln("updateEvent caldav")
+	if len(event.Href) == 0 {
+		if err = event.retrieveHref(ctx); err != nil {
+			return err
+		}
+	}
+	event.Sequence += 1
+	err = event.put(ctx, nil)
+	if err != nil {
+		return errors.New(fmt.Sprintf("error updating event of caldav calendar for email %s. %s", a.Mail(), err.Error()))
+	}
+	return
+}
+
+// Method that deletes the event
+//
+// DELETE {eventHref}
+func (event *CalDAVEvent) Delete() (err error) {
+	return event.DeleteContext(context.Background())
+}
+
+// Method like Delete that is stopped once the given context is done
+func (event *CalDAVEvent) DeleteContext(ctx context.Context) (err error) {
+	a := event.GetCalendar().GetAccount()
+	log.Debugln("deleteEvent caldav")
+	if len(event.Href) == 0 {
+		if err = event.retrieveHref(ctx); err != nil {
+			return err
+		}
+	}
+	_, err = event.calendar.request(ctx, http.MethodDelete, event.Href, "", nil)
+	if err != nil {
+		return errors.New(fmt.Sprintf("error deleting event of caldav calendar for email %s. %s", a.Mail(), err.Error()))
+	}
+	return
+}
+
+// Method that sends the event to the server
+func (event *CalDAVEvent) put(ctx context.Context, headers map[string]string) (err error) {
+	a := event.calendar.account
+	route, err := a.resolve(a.HomeURL, event.Href)
+	if err != nil {
+		return
+	}
+	if headers == nil {
+		headers = make(map[string]string)
+	}
+	headers["Authorization"] = a.AuthorizationRequest()
+	headers["Content-Type"] = "text/calendar; charset=utf-8"
+	event.LastModified = time.Now().UTC()
+
+	contents, status, header, err := util.DoRawRequestContext(ctx, http.MethodPut, route, strings.NewReader(event.iCal()), headers, nil)
+	if err != nil {
+		return
+	}
+	if err = createCalDAVResponseError(status, contents); err != nil {
+		return
+	}
+	event.ETag = header.Get("ETag")
+	return
+}
+
+// Method that looks for the resource that holds the event on the server
+func (event *CalDAVEvent) retrieveHref(ctx context.Context) (err error) {
+	retrieved, err := event.calendar.GetEventContext(ctx, event.ID)
+	if err != nil {
+		return
+	}
+	caldavEvent := retrieved.(*CalDAVEvent)
+	event.Href = caldavEvent.Href
+	event.ETag = caldavEvent.ETag
+	event.Sequence = caldavEvent.Sequence
+	return
+}
+
+// Method that returns the ID of the event
+func (event *CalDAVEvent) GetID() string {
+	return event.ID
+}
+
+// Method that returns the calendar which have this event
+func (event *CalDAVEvent) GetCalendar() CalendarManager {
+	return event.calendar
+}
+
+// Method that returns the syncing events with this
+func (event *CalDAVEvent) GetRelations() []EventManager {
+	return event.relations
+}
+
+// Method that checks if the event can try sync again
+func (event *CalDAVEvent) CanProcessAgain() bool {
+	return event.exponentialBackoff < maxBackoff
+}
+
+// Method that returns the calendar which have this event
+func (event *CalDAVEvent) SetCalendar(calendar CalendarManager) (err error) {
+	switch x := calendar.(type) {
+	case *CalDAVCalendar:
+		event.calendar = x
+	default:
+		return errors.New(fmt.Sprintf("type of calendar not valid for caldav: %T", x))
+	}
+	return
+}
+
+// Method that sets the events syncing with this
+func (event *CalDAVEvent) SetRelations(relations []EventManager) {
+	event.relations = relations
+}
+
+// Method that increments the number of failed attempts to sync
+func (event *CalDAVEvent) IncrementBackoff() {
+	event.exponentialBackoff += 1
+}
+
+// Method that sets the state of the event
+func (event *CalDAVEvent) SetState(stateInformed int) {
+	event.state = stateInformed
+}
+
+// Method that returns the state of the event
+func (event *CalDAVEvent) GetState() int {
+	return event.state
+}
+
+// Method that sets the internal ID generated on db
+func (event *CalDAVEvent) SetInternalID(internalID int) {
+	event.internalID = internalID
+}
+
+// Method that gets the internal ID of the event
+func (event *CalDAVEvent) GetInternalID() int {
+	return event.internalID
+}
+
+// Method that returns the start and end dates of the event.
+// The end is zero for recurring events
+func (event *CalDAVEvent) GetTimeRange() (start time.Time, end time.Time, err error) {
+	if event.Start == nil || event.End == nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("event %s has no dates", event.GetID())
+	}
+	return timeRange(event.Start.DateTime, event.End.DateTime, len(event.Recurrences) != 0)
+}
+
+// Method that returns the last update date.
+// Servers are not forced to send LAST-MODIFIED, so DTSTAMP is used when missing
+func (event *CalDAVEvent) GetUpdatedAt() (t time.Time, err error) {
+	if !event.LastModified.IsZero() {
+		return event.LastModified.UTC(), nil
+	}
+	if !event.Stamp.IsZero() {
+		return event.Stamp.UTC(), nil
+	}
+	err = errors.New(fmt.Sprintf("event %s has no modification date", event.ID))
+	sentryClient().CaptureErrorAndWait(err, map[string]string{"api": "caldav"})
+	return
+}
+
+// Method that converts a CalDAVTime struct to a interface{}.
+// All day dates are given as floating dates.
+// This method implements Deconverter interface
+func (date *CalDAVTime) Deconvert() interface{} {
+	m := make(map[string]interface{})
+	t := reflect.TypeOf(date).Elem()
+	dateTime := date.DateTime.UTC()
+	if date.IsAllDay {
+		dateTime = floatingDate(date.DateTime, date.TimeZone)
+	}
+	values := map[string]interface{}{
+		"DateTime": dateTime,
+		"IsAllDay": date.IsAllDay,
+		"TimeZone": date.TimeZone,
+	}
+	for name, value := range values {
+		field, ok := t.FieldByName(name)
+		if !ok {
+			return nil
+		}
+		tag, _ := parseTag(field.Tag.Get("convert"))
+		m[tag] = value
+	}
+	return m
+}
+
+// Method that converts an interface{} to a CalDAVTime struct.
+// This method implements Converter interface
+func (*CalDAVTime) Convert(m interface{}, tag string, opts string) (convert.Converter, error) {
+	d := m.(map[string]interface{})
+
+	dateTime, ok := d["dateTime"].(time.Time)
+	if !ok {
+		return nil, errors.New("incorrect type of field dateTime")
+	}
+	isAllDay, ok := d["isAllDay"].(bool)
+	if !ok {
+		return nil, errors.New("incorrect type of field isAllDay")
+	}
+	timeZone, ok := d["timeZone"].(*time.Location)
+	if !ok {
+		return nil, errors.New("incorrect type of field timeZone")
+	}
+
+	return &CalDAVTime{DateTime: dateTime, TimeZone: timeZone, IsAllDay: isAllDay}, nil
+}
+
+// Method that converts a CalDAVRecurrence to a interface{}.
+// This method implements Deconverter interface
+func (recurrences CalDAVRecurrence) Deconvert() interface{} {
+	return []string(recurrences)
+}
+
+// Method that converts an interface{} to a CalDAVRecurrence.
+// Only RRULE lines are kept, as they are the only ones written on the resource.
+// This method implements Converter interface
+func (CalDAVRecurrence) Convert(m interface{}, tag string, opts string) (convert.Converter, error) {
+	lines, err := recurrenceLines(m)
+	if err != nil {
+		return nil, err
+	}
+	var rules CalDAVRecurrence
+	for _, line := range lines {
+		if strings.HasPrefix(strings.ToUpper(line), "RRULE:") {
+			rules = append(rules, line)
+		}
+	}
+	return rules, nil
+}
+
+// Method that converts a CalDAVClass to a interface{}.
+// This method implements Deconverter interface
+func (class CalDAVClass) Deconvert() interface{} {
+	if len(class) == 0 {
+		return VisibilityDefault
+	}
+	return strings.ToLower(string(class))
+}
+
+// Method that converts an interface{} to a CalDAVClass.
+// This method implements Converter interface
+func (CalDAVClass) Convert(m interface{}, tag string, opts string) (convert.Converter, error) {
+	switch visibility := visibilityFrom(m); visibility {
+	case VisibilityPublic, VisibilityPrivate, VisibilityConfidential:
+		return CalDAVClass(strings.ToUpper(visibility)), nil
+	default:
+		return CalDAVClass(""), nil
+	}
+}
+
+// Method that returns the links to join the online meeting listed on the description
+func (event *CalDAVEvent) readMeetingLinks() []MeetingLink {
+	return meetingLinksFromDescription(event.Description)
+}
+
+// Method that writes the links to join the online meeting on the description
+func (event *CalDAVEvent) writeMeetingLinks(links []MeetingLink) {
+	event.Description = describeMeetingLinks(event.Description, links)
+}
+
+// Method that returns the free/busy status of the event
+func (event *CalDAVEvent) readFreeBusy() (string, bool) {
+	return transparencyFreeBusy(event.Transparency), true
+}
+
+// Method that writes the free/busy status. CalDAV events are only free or busy,
+// so other statuses are written as their fallbacks say
+func (event *CalDAVEvent) writeFreeBusy(status string, fallbacks FreeBusyFallbacks) {
+	if fallbacks.resolve(status) == FreeBusyFree {
+		event.Transparency = "TRANSPARENT"
+	} else {
+		event.Transparency = "OPAQUE"
+	}
+}
+
+// Function that returns the free/busy status of the TRANSP property of an event
+func transparencyFreeBusy(transparency string) string {
+	if transparency == "TRANSPARENT" {
+		return FreeBusyFree
+	}
+	return FreeBusyBusy
+}
+
+// Method that sets all day to the necessary attributes
+func (event *CalDAVEvent) setAllDay() {
+	if event.Start == nil && event.End == nil {
+		event.IsAllDay = false
+		return
+	}
+	event.IsAllDay = event.Start.IsAllDay
+}
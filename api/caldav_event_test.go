@@ -0,0 +1,117 @@
+package api_test
+
+import (
+	"testing"
+
+	"time"
+
+	"github.com/TetAlius/GoSyncMyCalendars/api"
+	"github.com/TetAlius/GoSyncMyCalendars/convert"
+	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
+)
+
+func TestCalDAVTime_Convert(t *testing.T) {
+	start := time.Date(2018, 6, 14, 10, 0, 0, 0, time.UTC)
+	caldavEvent := &api.CalDAVEvent{
+		Subject:     "Converted",
+		Description: "Description",
+		Start:       &api.CalDAVTime{DateTime: start, TimeZone: time.UTC},
+		End:         &api.CalDAVTime{DateTime: start.Add(time.Hour), TimeZone: time.UTC},
+	}
+
+	googleEvent := new(api.GoogleEvent)
+	err := convert.Convert(caldavEvent, googleEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if googleEvent.Subject != caldavEvent.Subject || !googleEvent.Start.DateTime.Equal(start) {
+		t.Fatalf("something went wrong converting to google. Found %s at %s", googleEvent.Subject, googleEvent.Start.DateTime)
+	}
+
+	outlookEvent := new(api.OutlookEvent)
+	err = convert.Convert(googleEvent, outlookEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	back := new(api.CalDAVEvent)
+	err = convert.Convert(outlookEvent, back)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if back.Subject != caldavEvent.Subject || !back.End.DateTime.Equal(start.Add(time.Hour)) {
+		t.Fatalf("something went wrong converting from outlook. Found %s until %s", back.Subject, back.End.DateTime)
+	}
+}
+
+func TestCalDAVEvent_EventLifeCycle(t *testing.T) {
+	standIn := newCalDAVStandIn()
+	defer standIn.Close()
+	account, err := api.NewCalDAVAccount(standIn.URL(), caldavUser, caldavPassword)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	calendar, err := account.GetPrimaryCalendar()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+
+	start := time.Date(2018, 6, 14, 10, 0, 0, 0, time.UTC)
+	event := &api.CalDAVEvent{
+		Subject: "Travis",
+		Start:   &api.CalDAVTime{DateTime: start, TimeZone: time.UTC},
+		End:     &api.CalDAVTime{DateTime: start.Add(time.Hour), TimeZone: time.UTC},
+	}
+	event.SetCalendar(calendar)
+
+	// good call to create event
+	err = event.Create()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(event.GetID()) == 0 || len(event.ETag) == 0 {
+		t.Fatal("something went wrong. Expected ID and ETag to be set")
+	}
+	updatedAt, err := event.GetUpdatedAt()
+	if err != nil || updatedAt.IsZero() {
+		t.Fatalf("something went wrong. Expected update date found error: %v", err)
+	}
+
+	// wrong call to create the same event
+	err = event.Create()
+	if err == nil {
+		t.Fatal("something went wrong. Expected error found nil")
+	}
+
+	// good call to update event without knowing its resource
+	toUpdate := calendar.CreateEmptyEvent(event.GetID()).(*api.CalDAVEvent)
+	toUpdate.Subject = "TravisRenamed"
+	toUpdate.Start = event.Start
+	toUpdate.End = event.End
+	err = toUpdate.Update()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	retrieved, err := calendar.GetEvent(event.GetID())
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if retrieved.(*api.CalDAVEvent).Subject != "TravisRenamed" {
+		t.Fatalf("something went wrong. Expected subject TravisRenamed found %s", retrieved.(*api.CalDAVEvent).Subject)
+	}
+
+	// good call to delete event
+	err = calendar.CreateEmptyEvent(event.GetID()).Delete()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	_, err = calendar.GetEvent(event.GetID())
+	if _, ok := err.(*customErrors.NotFoundError); !ok {
+		t.Fatalf("something went wrong. Expected NotFoundError found %v", err)
+	}
+
+	// wrong call to delete event
+	err = event.Delete()
+	if err == nil {
+		t.Fatal("something went wrong. Expected error found nil")
+	}
+}
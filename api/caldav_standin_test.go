@@ -0,0 +1,177 @@
+package api_test
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	caldavUser     = "travis"
+	caldavPassword = "secret"
+	caldavHome     = "/calendars/travis/"
+)
+
+var caldavUIDFilter = regexp.MustCompile(`<c:text-match[^>]*>([^<]*)</c:text-match>`)
+var caldavDisplayName = regexp.MustCompile(`<d:displayname>([^<]*)</d:displayname>`)
+
+// In-process stand-in of a CalDAV server that keeps all calendars in memory
+type caldavStandIn struct {
+	sync.Mutex
+	server    *httptest.Server
+	calendars map[string]*caldavStandInCalendar
+	etag      int
+}
+
+type caldavStandInCalendar struct {
+	name      string
+	resources map[string]caldavStandInResource
+}
+
+type caldavStandInResource struct {
+	etag string
+	data string
+}
+
+// Function that starts a CalDAV stand-in with a calendar already created
+func newCalDAVStandIn() *caldavStandIn {
+	standIn := &caldavStandIn{calendars: make(map[string]*caldavStandInCalendar)}
+	standIn.calendars[caldavHome+"default/"] = &caldavStandInCalendar{name: "Default", resources: make(map[string]caldavStandInResource)}
+	standIn.server = httptest.NewServer(standIn)
+	return standIn
+}
+
+func (standIn *caldavStandIn) Close() {
+	standIn.server.Close()
+}
+
+func (standIn *caldavStandIn) URL() string {
+	return standIn.server.URL
+}
+
+func (standIn *caldavStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	standIn.Lock()
+	defer standIn.Unlock()
+	user, password, ok := r.BasicAuth()
+	if !ok || user != caldavUser || password != caldavPassword {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	path := r.URL.Path
+	switch {
+	case path == "/" && r.Method == "PROPFIND":
+		standIn.multistatus(w, fmt.Sprintf(`<d:response><d:href>/</d:href><d:propstat><d:prop><d:current-user-principal><d:href>/principals/%s/</d:href></d:current-user-principal></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`, caldavUser))
+	case path == fmt.Sprintf("/principals/%s/", caldavUser) && r.Method == "PROPFIND":
+		standIn.multistatus(w, fmt.Sprintf(`<d:response><d:href>%s</d:href><d:propstat><d:prop><c:calendar-home-set><d:href>%s</d:href></c:calendar-home-set></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`, path, caldavHome))
+	case path == caldavHome && r.Method == "PROPFIND":
+		responses := fmt.Sprintf(`<d:response><d:href>%s</d:href><d:propstat><d:prop><d:resourcetype><d:collection/></d:resourcetype></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`, caldavHome)
+		for href, calendar := range standIn.calendars {
+			responses += calendarResponse(href, calendar)
+		}
+		standIn.multistatus(w, responses)
+	case strings.HasSuffix(path, "/"):
+		standIn.serveCalendar(w, r, path, string(body))
+	default:
+		standIn.serveResource(w, r, path, string(body))
+	}
+}
+
+func (standIn *caldavStandIn) serveCalendar(w http.ResponseWriter, r *http.Request, path string, body string) {
+	calendar, ok := standIn.calendars[path]
+	if r.Method == "MKCALENDAR" {
+		if ok {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		name := ""
+		if match := caldavDisplayName.FindStringSubmatch(body); match != nil {
+			name = match[1]
+		}
+		standIn.calendars[path] = &caldavStandInCalendar{name: name, resources: make(map[string]caldavStandInResource)}
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case "PROPFIND":
+		standIn.multistatus(w, calendarResponse(path, calendar))
+	case "PROPPATCH":
+		if match := caldavDisplayName.FindStringSubmatch(body); match != nil {
+			calendar.name = match[1]
+		}
+		standIn.multistatus(w, fmt.Sprintf(`<d:response><d:href>%s</d:href><d:propstat><d:prop><d:displayname/></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`, path))
+	case http.MethodDelete:
+		delete(standIn.calendars, path)
+		w.WriteHeader(http.StatusNoContent)
+	case "REPORT":
+		uid := ""
+		if match := caldavUIDFilter.FindStringSubmatch(body); match != nil {
+			uid = match[1]
+		}
+		responses := ""
+		for href, resource := range calendar.resources {
+			if len(uid) != 0 && !strings.Contains(resource.data, "UID:"+uid+"\r\n") {
+				continue
+			}
+			var data strings.Builder
+			xml.EscapeText(&data, []byte(resource.data))
+			responses += fmt.Sprintf(`<d:response><d:href>%s</d:href><d:propstat><d:prop><d:getetag>%s</d:getetag><c:calendar-data>%s</c:calendar-data></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`, href, resource.etag, data.String())
+		}
+		standIn.multistatus(w, responses)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (standIn *caldavStandIn) serveResource(w http.ResponseWriter, r *http.Request, path string, body string) {
+	calendar, ok := standIn.calendars[path[:strings.LastIndex(path, "/")+1]]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_, exists := calendar.resources[path]
+	switch r.Method {
+	case http.MethodPut:
+		if exists && r.Header.Get("If-None-Match") == "*" {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		standIn.etag += 1
+		etag := fmt.Sprintf(`"%d"`, standIn.etag)
+		calendar.resources[path] = caldavStandInResource{etag: etag, data: body}
+		w.Header().Set("ETag", etag)
+		if exists {
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			w.WriteHeader(http.StatusCreated)
+		}
+	case http.MethodDelete:
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(calendar.resources, path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (standIn *caldavStandIn) multistatus(w http.ResponseWriter, responses string) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?><d:multistatus xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav" xmlns:cs="http://calendarserver.org/ns/">%s</d:multistatus>`, responses)
+}
+
+func calendarResponse(href string, calendar *caldavStandInCalendar) string {
+	return fmt.Sprintf(`<d:response><d:href>%s</d:href><d:propstat><d:prop><d:displayname>%s</d:displayname><d:resourcetype><d:collection/><c:calendar/></d:resourcetype><cs:getctag>%d</cs:getctag></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`, href, calendar.name, len(calendar.resources))
+}
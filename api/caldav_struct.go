@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
+)
+
+type CalDAVError struct {
+	Code    int
+	Message string
+}
+
+func (err CalDAVError) Error() string {
+	return fmt.Sprintf("code: %d. message: %s", err.Code, err.Message)
+}
+
+type CalDAVAccount struct {
+	// Always Basic, as CalDAV servers are accessed with HTTP basic authentication
+	TokenType string
+	// Base64 encoding of the username and password. It is stored encrypted on DB
+	AccessToken string
+	// URL of the calendar home set
+	HomeURL   string
+	Email     string
+	Kind      int
+	InternID  int
+	calendars []CalendarManager
+}
+
+type CalDAVCalendar struct {
+	uuid      string
+	account   *CalDAVAccount
+	calendars []CalendarManager
+	window    SyncWindow
+	options   SyncOptions
+	// Href of the calendar collection
+	ID   string
+	Name string `convert:"Name"`
+	CTag string
+}
+
+type CalDAVEvent struct {
+	calendar           *CalDAVCalendar
+	relations          []EventManager
+	state              int
+	exponentialBackoff int
+	internalID         int
+
+	// UID of the event
+	ID string
+	// Href of the resource that holds the event
+	Href string
+	ETag string
+
+	Subject     string      `convert:"Subject"`
+	Description string      `convert:"Description"`
+	Start       *CalDAVTime `convert:"start"`
+	End         *CalDAVTime `convert:"end"`
+	IsAllDay    bool        `convert:"allDay"`
+
+	Status       string
+	Location     string
+	Recurrences  CalDAVRecurrence `convert:"recurrence"`
+	Class        CalDAVClass      `convert:"visibility"`
+	Transparency string
+	Sequence     int
+	LastModified time.Time
+	Stamp        time.Time
+}
+
+type CalDAVTime struct {
+	DateTime time.Time      `convert:"dateTime"`
+	TimeZone *time.Location `convert:"timeZone"`
+	IsAllDay bool           `convert:"isAllDay"`
+}
+
+// Recurrence rules of the event, as RRULE lines
+type CalDAVRecurrence []string
+
+// Access classification of the event: PUBLIC, PRIVATE or CONFIDENTIAL
+type CalDAVClass string
+
+type caldavMultistatus struct {
+	XMLName   xml.Name         `xml:"DAV: multistatus"`
+	Responses []caldavResponse `xml:"DAV: response"`
+}
+
+type caldavResponse struct {
+	Href      string           `xml:"DAV: href"`
+	Propstats []caldavPropstat `xml:"DAV: propstat"`
+}
+
+type caldavPropstat struct {
+	Status string     `xml:"DAV: status"`
+	Prop   caldavProp `xml:"DAV: prop"`
+}
+
+type caldavProp struct {
+	DisplayName          string             `xml:"DAV: displayname"`
+	ResourceType         caldavResourceType `xml:"DAV: resourcetype"`
+	CurrentUserPrincipal caldavHref         `xml:"DAV: current-user-principal"`
+	CalendarHomeSet      caldavHref         `xml:"urn:ietf:params:xml:ns:caldav calendar-home-set"`
+	ETag                 string             `xml:"DAV: getetag"`
+	CTag                 string             `xml:"http://calendarserver.org/ns/ getctag"`
+	CalendarData         string             `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+}
+
+type caldavResourceType struct {
+	Calendar *struct{} `xml:"urn:ietf:params:xml:ns:caldav calendar"`
+}
+
+type caldavHref struct {
+	Href string `xml:"DAV: href"`
+}
+
+// Method that returns the properties that were found on the response
+func (response caldavResponse) prop() (prop caldavProp, ok bool) {
+	for _, propstat := range response.Propstats {
+		if len(propstat.Status) == 0 || strings.Contains(propstat.Status, " 200 ") {
+			return propstat.Prop, true
+		}
+	}
+	return
+}
+
+func createCalDAVResponseError(status int, contents []byte) (err error) {
+	if status == http.StatusNotFound || status == http.StatusGone {
+		return &customErrors.NotFoundError{Message: fmt.Sprintf("caldav resource not found: %s", contents)}
+	}
+	if status >= http.StatusBadRequest {
+		return CalDAVError{Code: status, Message: string(contents)}
+	}
+	return nil
+}
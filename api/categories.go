@@ -0,0 +1,98 @@
+package api
+
+import "strings"
+
+// Category of outlook events and the color of google events with that category
+type CategoryColor struct {
+	Category string
+	ColorID  string
+}
+
+// Mapping between categories and colors of a user, in order of preference
+type CategoryColors []CategoryColor
+
+// Color of google events, by its ID and the name google shows
+type GoogleEventColor struct {
+	ID   string
+	Name string
+}
+
+// Colors that google events can have
+var GoogleEventColors = []GoogleEventColor{
+	{"1", "Lavender"},
+	{"2", "Sage"},
+	{"3", "Grape"},
+	{"4", "Flamingo"},
+	{"5", "Banana"},
+	{"6", "Tangerine"},
+	{"7", "Peacock"},
+	{"8", "Graphite"},
+	{"9", "Blueberry"},
+	{"10", "Basil"},
+	{"11", "Tomato"},
+}
+
+// Mapping used for users that have not set theirs: the preset categories of outlook
+// and the google colors that look like them
+var DefaultCategoryColors = CategoryColors{
+	{"Red category", "11"},
+	{"Orange category", "6"},
+	{"Yellow category", "5"},
+	{"Green category", "10"},
+	{"Blue category", "9"},
+	{"Purple category", "3"},
+}
+
+// Method that returns the color of the first category of the mapping that is on the given ones
+func (colors CategoryColors) color(categories []string) (string, bool) {
+	for _, mapping := range colors {
+		for _, category := range categories {
+			if strings.EqualFold(mapping.Category, category) {
+				return mapping.ColorID, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Method that returns the first category of the mapping with the given color
+func (colors CategoryColors) category(colorID string) (string, bool) {
+	for _, mapping := range colors {
+		if mapping.ColorID == colorID {
+			return mapping.Category, true
+		}
+	}
+	return "", false
+}
+
+// Interface for events whose categories are written on the events synced with them
+type categoriesReader interface {
+	// Method that returns the categories of the event, using the mapping for the ones given as colors.
+	// Returns false if the event has no category
+	readCategories(CategoryColors) ([]string, bool)
+}
+
+// Interface for events that are written with the categories of the events synced with them
+type categoriesWriter interface {
+	// Method that writes the categories, using the mapping for the ones written as colors
+	writeCategories([]string, CategoryColors)
+}
+
+// Function that writes the categories of an event on another one, if both have them.
+// No mapping means the default one
+func writeCategories(from EventManager, to EventManager, colors CategoryColors) {
+	reader, ok := from.(categoriesReader)
+	if !ok {
+		return
+	}
+	writer, ok := to.(categoriesWriter)
+	if !ok {
+		return
+	}
+	if colors == nil {
+		colors = DefaultCategoryColors
+	}
+	if categories, known := reader.readCategories(colors); known {
+		writer.writeCategories(categories, colors)
+	}
+}
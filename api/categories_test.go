@@ -0,0 +1,64 @@
+package api_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/TetAlius/GoSyncMyCalendars/api"
+)
+
+func TestCategories_OutlookToGoogle(t *testing.T) {
+	custom := api.CategoryColors{{Category: "Customer", ColorID: "4"}, {Category: "Internal", ColorID: "8"}}
+	testCases := []struct {
+		colors     api.CategoryColors
+		categories []string
+		colorID    string
+	}{
+		// default mapping
+		{nil, []string{"Blue category"}, "9"},
+		{nil, []string{"Holidays", "red CATEGORY"}, "11"},
+		// the first category of the mapping is used
+		{custom, []string{"Internal", "Customer"}, "4"},
+		{custom, []string{"Blue category"}, ""},
+		{custom, nil, ""},
+	}
+	for _, testCase := range testCases {
+		googleEvent, outlookEvent := reminderEvents()
+		outlookEvent.GetCalendar().SetSyncOptions(api.SyncOptions{CategoryColors: testCase.colors})
+		outlookEvent.Categories = testCase.categories
+		err := api.ConvertEvent(outlookEvent, googleEvent)
+		if err != nil {
+			t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+		}
+		if googleEvent.ColorID != testCase.colorID {
+			t.Fatalf("something went wrong. Expected %v to be color %s found %s", testCase.categories, testCase.colorID, googleEvent.ColorID)
+		}
+	}
+}
+
+func TestCategories_GoogleToOutlook(t *testing.T) {
+	custom := api.CategoryColors{{Category: "Customer", ColorID: "4"}, {Category: "Important customer", ColorID: "4"}}
+	testCases := []struct {
+		colors     api.CategoryColors
+		colorID    string
+		categories []string
+	}{
+		{nil, "10", []string{"Green category"}},
+		{custom, "4", []string{"Customer"}},
+		// colors without category are not written
+		{custom, "10", nil},
+		{nil, "", nil},
+	}
+	for _, testCase := range testCases {
+		googleEvent, outlookEvent := reminderEvents()
+		googleEvent.GetCalendar().SetSyncOptions(api.SyncOptions{CategoryColors: testCase.colors})
+		googleEvent.ColorID = testCase.colorID
+		err := api.ConvertEvent(googleEvent, outlookEvent)
+		if err != nil {
+			t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+		}
+		if !reflect.DeepEqual(outlookEvent.Categories, testCase.categories) {
+			t.Fatalf("something went wrong. Expected color %s to be %v found %v", testCase.colorID, testCase.categories, outlookEvent.Categories)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package api
+
+// Free/busy statuses of an event
+const (
+	FreeBusyFree             = "free"
+	FreeBusyBusy             = "busy"
+	FreeBusyTentative        = "tentative"
+	FreeBusyOutOfOffice      = "oof"
+	FreeBusyWorkingElsewhere = "workingElsewhere"
+)
+
+// Visibilities of an event
+const (
+	VisibilityDefault      = "default"
+	VisibilityPublic       = "public"
+	VisibilityPrivate      = "private"
+	VisibilityConfidential = "confidential"
+)
+
+// Interface for events whose free/busy status is written on the events synced with them
+type freeBusyReader interface {
+	// Method that returns the free/busy status of the event.
+	// Returns false if the status of the event is not known
+	readFreeBusy() (string, bool)
+}
+
+// Interface for events that are written with the free/busy status of the events synced with them
+type freeBusyWriter interface {
+	// Method that writes the free/busy status, using the fallbacks for the statuses the calendar does not have
+	writeFreeBusy(string, FreeBusyFallbacks)
+}
+
+// Function that writes the free/busy status of an event on another one, if both have it
+func writeFreeBusy(from EventManager, to EventManager, fallbacks FreeBusyFallbacks) {
+	reader, ok := from.(freeBusyReader)
+	if !ok {
+		return
+	}
+	writer, ok := to.(freeBusyWriter)
+	if !ok {
+		return
+	}
+	if status, known := reader.readFreeBusy(); known {
+		writer.writeFreeBusy(status, fallbacks)
+	}
+}
+
+// Function that returns the visibility given by a Deconverter
+func visibilityFrom(m interface{}) string {
+	visibility, _ := m.(string)
+	return visibility
+}
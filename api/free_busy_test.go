@@ -0,0 +1,140 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/TetAlius/GoSyncMyCalendars/api"
+)
+
+func TestFreeBusy_OutlookToGoogle(t *testing.T) {
+	testCases := []struct {
+		showAs       api.OutlookFreeBusyStatus
+		fallbacks    api.FreeBusyFallbacks
+		transparency string
+	}{
+		{"Busy", api.FreeBusyFallbacks{}, "opaque"},
+		{"Free", api.FreeBusyFallbacks{}, "transparent"},
+		{"Tentative", api.FreeBusyFallbacks{}, "opaque"},
+		{"Tentative", api.FreeBusyFallbacks{Tentative: api.FreeBusyFree}, "transparent"},
+		{"Oof", api.FreeBusyFallbacks{}, "opaque"},
+		{"Oof", api.FreeBusyFallbacks{OutOfOffice: api.FreeBusyFree}, "transparent"},
+		{"WorkingElsewhere", api.FreeBusyFallbacks{}, "transparent"},
+		{"WorkingElsewhere", api.FreeBusyFallbacks{WorkingElsewhere: api.FreeBusyBusy}, "opaque"},
+		// unknown statuses are not written
+		{"Unknown", api.FreeBusyFallbacks{}, ""},
+	}
+	for _, testCase := range testCases {
+		googleEvent, outlookEvent := reminderEvents()
+		outlookEvent.GetCalendar().SetSyncOptions(api.SyncOptions{FreeBusyFallbacks: testCase.fallbacks})
+		outlookEvent.ShowAs = testCase.showAs
+		err := api.ConvertEvent(outlookEvent, googleEvent)
+		if err != nil {
+			t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+		}
+		if googleEvent.Transparency != testCase.transparency {
+			t.Fatalf("something went wrong. Expected %s to be %s found %s", testCase.showAs, testCase.transparency, googleEvent.Transparency)
+		}
+	}
+}
+
+func TestFreeBusy_GoogleToOutlook(t *testing.T) {
+	for transparency, showAs := range map[string]api.OutlookFreeBusyStatus{"": "Busy", "opaque": "Busy", "transparent": "Free"} {
+		googleEvent, outlookEvent := reminderEvents()
+		googleEvent.Transparency = transparency
+		err := api.ConvertEvent(googleEvent, outlookEvent)
+		if err != nil {
+			t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+		}
+		if outlookEvent.ShowAs != showAs {
+			t.Fatalf("something went wrong. Expected %s found %s", showAs, outlookEvent.ShowAs)
+		}
+	}
+}
+
+func TestVisibility_Convert(t *testing.T) {
+	testCases := []struct {
+		sensitivity api.OutlookSensitivity
+		visibility  api.GoogleVisibility
+		back        api.OutlookSensitivity
+	}{
+		{"Normal", "default", "Normal"},
+		{"Private", "private", "Private"},
+		// personal events stay private
+		{"Personal", "private", "Private"},
+		{"Confidential", "confidential", "Confidential"},
+	}
+	for _, testCase := range testCases {
+		googleEvent, outlookEvent := reminderEvents()
+		outlookEvent.Sensitivity = testCase.sensitivity
+		err := api.ConvertEvent(outlookEvent, googleEvent)
+		if err != nil {
+			t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+		}
+		if googleEvent.Visibility != testCase.visibility {
+			t.Fatalf("something went wrong. Expected %s to be %s found %s", testCase.sensitivity, testCase.visibility, googleEvent.Visibility)
+		}
+		_, outlookEvent = reminderEvents()
+		err = api.ConvertEvent(googleEvent, outlookEvent)
+		if err != nil {
+			t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+		}
+		if outlookEvent.Sensitivity != testCase.back {
+			t.Fatalf("something went wrong. Expected %s to be %s found %s", testCase.visibility, testCase.back, outlookEvent.Sensitivity)
+		}
+	}
+
+	googleEvent, _ := reminderEvents()
+	googleEvent.Visibility = "public"
+	_, outlookEvent := reminderEvents()
+	api.ConvertEvent(googleEvent, outlookEvent)
+	if outlookEvent.Sensitivity != "Normal" {
+		t.Fatalf("something went wrong. Expected Normal found %s", outlookEvent.Sensitivity)
+	}
+}
+
+func TestFreeBusy_CalDAV(t *testing.T) {
+	standIn := newCalDAVStandIn()
+	defer standIn.Close()
+	account, err := api.NewCalDAVAccount(standIn.URL(), caldavUser, caldavPassword)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	calendar, err := account.GetPrimaryCalendar()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+
+	_, outlookEvent := reminderEvents()
+	outlookEvent.GetCalendar().SetSyncOptions(api.SyncOptions{FreeBusyFallbacks: api.FreeBusyFallbacks{Tentative: api.FreeBusyFree}})
+	outlookEvent.Subject = "Doctor"
+	outlookEvent.Sensitivity = "Private"
+	outlookEvent.ShowAs = "Tentative"
+	event := &api.CalDAVEvent{}
+	event.SetCalendar(calendar)
+	err = api.ConvertEvent(outlookEvent, event)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	err = event.Create()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+
+	retrieved, err := calendar.GetEvent(event.GetID())
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	caldavEvent := retrieved.(*api.CalDAVEvent)
+	if caldavEvent.Class != "PRIVATE" || caldavEvent.Transparency != "TRANSPARENT" {
+		t.Fatalf("something went wrong. Expected private and transparent found %s and %s", caldavEvent.Class, caldavEvent.Transparency)
+	}
+
+	googleEvent, _ := reminderEvents()
+	err = api.ConvertEvent(caldavEvent, googleEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if googleEvent.Visibility != "private" || googleEvent.Transparency != "transparent" {
+		t.Fatalf("something went wrong. Expected private and transparent found %s and %s", googleEvent.Visibility, googleEvent.Transparency)
+	}
+}
@@ -1,10 +1,10 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"strings"
 
@@ -12,8 +12,39 @@ import (
 
 	log "github.com/TetAlius/GoSyncMyCalendars/logger"
 	"github.com/TetAlius/GoSyncMyCalendars/util"
+	"github.com/google/uuid"
 )
 
+// Google accounts are authorized with OAuth and notify the changes of their calendars
+func init() {
+	RegisterProvider(&Provider{
+		Kind: GOOGLE,
+		Name: "google",
+		RetrieveAccount: func(tokenType string, refreshToken string, email string, kind int, accessToken string, serverURL string, credential string) AccountManager {
+			return RetrieveGoogleAccount(tokenType, refreshToken, email, kind, accessToken)
+		},
+		RetrieveCalendar: func(ID string, uid string, account AccountManager) CalendarManager {
+			return RetrieveGoogleCalendar(ID, uid, account.(*GoogleAccount))
+		},
+		NewEvent: func(ID string) EventManager {
+			return &GoogleEvent{ID: ID}
+		},
+		NewSubscription: func() SubscriptionManager {
+			return NewGoogleSubscription(uuid.New().String())
+		},
+		RetrieveSubscription: func(ID string, uid uuid.UUID, calendar CalendarManager, typ string, resourceID string) SubscriptionManager {
+			return RetrieveGoogleSubscription(ID, uid, calendar, resourceID)
+		},
+		OAuth: &OAuthEndpoints{
+			SignInPath:         "/SignInWithGoogle",
+			RedirectPath:       "/google",
+			LoginRoute:         "google/login",
+			TokenRoute:         "google/token/uri",
+			RequestParamsRoute: "google/token/request-params",
+		},
+	})
+}
+
 // Function that parses the JSON of the request to a GoogleAccount
 func NewGoogleAccount(contents []byte) (a *GoogleAccount, err error) {
 	err = json.Unmarshal(contents, &a)
@@ -46,43 +77,31 @@ func RetrieveGoogleAccount(tokenType string, refreshToken string, email string,
 
 // Method to refresh the access to the google account
 func (a *GoogleAccount) Refresh() (err error) {
-	client := http.Client{}
+	return a.RefreshContext(context.Background())
+}
 
-	route, err := util.CallAPIRoot("google/token/uri")
+// Method like Refresh that is stopped once the given context is done
+func (a *GoogleAccount) RefreshContext(ctx context.Context) (err error) {
+
+	route, err := util.GetRoute("google/token/uri")
 	if err != nil {
 		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
 	}
 	log.Debugf(route)
 
-	params, err := util.CallAPIRoot("google/token/refresh-params")
+	params, err := util.GetParamsRoute("google/token/refresh-params", a.RefreshToken)
 	if err != nil {
 		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
 	}
 	log.Debugln(a.RefreshToken)
-	log.Debugln(fmt.Sprintf(params, a.RefreshToken))
-	req, err := http.NewRequest(http.MethodPost,
+	headers := make(map[string]string)
+	headers["Content-Type"] = "application/x-www-form-urlencoded"
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodPost,
 		route,
-		strings.NewReader(
-			fmt.Sprintf(params, a.RefreshToken)))
+		strings.NewReader(params),
+		headers, nil)
 
 	if err != nil {
-		return errors.New(fmt.Sprintf("error creating new request: %s", err.Error()))
-	}
-
-	req.Header.Set("Content-Type",
-		"application/x-www-form-urlencoded")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return errors.New(fmt.Sprintf("error doing google request: %s", err.Error()))
-	}
-
-	defer resp.Body.Close()
-	contents, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return errors.New(fmt.Sprintf("error reading response body from google request: %s", err.Error()))
-	}
-	if resp.StatusCode != 201 && resp.StatusCode != 204 {
 		e := new(RefreshError)
 		_ = json.Unmarshal(contents, &e)
 		if len(e.Code) != 0 && len(e.Message) != 0 {
@@ -90,6 +109,7 @@ func (a *GoogleAccount) Refresh() (err error) {
 			log.Errorln(e.Message)
 			return e
 		}
+		return util.RequestError(err, "error doing google request")
 	}
 
 	err = json.Unmarshal(contents, &a)
@@ -104,9 +124,14 @@ func (a *GoogleAccount) Refresh() (err error) {
 //
 // GET https://www.googleapis.com/calendar/v3/users/me/calendarList
 func (a *GoogleAccount) GetAllCalendars() (calendars []CalendarManager, err error) {
+	return a.GetAllCalendarsContext(context.Background())
+}
+
+// Method like GetAllCalendars that is stopped once the given context is done
+func (a *GoogleAccount) GetAllCalendarsContext(ctx context.Context) (calendars []CalendarManager, err error) {
 
 	log.Debugln("getAllCalendars google")
-	route, err := util.CallAPIRoot("google/calendar-list")
+	route, err := util.GetRoute("google/calendar-list")
 	if err != nil {
 		return nil, errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
 	}
@@ -114,37 +139,50 @@ func (a *GoogleAccount) GetAllCalendars() (calendars []CalendarManager, err erro
 	headers := make(map[string]string)
 	headers["Authorization"] = a.AuthorizationRequest()
 	queryParams := map[string]string{"minAccessRole": "writer"}
-	contents, err :=
-		util.DoRequest(
-			http.MethodGet,
-			route,
-			nil,
-			headers, queryParams)
-
-	if err != nil {
-		return nil, errors.New(fmt.Sprintf("error getting all calendars for email %s. %s", a.Mail(), err.Error()))
-	}
-	err = createGoogleResponseError(contents)
-	if err != nil {
-		return nil, err
-	}
+	for {
+		contents, err :=
+			util.DoProviderRequestContext(ctx,
+				http.MethodGet,
+				route,
+				nil,
+				headers, queryParams)
+
+		if err != nil {
+			return nil, util.RequestError(err, fmt.Sprintf("error getting all calendars for email %s", a.Mail()))
+		}
+		err = createGoogleResponseError(contents)
+		if err != nil {
+			return nil, err
+		}
 
-	calendarResponse := new(GoogleCalendarListResponse)
-	err = json.Unmarshal(contents, &calendarResponse)
+		calendarResponse := new(GoogleCalendarListResponse)
+		err = json.Unmarshal(contents, &calendarResponse)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("error unmarshalling calendars: %s", err.Error()))
+		}
 
-	for _, s := range calendarResponse.Calendars {
-		s.SetAccount(a)
-		calendars = append(calendars, s)
+		for _, s := range calendarResponse.Calendars {
+			s.SetAccount(a)
+			calendars = append(calendars, s)
+		}
+		if len(calendarResponse.NextPageToken) == 0 {
+			return calendars, nil
+		}
+		queryParams["pageToken"] = calendarResponse.NextPageToken
 	}
-	return
 }
 
 // Method that retrieves one calendar given an ID
 //
 // GET https://www.googleapis.com/calendar/v3/users/me/calendarList/{calendarID}
 func (a *GoogleAccount) GetCalendar(calendarID string) (calendar CalendarManager, err error) {
+	return a.GetCalendarContext(context.Background(), calendarID)
+}
+
+// Method like GetCalendar that is stopped once the given context is done
+func (a *GoogleAccount) GetCalendarContext(ctx context.Context, calendarID string) (calendar CalendarManager, err error) {
 	log.Debugln("getCalendar google")
-	route, err := util.CallAPIRoot("google/calendars/id")
+	route, err := util.GetRoute("google/calendars/id")
 	log.Debugln(route)
 	if err != nil {
 		return nil, errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
@@ -153,14 +191,14 @@ func (a *GoogleAccount) GetCalendar(calendarID string) (calendar CalendarManager
 	headers := make(map[string]string)
 	headers["Authorization"] = a.AuthorizationRequest()
 	contents, err :=
-		util.DoRequest(
+		util.DoProviderRequestContext(ctx,
 			http.MethodGet,
 			fmt.Sprintf(route, url.QueryEscape(calendarID)),
 			nil,
 			headers, nil)
 
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("error getting calendar for email %s. %s", a.Email, err.Error()))
+		return nil, util.RequestError(err, fmt.Sprintf("error getting calendar for email %s", a.Email))
 	}
 	err = createGoogleResponseError(contents)
 	if err != nil {
@@ -179,8 +217,13 @@ func (a *GoogleAccount) GetCalendar(calendarID string) (calendar CalendarManager
 //
 // GET https://www.googleapis.com/calendar/v3/calendars/primary
 func (a *GoogleAccount) GetPrimaryCalendar() (calendar CalendarManager, err error) {
+	return a.GetPrimaryCalendarContext(context.Background())
+}
+
+// Method like GetPrimaryCalendar that is stopped once the given context is done
+func (a *GoogleAccount) GetPrimaryCalendarContext(ctx context.Context) (calendar CalendarManager, err error) {
 	log.Debugln("getPrimaryCalendar google")
-	route, err := util.CallAPIRoot("google/calendars/primary")
+	route, err := util.GetRoute("google/calendars/primary")
 	if err != nil {
 		return calendar, errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
 	}
@@ -189,14 +232,14 @@ func (a *GoogleAccount) GetPrimaryCalendar() (calendar CalendarManager, err erro
 	headers["Authorization"] = a.AuthorizationRequest()
 
 	contents, err :=
-		util.DoRequest(
+		util.DoProviderRequestContext(ctx,
 			http.MethodGet,
 			route,
 			nil,
 			headers, nil)
 
 	if err != nil {
-		return calendar, errors.New(fmt.Sprintf("error getting primary calendar for email %s. %s", a.Email, err.Error()))
+		return calendar, util.RequestError(err, fmt.Sprintf("error getting primary calendar for email %s", a.Email))
 	}
 	err = createGoogleResponseError(contents)
 	if err != nil {
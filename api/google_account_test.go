@@ -2,6 +2,7 @@ package api_test
 
 import (
 	"encoding/json"
+	"net/http"
 	"os"
 	"testing"
 
@@ -10,7 +11,7 @@ import (
 )
 
 func TestNewGoogleAccount(t *testing.T) {
-	setupApiRoot()
+	setupRoutes()
 	// Bad Info inside Json
 	b := []byte(`{"Name":"Bob","Food":"Pickle"}`)
 	_, err := api.NewGoogleAccount(b)
@@ -39,7 +40,7 @@ func TestNewGoogleAccount(t *testing.T) {
 }
 
 func TestGoogleAccount_Refresh(t *testing.T) {
-	setupApiRoot()
+	setupRoutes()
 	//Empty initialized info account
 	account := new(api.GoogleAccount)
 	err := account.Refresh()
@@ -54,7 +55,7 @@ func TestGoogleAccount_Refresh(t *testing.T) {
 		t.Fatalf("something went wrong. Expected nil found %s", err.Error())
 	}
 
-	os.Setenv("API_ROOT", "")
+	unreachableRoutes()
 	err = account.Refresh()
 
 	if err == nil {
@@ -64,7 +65,7 @@ func TestGoogleAccount_Refresh(t *testing.T) {
 }
 
 func TestGoogleAccount_GetAllCalendars(t *testing.T) {
-	setupApiRoot()
+	setupRoutes()
 	_, account := setup()
 	//Refresh previous petition in order to have tokens updated
 	account.Refresh()
@@ -74,7 +75,7 @@ func TestGoogleAccount_GetAllCalendars(t *testing.T) {
 		t.Fatalf("something went wrong. Expected nil found: %s", err.Error())
 	}
 
-	os.Setenv("API_ROOT", "")
+	unreachableRoutes()
 	// Bad calling to GetPrimaryCalendar
 	_, err = account.GetAllCalendars()
 	if err == nil {
@@ -83,8 +84,28 @@ func TestGoogleAccount_GetAllCalendars(t *testing.T) {
 
 }
 
+func TestGoogleAccount_GetAllCalendarsPaginated(t *testing.T) {
+	_, teardown := setupStandIn(map[string]string{"google/calendar-list": "/users/me/calendarList"}, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("pageToken") == "page2" {
+			w.Write([]byte(`{"items":[{"id":"second","summary":"Second"}]}`))
+			return
+		}
+		w.Write([]byte(`{"nextPageToken":"page2","items":[{"id":"first","summary":"First"}]}`))
+	})
+	defer teardown()
+	account := &api.GoogleAccount{TokenType: "Bearer", AccessToken: "token"}
+
+	calendars, err := account.GetAllCalendars()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(calendars) != 2 || calendars[1].GetID() != "second" {
+		t.Fatalf("something went wrong. Expected 2 calendars found %d", len(calendars))
+	}
+}
+
 func TestGoogleAccount_GetPrimaryCalendar(t *testing.T) {
-	setupApiRoot()
+	setupRoutes()
 	_, account := setup()
 	//Refresh previous petition in order to have tokens updated
 	err := account.Refresh()
@@ -99,7 +120,7 @@ func TestGoogleAccount_GetPrimaryCalendar(t *testing.T) {
 	os.Setenv("GOOGLE_CALENDAR_ID", calendar.GetID())
 	os.Setenv("GOOGLE_CALENDAR_NAME", calendar.GetName())
 
-	os.Setenv("API_ROOT", "")
+	unreachableRoutes()
 	// Bad calling to GetPrimaryCalendar
 	_, err = account.GetPrimaryCalendar()
 	if err == nil {
@@ -109,7 +130,7 @@ func TestGoogleAccount_GetPrimaryCalendar(t *testing.T) {
 }
 
 func TestGoogleAccount_GetCalendar(t *testing.T) {
-	setupApiRoot()
+	setupRoutes()
 	_, account := setup()
 	//Refresh previous petition in order to have tokens updated
 	account.Refresh()
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 
 	"fmt"
 
@@ -13,6 +14,8 @@ import (
 
 	"errors"
 
+	"time"
+
 	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
 	log "github.com/TetAlius/GoSyncMyCalendars/logger"
 	"github.com/TetAlius/GoSyncMyCalendars/util"
@@ -31,8 +34,13 @@ func RetrieveGoogleCalendar(ID string, uid string, account *GoogleAccount) *Goog
 //
 // PUT https://www.googleapis.com/calendar/v3/users/me/calendarList/{calendarId}
 func (calendar *GoogleCalendar) Update() (err error) {
+	return calendar.UpdateContext(context.Background())
+}
+
+// Method like Update that is stopped once the given context is done
+func (calendar *GoogleCalendar) UpdateContext(ctx context.Context) (err error) {
 	log.Debugln("updateCalendar google")
-	route, err := util.CallAPIRoot("google/calendars/id")
+	route, err := util.GetRoute("google/calendars/id")
 	if err != nil {
 		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
 	}
@@ -45,14 +53,14 @@ func (calendar *GoogleCalendar) Update() (err error) {
 	headers := make(map[string]string)
 	headers["Authorization"] = calendar.GetAccount().AuthorizationRequest()
 	contents, err :=
-		util.DoRequest(
+		util.DoProviderRequestContext(ctx,
 			http.MethodPut,
 			fmt.Sprintf(route, calendar.GetQueryID()),
 			bytes.NewBuffer(data),
 			headers, nil)
 
 	if err != nil {
-		return errors.New(fmt.Sprintf("error updating a calendar for email %s. %s", calendar.GetAccount().Mail(), err.Error()))
+		return util.RequestError(err, fmt.Sprintf("error updating a calendar for email %s", calendar.GetAccount().Mail()))
 	}
 
 	err = createGoogleResponseError(contents)
@@ -69,22 +77,27 @@ func (calendar *GoogleCalendar) Update() (err error) {
 //
 // DELETE https://www.googleapis.com/calendar/v3/users/me/calendarList/{calendarId}
 func (calendar *GoogleCalendar) Delete() (err error) {
+	return calendar.DeleteContext(context.Background())
+}
+
+// Method like Delete that is stopped once the given context is done
+func (calendar *GoogleCalendar) DeleteContext(ctx context.Context) (err error) {
 	log.Debugln("Delete calendar")
-	route, err := util.CallAPIRoot("google/calendars/id")
+	route, err := util.GetRoute("google/calendars/id")
 	if err != nil {
 		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
 	}
 
 	headers := make(map[string]string)
 	headers["Authorization"] = calendar.GetAccount().AuthorizationRequest()
-	contents, err := util.DoRequest(
+	contents, err := util.DoProviderRequestContext(ctx,
 		http.MethodDelete,
 		fmt.Sprintf(route, calendar.GetQueryID()),
 		nil,
 		headers, nil)
 
 	if err != nil {
-		return errors.New(fmt.Sprintf("error deleting a calendar for email %s. %s", calendar.GetAccount().Mail(), err.Error()))
+		return util.RequestError(err, fmt.Sprintf("error deleting a calendar for email %s", calendar.GetAccount().Mail()))
 	}
 
 	if len(contents) != 0 {
@@ -99,8 +112,13 @@ func (calendar *GoogleCalendar) Delete() (err error) {
 //
 // POST https://www.googleapis.com/calendar/v3/calendars
 func (calendar *GoogleCalendar) Create() (err error) {
+	return calendar.CreateContext(context.Background())
+}
+
+// Method like Create that is stopped once the given context is done
+func (calendar *GoogleCalendar) CreateContext(ctx context.Context) (err error) {
 	log.Debugln("createCalendar google")
-	route, err := util.CallAPIRoot("google/calendars")
+	route, err := util.GetRoute("google/calendars")
 	if err != nil {
 		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
 	}
@@ -114,14 +132,14 @@ func (calendar *GoogleCalendar) Create() (err error) {
 	headers["Authorization"] = calendar.GetAccount().AuthorizationRequest()
 
 	contents, err :=
-		util.DoRequest(
+		util.DoProviderRequestContext(ctx,
 			http.MethodPost,
 			route,
 			bytes.NewBuffer(data),
 			headers, nil)
 
 	if err != nil {
-		return errors.New(fmt.Sprintf("error creating a calendar for email %s. %s", calendar.GetAccount().Mail(), err.Error()))
+		return util.RequestError(err, fmt.Sprintf("error creating a calendar for email %s", calendar.GetAccount().Mail()))
 	}
 	err = createGoogleResponseError(contents)
 	if err != nil {
@@ -135,53 +153,167 @@ func (calendar *GoogleCalendar) Create() (err error) {
 //
 // GET https://www.googleapis.com/calendar/v3/calendars/{calendarID}/events
 func (calendar *GoogleCalendar) GetAllEvents() (events []EventManager, err error) {
+	return calendar.GetAllEventsContext(context.Background())
+}
+
+// Method like GetAllEvents that is stopped once the given context is done
+func (calendar *GoogleCalendar) GetAllEventsContext(ctx context.Context) (events []EventManager, err error) {
 	log.Debugln("getAllEvents google")
+	err = calendar.ForEachEventPageContext(ctx, func(page []EventManager) error {
+		events = append(events, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Method that calls the given function with every page of events of the calendar.
+// Only the events inside the sync window are given
+//
+// GET https://www.googleapis.com/calendar/v3/calendars/{calendarID}/events?pageToken={token}
+func (calendar *GoogleCalendar) ForEachEventPage(fn func([]EventManager) error) (err error) {
+	return calendar.ForEachEventPageContext(context.Background(), fn)
+}
 
-	route, err := util.CallAPIRoot("google/calendars/id/events")
+// Method like ForEachEventPage that is stopped once the given context is done
+func (calendar *GoogleCalendar) ForEachEventPageContext(ctx context.Context, fn func([]EventManager) error) (err error) {
+	route, err := util.GetRoute("google/calendars/id/events")
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
 	}
 
 	headers := make(map[string]string)
 	headers["Authorization"] = calendar.GetAccount().AuthorizationRequest()
 
-	queryParams := map[string]string{"timeZone": "UTC"}
+	queryParams := make(map[string]string)
+	now := time.Now()
+	if start := calendar.window.Start(now); !start.IsZero() {
+		queryParams["timeMin"] = start.Format(time.RFC3339)
+	}
+	if end := calendar.window.End(now); !end.IsZero() {
+		queryParams["timeMax"] = end.Format(time.RFC3339)
+	}
 
-	contents, err := util.DoRequest(http.MethodGet,
-		fmt.Sprintf(route, calendar.GetQueryID()),
-		nil,
-		headers, queryParams)
+	for {
+		contents, err := util.DoProviderRequestContext(ctx, http.MethodGet,
+			fmt.Sprintf(route, calendar.GetQueryID()),
+			nil,
+			headers, queryParams)
 
-	if err != nil {
-		return nil, errors.New(fmt.Sprintf("error getting all events of g calendar for email %s. %s", calendar.GetAccount().Mail(), err.Error()))
+		if err != nil {
+			return util.RequestError(err, fmt.Sprintf("error getting all events of g calendar for email %s", calendar.GetAccount().Mail()))
+		}
+		err = createGoogleResponseError(contents)
+		if err != nil {
+			return err
+		}
+		eventList := new(GoogleEventList)
+		err = json.Unmarshal(contents, &eventList)
+		if err != nil {
+			return errors.New(fmt.Sprintf("error unmarshalling events: %s", err.Error()))
+		}
+
+		calendar.setDefaultReminders(eventList.DefaultReminders)
+		if len(eventList.TimeZone) != 0 {
+			calendar.TmeZone = eventList.TimeZone
+		}
+
+		var events []EventManager
+		for _, event := range eventList.Events {
+			event.SetCalendar(calendar)
+			// ignore cancelled events
+			if event.Status != "cancelled" {
+				event.setAllDay()
+				//TODO: this status
+				events = append(events, event)
+			}
+		}
+		err = fn(events)
+		if err != nil || len(eventList.NextPageToken) == 0 {
+			return err
+		}
+		queryParams["pageToken"] = eventList.NextPageToken
 	}
-	err = createGoogleResponseError(contents)
+}
+
+// Method that returns the events changed since the given sync token and the next sync token.
+// Cancelled events are returned with Deleted state
+//
+// GET https://www.googleapis.com/calendar/v3/calendars/{calendarID}/events?syncToken={token}
+func (calendar *GoogleCalendar) GetChangedEvents(token string) (events []EventManager, nextToken string, err error) {
+	return calendar.GetChangedEventsContext(context.Background(), token)
+}
+
+// Method like GetChangedEvents that is stopped once the given context is done
+func (calendar *GoogleCalendar) GetChangedEventsContext(ctx context.Context, token string) (events []EventManager, nextToken string, err error) {
+	log.Debugln("getChangedEvents google")
+
+	route, err := util.GetRoute("google/calendars/id/events")
 	if err != nil {
-		return nil, err
+		return nil, "", errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
 	}
-	eventList := new(GoogleEventList)
-	err = json.Unmarshal(contents, &eventList)
 
-	//events = new([]EventManager)
-	for _, event := range eventList.Events {
-		event.SetCalendar(calendar)
-		// ignore cancelled events
-		if event.Status != "cancelled" {
-			event.setAllDay()
-			//TODO: this status
+	headers := make(map[string]string)
+	headers["Authorization"] = calendar.GetAccount().AuthorizationRequest()
+
+	queryParams := make(map[string]string)
+	if len(token) != 0 {
+		queryParams["syncToken"] = token
+	}
+	for {
+		contents, status, _, err := util.DoProviderRawRequestContext(ctx, http.MethodGet,
+			fmt.Sprintf(route, calendar.GetQueryID()),
+			nil,
+			headers, queryParams)
+		if status == http.StatusGone {
+			return nil, "", &SyncTokenExpiredError{ID: calendar.GetID()}
+		}
+		if err != nil {
+			return nil, "", util.RequestError(err, fmt.Sprintf("error getting changed events of g calendar for email %s", calendar.GetAccount().Mail()))
+		}
+		err = createGoogleResponseError(contents)
+		if err != nil {
+			return nil, "", err
+		}
+		eventList := new(GoogleEventList)
+		err = json.Unmarshal(contents, &eventList)
+		if err != nil {
+			return nil, "", errors.New(fmt.Sprintf("error unmarshalling events: %s", err.Error()))
+		}
+		calendar.setDefaultReminders(eventList.DefaultReminders)
+		if len(eventList.TimeZone) != 0 {
+			calendar.TmeZone = eventList.TimeZone
+		}
+		for _, event := range eventList.Events {
+			event.SetCalendar(calendar)
+			if event.Status == "cancelled" {
+				event.SetState(Deleted)
+			} else {
+				event.setAllDay()
+			}
 			events = append(events, event)
 		}
+		if len(eventList.NextPageToken) == 0 {
+			return events, eventList.NextSyncToken, nil
+		}
+		queryParams["pageToken"] = eventList.NextPageToken
 	}
-	return events, err
 }
 
 // Method that returns a single event given the ID
 //
 // GET https://www.googleapis.com/calendar/v3/calendars/{calendarID}/events/{eventID}
 func (calendar *GoogleCalendar) GetEvent(eventID string) (event EventManager, err error) {
+	return calendar.GetEventContext(context.Background(), eventID)
+}
+
+// Method like GetEvent that is stopped once the given context is done
+func (calendar *GoogleCalendar) GetEventContext(ctx context.Context, eventID string) (event EventManager, err error) {
 	log.Debugln("getEvent google")
 
-	route, err := util.CallAPIRoot("google/calendars/id/events/id")
+	route, err := util.GetRoute("google/calendars/id/events/id")
 	if err != nil {
 		return nil, errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
 	}
@@ -189,16 +321,16 @@ func (calendar *GoogleCalendar) GetEvent(eventID string) (event EventManager, er
 	headers := make(map[string]string)
 	headers["Authorization"] = calendar.GetAccount().AuthorizationRequest()
 
-	queryParams := map[string]string{"timeZone": "UTC"}
+	queryParams := make(map[string]string)
 
-	contents, err := util.DoRequest(
+	contents, err := util.DoProviderRequestContext(ctx,
 		http.MethodGet,
 		fmt.Sprintf(route, calendar.GetQueryID(), eventID),
 		nil,
 		headers, queryParams)
 
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("error getting an event of g calendar for email %s. %s", calendar.GetAccount().Mail(), err.Error()))
+		return nil, util.RequestError(err, fmt.Sprintf("error getting an event of g calendar for email %s", calendar.GetAccount().Mail()))
 	}
 
 	err = createGoogleResponseError(contents)
@@ -224,6 +356,82 @@ func (calendar *GoogleCalendar) GetEvent(eventID string) (event EventManager, er
 	return
 }
 
+// Method that returns the instance of the given series that originally started at the given time
+//
+// GET https://www.googleapis.com/calendar/v3/calendars/{calendarID}/events/{eventID}/instances?originalStart={start}
+func (calendar *GoogleCalendar) GetInstance(seriesID string, originalStart time.Time) (event EventManager, err error) {
+	return calendar.GetInstanceContext(context.Background(), seriesID, originalStart)
+}
+
+// Method like GetInstance that is stopped once the given context is done
+func (calendar *GoogleCalendar) GetInstanceContext(ctx context.Context, seriesID string, originalStart time.Time) (event EventManager, err error) {
+	log.Debugln("getInstance google")
+
+	route, err := util.GetRoute("google/calendars/id/events/id/instances")
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+
+	headers := make(map[string]string)
+	headers["Authorization"] = calendar.GetAccount().AuthorizationRequest()
+
+	queryParams := map[string]string{
+		"originalStart": originalStart.UTC().Format(time.RFC3339),
+	}
+
+	contents, err := util.DoProviderRequestContext(ctx,
+		http.MethodGet,
+		fmt.Sprintf(route, calendar.GetQueryID(), seriesID),
+		nil,
+		headers, queryParams)
+
+	if err != nil {
+		return nil, util.RequestError(err, fmt.Sprintf("error getting an instance of g calendar for email %s", calendar.GetAccount().Mail()))
+	}
+
+	err = createGoogleResponseError(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	eventList := new(GoogleEventList)
+	err = json.Unmarshal(contents, &eventList)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error unmarshalling events: %s", err.Error()))
+	}
+	for _, instance := range eventList.Events {
+		if instance.Status != "cancelled" {
+			instance.SetCalendar(calendar)
+			instance.setAllDay()
+			return instance, nil
+		}
+	}
+	return nil, &customErrors.NotFoundError{Message: fmt.Sprintf("instance of event with id: %s starting at %s not found", seriesID, originalStart)}
+}
+
+// Method that writes the requests of several events with a single batch request
+//
+// POST https://www.googleapis.com/batch/calendar/v3
+func (calendar *GoogleCalendar) doBatch(ctx context.Context, requests []util.BatchRequest) (responses []util.BatchResponse, err error) {
+	route, err := util.GetRoute("google/batch")
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+	headers := make(map[string]string)
+	headers["Authorization"] = calendar.GetAccount().AuthorizationRequest()
+
+	responses, err = util.DoMultipartBatchContext(ctx, route, headers, requests)
+	if err != nil {
+		return nil, util.RequestError(err, fmt.Sprintf("error writing batch of events for email %s", calendar.GetAccount().Mail()))
+	}
+	return
+}
+
+// Method that returns the maximum number of requests inside a batch of google calendar
+func (calendar *GoogleCalendar) batchSize() int {
+	return 50
+}
+
 // Method that sets the account which the calendar belongs
 func (calendar *GoogleCalendar) SetAccount(a AccountManager) (err error) {
 	switch x := a.(type) {
@@ -280,3 +488,43 @@ func (calendar *GoogleCalendar) GetCalendars() []CalendarManager {
 func (calendar *GoogleCalendar) CreateEmptyEvent(ID string) EventManager {
 	return &GoogleEvent{ID: ID, calendar: calendar}
 }
+
+// Method that sets the window of days whose events are synchronized
+func (calendar *GoogleCalendar) SetSyncWindow(window SyncWindow) {
+	calendar.window = window
+}
+
+// Method that returns the window of days whose events are synchronized
+func (calendar *GoogleCalendar) GetSyncWindow() SyncWindow {
+	return calendar.window
+}
+
+// Method that sets the options of the relation of the calendar
+func (calendar *GoogleCalendar) SetSyncOptions(options SyncOptions) {
+	calendar.options = options
+}
+
+// Method that returns the options of the relation of the calendar
+func (calendar *GoogleCalendar) GetSyncOptions() SyncOptions {
+	return calendar.options
+}
+
+// Method that sets the reminders of the events of the calendar that use the default ones
+func (calendar *GoogleCalendar) setDefaultReminders(reminders []GoogleReminder) {
+	calendar.DefaultReminders = reminders
+	calendar.remindersLoaded = true
+}
+
+// Method that returns the reminders of the events of the calendar that use the default ones,
+// retrieving them if they were not given yet
+func (calendar *GoogleCalendar) defaultReminders(ctx context.Context) ([]GoogleReminder, error) {
+	if calendar.remindersLoaded {
+		return calendar.DefaultReminders, nil
+	}
+	retrieved, err := calendar.account.GetCalendarContext(ctx, calendar.ID)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error getting default reminders of calendar %s: %s", calendar.ID, err.Error()))
+	}
+	calendar.setDefaultReminders(retrieved.(*GoogleCalendar).DefaultReminders)
+	return calendar.DefaultReminders, nil
+}
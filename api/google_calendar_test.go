@@ -2,15 +2,19 @@ package api_test
 
 import (
 	"encoding/json"
+	"net/http"
+	"net/url"
 	"testing"
+	"time"
 
 	"fmt"
 
 	"github.com/TetAlius/GoSyncMyCalendars/api"
+	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
 )
 
 func TestGoogleCalendar_CalendarLifeCycle(t *testing.T) {
-	setupApiRoot()
+	setupRoutes()
 	_, account := setup()
 	//Refresh previous petition in order to have tokens updated
 	err := account.Refresh()
@@ -79,7 +83,7 @@ func TestGoogleCalendar_CalendarLifeCycle(t *testing.T) {
 }
 
 func TestGoogleCalendar_GetAllEvents(t *testing.T) {
-	setupApiRoot()
+	setupRoutes()
 	_, account := setup()
 	//Refresh previous petition in order to have tokens updated
 	account.Refresh()
@@ -106,8 +110,162 @@ func TestGoogleCalendar_GetAllEvents(t *testing.T) {
 
 }
 
+func TestGoogleCalendar_ForEachEventPage(t *testing.T) {
+	_, teardown := setupStandIn(map[string]string{"google/calendars/id/events": "/calendars/%s/events"}, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("pageToken") {
+		case "":
+			w.Write([]byte(`{"nextPageToken":"page2","items":[{"id":"1","status":"confirmed","start":{"dateTime":"2018-06-14T10:00:00Z"},"end":{"dateTime":"2018-06-14T11:00:00Z"}},{"id":"2","status":"cancelled"}]}`))
+		case "page2":
+			w.Write([]byte(`{"nextPageToken":"page3","items":[{"id":"3","status":"confirmed","start":{"date":"2018-06-14"},"end":{"date":"2018-06-15"}}]}`))
+		case "page3":
+			w.Write([]byte(`{"items":[{"id":"4","status":"confirmed","start":{"dateTime":"2018-06-14T10:00:00Z"},"end":{"dateTime":"2018-06-14T11:00:00Z"}}]}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	defer teardown()
+	calendar := api.RetrieveGoogleCalendar("primary", "", &api.GoogleAccount{TokenType: "Bearer", AccessToken: "token"})
+
+	// good call retrieves all pages without the cancelled events
+	events, err := calendar.GetAllEvents()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(events) != 3 {
+		t.Fatalf("something went wrong. Expected 3 events found %d", len(events))
+	}
+
+	// pages stop being retrieved once the function fails
+	pages := 0
+	err = calendar.ForEachEventPage(func(page []api.EventManager) error {
+		pages++
+		return fmt.Errorf("stop")
+	})
+	if err == nil || pages != 1 {
+		t.Fatalf("something went wrong. Expected error after 1 page found %d pages and error %v", pages, err)
+	}
+}
+
+func TestGoogleCalendar_SyncWindow(t *testing.T) {
+	var query url.Values
+	_, teardown := setupStandIn(map[string]string{"google/calendars/id/events": "/calendars/%s/events"}, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Write([]byte(`{"items":[]}`))
+	})
+	defer teardown()
+	calendar := api.RetrieveGoogleCalendar("primary", "", &api.GoogleAccount{TokenType: "Bearer", AccessToken: "token"})
+
+	// no window does not limit the query
+	_, err := calendar.GetAllEvents()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(query.Get("timeMin")) != 0 || len(query.Get("timeMax")) != 0 {
+		t.Fatalf("something went wrong. Expected no bounds found %s", query.Encode())
+	}
+
+	// the window is used as bounds of the query
+	window := api.SyncWindow{DaysBefore: 30, DaysAfter: 365}
+	calendar.SetSyncWindow(window)
+	_, err = calendar.GetAllEvents()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	now := time.Now()
+	if query.Get("timeMin") != window.Start(now).Format(time.RFC3339) || query.Get("timeMax") != window.End(now).Format(time.RFC3339) {
+		t.Fatalf("something went wrong. Expected window bounds found %s", query.Encode())
+	}
+}
+
+func TestGoogleCalendar_GetChangedEvents(t *testing.T) {
+	var requests []string
+	_, teardown := setupStandIn(map[string]string{"google/calendars/id/events": "/calendars/%s/events"}, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		requests = append(requests, query.Encode())
+		switch {
+		case query.Get("syncToken") == "expired":
+			w.WriteHeader(http.StatusGone)
+			w.Write([]byte(`{"error":{"code":410,"message":"Sync token is no longer valid, a full sync is required."}}`))
+		case query.Get("syncToken") == "first":
+			w.Write([]byte(`{"nextSyncToken":"second","items":[{"id":"1","status":"cancelled"},{"id":"3","status":"confirmed","start":{"date":"2018-06-14"},"end":{"date":"2018-06-15"}}]}`))
+		case query.Get("pageToken") == "page2":
+			w.Write([]byte(`{"nextSyncToken":"first","items":[{"id":"2","status":"confirmed","start":{"dateTime":"2018-06-14T10:00:00Z"},"end":{"dateTime":"2018-06-14T11:00:00Z"}}]}`))
+		default:
+			w.Write([]byte(`{"nextPageToken":"page2","items":[{"id":"1","status":"confirmed","start":{"dateTime":"2018-06-14T10:00:00Z"},"end":{"dateTime":"2018-06-14T11:00:00Z"}}]}`))
+		}
+	})
+	defer teardown()
+	calendar := api.RetrieveGoogleCalendar("primary", "", &api.GoogleAccount{TokenType: "Bearer", AccessToken: "token"})
+
+	// good call without token retrieves all pages
+	events, token, err := calendar.GetChangedEvents("")
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(events) != 2 || token != "first" {
+		t.Fatalf("something went wrong. Expected 2 events and token first found %d events and token %s", len(events), token)
+	}
+
+	// good call with token retrieves only changes, also the cancelled ones
+	events, token, err = calendar.GetChangedEvents(token)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(events) != 2 || token != "second" {
+		t.Fatalf("something went wrong. Expected 2 events and token second found %d events and token %s", len(events), token)
+	}
+	if events[0].GetState() != api.Deleted || events[1].GetState() == api.Deleted {
+		t.Fatal("something went wrong. Expected only cancelled event to be deleted")
+	}
+	if !events[1].(*api.GoogleEvent).IsAllDay {
+		t.Fatal("something went wrong. Expected all day event")
+	}
+
+	// wrong call with an expired token
+	_, _, err = calendar.GetChangedEvents("expired")
+	if _, ok := err.(*api.SyncTokenExpiredError); !ok {
+		t.Fatalf("something went wrong. Expected SyncTokenExpiredError found %v", err)
+	}
+	if len(requests) != 4 {
+		t.Fatalf("something went wrong. Expected 4 requests found %d", len(requests))
+	}
+}
+
+func TestGoogleCalendar_GetInstance(t *testing.T) {
+	_, teardown := setupStandIn(map[string]string{"google/calendars/id/events/id/instances": "/calendars/%s/events/%s/instances"}, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("originalStart") {
+		case "2018-06-21T10:00:00Z":
+			w.Write([]byte(`{"items":[{"id":"series_20180621T100000Z","status":"confirmed","recurringEventId":"series","originalStartTime":{"dateTime":"2018-06-21T10:00:00Z"},"start":{"dateTime":"2018-06-21T12:00:00Z"},"end":{"dateTime":"2018-06-21T13:00:00Z"}}]}`))
+		case "2018-06-28T10:00:00Z":
+			w.Write([]byte(`{"items":[{"id":"series_20180628T100000Z","status":"cancelled","recurringEventId":"series","originalStartTime":{"dateTime":"2018-06-28T10:00:00Z"}}]}`))
+		default:
+			w.Write([]byte(`{"items":[]}`))
+		}
+	})
+	defer teardown()
+	calendar := api.RetrieveGoogleCalendar("primary", "", &api.GoogleAccount{TokenType: "Bearer", AccessToken: "token"})
+
+	originalStart := time.Date(2018, 6, 21, 10, 0, 0, 0, time.UTC)
+	event, err := calendar.GetInstance("series", originalStart)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	instance := event.(api.InstanceEventManager)
+	if instance.GetID() != "series_20180621T100000Z" || instance.GetSeriesID() != "series" || !instance.GetOriginalStart().Equal(originalStart) {
+		t.Fatalf("something went wrong. Expected instance of series found %s of %s", instance.GetID(), instance.GetSeriesID())
+	}
+
+	// cancelled and missing instances are not found
+	for _, day := range []int{28, 29} {
+		_, err = calendar.GetInstance("series", time.Date(2018, 6, day, 10, 0, 0, 0, time.UTC))
+		if _, ok := err.(*customErrors.NotFoundError); !ok {
+			t.Fatalf("something went wrong. Expected NotFoundError found %v", err)
+		}
+	}
+}
+
 func TestGoogleCalendar_GetEvent(t *testing.T) {
-	setupApiRoot()
+	setupRoutes()
 	_, account := setup()
 	//Refresh previous petition in order to have tokens updated
 	account.Refresh()
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -17,14 +18,20 @@ import (
 	"github.com/TetAlius/GoSyncMyCalendars/util"
 )
 
-// Method that creates the event
+// Method that creates the event.
+// Attendees are only notified if the options of the relation say so
 //
-// POST https://www.googleapis.com/calendar/v3/calendars/{calendarID}/events
+// POST https://www.googleapis.com/calendar/v3/calendars/{calendarID}/events?supportsAttachments=true
 func (event *GoogleEvent) Create() (err error) {
+	return event.CreateContext(context.Background())
+}
+
+// Method like Create that is stopped once the given context is done
+func (event *GoogleEvent) CreateContext(ctx context.Context) (err error) {
 	a := event.GetCalendar().GetAccount()
 	log.Debugln("createEvent google")
 
-	route, err := util.CallAPIRoot("google/calendars/id/events")
+	route, err := util.GetRoute("google/calendars/id/events")
 	if err != nil {
 		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
 	}
@@ -38,13 +45,13 @@ func (event *GoogleEvent) Create() (err error) {
 	headers := make(map[string]string)
 	headers["Authorization"] = a.AuthorizationRequest()
 
-	contents, err := util.DoRequest(http.MethodPost,
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodPost,
 		fmt.Sprintf(route, event.GetCalendar().GetQueryID()),
 		bytes.NewBuffer(data),
-		headers, nil)
+		headers, event.writeParams())
 
 	if err != nil {
-		return errors.New(fmt.Sprintf("error creating event in g calendar for email %s. %s", a.Mail(), err.Error()))
+		return util.RequestError(err, fmt.Sprintf("error creating event in g calendar for email %s", a.Mail()))
 	}
 	err = createGoogleResponseError(contents)
 	if err != nil {
@@ -56,16 +63,23 @@ func (event *GoogleEvent) Create() (err error) {
 	return
 }
 
-// Method that updates the event
+// Method that updates the event.
+// Fields not given, like the attendees listed on the body, are kept as they are.
+// Attendees are only notified if the options of the relation say so
 //
-// PUT https://www.googleapis.com/calendar/v3/calendars/{calendarID}/events/{eventID}
+// PATCH https://www.googleapis.com/calendar/v3/calendars/{calendarID}/events/{eventID}?supportsAttachments=true
 func (event *GoogleEvent) Update() (err error) {
+	return event.UpdateContext(context.Background())
+}
+
+// Method like Update that is stopped once the given context is done
+func (event *GoogleEvent) UpdateContext(ctx context.Context) (err error) {
 	a := event.GetCalendar().GetAccount()
 	log.Debugln("updateEvent google")
 	//TODO: Test if ids are two given
 
 	//Meter en los header el etag
-	route, err := util.CallAPIRoot("google/calendars/id/events/id")
+	route, err := util.GetRoute("google/calendars/id/events/id")
 	if err != nil {
 		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
 	}
@@ -77,13 +91,13 @@ func (event *GoogleEvent) Update() (err error) {
 	headers := make(map[string]string)
 	headers["Authorization"] = a.AuthorizationRequest()
 
-	contents, err := util.DoRequest(http.MethodPut,
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodPatch,
 		fmt.Sprintf(route, event.GetCalendar().GetQueryID(), event.ID),
 		bytes.NewBuffer(data),
-		headers, nil)
+		headers, event.writeParams())
 
 	if err != nil {
-		return errors.New(fmt.Sprintf("error updating event of g calendar for email %s. %s", a.Mail(), err.Error()))
+		return util.RequestError(err, fmt.Sprintf("error updating event of g calendar for email %s", a.Mail()))
 	}
 	err = createGoogleResponseError(contents)
 	if err != nil {
@@ -94,15 +108,21 @@ func (event *GoogleEvent) Update() (err error) {
 	return
 }
 
-// Method that deletes the event
+// Method that deletes the event.
+// Attendees are only notified if the options of the relation say so
 //
 // DELETE https://www.googleapis.com/calendar/v3/calendars/{calendarID}/events/{eventID}
 func (event *GoogleEvent) Delete() (err error) {
+	return event.DeleteContext(context.Background())
+}
+
+// Method like Delete that is stopped once the given context is done
+func (event *GoogleEvent) DeleteContext(ctx context.Context) (err error) {
 	a := event.GetCalendar().GetAccount()
 	log.Debugln("deleteEvent google")
 	//TODO: Test if ids are two given
 
-	route, err := util.CallAPIRoot("google/calendars/id/events/id")
+	route, err := util.GetRoute("google/calendars/id/events/id")
 	if err != nil {
 		log.Errorf("error generating URL: %s", err.Error())
 		return
@@ -111,14 +131,14 @@ func (event *GoogleEvent) Delete() (err error) {
 	headers := make(map[string]string)
 	headers["Authorization"] = a.AuthorizationRequest()
 
-	contents, err := util.DoRequest(
+	contents, err := util.DoProviderRequestContext(ctx,
 		http.MethodDelete,
 		fmt.Sprintf(route, event.GetCalendar().GetQueryID(), event.ID),
 		nil,
-		headers, nil)
+		headers, event.notificationParams())
 
 	if err != nil {
-		log.Errorf("error deleting event of g calendar for email %s. %s", a.Mail(), err.Error())
+		return util.RequestError(err, fmt.Sprintf("error deleting event of g calendar for email %s", a.Mail()))
 	}
 
 	if len(contents) != 0 {
@@ -128,6 +148,47 @@ func (event *GoogleEvent) Delete() (err error) {
 	return
 }
 
+// Method that returns the request that writes the event inside a batch as the operation says
+func (event *GoogleEvent) batchRequest(operation int) (request util.BatchRequest, err error) {
+	name := "google/calendars/id/events/id"
+	switch operation {
+	case Created:
+		name = "google/calendars/id/events"
+		request = util.BatchRequest{Method: http.MethodPost, Params: event.writeParams()}
+	case Updated:
+		request = util.BatchRequest{Method: http.MethodPatch, Params: event.writeParams()}
+	case Deleted:
+		request = util.BatchRequest{Method: http.MethodDelete, Params: event.notificationParams()}
+	default:
+		return request, errors.New(fmt.Sprintf("operation %d not supported for event %s", operation, event.ID))
+	}
+	route, err := util.GetRoute(name)
+	if err != nil {
+		return request, errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+	if operation == Created {
+		request.URL = fmt.Sprintf(route, event.GetCalendar().GetQueryID())
+	} else {
+		request.URL = fmt.Sprintf(route, event.GetCalendar().GetQueryID(), event.ID)
+	}
+	if operation == Deleted {
+		return
+	}
+	request.Body, err = json.Marshal(event)
+	if err != nil {
+		return request, errors.New(fmt.Sprintf("error marshalling event data: %s", err.Error()))
+	}
+	return
+}
+
+// Method that reads the event back from the response of its request inside a batch
+func (event *GoogleEvent) readBatchResponse(operation int, contents []byte) (err error) {
+	if operation == Deleted {
+		return
+	}
+	return json.Unmarshal(contents, &event)
+}
+
 // Method that returns the ID of the event
 func (event *GoogleEvent) GetID() string {
 	return event.ID
@@ -189,6 +250,37 @@ func (event *GoogleEvent) GetInternalID() int {
 	return event.internalID
 }
 
+// Method that returns the start and end dates of the event.
+// The end is zero for recurring events
+func (event *GoogleEvent) GetTimeRange() (start time.Time, end time.Time, err error) {
+	if event.Start == nil || event.End == nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("event %s has no dates", event.GetID())
+	}
+	start = event.Start.DateTime
+	end = event.End.DateTime
+	if event.Start.IsAllDay {
+		start = event.Start.Date
+		end = event.End.Date
+	}
+	return timeRange(start, end, len(event.Recurrences) != 0)
+}
+
+// Method that returns the ID of the series master. It is empty if the event is not an instance
+func (event *GoogleEvent) GetSeriesID() string {
+	return event.RecurringEventId
+}
+
+// Method that returns the start that the instance had inside its series
+func (event *GoogleEvent) GetOriginalStart() time.Time {
+	if event.OriginalStartTime == nil {
+		return time.Time{}
+	}
+	if !event.OriginalStartTime.Date.IsZero() {
+		return event.OriginalStartTime.Date
+	}
+	return event.OriginalStartTime.DateTime
+}
+
 // Method that returns the last update date
 func (event *GoogleEvent) GetUpdatedAt() (t time.Time, err error) {
 	t, err = time.Parse(time.RFC3339, event.Updated)
@@ -223,14 +315,22 @@ func (date *GoogleTime) UnmarshalJSON(b []byte) error {
 				return err
 			}
 			date.DateTime = t.UTC()
+		case "timeZone":
+			location, err := loadTimeZone(value)
+			if err != nil {
+				return err
+			}
+			date.TimeZone = location
 		}
 	}
-	date.TimeZone = time.UTC
+	// dates without time zone are shown in the one of the calendar, which is not known here
+	date.TimeZone = timeZoneOrUTC(date.TimeZone)
 
 	return nil
 }
 
 // Method that converts from GoogleTime struct to a json.
+// Dates with time are written on their time zone, so recurring events keep their time across DST.
 // This method implements Marshaler interface
 func (date *GoogleTime) MarshalJSON() ([]byte, error) {
 	if date.DateTime.IsZero() && date.Date.IsZero() {
@@ -238,12 +338,13 @@ func (date *GoogleTime) MarshalJSON() ([]byte, error) {
 	}
 	var jsonValue string
 	var name string
+	timeZone := timeZoneOrUTC(date.TimeZone)
 	if date.IsAllDay {
 		name = "Date"
 		jsonValue = date.Date.UTC().Format("2006-01-02")
 	} else {
 		name = "DateTime"
-		jsonValue = date.DateTime.UTC().Format(time.RFC3339)
+		jsonValue = date.DateTime.In(timeZone).Format(time.RFC3339)
 	}
 	field, ok := reflect.TypeOf(date).Elem().FieldByName(name)
 	if !ok {
@@ -251,32 +352,282 @@ func (date *GoogleTime) MarshalJSON() ([]byte, error) {
 	}
 	tag, _ := parseTag(field.Tag.Get("json"))
 	buffer := bytes.NewBufferString("{")
-	_, err := buffer.WriteString(fmt.Sprintf(`"%s":"%s"}`, tag, jsonValue))
+	_, err := buffer.WriteString(fmt.Sprintf(`"%s":"%s"`, tag, jsonValue))
+	if err != nil {
+		return nil, err
+	}
+	if !date.IsAllDay {
+		_, err = buffer.WriteString(fmt.Sprintf(`,"timeZone":"%s"`, timeZone))
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, err = buffer.WriteString("}")
 	if err != nil {
 		return nil, err
 	}
 	return buffer.Bytes(), nil
 }
 
-// Method that converts from a JSON to a GoogleRecurrence struct.
-// This method implements Unmarshaler interface
-func (recurrences *GoogleRecurrence) MarshalJSON() ([]byte, error) {
-	return bytes.NewBufferString("").Bytes(), nil
+// Method that converts a GoogleAttendees to a interface{}.
+// This method implements Deconverter interface
+func (people GoogleAttendees) Deconvert() interface{} {
+	var attendees []Attendee
+	for _, person := range people {
+		attendee := Attendee{
+			Email:     person.Email,
+			Name:      person.Name,
+			Type:      AttendeeRequired,
+			Response:  person.ResponseStatus,
+			Organizer: person.Organizer,
+		}
+		switch {
+		case person.Resource:
+			attendee.Type = AttendeeResource
+		case person.Optional:
+			attendee.Type = AttendeeOptional
+		}
+		if len(attendee.Response) == 0 {
+			attendee.Response = ResponseNeedsAction
+		}
+		attendees = append(attendees, attendee)
+	}
+	return attendees
 }
 
-// Method that converts from GoogleRecurrence struct to a json.
-// This method implements Marshaler interface
-func (recurrences *GoogleRecurrence) UnmarshalJSON(b []byte) error {
-	return nil
+// Method that converts an interface{} to a GoogleAttendees.
+// This method implements Converter interface
+func (GoogleAttendees) Convert(m interface{}, tag string, opts string) (convert.Converter, error) {
+	attendees, err := attendeesFrom(m)
+	if err != nil {
+		return nil, err
+	}
+	var people GoogleAttendees
+	for _, attendee := range attendees {
+		people = append(people, GooglePerson{
+			Email:          attendee.Email,
+			Name:           attendee.Name,
+			Optional:       attendee.Type == AttendeeOptional,
+			Resource:       attendee.Type == AttendeeResource,
+			ResponseStatus: attendee.Response,
+			Organizer:      attendee.Organizer,
+		})
+	}
+	return people, nil
+}
+
+// Method that sets whether the next writes of the event notify its attendees
+func (event *GoogleEvent) notifyAttendees(notify bool) {
+	event.notify = notify
+}
+
+// Method that returns the params that tell google whether to send updates to the attendees
+func (event *GoogleEvent) notificationParams() map[string]string {
+	if event.notify {
+		return map[string]string{"sendUpdates": "all"}
+	}
+	return map[string]string{"sendUpdates": "none"}
+}
+
+// Method that returns the params of the writes of the event,
+// which also tell google that the attachments written are supported
+func (event *GoogleEvent) writeParams() map[string]string {
+	params := event.notificationParams()
+	params["supportsAttachments"] = "true"
+	return params
+}
+
+// Method that writes the attendees already converted as the mode says
+func (event *GoogleEvent) writeAttendees(mode AttendeesMode) {
+	event.Description = describeAttendees(event.Description, event.Attendees.Deconvert().([]Attendee), mode)
+	if mode == AttendeesInBody {
+		event.Attendees = nil
+	}
+}
+
+// Method that returns the minutes before the start of each reminder of the event.
+// Reminders by default are the ones of the calendar
+func (event *GoogleEvent) readReminders(ctx context.Context) ([]int, bool) {
+	if event.Reminders == nil {
+		return nil, false
+	}
+	reminders := event.Reminders.Overrides
+	if event.Reminders.UseDefault {
+		if event.calendar == nil {
+			return nil, false
+		}
+		var err error
+		reminders, err = event.calendar.defaultReminders(ctx)
+		if err != nil {
+			log.Warningf("reminders of event %s not synced: %s", event.ID, err.Error())
+			return nil, false
+		}
+	}
+	minutes := make([]int, len(reminders))
+	for i, reminder := range reminders {
+		minutes[i] = int(reminder.Minutes)
+	}
+	return minutes, true
+}
+
+// Method that writes the reminders given as minutes before the start
+func (event *GoogleEvent) writeReminders(minutes []int) {
+	reminders := make([]GoogleReminder, len(minutes))
+	for i, m := range minutes {
+		reminders[i] = GoogleReminder{Method: googleReminderMethod, Minutes: int32(m)}
+	}
+	event.Reminders = &GoogleEventReminder{UseDefault: false, Overrides: reminders}
+}
+
+// Method that returns the free/busy status of the event
+func (event *GoogleEvent) readFreeBusy() (string, bool) {
+	if event.Transparency == "transparent" {
+		return FreeBusyFree, true
+	}
+	return FreeBusyBusy, true
+}
+
+// Method that writes the free/busy status. Google events are only free or busy,
+// so other statuses are written as their fallbacks say
+func (event *GoogleEvent) writeFreeBusy(status string, fallbacks FreeBusyFallbacks) {
+	if fallbacks.resolve(status) == FreeBusyFree {
+		event.Transparency = "transparent"
+	} else {
+		event.Transparency = "opaque"
+	}
+}
+
+// Method that returns the links to join the online meeting of the event.
+// Events without a conference of their own give the links listed on their description
+func (event *GoogleEvent) readMeetingLinks() (links []MeetingLink) {
+	hangout := len(event.HangoutLink) != 0
+	if event.ConferenceData != nil {
+		for _, entryPoint := range event.ConferenceData.EntryPoints {
+			if len(entryPoint.URI) == 0 {
+				continue
+			}
+			links = append(links, MeetingLink{Kind: entryPoint.EntryPointType, URI: entryPoint.URI})
+			hangout = hangout && entryPoint.URI != event.HangoutLink
+		}
+	}
+	if hangout {
+		links = append([]MeetingLink{{Kind: MeetingVideo, URI: event.HangoutLink}}, links...)
+	}
+	if len(links) == 0 {
+		links = meetingLinksFromDescription(event.Description)
+	}
+	return
+}
+
+// Method that writes the links to join the online meeting on the description,
+// as google only creates conferences of its own
+func (event *GoogleEvent) writeMeetingLinks(links []MeetingLink) {
+	event.Description = describeMeetingLinks(event.Description, links)
+}
+
+// Method that returns the attachments of the event and the ones listed on its description
+func (event *GoogleEvent) readAttachments(ctx context.Context) (attachments []Attachment) {
+	for _, attachment := range event.Attachments {
+		attachments = append(attachments, Attachment{Name: attachment.Title, URL: attachment.FileURL, ContentType: attachment.MimeType})
+	}
+	return append(attachments, attachmentsFromDescription(event.Description)...)
+}
+
+// Method that writes the files of google drive as attachments and lists the rest on the description,
+// as google only holds attachments of its own drive. Links to the event itself are not written
+func (event *GoogleEvent) writeAttachments(ctx context.Context, attachments []Attachment) {
+	event.Attachments = []GoogleAttachment{}
+	var listed []Attachment
+	for _, attachment := range attachments {
+		switch {
+		case len(attachment.URL) != 0 && attachment.URL == event.Link:
+		case attachment.isDriveFile():
+			event.Attachments = append(event.Attachments, GoogleAttachment{FileURL: attachment.URL, Title: attachment.Name, MimeType: attachment.ContentType})
+		default:
+			listed = append(listed, attachment)
+		}
+	}
+	event.Description = describeAttachments(event.Description, listed)
+}
+
+// Method that returns the category mapped to the color of the event
+func (event *GoogleEvent) readCategories(colors CategoryColors) ([]string, bool) {
+	if len(event.ColorID) == 0 {
+		return nil, false
+	}
+	category, ok := colors.category(event.ColorID)
+	if !ok {
+		return nil, false
+	}
+	return []string{category}, true
+}
+
+// Method that writes the color mapped to the first category that has one.
+// Google events only have a color, so the rest of categories are not written
+func (event *GoogleEvent) writeCategories(categories []string, colors CategoryColors) {
+	if colorID, ok := colors.color(categories); ok {
+		event.ColorID = colorID
+	}
+}
+
+// Method that converts a GoogleVisibility to a interface{}.
+// This method implements Deconverter interface
+func (visibility GoogleVisibility) Deconvert() interface{} {
+	if len(visibility) == 0 {
+		return VisibilityDefault
+	}
+	return string(visibility)
+}
+
+// Method that converts an interface{} to a GoogleVisibility.
+// This method implements Converter interface
+func (GoogleVisibility) Convert(m interface{}, tag string, opts string) (convert.Converter, error) {
+	return GoogleVisibility(visibilityFrom(m)), nil
+}
+
+// Method that converts a GoogleLocation to a interface{}.
+// This method implements Deconverter interface
+func (location GoogleLocation) Deconvert() interface{} {
+	if len(location) == 0 {
+		return nil
+	}
+	return parseLocation(string(location))
+}
+
+// Method that converts an interface{} to a GoogleLocation.
+// This method implements Converter interface
+func (GoogleLocation) Convert(m interface{}, tag string, opts string) (convert.Converter, error) {
+	location, err := locationFrom(m)
+	if err != nil || location == nil {
+		return GoogleLocation(""), err
+	}
+	return GoogleLocation(location.String()), nil
+}
+
+// Method that converts a GoogleRecurrence to a interface{}.
+// This method implements Deconverter interface
+func (recurrences GoogleRecurrence) Deconvert() interface{} {
+	return []string(recurrences)
+}
+
+// Method that converts an interface{} to a GoogleRecurrence.
+// This method implements Converter interface
+func (GoogleRecurrence) Convert(m interface{}, tag string, opts string) (convert.Converter, error) {
+	lines, err := recurrenceLines(m)
+	if err != nil {
+		return nil, err
+	}
+	return GoogleRecurrence(lines), nil
 }
 
 // Method that converts a GoogleTime struct to a interface{}.
+// All day dates are given as floating dates.
 // This method implements Deconverter interface
 func (date *GoogleTime) Deconvert() interface{} {
 	m := make(map[string]interface{})
 	var value time.Time
 	if date.IsAllDay {
-		value = date.Date.UTC()
+		value = floatingDate(date.Date, time.UTC)
 	} else {
 		value = date.DateTime.UTC()
 	}
@@ -298,7 +649,7 @@ func (date *GoogleTime) Deconvert() interface{} {
 		return nil
 	}
 	tag, _ = parseTag(field.Tag.Get("convert"))
-	m[tag] = date.TimeZone
+	m[tag] = timeZoneOrUTC(date.TimeZone)
 	return m
 }
 
@@ -323,11 +674,25 @@ func (*GoogleTime) Convert(m interface{}, tag string, opts string) (convert.Conv
 	return &GoogleTime{DateTime: dateTime, Date: dateTime, TimeZone: timeZone, IsAllDay: isAllDay}, nil
 }
 
-// Method that sets all day to the necessary attributes
+// Method that sets all day to the necessary attributes.
+// All day dates have no time zone, so they are given the one of the calendar they are shown in
 func (event *GoogleEvent) setAllDay() {
 	if event.Start == nil && event.End == nil {
 		event.IsAllDay = false
 		return
 	}
 	event.IsAllDay = event.Start.IsAllDay
+	if !event.IsAllDay || event.calendar == nil || len(event.calendar.TmeZone) == 0 {
+		return
+	}
+	location, err := loadTimeZone(event.calendar.TmeZone)
+	if err != nil {
+		log.Warningf("all day event %s kept in UTC: %s", event.ID, err.Error())
+		return
+	}
+	for _, date := range []*GoogleTime{event.Start, event.End} {
+		if date != nil {
+			date.TimeZone = location
+		}
+	}
 }
@@ -1,9 +1,14 @@
 package api_test
 
 import (
+	"context"
+	"net/http"
+	"sync/atomic"
 	"testing"
 
 	"github.com/TetAlius/GoSyncMyCalendars/api"
+	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
+	"github.com/TetAlius/GoSyncMyCalendars/util"
 
 	"encoding/json"
 
@@ -99,7 +104,7 @@ func TestGoogleTime_JSON(t *testing.T) {
 }
 
 func TestGoogleEventCalendar_EventLifeCycle(t *testing.T) {
-	setupApiRoot()
+	setupRoutes()
 	_, account := setup()
 	//Refresh previous petition in order to have tokens updated
 	account.Refresh()
@@ -157,3 +162,132 @@ func TestGoogleEventCalendar_EventLifeCycle(t *testing.T) {
 		t.Fatalf("error converting from outlook to google: %s", err.Error())
 	}
 }
+
+func TestGoogleEvent_Notifications(t *testing.T) {
+	var sendUpdates []string
+	_, teardown := setupStandIn(map[string]string{"google/calendars/id/events": "/calendars/%s/events", "google/calendars/id/events/id": "/calendars/%s/events/%s"}, func(w http.ResponseWriter, r *http.Request) {
+		sendUpdates = append(sendUpdates, r.URL.Query().Get("sendUpdates"))
+		if r.Method != http.MethodDelete {
+			w.Write([]byte(`{"id":"event"}`))
+		}
+	})
+	defer teardown()
+
+	for _, notify := range []bool{false, true} {
+		sendUpdates = nil
+		from := meetingGoogleEvent(api.SyncOptions{Attendees: api.AttendeesCopied, NotifyAttendees: notify})
+		to := &api.GoogleEvent{}
+		to.SetCalendar(api.RetrieveGoogleCalendar("other", "", &api.GoogleAccount{TokenType: "Bearer", AccessToken: "token"}))
+		err := api.ConvertEvent(from, to)
+		if err != nil {
+			t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+		}
+		if err = to.Create(); err != nil {
+			t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+		}
+		if err = to.Update(); err != nil {
+			t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+		}
+		if err = api.DeleteEvent(from, to); err != nil {
+			t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+		}
+		expected := "none"
+		if notify {
+			expected = "all"
+		}
+		if len(sendUpdates) != 3 || sendUpdates[0] != expected || sendUpdates[1] != expected || sendUpdates[2] != expected {
+			t.Fatalf("something went wrong. Expected sendUpdates %s on every write found %v", expected, sendUpdates)
+		}
+	}
+
+	// writes that do not come from a relation notify nobody
+	sendUpdates = nil
+	event := &api.GoogleEvent{ID: "event"}
+	event.SetCalendar(api.RetrieveGoogleCalendar("other", "", &api.GoogleAccount{TokenType: "Bearer", AccessToken: "token"}))
+	if err := event.Delete(); err != nil || len(sendUpdates) != 1 || sendUpdates[0] != "none" {
+		t.Fatalf("something went wrong. Expected sendUpdates none found %v and error %v", sendUpdates, err)
+	}
+}
+
+func TestGoogleEvent_RateLimited(t *testing.T) {
+	var methods []string
+	_, teardown := setupStandIn(map[string]string{"google/calendars/id/events": "/calendars/%s/events", "google/calendars/id/events/id": "/calendars/%s/events/%s"}, func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		switch {
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusInternalServerError)
+		case len(methods) == 1:
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":{"errors":[{"domain":"usageLimits","reason":"rateLimitExceeded"}],"code":403,"message":"Rate Limit Exceeded"}}`))
+		case len(methods) == 2:
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Write([]byte(`{"id":"event"}`))
+		}
+	})
+	defer teardown()
+	maxDelay := util.DefaultProviderClient.MaxDelay
+	util.DefaultProviderClient.MaxDelay = 2 * time.Second
+	defer func() { util.DefaultProviderClient.MaxDelay = maxDelay }()
+	event := &api.GoogleEvent{ID: "event"}
+	event.SetCalendar(api.RetrieveGoogleCalendar("calendar", "", &api.GoogleAccount{TokenType: "Bearer", AccessToken: "token"}))
+
+	// rate limited updates are retried waiting what the provider asks for
+	start := time.Now()
+	if err := event.Update(); err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(methods) != 3 || time.Since(start) < time.Second {
+		t.Fatalf("something went wrong. Expected 3 requests waiting 1s found %d in %s", len(methods), time.Since(start))
+	}
+
+	// creations failed by the provider are not retried, as they could have been done
+	methods = nil
+	err := event.Create()
+	if _, ok := err.(*customErrors.ServerError); !ok || len(methods) != 1 {
+		t.Fatalf("something went wrong. Expected ServerError after 1 request found %v after %d", err, len(methods))
+	}
+}
+
+func TestGoogleEvent_Cancelled(t *testing.T) {
+	// handlers still run after their requests are cancelled, so they are counted atomically
+	var requests int32
+	_, teardown := setupStandIn(map[string]string{"google/calendars/id/events/id": "/calendars/%s/events/%s"}, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		switch r.Method {
+		case http.MethodDelete:
+			// slow provider, answering only once the request is cancelled
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		default:
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	})
+	defer teardown()
+	maxDelay := util.DefaultProviderClient.MaxDelay
+	util.DefaultProviderClient.MaxDelay = 2 * time.Second
+	defer func() { util.DefaultProviderClient.MaxDelay = maxDelay }()
+	event := &api.GoogleEvent{ID: "event"}
+	event.SetCalendar(api.RetrieveGoogleCalendar("calendar", "", &api.GoogleAccount{TokenType: "Bearer", AccessToken: "token"}))
+
+	// the wait before retrying stops once the deadline is reached
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := event.UpdateContext(ctx); err == nil || atomic.LoadInt32(&requests) != 1 || time.Since(start) >= time.Second {
+		t.Fatalf("something went wrong. Expected error after 1 request without retrying found %v after %d in %s", err, atomic.LoadInt32(&requests), time.Since(start))
+	}
+
+	// requests in flight are cancelled with their context
+	atomic.StoreInt32(&requests, 0)
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start = time.Now()
+	if err := event.DeleteContext(ctx); err == nil || atomic.LoadInt32(&requests) != 1 || time.Since(start) >= time.Second {
+		t.Fatalf("something went wrong. Expected error after 1 cancelled request found %v after %d in %s", err, atomic.LoadInt32(&requests), time.Since(start))
+	}
+}
@@ -46,6 +46,10 @@ type GoogleCalendar struct {
 	uuid      string
 	account   *GoogleAccount
 	calendars []CalendarManager
+	window    SyncWindow
+	options   SyncOptions
+	// Whether DefaultReminders were already retrieved
+	remindersLoaded bool
 	//From CalendarLIST resource
 	ID              string `json:"id"`
 	Name            string `json:"summary" convert:"Name"`
@@ -86,7 +90,12 @@ type GoogleConferenceProperties struct {
 }
 
 type GoogleEventList struct {
-	Events []*GoogleEvent `json:"items"`
+	NextPageToken    string           `json:"nextPageToken"`
+	NextSyncToken    string           `json:"nextSyncToken"`
+	DefaultReminders []GoogleReminder `json:"defaultReminders"`
+	// Time zone of the calendar, the one its all day events are shown in
+	TimeZone string         `json:"timeZone"`
+	Events   []*GoogleEvent `json:"items"`
 }
 
 type GoogleEvent struct {
@@ -95,6 +104,8 @@ type GoogleEvent struct {
 	state              int
 	exponentialBackoff int
 	internalID         int
+	// Whether the writes of the event notify its attendees
+	notify bool
 
 	ID string `json:"id"`
 
@@ -103,33 +114,35 @@ type GoogleEvent struct {
 	Start       *GoogleTime `json:"start,omitempty"convert:"start"`
 	End         *GoogleTime `json:"end,omitempty"convert:"end"`
 	IsAllDay    bool        `json:"-"convert:"allDay"`
+	// Free text, written from and parsed into the location of other calendars
+	Location GoogleLocation `json:"location,omitempty" convert:"location"`
 
 	Status             string           `json:"status,omitempty"`
 	ColorID            string           `json:"colorId,omitempty"`
 	EndTimeUnspecified bool             `json:"endTimeUnspecified,omitempty"`
-	Recurrences        GoogleRecurrence `json:"recurrence,omitempty"`
+	Recurrences        GoogleRecurrence `json:"recurrence,omitempty" convert:"recurrence"`
 	RecurringEventId   string           `json:"recurringEventId,omitempty"`
 	Transparency       string           `json:"transparency,omitempty"`
-	Visibility         string           `json:"visibility,omitempty"`
+	Visibility         GoogleVisibility `json:"visibility,omitempty" convert:"visibility"`
 	ICalUID            string           `json:"iCalUID,omitempty"`
 	Sequence           int32            `json:"sequence,omitempty"`
 	HangoutLink        string           `json:"hangoutLink,omitempty"`
 	Locked             bool             `json:"locked,omitempty"`
 
 	OriginalStartTime *GoogleTime           `json:"originalStartTime,omitempty"`
-	Attendees         []GooglePerson        `json:"attendees,omitempty"`
+	Attendees         GoogleAttendees       `json:"attendees,omitempty" convert:"attendees"`
 	Gadget            *GoogleGadget         `json:"gadget,omitempty"`
 	ConferenceData    *GoogleConferenceData `json:"conferenceData,omitempty"`
 	Reminders         *GoogleEventReminder  `json:"reminders,omitempty"`
 	Source            *GoogleSource         `json:"source,omitempty"`
-	Attachments       []GoogleAttachment    `json:"attachments,omitempty"`
-	Organizer         *GooglePerson         `json:"organizer,omitempty"`
+	// Always written, so attachments that are not on the event any more are removed
+	Attachments []GoogleAttachment `json:"attachments"`
+	Organizer   *GooglePerson      `json:"organizer,omitempty"`
 
 	//Not to sync
 	Link                    string `json:"htmlLink,omitempty"`
 	Created                 string `json:"created,omitempty"`
 	Updated                 string `json:"updated,omitempty"`
-	Location                string `json:"location,omitempty"`
 	AttendeesOmitted        bool   `json:"attendeesOmitted,omitempty"`
 	AnyoneCanAddSelf        bool   `json:"anyoneCanAddSelf,omitempty"`
 	GuestsCanInviteOthers   bool   `json:"guestsCanInviteOthers,omitempty"`
@@ -150,14 +163,20 @@ type GooglePerson struct {
 	Comment          string `json:"comment,omitempty"`
 	AdditionalGuests int32  `json:"additionalGuests,omitempty"`
 }
+type GoogleAttendees []GooglePerson
+
 type GoogleRecurrence []string
 
+type GoogleLocation string
+
+type GoogleVisibility string
+
 type GoogleTime struct {
 	Date time.Time `json:"date,omitempty"`
 	//time.RFC3339 gives TimeZone inside string
 	DateTime time.Time `json:"dateTime,omitempty"convert:"dateTime"`
-	//Ignore TimeZone as the json returns the original TimeZon
-	//Although it is always asked in UTC it may cause confusion
+	//TimeZone of the event, kept on the synced events so they do not shift across DST.
+	//It is read and written by the methods of Unmarshaler and Marshaler interfaces
 	TimeZone *time.Location `json:"-"convert:"timeZone"`
 	IsAllDay bool           `json:"-" convert:"isAllDay"`
 }
@@ -206,9 +225,10 @@ type GoogleGadget struct {
 	//Preferences
 }
 
+// Always written, so reminders that are not on the event any more are removed
 type GoogleEventReminder struct {
-	UseDefault bool             `json:"useDefault,omitempty"`
-	Overrides  []GoogleReminder `json:"overrides,omitempty"`
+	UseDefault bool             `json:"useDefault"`
+	Overrides  []GoogleReminder `json:"overrides"`
 }
 
 type GoogleSource struct {
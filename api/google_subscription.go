@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -47,6 +48,11 @@ func (subscription *GoogleSubscription) manageRenewalData() {
 //
 // POST https://www.googleapis.com/calendar/v3/calendars/calendarId/events/watch
 func (subscription *GoogleSubscription) Subscribe(calendar CalendarManager) (err error) {
+	return subscription.SubscribeContext(context.Background(), calendar)
+}
+
+// Method like Subscribe that is stopped once the given context is done
+func (subscription *GoogleSubscription) SubscribeContext(ctx context.Context, calendar CalendarManager) (err error) {
 	if err = subscription.setCalendar(calendar); err != nil {
 		log.Errorf("kind of subscription and calender differs: %s", calendar.GetName())
 		return err
@@ -54,7 +60,7 @@ func (subscription *GoogleSubscription) Subscribe(calendar CalendarManager) (err
 	a := calendar.GetAccount()
 	log.Debugln("subscribe calendar google")
 
-	route, err := util.CallAPIRoot("google/calendars/subscription")
+	route, err := util.GetRoute("google/calendars/subscription")
 	if err != nil {
 		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
 	}
@@ -69,11 +75,14 @@ func (subscription *GoogleSubscription) Subscribe(calendar CalendarManager) (err
 	headers["Authorization"] = a.AuthorizationRequest()
 	headers["X-AnchorMailbox"] = a.Mail()
 
-	contents, err := util.DoRequest(http.MethodPost,
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodPost,
 		fmt.Sprintf(route, calendar.GetID()),
 		bytes.NewBuffer(data),
 		headers, nil)
 	log.Warningf("RESPONSE: %s", contents)
+	if err != nil {
+		return util.RequestError(err, fmt.Sprintf("error subscribing a calendar for email %s", a.Mail()))
+	}
 
 	err = createGoogleResponseError(contents)
 	if err != nil {
@@ -90,19 +99,29 @@ func (subscription *GoogleSubscription) Subscribe(calendar CalendarManager) (err
 // Google does not let a subscription be renewed so
 // a new subscription must be request
 func (subscription *GoogleSubscription) Renew() (err error) {
+	return subscription.RenewContext(context.Background())
+}
+
+// Method like Renew that is stopped once the given context is done
+func (subscription *GoogleSubscription) RenewContext(ctx context.Context) (err error) {
 	log.Debugln("Renew google subscription")
 	subscription.manageRenewalData()
-	return subscription.Subscribe(subscription.calendar)
+	return subscription.SubscribeContext(ctx, subscription.calendar)
 }
 
 // Method that deletes subscription
 //
 // POST https://www.googleapis.com/calendar/v3/channels/stop
 func (subscription *GoogleSubscription) Delete() (err error) {
+	return subscription.DeleteContext(context.Background())
+}
+
+// Method like Delete that is stopped once the given context is done
+func (subscription *GoogleSubscription) DeleteContext(ctx context.Context) (err error) {
 	a := subscription.calendar.GetAccount()
 	log.Debugln("Delete google subscription")
 
-	route, err := util.CallAPIRoot("google/calendars/subscription/stop")
+	route, err := util.GetRoute("google/calendars/subscription/stop")
 	if err != nil {
 		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
 	}
@@ -115,15 +134,14 @@ func (subscription *GoogleSubscription) Delete() (err error) {
 		return errors.New(fmt.Sprintf("error marshalling event data: %s", err.Error()))
 	}
 
-	contents, err := util.DoRequest(http.MethodPost,
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodPost,
 		route,
 		bytes.NewBuffer(data),
 		headers, nil)
 	log.Warningf("RESPONSE: %s", contents)
-
-	if len(contents) != 0 {
-		err = createGoogleResponseError(contents)
+	if err != nil {
 		log.Errorf("error deleting subscription: %s", err.Error())
+		return util.RequestError(err, fmt.Sprintf("error deleting a subscription for email %s", a.Mail()))
 	}
 	return
 }
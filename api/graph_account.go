@@ -0,0 +1,287 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	log "github.com/TetAlius/GoSyncMyCalendars/logger"
+	"github.com/TetAlius/GoSyncMyCalendars/util"
+	"github.com/google/uuid"
+)
+
+// Graph accounts are authorized with OAuth, from the same paths that outlook accounts used
+func init() {
+	RegisterProvider(&Provider{
+		Kind: GRAPH,
+		Name: "graph",
+		RetrieveAccount: func(tokenType string, refreshToken string, email string, kind int, accessToken string, serverURL string, credential string) AccountManager {
+			return RetrieveGraphAccount(tokenType, refreshToken, email, kind, accessToken)
+		},
+		RetrieveCalendar: func(ID string, uid string, account AccountManager) CalendarManager {
+			return RetrieveGraphCalendar(ID, uid, account.(*GraphAccount))
+		},
+		NewEvent: func(ID string) EventManager {
+			return &GraphEvent{ID: ID}
+		},
+		NewSubscription: func() SubscriptionManager {
+			return NewGraphSubscription()
+		},
+		RetrieveSubscription: func(ID string, uid uuid.UUID, calendar CalendarManager, typ string, resourceID string) SubscriptionManager {
+			return RetrieveGraphSubscription(ID, uid, calendar, typ)
+		},
+		OAuth: &OAuthEndpoints{
+			SignInPath:         "/SignInWithOutlook",
+			RedirectPath:       "/outlook",
+			LoginRoute:         "graph/login",
+			TokenRoute:         "graph/token/uri",
+			RequestParamsRoute: "graph/token/request-params",
+		},
+	})
+}
+
+// Function that parses the JSON of the request to a GraphAccount
+func NewGraphAccount(contents []byte) (a *GraphAccount, err error) {
+	err = json.Unmarshal(contents, &a)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error unmarshaling graph response: %s", err.Error()))
+	}
+
+	email, preferred, err := util.MailFromToken(strings.Split(a.TokenID, "."))
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Error retrieving graph mail: %s", err.Error()))
+	}
+	a.AnchorMailbox = email
+	a.PreferredUsername = preferred
+	return
+}
+
+// Function that returns a GraphAccount given specific info
+func RetrieveGraphAccount(tokenType string, refreshToken string, email string, kind int, accessToken string) (a *GraphAccount) {
+	a = new(GraphAccount)
+	a.TokenType = tokenType
+	a.RefreshToken = refreshToken
+	a.AnchorMailbox = email
+	a.Kind = kind
+	a.AccessToken = accessToken
+	return
+}
+
+// Method to refresh the access to the graph account.
+// The refresh tokens of the accounts moved from Outlook are given access to Graph by the scopes asked for
+func (a *GraphAccount) Refresh() (err error) {
+	return a.RefreshContext(context.Background())
+}
+
+// Method like Refresh that is stopped once the given context is done
+func (a *GraphAccount) RefreshContext(ctx context.Context) (err error) {
+
+	route, err := util.GetRoute("graph/token/uri")
+	log.Debugln(route)
+	if err != nil {
+		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+
+	params, err := util.GetParamsRoute("graph/token/refresh-params", a.RefreshToken)
+	if err != nil {
+		return errors.New(fmt.Sprintf("error generating params: %s", err.Error()))
+	}
+
+	headers := make(map[string]string)
+	headers["Content-Type"] = "application/x-www-form-urlencoded"
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodPost,
+		route,
+		strings.NewReader(params),
+		headers, nil)
+
+	if err != nil {
+		e := new(RefreshError)
+		_ = json.Unmarshal(contents, &e)
+		if len(e.Code) != 0 && len(e.Message) != 0 {
+			log.Errorln(e.Code)
+			log.Errorln(e.Message)
+			return e
+		}
+		return util.RequestError(err, "error doing graph request")
+	}
+
+	err = json.Unmarshal(contents, &a)
+	if err != nil {
+		return errors.New(fmt.Sprintf("there was an error with the graph request: %s", err.Error()))
+	}
+	return
+}
+
+// Method that retrieves all calendars from account
+//
+// GET https://graph.microsoft.com/v1.0/me/calendars
+func (a *GraphAccount) GetAllCalendars() (calendars []CalendarManager, err error) {
+	return a.GetAllCalendarsContext(context.Background())
+}
+
+// Method like GetAllCalendars that is stopped once the given context is done
+func (a *GraphAccount) GetAllCalendarsContext(ctx context.Context) (calendars []CalendarManager, err error) {
+	log.Debugln("getAllCalendars graph")
+
+	route, err := util.GetRoute("graph/calendars")
+	if err != nil {
+		log.Errorf("%s", err.Error())
+		return calendars, errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+
+	headers := make(map[string]string)
+	headers["Authorization"] = a.AuthorizationRequest()
+	queryParams := map[string]string{"$filter": "canEdit eq false"}
+
+	for {
+		contents, err := util.DoProviderRequestContext(ctx, http.MethodGet,
+			route,
+			nil,
+			headers, queryParams)
+
+		if err != nil {
+			return nil, util.RequestError(err, fmt.Sprintf("error getting all calendars for email %s", a.AnchorMailbox))
+		}
+		err = createGraphResponseError(contents)
+		if err != nil {
+			return nil, err
+		}
+
+		calendarResponse := new(GraphCalendarListResponse)
+		err = json.Unmarshal(contents, &calendarResponse)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("error unmarshalling calendars: %s", err.Error()))
+		}
+
+		for _, s := range calendarResponse.Calendars {
+			s.SetAccount(a)
+			calendars = append(calendars, s)
+		}
+		if len(calendarResponse.OdataNextLink) == 0 {
+			return calendars, nil
+		}
+		// next links already carry all the query params
+		route = calendarResponse.OdataNextLink
+		queryParams = nil
+	}
+}
+
+// Method that retrieves one calendar given an ID
+//
+// GET https://graph.microsoft.com/v1.0/me/calendars/{calendarID}
+func (a *GraphAccount) GetCalendar(calendarID string) (calendar CalendarManager, err error) {
+	return a.GetCalendarContext(context.Background(), calendarID)
+}
+
+// Method like GetCalendar that is stopped once the given context is done
+func (a *GraphAccount) GetCalendarContext(ctx context.Context, calendarID string) (calendar CalendarManager, err error) {
+	if len(calendarID) == 0 {
+		return calendar, errors.New("no ID for calendar was given")
+	}
+	log.Debugln("getCalendar graph")
+
+	route, err := util.GetRoute("graph/calendars/id")
+	if err != nil {
+		log.Errorf("error generating URL: %s", err.Error())
+		return
+	}
+	return a.getCalendar(ctx, fmt.Sprintf(route, calendarID))
+}
+
+// Method that returns the principal calendar from the account
+//
+// GET https://graph.microsoft.com/v1.0/me/calendar
+func (a *GraphAccount) GetPrimaryCalendar() (calendar CalendarManager, err error) {
+	return a.GetPrimaryCalendarContext(context.Background())
+}
+
+// Method like GetPrimaryCalendar that is stopped once the given context is done
+func (a *GraphAccount) GetPrimaryCalendarContext(ctx context.Context) (calendar CalendarManager, err error) {
+	log.Debugln("getPrimaryCalendar graph")
+
+	route, err := util.GetRoute("graph/calendars/primary")
+	if err != nil {
+		log.Errorf("%s", err.Error())
+		return calendar, errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+	return a.getCalendar(ctx, route)
+}
+
+// Method that retrieves the calendar given by the route
+func (a *GraphAccount) getCalendar(ctx context.Context, route string) (calendar CalendarManager, err error) {
+	headers := make(map[string]string)
+	headers["Authorization"] = a.AuthorizationRequest()
+
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodGet,
+		route,
+		nil,
+		headers, nil)
+	if err != nil {
+		return nil, util.RequestError(err, fmt.Sprintf("error getting a calendar for email %s", a.AnchorMailbox))
+	}
+	err = createGraphResponseError(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	calendarResponse := new(GraphCalendarResponse)
+	err = json.Unmarshal(contents, &calendarResponse)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error unmarshalling calendar: %s", err.Error()))
+	}
+	calendarResponse.GraphCalendar.SetAccount(a)
+	return calendarResponse.GraphCalendar, nil
+}
+
+// Method that format the authorization request
+func (a *GraphAccount) AuthorizationRequest() (auth string) {
+	return fmt.Sprintf("%s %s", a.TokenType, a.AccessToken)
+}
+
+// Method that returns the mail associated with the account
+func (a *GraphAccount) Mail() string {
+	return a.AnchorMailbox
+}
+
+// Method that sets which kind of account is
+func (a *GraphAccount) SetKind(kind int) {
+	a.Kind = kind
+}
+
+// Method that returns the token type
+func (a *GraphAccount) GetTokenType() string {
+	return a.TokenType
+}
+
+// Method that returns the refresh token
+func (a *GraphAccount) GetRefreshToken() string {
+	return a.RefreshToken
+}
+
+// Method that returns the kind of the account
+func (a *GraphAccount) GetKind() int {
+	return a.Kind
+}
+
+// Method that returns the access token
+func (a *GraphAccount) GetAccessToken() string {
+	return a.AccessToken
+}
+
+// Method that returns the internal ID given to the account on DB
+func (a *GraphAccount) GetInternalID() int {
+	return a.InternID
+}
+
+// Method that sets all synced calendars associated with the account
+func (a *GraphAccount) SetCalendars(calendars []CalendarManager) {
+	a.calendars = calendars
+}
+
+// Method that returns all synced calendars associated with the account
+func (a *GraphAccount) GetSyncCalendars() []CalendarManager {
+	return a.calendars
+}
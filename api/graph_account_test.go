@@ -0,0 +1,73 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/TetAlius/GoSyncMyCalendars/api"
+	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
+)
+
+func TestGraphAccount_GetAllCalendarsPaginated(t *testing.T) {
+	_, teardown := setupStandIn(map[string]string{"graph/calendars": "/me/calendars"}, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("$skip") == "1" {
+			w.Write([]byte(`{"value":[{"id":"second","name":"Second"}]}`))
+			return
+		}
+		// same filter of the calendars as outlook
+		if r.URL.Query().Get("$filter") != "canEdit eq false" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, `{"@odata.nextLink":"http://%s%s?$skip=1","value":[{"id":"first","name":"First"}]}`, r.Host, r.URL.Path)
+	})
+	defer teardown()
+	account := api.RetrieveGraphAccount("Bearer", "refresh", "travis@example.com", api.GRAPH, "token")
+
+	calendars, err := account.GetAllCalendars()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(calendars) != 2 || calendars[1].GetID() != "second" || calendars[1].GetName() != "Second" {
+		t.Fatalf("something went wrong. Expected 2 calendars found %d", len(calendars))
+	}
+}
+
+func TestGraphAccount_GetCalendar(t *testing.T) {
+	_, teardown := setupStandIn(map[string]string{"graph/calendars/id": "/me/calendars/%s"}, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/me/calendars/calendar" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":"ErrorItemNotFound","message":"The specified object was not found in the store."}}`))
+			return
+		}
+		w.Write([]byte(`{"id":"calendar","name":"Calendar","canEdit":true}`))
+	})
+	defer teardown()
+	account := api.RetrieveGraphAccount("Bearer", "refresh", "travis@example.com", api.GRAPH, "token")
+
+	//wrong call without ID
+	_, err := account.GetCalendar("")
+	if err == nil {
+		t.Fatal("something went wrong. Expected error found nil")
+	}
+
+	//wrong call to a calendar that does not exist
+	_, err = account.GetCalendar("missing")
+	if _, ok := err.(*customErrors.NotFoundError); !ok {
+		t.Fatalf("something went wrong. Expected NotFoundError found %v", err)
+	}
+
+	//good call
+	calendar, err := account.GetCalendar("calendar")
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if calendar.GetID() != "calendar" || calendar.GetAccount() != account {
+		t.Fatalf("something went wrong. Expected calendar with its account found %s", calendar.GetID())
+	}
+}
@@ -0,0 +1,588 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/TetAlius/GoSyncMyCalendars/convert"
+	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
+	log "github.com/TetAlius/GoSyncMyCalendars/logger"
+	"github.com/TetAlius/GoSyncMyCalendars/util"
+)
+
+// Preferences of the requests that read events: dates in UTC and bodies as text
+const graphEventPreferences = `outlook.timezone="UTC", outlook.body-content-type="text"`
+
+// Error codes given by Graph when a delta link can not be used anymore
+var graphSyncStateErrors = map[string]bool{
+	"SyncStateNotFound": true,
+	"SyncStateInvalid":  true,
+	"syncStateNotFound": true,
+	"fullSyncRequired":  true,
+	"resyncRequired":    true,
+}
+
+// Method that returns a GraphCalendar given specific info
+func RetrieveGraphCalendar(ID string, uid string, account *GraphAccount) *GraphCalendar {
+	cal := new(GraphCalendar)
+	cal.ID = ID
+	cal.account = account
+	cal.uuid = uid
+	return cal
+}
+
+// Method that creates the calendar
+//
+// POST https://graph.microsoft.com/v1.0/me/calendars
+func (calendar *GraphCalendar) Create() (err error) {
+	return calendar.CreateContext(context.Background())
+}
+
+// Method like Create that is stopped once the given context is done
+func (calendar *GraphCalendar) CreateContext(ctx context.Context) (err error) {
+	log.Debugln("createCalendars graph")
+
+	route, err := util.GetRoute("graph/calendars")
+	if err != nil {
+		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+
+	data, err := json.Marshal(calendar)
+	if err != nil {
+		return errors.New(fmt.Sprintf("error marshalling calendar data: %s", err.Error()))
+	}
+
+	headers := make(map[string]string)
+	headers["Authorization"] = calendar.GetAccount().AuthorizationRequest()
+
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodPost,
+		route,
+		bytes.NewBuffer(data),
+		headers, nil)
+	if err != nil {
+		return util.RequestError(err, fmt.Sprintf("error creating a calendar for email %s", calendar.GetAccount().Mail()))
+	}
+	err = createGraphResponseError(contents)
+	if err != nil {
+		return err
+	}
+
+	calendarResponse := GraphCalendarResponse{OdataContext: "", GraphCalendar: calendar}
+	return json.Unmarshal(contents, &calendarResponse)
+}
+
+// Method that updates the calendar.
+// The default calendar can not be renamed, so its name is taken back from graph
+//
+// PATCH https://graph.microsoft.com/v1.0/me/calendars/{calendarID}
+func (calendar *GraphCalendar) Update() error {
+	return calendar.UpdateContext(context.Background())
+}
+
+// Method like Update that is stopped once the given context is done
+func (calendar *GraphCalendar) UpdateContext(ctx context.Context) error {
+	log.Debugln("updateCalendar graph")
+
+	route, err := util.GetRoute("graph/calendars/id")
+	if err != nil {
+		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+
+	data, err := json.Marshal(calendar)
+	if err != nil {
+		return errors.New(fmt.Sprintf("error marshalling calendar data: %s", err.Error()))
+	}
+
+	headers := make(map[string]string)
+	headers["Authorization"] = calendar.GetAccount().AuthorizationRequest()
+
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodPatch,
+		fmt.Sprintf(route, calendar.GetID()),
+		bytes.NewBuffer(data),
+		headers, nil)
+	if err != nil && strings.Contains(err.Error(), "default calendar cannot be renamed") {
+		cal, err := calendar.GetAccount().GetCalendarContext(ctx, calendar.GetID())
+		if err != nil {
+			return err
+		}
+		return convert.Convert(cal, calendar)
+	}
+	if err != nil {
+		return util.RequestError(err, fmt.Sprintf("error updating a calendar for email %s", calendar.GetAccount().Mail()))
+	}
+	err = createGraphResponseError(contents)
+	if err != nil {
+		return err
+	}
+
+	calendarResponse := GraphCalendarResponse{OdataContext: "", GraphCalendar: calendar}
+	return json.Unmarshal(contents, &calendarResponse)
+}
+
+// Method that deletes the calendar
+//
+// DELETE https://graph.microsoft.com/v1.0/me/calendars/{calendarID}
+func (calendar *GraphCalendar) Delete() (err error) {
+	return calendar.DeleteContext(context.Background())
+}
+
+// Method like Delete that is stopped once the given context is done
+func (calendar *GraphCalendar) DeleteContext(ctx context.Context) (err error) {
+	log.Debugln("deleteCalendar graph")
+	if len(calendar.GetID()) == 0 {
+		return errors.New("no ID for calendar was given")
+	}
+
+	route, err := util.GetRoute("graph/calendars/id")
+	if err != nil {
+		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+
+	headers := make(map[string]string)
+	headers["Authorization"] = calendar.GetAccount().AuthorizationRequest()
+
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodDelete,
+		fmt.Sprintf(route, calendar.GetID()),
+		nil,
+		headers, nil)
+	if err != nil {
+		return util.RequestError(err, fmt.Sprintf("error deleting a calendar for email %s", calendar.GetAccount().Mail()))
+	}
+
+	if len(contents) != 0 {
+		return createGraphResponseError(contents)
+	}
+	return
+}
+
+// Method that returns all events inside the calendar
+//
+// GET https://graph.microsoft.com/v1.0/me/calendars/{calendarID}/events
+func (calendar *GraphCalendar) GetAllEvents() (events []EventManager, err error) {
+	return calendar.GetAllEventsContext(context.Background())
+}
+
+// Method like GetAllEvents that is stopped once the given context is done
+func (calendar *GraphCalendar) GetAllEventsContext(ctx context.Context) (events []EventManager, err error) {
+	log.Debugln("getAllEvents graph")
+	err = calendar.ForEachEventPageContext(ctx, func(page []EventManager) error {
+		events = append(events, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Method that calls the given function with every page of events of the calendar.
+// If the sync window is limited, the calendar view inside it is used
+//
+// GET https://graph.microsoft.com/v1.0/me/calendars/{calendarID}/events
+// GET https://graph.microsoft.com/v1.0/me/calendars/{calendarID}/calendarView?startDateTime={start}&endDateTime={end}
+func (calendar *GraphCalendar) ForEachEventPage(fn func([]EventManager) error) (err error) {
+	return calendar.ForEachEventPageContext(context.Background(), fn)
+}
+
+// Method like ForEachEventPage that is stopped once the given context is done
+func (calendar *GraphCalendar) ForEachEventPageContext(ctx context.Context, fn func([]EventManager) error) (err error) {
+	var queryParams map[string]string
+	routeName := "graph/calendars/id/events"
+	if calendar.window.IsLimited() {
+		routeName = "graph/calendars/id/calendarview"
+		queryParams = calendar.viewParams(time.Now())
+	}
+	route, err := util.GetRoute(routeName)
+	if err != nil {
+		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+	link := fmt.Sprintf(route, calendar.GetID())
+
+	headers := make(map[string]string)
+	headers["Authorization"] = calendar.GetAccount().AuthorizationRequest()
+	headers["Prefer"] = graphEventPreferences
+
+	series := make(map[string]bool)
+	for {
+		contents, err := util.DoProviderRequestContext(ctx, http.MethodGet,
+			link,
+			nil,
+			headers, queryParams)
+		if err != nil {
+			return util.RequestError(err, fmt.Sprintf("error getting all events of a calendar for email %s", calendar.GetAccount().Mail()))
+		}
+
+		err = createGraphResponseError(contents)
+		if err != nil {
+			return err
+		}
+		eventListResponse := new(GraphEventListResponse)
+		err = json.Unmarshal(contents, &eventListResponse)
+		if err != nil {
+			return errors.New(fmt.Sprintf("error unmarshalling events: %s", err.Error()))
+		}
+
+		events, err := calendar.seriesMasters(ctx, eventListResponse.Events, series)
+		if err != nil {
+			return err
+		}
+		err = fn(events)
+		if err != nil || len(eventListResponse.OdataNextLink) == 0 {
+			return err
+		}
+		// next links already carry all the query params
+		link = eventListResponse.OdataNextLink
+		queryParams = nil
+	}
+}
+
+// Method that returns the bounds of the calendar view. The sides not limited by the
+// sync window cover the default range of days
+func (calendar *GraphCalendar) viewParams(now time.Time) map[string]string {
+	start := calendar.window.Start(now)
+	if start.IsZero() {
+		start = now.UTC().AddDate(0, 0, -outlookDeltaDays)
+	}
+	end := calendar.window.End(now)
+	if end.IsZero() {
+		end = now.UTC().AddDate(0, 0, outlookDeltaDays)
+	}
+	return map[string]string{
+		"startDateTime": start.Format(time.RFC3339),
+		"endDateTime":   end.Format(time.RFC3339),
+	}
+}
+
+// Method that replaces the occurrences given by calendar views with the master of
+// their series, so every series is synchronized once. Exceptions are kept after the
+// master, as they are instances changed on their own.
+// Series already given are stored on the map
+func (calendar *GraphCalendar) seriesMasters(ctx context.Context, graphEvents []*GraphEvent, series map[string]bool) (events []EventManager, err error) {
+	for _, event := range graphEvents {
+		event.SetCalendar(calendar)
+		if event.Type != "occurrence" && event.Type != "exception" || len(event.SeriesMasterID) == 0 {
+			if event.Type == "seriesMaster" {
+				if series[event.ID] {
+					continue
+				}
+				series[event.ID] = true
+			}
+			if event.Removed == nil {
+				event.setAllDay()
+			}
+			events = append(events, event)
+			continue
+		}
+		if !series[event.SeriesMasterID] {
+			series[event.SeriesMasterID] = true
+			master, err := calendar.GetEventContext(ctx, event.SeriesMasterID)
+			if _, ok := err.(*customErrors.NotFoundError); ok {
+				// the series was removed after the view was given
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, master)
+		}
+		if event.Type == "exception" {
+			event.setAllDay()
+			events = append(events, event)
+		}
+	}
+	return
+}
+
+// Method that returns the events changed since the given delta link and the delta link for the next call.
+// Removed events are returned with Deleted state
+//
+// GET https://graph.microsoft.com/v1.0/me/calendars/{calendarID}/calendarView/delta
+func (calendar *GraphCalendar) GetChangedEvents(token string) (events []EventManager, nextToken string, err error) {
+	return calendar.GetChangedEventsContext(context.Background(), token)
+}
+
+// Method like GetChangedEvents that is stopped once the given context is done
+func (calendar *GraphCalendar) GetChangedEventsContext(ctx context.Context, token string) (events []EventManager, nextToken string, err error) {
+	log.Debugln("getChangedEvents graph")
+	link := token
+	var queryParams map[string]string
+	if len(link) == 0 {
+		route, err := util.GetRoute("graph/calendars/id/calendarview/delta")
+		if err != nil {
+			return nil, "", errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+		}
+		link = fmt.Sprintf(route, calendar.GetID())
+		// the delta link keeps the bounds of the first call, which are the ones of the sync window
+		queryParams = calendar.viewParams(time.Now())
+	}
+
+	headers := make(map[string]string)
+	headers["Authorization"] = calendar.GetAccount().AuthorizationRequest()
+	headers["Prefer"] = graphEventPreferences
+
+	series := make(map[string]bool)
+	for {
+		contents, status, _, err := util.DoProviderRawRequestContext(ctx, http.MethodGet, link, nil, headers, queryParams)
+		if status == http.StatusGone {
+			return nil, "", &SyncTokenExpiredError{ID: calendar.GetID()}
+		}
+		// the expired sync states are also answered with bad requests
+		responseErr := createGraphResponseError(contents)
+		if graphErr, ok := responseErr.(*GraphError); ok && graphSyncStateErrors[graphErr.Code] {
+			return nil, "", &SyncTokenExpiredError{ID: calendar.GetID()}
+		}
+		if err != nil {
+			return nil, "", util.RequestError(err, fmt.Sprintf("error getting changed events of a calendar for email %s", calendar.GetAccount().Mail()))
+		}
+		if responseErr != nil {
+			return nil, "", responseErr
+		}
+		eventListResponse := new(GraphEventListResponse)
+		err = json.Unmarshal(contents, &eventListResponse)
+		if err != nil {
+			return nil, "", errors.New(fmt.Sprintf("error unmarshalling events: %s", err.Error()))
+		}
+		page, err := calendar.seriesMasters(ctx, eventListResponse.Events, series)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, event := range page {
+			if removed := event.(*GraphEvent).Removed; removed != nil {
+				if removed.Reason != "deleted" {
+					// only the ID is given, so there is nothing to synchronize
+					log.Debugf("event with id: %s removed from delta: %s", event.GetID(), removed.Reason)
+					continue
+				}
+				event.SetState(Deleted)
+			}
+			events = append(events, event)
+		}
+		if len(eventListResponse.OdataNextLink) == 0 {
+			return events, eventListResponse.OdataDeltaLink, nil
+		}
+		// next links already carry all the query params
+		link = eventListResponse.OdataNextLink
+		queryParams = nil
+	}
+}
+
+// Method that returns a single event given the ID
+//
+// GET https://graph.microsoft.com/v1.0/me/events/{eventID}
+func (calendar *GraphCalendar) GetEvent(ID string) (event EventManager, err error) {
+	return calendar.GetEventContext(context.Background(), ID)
+}
+
+// Method like GetEvent that is stopped once the given context is done
+func (calendar *GraphCalendar) GetEventContext(ctx context.Context, ID string) (event EventManager, err error) {
+	log.Debugln("getEvent graph")
+	if len(ID) == 0 {
+		return nil, errors.New("an ID for the event must be given")
+	}
+
+	route, err := util.GetRoute("graph/events/id")
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+
+	headers := make(map[string]string)
+	headers["Authorization"] = calendar.GetAccount().AuthorizationRequest()
+	headers["Prefer"] = graphEventPreferences
+
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodGet,
+		fmt.Sprintf(route, ID),
+		nil,
+		headers, nil)
+	if err != nil {
+		return nil, util.RequestError(err, fmt.Sprintf("error getting an event of a calendar for email %s", calendar.GetAccount().Mail()))
+	}
+	err = createGraphResponseError(contents)
+	if err != nil {
+		return
+	}
+
+	eventResponse := new(GraphEventResponse)
+	err = json.Unmarshal(contents, &eventResponse)
+	if err != nil {
+		return
+	}
+
+	e := eventResponse.GraphEvent
+	err = e.SetCalendar(calendar)
+	if err != nil {
+		return
+	}
+	e.setAllDay()
+	return e, nil
+}
+
+// Method that returns the instance of the given series that originally started at the given time.
+// Instances are looked for in the days around their original start
+//
+// GET https://graph.microsoft.com/v1.0/me/events/{eventID}/instances?startDateTime={start}&endDateTime={end}
+func (calendar *GraphCalendar) GetInstance(seriesID string, originalStart time.Time) (event EventManager, err error) {
+	return calendar.GetInstanceContext(context.Background(), seriesID, originalStart)
+}
+
+// Method like GetInstance that is stopped once the given context is done
+func (calendar *GraphCalendar) GetInstanceContext(ctx context.Context, seriesID string, originalStart time.Time) (event EventManager, err error) {
+	log.Debugln("getInstance graph")
+
+	route, err := util.GetRoute("graph/events/id/instances")
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+	link := fmt.Sprintf(route, seriesID)
+
+	headers := make(map[string]string)
+	headers["Authorization"] = calendar.GetAccount().AuthorizationRequest()
+	headers["Prefer"] = graphEventPreferences
+
+	queryParams := map[string]string{
+		"startDateTime": originalStart.UTC().AddDate(0, 0, -outlookInstanceDays).Format(time.RFC3339),
+		"endDateTime":   originalStart.UTC().AddDate(0, 0, outlookInstanceDays).Format(time.RFC3339),
+	}
+	for {
+		contents, err := util.DoProviderRequestContext(ctx, http.MethodGet,
+			link,
+			nil,
+			headers, queryParams)
+		if err != nil {
+			return nil, util.RequestError(err, fmt.Sprintf("error getting an instance of a calendar for email %s", calendar.GetAccount().Mail()))
+		}
+		err = createGraphResponseError(contents)
+		if err != nil {
+			return nil, err
+		}
+		eventListResponse := new(GraphEventListResponse)
+		err = json.Unmarshal(contents, &eventListResponse)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("error unmarshalling events: %s", err.Error()))
+		}
+		for _, instance := range eventListResponse.Events {
+			if instance.GetOriginalStart().Equal(originalStart) {
+				instance.SetCalendar(calendar)
+				instance.setAllDay()
+				return instance, nil
+			}
+		}
+		if len(eventListResponse.OdataNextLink) == 0 {
+			break
+		}
+		// next links already carry all the query params
+		link = eventListResponse.OdataNextLink
+		queryParams = nil
+	}
+	return nil, &customErrors.NotFoundError{Message: fmt.Sprintf("instance of event with id: %s starting at %s not found", seriesID, originalStart)}
+}
+
+// Method that writes the requests of several events with a single batch request
+//
+// POST https://graph.microsoft.com/v1.0/$batch
+func (calendar *GraphCalendar) doBatch(ctx context.Context, requests []util.BatchRequest) (responses []util.BatchResponse, err error) {
+	route, err := util.GetRoute("graph/batch")
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+	headers := make(map[string]string)
+	headers["Authorization"] = calendar.GetAccount().AuthorizationRequest()
+
+	responses, err = util.DoJSONBatchContext(ctx, route, headers, requests)
+	if err != nil {
+		return nil, util.RequestError(err, fmt.Sprintf("error writing batch of events for email %s", calendar.GetAccount().Mail()))
+	}
+	return
+}
+
+// Method that returns the maximum number of requests inside a batch of graph
+func (calendar *GraphCalendar) batchSize() int {
+	return 20
+}
+
+// Method that sets the account which the calendar belongs
+func (calendar *GraphCalendar) SetAccount(a AccountManager) (err error) {
+	switch x := a.(type) {
+	case *GraphAccount:
+		calendar.account = x
+	default:
+		return errors.New(fmt.Sprintf("type of account not valid for graph: %T", x))
+	}
+	return
+}
+
+// Method that returns the ID of the calendar
+func (calendar *GraphCalendar) GetID() string {
+	return calendar.ID
+}
+
+// Method that returns the ID formatted for a query request
+func (calendar *GraphCalendar) GetQueryID() string {
+	return calendar.ID
+}
+
+// Method that returns the account
+func (calendar *GraphCalendar) GetAccount() AccountManager {
+	return calendar.account
+}
+
+// Method that returns the name of the calendar
+func (calendar *GraphCalendar) GetName() string {
+	return calendar.Name
+}
+
+// Method that returns the internal UUID given to the calendar
+func (calendar *GraphCalendar) GetUUID() string {
+	return calendar.uuid
+}
+
+// Method that sets the internal UUID for the calendar
+func (calendar *GraphCalendar) SetUUID(id string) {
+	calendar.uuid = id
+}
+
+// Method that sets the synced calendars
+func (calendar *GraphCalendar) SetCalendars(calendars []CalendarManager) {
+	calendar.calendars = calendars
+}
+
+// Method that returns the synced calendar
+func (calendar *GraphCalendar) GetCalendars() []CalendarManager {
+	return calendar.calendars
+}
+
+// Method that creates an empty event
+func (calendar *GraphCalendar) CreateEmptyEvent(ID string) EventManager {
+	return &GraphEvent{ID: ID, calendar: calendar}
+}
+
+// Method that sets the window of days whose events are synchronized
+func (calendar *GraphCalendar) SetSyncWindow(window SyncWindow) {
+	calendar.window = window
+}
+
+// Method that returns the window of days whose events are synchronized
+func (calendar *GraphCalendar) GetSyncWindow() SyncWindow {
+	return calendar.window
+}
+
+// Method that returns whether the changed events asked without a token are bounded.
+// They are always bounded, as the delta is only given on calendar views
+func (calendar *GraphCalendar) HasBoundedChanges() bool {
+	return true
+}
+
+// Method that sets the options of the relation of the calendar
+func (calendar *GraphCalendar) SetSyncOptions(options SyncOptions) {
+	calendar.options = options
+}
+
+// Method that returns the options of the relation of the calendar
+func (calendar *GraphCalendar) GetSyncOptions() SyncOptions {
+	return calendar.options
+}
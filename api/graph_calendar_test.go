@@ -0,0 +1,106 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/TetAlius/GoSyncMyCalendars/api"
+	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
+)
+
+func TestGraphCalendar_GetChangedEvents(t *testing.T) {
+	var requests []string
+	_, teardown := setupStandIn(map[string]string{"graph/calendars/id/calendarview/delta": "/me/calendars/%s/calendarView/delta"}, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		requests = append(requests, query.Encode())
+		if r.Header.Get("Prefer") == "" || len(r.Header.Get("X-AnchorMailbox")) != 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		link := fmt.Sprintf("http://%s%s", r.Host, r.URL.Path)
+		switch {
+		case query.Get("$deltatoken") == "expired":
+			w.WriteHeader(http.StatusGone)
+			w.Write([]byte(`{"error":{"code":"SyncStateNotFound","message":"The sync state is not valid."}}`))
+		case query.Get("$deltatoken") == "first":
+			fmt.Fprintf(w, `{"@odata.deltaLink":"%s?$deltatoken=second","value":[{"id":"1","@removed":{"reason":"deleted"}},{"id":"2","@removed":{"reason":"changed"}},{"id":"3","isAllDay":true,"start":{"dateTime":"2018-06-14T00:00:00.0000000","timeZone":"UTC"},"end":{"dateTime":"2018-06-15T00:00:00.0000000","timeZone":"UTC"}}]}`, link)
+		case query.Get("$skiptoken") == "page2":
+			fmt.Fprintf(w, `{"@odata.deltaLink":"%s?$deltatoken=first","value":[{"id":"2","type":"singleInstance","start":{"dateTime":"2018-06-14T10:00:00.0000000","timeZone":"UTC"},"end":{"dateTime":"2018-06-14T11:00:00.0000000","timeZone":"UTC"}}]}`, link)
+		case len(query.Get("startDateTime")) != 0 && len(query.Get("endDateTime")) != 0:
+			fmt.Fprintf(w, `{"@odata.nextLink":"%s?$skiptoken=page2","value":[{"id":"1","type":"singleInstance","start":{"dateTime":"2018-06-14T10:00:00.0000000","timeZone":"UTC"},"end":{"dateTime":"2018-06-14T11:00:00.0000000","timeZone":"UTC"}}]}`, link)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	defer teardown()
+	calendar := api.RetrieveGraphCalendar("calendar", "", api.RetrieveGraphAccount("Bearer", "refresh", "travis@example.com", api.GRAPH, "token"))
+
+	// good call without token retrieves all pages
+	events, token, err := calendar.GetChangedEvents("")
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(events) != 2 || len(token) == 0 {
+		t.Fatalf("something went wrong. Expected 2 events and a delta link found %d events and delta link %s", len(events), token)
+	}
+
+	// good call with token retrieves only changes, the ones only changed on their ID are skipped
+	events, token, err = calendar.GetChangedEvents(token)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(events) != 2 || len(token) == 0 {
+		t.Fatalf("something went wrong. Expected 2 events and a delta link found %d events and delta link %s", len(events), token)
+	}
+	if events[0].GetState() != api.Deleted || events[0].GetID() != "1" || events[1].GetState() == api.Deleted || events[1].GetID() != "3" {
+		t.Fatal("something went wrong. Expected only removed event to be deleted")
+	}
+
+	// wrong call with a rejected token
+	_, _, err = calendar.GetChangedEvents(token[:len(token)-len("second")] + "expired")
+	if _, ok := err.(*api.SyncTokenExpiredError); !ok {
+		t.Fatalf("something went wrong. Expected SyncTokenExpiredError found %v", err)
+	}
+	if len(requests) != 4 {
+		t.Fatalf("something went wrong. Expected 4 requests found %d", len(requests))
+	}
+}
+
+func TestGraphCalendar_GetEvent(t *testing.T) {
+	_, teardown := setupStandIn(map[string]string{"graph/events/id": "/me/events/%s"}, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/me/events/event" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":"ErrorItemNotFound","message":"The specified object was not found in the store."}}`))
+			return
+		}
+		w.Write([]byte(`{"id":"event","subject":"Meeting","body":{"contentType":"text","content":"Agenda"},"showAs":"tentative","sensitivity":"private","start":{"dateTime":"2018-06-14T10:00:00.0000000","timeZone":"UTC"},"end":{"dateTime":"2018-06-14T11:00:00.0000000","timeZone":"UTC"},"originalStartTimeZone":"Romance Standard Time","originalEndTimeZone":"Romance Standard Time"}`))
+	})
+	defer teardown()
+	calendar := api.RetrieveGraphCalendar("calendar", "", api.RetrieveGraphAccount("Bearer", "refresh", "travis@example.com", api.GRAPH, "token"))
+
+	//wrong call without ID
+	_, err := calendar.GetEvent("")
+	if err == nil {
+		t.Fatal("something went wrong. Expected error found nil")
+	}
+
+	//wrong call to an event that does not exist
+	_, err = calendar.GetEvent("missing")
+	if _, ok := err.(*customErrors.NotFoundError); !ok {
+		t.Fatalf("something went wrong. Expected NotFoundError found %v", err)
+	}
+
+	//good call keeps the time zone of the origin
+	retrieved, err := calendar.GetEvent("event")
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	event := retrieved.(*api.GraphEvent)
+	if event.Subject != "Meeting" || event.Body.Description != "Agenda" || event.ShowAs != "tentative" {
+		t.Fatalf("something went wrong. Expected event with its fields found %v", event)
+	}
+	if event.Start.TimeZone.String() != "Europe/Paris" || event.Start.DateTime.Hour() != 10 {
+		t.Fatalf("something went wrong. Expected start at 10:00 UTC on Europe/Paris found %s on %s", event.Start.DateTime, event.Start.TimeZone)
+	}
+}
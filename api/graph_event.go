@@ -0,0 +1,748 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	conv "github.com/TetAlius/GoSyncMyCalendars/convert"
+	log "github.com/TetAlius/GoSyncMyCalendars/logger"
+	"github.com/TetAlius/GoSyncMyCalendars/util"
+)
+
+// Format of the dates of graph, given without offset as their time zone is apart
+const graphDateTimeFormat = "2006-01-02T15:04:05.999999999"
+
+// Function that returns the value of an Outlook enumeration as written on Graph, which uses camelCase
+func graphEnum(value string) string {
+	if len(value) == 0 {
+		return value
+	}
+	r, size := utf8.DecodeRuneInString(value)
+	return string(unicode.ToLower(r)) + value[size:]
+}
+
+// Function that returns the value of a Graph enumeration as written on Outlook, which uses PascalCase
+func outlookEnum(value string) string {
+	if len(value) == 0 {
+		return value
+	}
+	r, size := utf8.DecodeRuneInString(value)
+	return string(unicode.ToUpper(r)) + value[size:]
+}
+
+// Method that creates the event.
+// Attendees are only on the event, and so sent meeting requests, if the options of the relation say so
+//
+// POST https://graph.microsoft.com/v1.0/me/calendars/{calendarID}/events
+func (event *GraphEvent) Create() (err error) {
+	return event.CreateContext(context.Background())
+}
+
+// Method like Create that is stopped once the given context is done
+func (event *GraphEvent) CreateContext(ctx context.Context) (err error) {
+	log.Debugln("createEvent graph")
+	route, err := util.GetRoute("graph/calendars/id/events")
+	if err != nil {
+		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+	return event.write(ctx, http.MethodPost, fmt.Sprintf(route, event.GetCalendar().GetID()))
+}
+
+// Method that updates the event
+//
+// PATCH https://graph.microsoft.com/v1.0/me/events/{eventID}
+func (event *GraphEvent) Update() (err error) {
+	return event.UpdateContext(context.Background())
+}
+
+// Method like Update that is stopped once the given context is done
+func (event *GraphEvent) UpdateContext(ctx context.Context) (err error) {
+	log.Debugln("updateEvent graph")
+	route, err := util.GetRoute("graph/events/id")
+	if err != nil {
+		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+	return event.write(ctx, http.MethodPatch, fmt.Sprintf(route, event.ID))
+}
+
+// Method that writes the event with the given method and reads it back from the response
+func (event *GraphEvent) write(ctx context.Context, method string, route string) (err error) {
+	a := event.GetCalendar().GetAccount()
+	if event.Recurrence != nil && event.Start != nil {
+		event.Recurrence.complete(event.Start.DateTime.In(timeZoneOrUTC(event.Start.TimeZone)))
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return errors.New(fmt.Sprintf("error marshalling event data: %s", err.Error()))
+	}
+
+	headers := make(map[string]string)
+	headers["Authorization"] = a.AuthorizationRequest()
+
+	contents, err := util.DoProviderRequestContext(ctx, method,
+		route,
+		bytes.NewBuffer(data),
+		headers, nil)
+	if err != nil {
+		return util.RequestError(err, fmt.Sprintf("error writing event in a calendar for email %s", a.Mail()))
+	}
+	err = createGraphResponseError(contents)
+	if err != nil {
+		return err
+	}
+
+	eventResponse := GraphEventResponse{OdataContext: "", GraphEvent: event}
+	err = json.Unmarshal(contents, &eventResponse)
+	if err != nil {
+		return err
+	}
+	event.setAllDay()
+	return
+}
+
+// Method that deletes the event
+//
+// DELETE https://graph.microsoft.com/v1.0/me/events/{eventID}
+func (event *GraphEvent) Delete() (err error) {
+	return event.DeleteContext(context.Background())
+}
+
+// Method like Delete that is stopped once the given context is done
+func (event *GraphEvent) DeleteContext(ctx context.Context) (err error) {
+	a := event.GetCalendar().GetAccount()
+	log.Debugln("deleteEvent graph")
+
+	route, err := util.GetRoute("graph/events/id")
+	if err != nil {
+		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+
+	headers := make(map[string]string)
+	headers["Authorization"] = a.AuthorizationRequest()
+
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodDelete,
+		fmt.Sprintf(route, event.ID),
+		nil,
+		headers, nil)
+	if err != nil {
+		return util.RequestError(err, fmt.Sprintf("error deleting event of a calendar for email %s", a.Mail()))
+	}
+
+	if len(contents) != 0 {
+		return createGraphResponseError(contents)
+	}
+	return
+}
+
+// Method that returns the request that writes the event inside a batch as the operation says
+func (event *GraphEvent) batchRequest(operation int) (request util.BatchRequest, err error) {
+	name := "graph/events/id"
+	switch operation {
+	case Created:
+		name = "graph/calendars/id/events"
+		request = util.BatchRequest{Method: http.MethodPost}
+	case Updated:
+		request = util.BatchRequest{Method: http.MethodPatch}
+	case Deleted:
+		request = util.BatchRequest{Method: http.MethodDelete}
+	default:
+		return request, errors.New(fmt.Sprintf("operation %d not supported for event %s", operation, event.ID))
+	}
+	route, err := util.GetRoute(name)
+	if err != nil {
+		return request, errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+	if operation == Created {
+		request.URL = fmt.Sprintf(route, event.GetCalendar().GetID())
+	} else {
+		request.URL = fmt.Sprintf(route, event.ID)
+	}
+	if operation == Deleted {
+		return
+	}
+	if event.Recurrence != nil && event.Start != nil {
+		event.Recurrence.complete(event.Start.DateTime.In(timeZoneOrUTC(event.Start.TimeZone)))
+	}
+	request.Body, err = json.Marshal(event)
+	if err != nil {
+		return request, errors.New(fmt.Sprintf("error marshalling event data: %s", err.Error()))
+	}
+	return
+}
+
+// Method that reads the event back from the response of its request inside a batch
+func (event *GraphEvent) readBatchResponse(operation int, contents []byte) (err error) {
+	if operation == Deleted {
+		return
+	}
+	eventResponse := GraphEventResponse{OdataContext: "", GraphEvent: event}
+	err = json.Unmarshal(contents, &eventResponse)
+	if err != nil {
+		return err
+	}
+	event.setAllDay()
+	return
+}
+
+// Method that returns the ID of the event
+func (event *GraphEvent) GetID() string {
+	return event.ID
+}
+
+// Method that returns the calendar which have this event
+func (event *GraphEvent) GetCalendar() CalendarManager {
+	return event.calendar
+}
+
+// Method that returns the syncing events with this
+func (event *GraphEvent) GetRelations() []EventManager {
+	return event.relations
+}
+
+// Method that sets the calendar which have this event
+func (event *GraphEvent) SetCalendar(calendar CalendarManager) (err error) {
+	switch x := calendar.(type) {
+	case *GraphCalendar:
+		event.calendar = x
+	default:
+		return errors.New(fmt.Sprintf("type of calendar not valid for graph: %T", x))
+	}
+	return
+}
+
+// Method that checks if the event can try sync again
+func (event *GraphEvent) CanProcessAgain() bool {
+	return event.exponentialBackoff < maxBackoff
+}
+
+// Method that sets the events syncing with this
+func (event *GraphEvent) SetRelations(relations []EventManager) {
+	event.relations = relations
+}
+
+// Method that increments the number of failed attempts to sync
+func (event *GraphEvent) IncrementBackoff() {
+	event.exponentialBackoff += 1
+}
+
+// Method that sets the state of the event
+func (event *GraphEvent) SetState(stateInformed int) {
+	event.state = stateInformed
+}
+
+// Method that sets the internal ID generated on db
+func (event *GraphEvent) SetInternalID(internalID int) {
+	event.internalID = internalID
+}
+
+// Method that gets the internal ID of the event
+func (event *GraphEvent) GetInternalID() int {
+	return event.internalID
+}
+
+// Method that returns the state of the event
+func (event *GraphEvent) GetState() int {
+	return event.state
+}
+
+// Method that returns the ID of the series master. It is empty if the event is not an instance
+func (event *GraphEvent) GetSeriesID() string {
+	if event.Type != "occurrence" && event.Type != "exception" {
+		return ""
+	}
+	return event.SeriesMasterID
+}
+
+// Method that returns the start that the instance had inside its series
+func (event *GraphEvent) GetOriginalStart() time.Time {
+	if event.OriginalStart == nil {
+		return time.Time{}
+	}
+	return event.OriginalStart.UTC()
+}
+
+// Method that returns the start and end dates of the event.
+// The end is zero for recurring events
+func (event *GraphEvent) GetTimeRange() (start time.Time, end time.Time, err error) {
+	if event.Start == nil || event.End == nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("event %s has no dates", event.GetID())
+	}
+	return timeRange(event.Start.DateTime, event.End.DateTime, event.Recurrence != nil)
+}
+
+// Method that returns the last update date
+func (event *GraphEvent) GetUpdatedAt() (t time.Time, err error) {
+	t, err = time.Parse(time.RFC3339, event.LastModifiedDateTime)
+	if err != nil {
+		sentryClient().CaptureErrorAndWait(err, map[string]string{"api": "graph"})
+		return
+	}
+	return t.UTC(), nil
+}
+
+// Method that converts from a JSON to a GraphDateTimeTimeZone struct.
+// This method implements Unmarshaler interface
+func (date *GraphDateTimeTimeZone) UnmarshalJSON(b []byte) error {
+	var s struct {
+		DateTime string `json:"dateTime"`
+		TimeZone string `json:"timeZone"`
+	}
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	location, err := loadTimeZone(s.TimeZone)
+	if err != nil {
+		log.Errorf("error getting location: %s", err.Error())
+		return err
+	}
+	date.TimeZone = location
+
+	t, err := time.ParseInLocation(graphDateTimeFormat, s.DateTime, location)
+	if err != nil {
+		log.Errorf("error parsing time: %s", err.Error())
+	}
+	date.DateTime = t.UTC()
+	return nil
+}
+
+// Method that converts from GraphDateTimeTimeZone struct to a json.
+// Dates are written on the windows name of their time zone, as on Outlook.
+// All day dates are kept at midnight of their time zone.
+// This method implements Marshaler interface
+func (date *GraphDateTimeTimeZone) MarshalJSON() (b []byte, err error) {
+	if date.DateTime.IsZero() {
+		return bytes.NewBufferString("{}").Bytes(), nil
+	}
+	timeZone := timeZoneOrUTC(date.TimeZone)
+	windows, err := windowsTimeZone(timeZone)
+	if err != nil {
+		return nil, err
+	}
+	dateTime := date.DateTime.In(timeZone)
+	if date.IsAllDay {
+		// midnight of the day on the time zone of the date
+		dateTime = floatingDate(date.DateTime, timeZone)
+	}
+	return json.Marshal(map[string]string{
+		"dateTime": dateTime.Format(graphDateTimeFormat),
+		"timeZone": windows,
+	})
+}
+
+// Method that converts a GraphDateTimeTimeZone struct to a interface{}.
+// This method implements Deconverter interface
+func (date *GraphDateTimeTimeZone) Deconvert() interface{} {
+	return (*OutlookDateTimeTimeZone)(date).Deconvert()
+}
+
+// Method that converts an interface{} to a GraphDateTimeTimeZone struct.
+// This method implements Converter interface
+func (*GraphDateTimeTimeZone) Convert(m interface{}, tag string, opts string) (conv.Converter, error) {
+	date, err := (*OutlookDateTimeTimeZone)(nil).Convert(m, tag, opts)
+	if err != nil {
+		return nil, err
+	}
+	return (*GraphDateTimeTimeZone)(date.(*OutlookDateTimeTimeZone)), nil
+}
+
+// Method that converts a GraphItemBody struct to a interface{}.
+// This method implements Deconverter interface
+func (body *GraphItemBody) Deconvert() interface{} {
+	return body.Description
+}
+
+// Method that converts an interface{} to a GraphItemBody struct.
+// This method implements Converter interface
+func (*GraphItemBody) Convert(m interface{}, tag string, opts string) (conv.Converter, error) {
+	desc, ok := m.(string)
+	if !ok {
+		return nil, errors.New("incorrect type of field description")
+	}
+	return &GraphItemBody{ContentType: "text", Description: desc}, nil
+}
+
+// Method that converts a GraphAttendees to a interface{}.
+// This method implements Deconverter interface
+func (graphAttendees GraphAttendees) Deconvert() interface{} {
+	var outlookAttendees OutlookAttendees
+	for _, graphAttendee := range graphAttendees {
+		outlookAttendee := OutlookAttendee{Type: outlookEnum(graphAttendee.Type)}
+		if graphAttendee.EmailAddress != nil {
+			outlookAttendee.EmailAddress = &OutlookEmailAddress{Address: graphAttendee.EmailAddress.Address, Name: graphAttendee.EmailAddress.Name}
+		}
+		if graphAttendee.Status != nil {
+			outlookAttendee.Status = &OutlookStatus{Response: outlookEnum(graphAttendee.Status.Response), Time: graphAttendee.Status.Time}
+		}
+		outlookAttendees = append(outlookAttendees, outlookAttendee)
+	}
+	return outlookAttendees.Deconvert()
+}
+
+// Method that converts an interface{} to a GraphAttendees.
+// The response status can not be written on Graph, so it is kept only for reading.
+// This method implements Converter interface
+func (GraphAttendees) Convert(m interface{}, tag string, opts string) (conv.Converter, error) {
+	converted, err := OutlookAttendees(nil).Convert(m, tag, opts)
+	if err != nil {
+		return nil, err
+	}
+	var graphAttendees GraphAttendees
+	for _, outlookAttendee := range converted.(OutlookAttendees) {
+		graphAttendees = append(graphAttendees, GraphAttendee{
+			EmailAddress: &GraphEmailAddress{Address: outlookAttendee.EmailAddress.Address, Name: outlookAttendee.EmailAddress.Name},
+			Status:       &GraphStatus{Response: graphEnum(outlookAttendee.Status.Response)},
+			Type:         graphEnum(outlookAttendee.Type),
+		})
+	}
+	return graphAttendees, nil
+}
+
+// Method that sets whether the next writes of the event notify its attendees
+func (event *GraphEvent) notifyAttendees(notify bool) {
+	event.notify = notify
+}
+
+// Method that writes the attendees already converted as the mode says.
+// Graph sends meeting requests to every attendee of the events written, so
+// attendees are listed on the body instead of copied unless they have to be notified
+func (event *GraphEvent) writeAttendees(mode AttendeesMode) {
+	if mode == AttendeesCopied && !event.notify {
+		mode = AttendeesInBody
+	}
+	if event.Body == nil {
+		event.Body = &GraphItemBody{}
+	}
+	event.Body.Description = describeAttendees(event.Body.Description, event.Attendees.Deconvert().([]Attendee), mode)
+	if mode == AttendeesInBody {
+		event.Attendees = nil
+	}
+}
+
+// Method that returns the free/busy status of the event
+func (event *GraphEvent) readFreeBusy() (string, bool) {
+	status, ok := outlookFreeBusy[OutlookFreeBusyStatus(outlookEnum(event.ShowAs))]
+	return status, ok
+}
+
+// Method that writes the free/busy status. Graph has every status, so no fallback is needed
+func (event *GraphEvent) writeFreeBusy(status string, fallbacks FreeBusyFallbacks) {
+	for showAs, s := range outlookFreeBusy {
+		if s == status {
+			event.ShowAs = graphEnum(string(showAs))
+			return
+		}
+	}
+}
+
+// Method that returns the link to join the online meeting of the event.
+// Events without an online meeting of their own give the links listed on their body
+func (event *GraphEvent) readMeetingLinks() []MeetingLink {
+	if len(event.OnlineMeetingUrl) != 0 {
+		return []MeetingLink{{Kind: MeetingVideo, URI: event.OnlineMeetingUrl}}
+	}
+	if event.Body == nil {
+		return nil
+	}
+	return meetingLinksFromDescription(event.Body.Description)
+}
+
+// Method that writes the links to join the online meeting on the body,
+// as the online meeting URL can not be written
+func (event *GraphEvent) writeMeetingLinks(links []MeetingLink) {
+	if event.Body == nil {
+		event.Body = &GraphItemBody{}
+	}
+	event.Body.Description = describeMeetingLinks(event.Body.Description, links)
+}
+
+// Method that returns the attachments of the event and the ones listed on its body.
+// Graph does not give where the files are, so they are given as the link to the event,
+// where they can be downloaded from
+func (event *GraphEvent) readAttachments(ctx context.Context) (attachments []Attachment) {
+	if event.HasAttachments && event.Attachments == nil && event.calendar != nil {
+		err := event.getAttachments(ctx)
+		if err != nil {
+			log.Warningf("attachments of event %s not synced: %s", event.ID, err.Error())
+		}
+	}
+	for _, attachment := range event.Attachments {
+		if attachment.IsInline {
+			continue
+		}
+		// graph gives no link to download the files without the token of the account,
+		// so they are only listed by their name
+		attachments = append(attachments, Attachment{Name: attachment.Name, ContentType: attachment.ContentType})
+	}
+	if event.Body != nil {
+		attachments = append(attachments, attachmentsFromDescription(event.Body.Description)...)
+	}
+	return
+}
+
+// Method that lists the attachments on the body, as graph events are not written with attachments.
+// Files of the event itself, listed by their name, and links to the event are not written
+func (event *GraphEvent) writeAttachments(ctx context.Context, attachments []Attachment) {
+	files := event.fileNames(ctx, attachments)
+	var listed []Attachment
+	for _, attachment := range attachments {
+		switch {
+		case len(attachment.URL) == 0 && files[attachment.Name]:
+		case len(attachment.URL) != 0 && attachment.URL == event.Link:
+		default:
+			listed = append(listed, attachment)
+		}
+	}
+	if event.Body == nil {
+		event.Body = &GraphItemBody{}
+	}
+	event.Body.Description = describeAttachments(event.Body.Description, listed)
+}
+
+// Method that returns the names of the files attached to the event, retrieving them only if some
+// of the attachments given are listed by their name, as they may be the files of the event itself
+func (event *GraphEvent) fileNames(ctx context.Context, attachments []Attachment) (names map[string]bool) {
+	names = make(map[string]bool)
+	for _, attachment := range attachments {
+		if len(attachment.URL) != 0 {
+			continue
+		}
+		if event.Attachments == nil && len(event.ID) != 0 && event.calendar != nil {
+			err := event.getAttachments(ctx)
+			if err != nil {
+				log.Warningf("attachments of event %s not retrieved: %s", event.ID, err.Error())
+			}
+		}
+		break
+	}
+	for _, attachment := range event.Attachments {
+		if !attachment.IsInline {
+			names[attachment.Name] = true
+		}
+	}
+	return
+}
+
+// Method that retrieves the attachments of the event
+//
+// GET https://graph.microsoft.com/v1.0/me/events/{eventID}/attachments?$select=name,contentType,size,isInline
+func (event *GraphEvent) getAttachments(ctx context.Context) (err error) {
+	a := event.GetCalendar().GetAccount()
+	route, err := util.GetRoute("graph/events/id/attachments")
+	if err != nil {
+		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+
+	headers := make(map[string]string)
+	headers["Authorization"] = a.AuthorizationRequest()
+
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodGet,
+		fmt.Sprintf(route, event.ID),
+		nil,
+		headers, map[string]string{"$select": graphAttachmentsSelect})
+	if err != nil {
+		return util.RequestError(err, fmt.Sprintf("error getting attachments of an event for email %s", a.Mail()))
+	}
+	err = createGraphResponseError(contents)
+	if err != nil {
+		return
+	}
+
+	attachmentsResponse := new(GraphAttachmentListResponse)
+	err = json.Unmarshal(contents, &attachmentsResponse)
+	if err != nil {
+		return errors.New(fmt.Sprintf("error unmarshalling attachments: %s", err.Error()))
+	}
+	event.Attachments = attachmentsResponse.Attachments
+	return
+}
+
+// Method that returns the categories of the event
+func (event *GraphEvent) readCategories(colors CategoryColors) ([]string, bool) {
+	return event.Categories, len(event.Categories) != 0
+}
+
+// Method that writes the categories of the event
+func (event *GraphEvent) writeCategories(categories []string, colors CategoryColors) {
+	event.Categories = categories
+}
+
+// Method that converts a GraphSensitivity to a interface{}.
+// This method implements Deconverter interface
+func (sensitivity GraphSensitivity) Deconvert() interface{} {
+	return OutlookSensitivity(outlookEnum(string(sensitivity))).Deconvert()
+}
+
+// Method that converts an interface{} to a GraphSensitivity.
+// This method implements Converter interface
+func (GraphSensitivity) Convert(m interface{}, tag string, opts string) (conv.Converter, error) {
+	sensitivity, err := OutlookSensitivity("").Convert(m, tag, opts)
+	if err != nil {
+		return nil, err
+	}
+	return GraphSensitivity(graphEnum(string(sensitivity.(OutlookSensitivity)))), nil
+}
+
+// Method that returns the minutes before the start of the reminder of the event
+func (event *GraphEvent) readReminders(ctx context.Context) ([]int, bool) {
+	if event.IsReminderOn == nil {
+		return nil, false
+	}
+	if !*event.IsReminderOn || event.ReminderMinutesBeforeStart == nil {
+		return []int{}, true
+	}
+	return []int{int(*event.ReminderMinutesBeforeStart)}, true
+}
+
+// Method that writes the reminders given as minutes before the start.
+// Graph only has one reminder, so several ones are collapsed into the earliest one
+func (event *GraphEvent) writeReminders(minutes []int) {
+	on := len(minutes) != 0
+	event.IsReminderOn = &on
+	event.ReminderMinutesBeforeStart = nil
+	if on {
+		earliest := int32(earliestReminder(minutes))
+		event.ReminderMinutesBeforeStart = &earliest
+	}
+}
+
+// Method that converts a GraphLocation struct to a interface{}.
+// This method implements Deconverter interface
+func (location *GraphLocation) Deconvert() interface{} {
+	coordinates := location.Coordinates
+	return Location{
+		DisplayName:    location.DisplayName,
+		Street:         location.Address.Street,
+		City:           location.Address.City,
+		State:          location.Address.State,
+		PostalCode:     location.Address.PostalCode,
+		Country:        location.Address.CountryOrRegion,
+		HasCoordinates: coordinates.Latitude != 0 || coordinates.Longitude != 0,
+		Latitude:       coordinates.Latitude,
+		Longitude:      coordinates.Longitude,
+	}
+}
+
+// Method that converts an interface{} to a GraphLocation struct.
+// This method implements Converter interface
+func (*GraphLocation) Convert(m interface{}, tag string, opts string) (conv.Converter, error) {
+	location, err := locationFrom(m)
+	if err != nil || location == nil {
+		return (*GraphLocation)(nil), err
+	}
+	graphLocation := &GraphLocation{
+		DisplayName: location.DisplayName,
+		Address: GraphPhysicalAddress{
+			Street:          location.Street,
+			City:            location.City,
+			State:           location.State,
+			PostalCode:      location.PostalCode,
+			CountryOrRegion: location.Country,
+		},
+	}
+	if location.HasCoordinates {
+		graphLocation.Coordinates = GraphGeoCoordinates{Latitude: location.Latitude, Longitude: location.Longitude}
+	}
+	return graphLocation, nil
+}
+
+// Method that returns the recurrence as an Outlook recurrence, whose conversions are shared
+func (recurrence *GraphPatternedRecurrence) outlook() *OutlookPatternedRecurrence {
+	pattern := OutlookRecurrencePattern(recurrence.Pattern)
+	pattern.Type = outlookEnum(pattern.Type)
+	pattern.FirstDayOfWeek = outlookEnum(pattern.FirstDayOfWeek)
+	pattern.Index = outlookEnum(pattern.Index)
+	pattern.DaysOfWeek = nil
+	for _, day := range recurrence.Pattern.DaysOfWeek {
+		pattern.DaysOfWeek = append(pattern.DaysOfWeek, outlookEnum(day))
+	}
+	recurrenceRange := OutlookRecurrenceRange(recurrence.Range)
+	recurrenceRange.Type = outlookEnum(recurrenceRange.Type)
+	return &OutlookPatternedRecurrence{Pattern: pattern, RecurrenceTimeZone: recurrence.RecurrenceTimeZone, Range: recurrenceRange,
+		allDay: recurrence.allDay, until: recurrence.until}
+}
+
+// Function that returns an Outlook recurrence as written on Graph
+func newGraphRecurrence(recurrence *OutlookPatternedRecurrence) *GraphPatternedRecurrence {
+	if recurrence == nil {
+		return nil
+	}
+	pattern := GraphRecurrencePattern(recurrence.Pattern)
+	pattern.Type = graphEnum(pattern.Type)
+	pattern.FirstDayOfWeek = graphEnum(pattern.FirstDayOfWeek)
+	pattern.Index = graphEnum(pattern.Index)
+	pattern.DaysOfWeek = nil
+	for _, day := range recurrence.Pattern.DaysOfWeek {
+		pattern.DaysOfWeek = append(pattern.DaysOfWeek, graphEnum(day))
+	}
+	recurrenceRange := GraphRecurrenceRange(recurrence.Range)
+	recurrenceRange.Type = graphEnum(recurrenceRange.Type)
+	return &GraphPatternedRecurrence{Pattern: pattern, RecurrenceTimeZone: recurrence.RecurrenceTimeZone, Range: recurrenceRange,
+		allDay: recurrence.allDay, until: recurrence.until}
+}
+
+// Method that converts a GraphPatternedRecurrence struct to a interface{}.
+// A recurrence that can not be expressed as RRULE lines is given as its error.
+// This method implements Deconverter interface
+func (recurrence *GraphPatternedRecurrence) Deconvert() interface{} {
+	return recurrence.outlook().Deconvert()
+}
+
+// Method that converts an interface{} to a GraphPatternedRecurrence struct.
+// This method implements Converter interface
+func (*GraphPatternedRecurrence) Convert(m interface{}, tag string, opts string) (conv.Converter, error) {
+	lines, err := recurrenceLines(m)
+	if err != nil {
+		return nil, err
+	}
+	recurrence, err := newOutlookRecurrence(lines)
+	if err != nil {
+		return nil, err
+	}
+	return newGraphRecurrence(recurrence), nil
+}
+
+// Method that completes the parts of the recurrence that depend on the start of the event
+func (recurrence *GraphPatternedRecurrence) complete(start time.Time) {
+	outlookRecurrence := recurrence.outlook()
+	outlookRecurrence.complete(start)
+	*recurrence = *newGraphRecurrence(outlookRecurrence)
+}
+
+// Method that sets all day to the necessary attributes
+func (event *GraphEvent) setAllDay() {
+	if event.Start != nil {
+		event.Start.IsAllDay = event.IsAllDay
+	}
+	if event.End != nil {
+		event.End.IsAllDay = event.IsAllDay
+	}
+	if event.Recurrence != nil {
+		event.Recurrence.allDay = event.IsAllDay
+	}
+	event.setOriginalTimeZones()
+}
+
+// Method that sets to the dates the time zones the event was created with,
+// as the dates are always asked in UTC. Dates with time zones that are not known,
+// like the custom ones, are kept in UTC and the error is logged
+func (event *GraphEvent) setOriginalTimeZones() {
+	dates := []*GraphDateTimeTimeZone{event.Start, event.End}
+	for i, name := range []string{event.OriginalStartTimeZone, event.OriginalEndTimeZone} {
+		if dates[i] == nil || len(name) == 0 {
+			continue
+		}
+		location, err := loadTimeZone(name)
+		if err != nil {
+			log.Errorf("time zone of event %s not kept: %s", event.ID, err.Error())
+			continue
+		}
+		dates[i].TimeZone = location
+	}
+}
@@ -0,0 +1,91 @@
+package api_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/TetAlius/GoSyncMyCalendars/api"
+)
+
+func TestGraphEvent_GoogleToGraph(t *testing.T) {
+	var created map[string]interface{}
+	var path string
+	_, teardown := setupStandIn(map[string]string{"graph/calendars/id/events": "/me/calendars/%s/events"}, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &created)
+		created["id"] = "created"
+		json.NewEncoder(w).Encode(created)
+	})
+	defer teardown()
+
+	googleEvent := new(api.GoogleEvent)
+	err := json.Unmarshal([]byte(`{"id":"series","summary":"Weekly","description":"Agenda","visibility":"private","start":{"dateTime":"2018-03-08T10:00:00-05:00","timeZone":"America/New_York"},"end":{"dateTime":"2018-03-08T11:00:00-05:00","timeZone":"America/New_York"},"recurrence":["RRULE:FREQ=WEEKLY;BYDAY=TH;COUNT=4"]}`), googleEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	googleEvent.SetCalendar(api.RetrieveGoogleCalendar("primary", "", &api.GoogleAccount{}))
+	graphEvent := new(api.GraphEvent)
+	graphEvent.SetCalendar(api.RetrieveGraphCalendar("calendar", "", api.RetrieveGraphAccount("Bearer", "refresh", "travis@example.com", api.GRAPH, "token")))
+	err = api.ConvertEvent(googleEvent, graphEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	err = graphEvent.Create()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if path != "/me/calendars/calendar/events" || graphEvent.GetID() != "created" {
+		t.Fatalf("something went wrong. Expected event created on calendar found path %s and ID %s", path, graphEvent.GetID())
+	}
+
+	// payload is written in camelCase, also the values of the enumerations
+	if created["subject"] != "Weekly" || created["sensitivity"] != "private" {
+		t.Fatalf("something went wrong. Expected subject and sensitivity in camelCase found %v", created)
+	}
+	body := created["body"].(map[string]interface{})
+	if body["contentType"] != "text" || body["content"] != "Agenda" {
+		t.Fatalf("something went wrong. Expected text body found %v", body)
+	}
+	start := created["start"].(map[string]interface{})
+	if start["dateTime"] != "2018-03-08T10:00:00" || start["timeZone"] != "Eastern Standard Time" {
+		t.Fatalf("something went wrong. Expected 10:00 on Eastern Standard Time found %v", start)
+	}
+	recurrence := created["recurrence"].(map[string]interface{})
+	pattern := recurrence["pattern"].(map[string]interface{})
+	if pattern["type"] != "weekly" || pattern["daysOfWeek"].([]interface{})[0] != "thursday" {
+		t.Fatalf("something went wrong. Expected weekly pattern on thursday found %v", pattern)
+	}
+	rng := recurrence["range"].(map[string]interface{})
+	if rng["type"] != "numbered" || rng["numberOfOccurrences"] != float64(4) || rng["startDate"] != "2018-03-08" {
+		t.Fatalf("something went wrong. Expected numbered range of 4 found %v", rng)
+	}
+}
+
+func TestGraphEvent_GraphToGoogle(t *testing.T) {
+	graphEvent := new(api.GraphEvent)
+	err := json.Unmarshal([]byte(`{"id":"event","subject":"Meeting","body":{"contentType":"text","content":"Agenda"},"sensitivity":"confidential","showAs":"oof","start":{"dateTime":"2018-06-14T10:00:00.0000000","timeZone":"UTC"},"end":{"dateTime":"2018-06-14T11:00:00.0000000","timeZone":"UTC"},"recurrence":{"pattern":{"type":"absoluteMonthly","interval":1,"dayOfMonth":14},"range":{"type":"endDate","startDate":"2018-06-14","endDate":"2018-12-14"}},"attendees":[{"type":"optional","status":{"response":"accepted"},"emailAddress":{"address":"guest@example.com","name":"Guest"}}]}`), graphEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	graphCalendar := api.RetrieveGraphCalendar("calendar", "", api.RetrieveGraphAccount("Bearer", "refresh", "travis@example.com", api.GRAPH, "token"))
+	graphCalendar.SetSyncOptions(api.SyncOptions{Attendees: api.AttendeesCopied})
+	graphEvent.SetCalendar(graphCalendar)
+	googleEvent := new(api.GoogleEvent)
+	googleEvent.SetCalendar(api.RetrieveGoogleCalendar("primary", "", &api.GoogleAccount{}))
+	err = api.ConvertEvent(graphEvent, googleEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if googleEvent.Subject != "Meeting" || googleEvent.Description != "Agenda" || googleEvent.Visibility != "confidential" {
+		t.Fatalf("something went wrong. Expected subject, description and visibility found %s, %s, %s", googleEvent.Subject, googleEvent.Description, googleEvent.Visibility)
+	}
+	if len(googleEvent.Recurrences) != 1 || googleEvent.Recurrences[0] != "RRULE:FREQ=MONTHLY;UNTIL=20181214T235959Z;BYMONTHDAY=14" {
+		t.Fatalf("something went wrong. Expected monthly recurrence found %v", googleEvent.Recurrences)
+	}
+	if len(googleEvent.Attendees) != 1 || !googleEvent.Attendees[0].Optional || googleEvent.Attendees[0].ResponseStatus != "accepted" {
+		t.Fatalf("something went wrong. Expected optional attendee that accepted found %v", googleEvent.Attendees)
+	}
+}
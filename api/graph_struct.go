@@ -0,0 +1,318 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
+	"github.com/google/uuid"
+)
+
+type GraphError struct {
+	GraphConcreteError `json:"error,omitempty"`
+}
+
+type GraphConcreteError struct {
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+func (err GraphError) Error() string {
+	return fmt.Sprintf("code: %s. message: %s", err.Code, err.Message)
+}
+
+type GraphAccount struct {
+	TokenType         string            `json:"token_type"`
+	ExpiresIn         int               `json:"expires_in"`
+	AccessToken       string            `json:"access_token"`
+	RefreshToken      string            `json:"refresh_token"`
+	TokenID           string            `json:"id_token"`
+	AnchorMailbox     string            `json:"-"`
+	PreferredUsername bool              `json:"-"`
+	Kind              int               `json:"-"`
+	InternID          int               `json:"-"`
+	calendars         []CalendarManager `json:"-"`
+}
+
+type GraphCalendarResponse struct {
+	OdataContext string `json:"@odata.context"`
+	*GraphCalendar
+}
+
+type GraphCalendarListResponse struct {
+	OdataContext  string           `json:"@odata.context"`
+	OdataNextLink string           `json:"@odata.nextLink,omitempty"`
+	Calendars     []*GraphCalendar `json:"value"`
+}
+
+type GraphCalendar struct {
+	uuid      string
+	account   *GraphAccount
+	calendars []CalendarManager
+	window    SyncWindow
+	options   SyncOptions
+
+	CanEdit             bool              `json:"canEdit,omitempty"`
+	CanShare            bool              `json:"canShare,omitempty"`
+	CanViewPrivateItems bool              `json:"canViewPrivateItems,omitempty"`
+	ChangeKey           string            `json:"changeKey,omitempty"`
+	Color               string            `json:"color,omitempty"`
+	ID                  string            `json:"id,omitempty"`
+	Name                string            `json:"name,omitempty" convert:"Name"`
+	Owner               GraphEmailAddress `json:"owner,omitempty"`
+}
+
+type GraphEmailAddress struct {
+	Address string `json:"address,omitempty"`
+	Name    string `json:"name,omitempty"`
+}
+
+type GraphEventResponse struct {
+	OdataContext string `json:"@odata.context"`
+	*GraphEvent
+}
+
+type GraphEventListResponse struct {
+	OdataContext   string        `json:"@odata.context"`
+	OdataNextLink  string        `json:"@odata.nextLink,omitempty"`
+	OdataDeltaLink string        `json:"@odata.deltaLink,omitempty"`
+	Events         []*GraphEvent `json:"value"`
+}
+
+type GraphEvent struct {
+	calendar           *GraphCalendar
+	relations          []EventManager
+	state              int
+	exponentialBackoff int
+	internalID         int
+	// Whether the writes of the event notify its attendees
+	notify bool
+
+	ID string `json:"id,omitempty"`
+	// Only given on delta responses for the events removed
+	Removed *GraphRemoved `json:"@removed,omitempty"`
+
+	Subject     string         `json:"subject,omitempty" convert:"Subject"`
+	Description string         `json:"bodyPreview,omitempty"`
+	IsAllDay    bool           `json:"isAllDay,omitempty" convert:"allDay"`
+	Body        *GraphItemBody `json:"body,omitempty" convert:"Description"`
+
+	Start                      *GraphDateTimeTimeZone `json:"start,omitempty" convert:"start"`
+	End                        *GraphDateTimeTimeZone `json:"end,omitempty" convert:"end"`
+	Categories                 []string               `json:"categories,omitempty"`
+	ChangeKey                  string                 `json:"changeKey,omitempty"`
+	OnlineMeetingUrl           string                 `json:"onlineMeetingUrl,omitempty"`
+	OriginalStartTimeZone      string                 `json:"originalStartTimeZone,omitempty"`
+	OriginalEndTimeZone        string                 `json:"originalEndTimeZone,omitempty"`
+	ReminderMinutesBeforeStart *int32                 `json:"reminderMinutesBeforeStart,omitempty"`
+	ResponseRequested          bool                   `json:"responseRequested,omitempty"`
+	SeriesMasterID             string                 `json:"seriesMasterId,omitempty"`
+	// Pointers, so turning off a reminder or setting it at the start is written
+	IsReminderOn *bool `json:"isReminderOn,omitempty"`
+	// Start that an occurrence or exception had inside its series
+	OriginalStart *time.Time `json:"originalStart,omitempty"`
+
+	Organizer *GraphRecipient `json:"organizer,omitempty"`
+	// Retrieved on their own, HasAttachments says whether there are some to retrieve.
+	// They are not written back with the event
+	Attachments    []GraphAttachment `json:"-"`
+	HasAttachments bool              `json:"hasAttachments,omitempty"`
+	// Copied or listed on the body depending on the options of the relation
+	Attendees GraphAttendees `json:"attendees,omitempty" convert:"attendees"`
+
+	Importance string `json:"importance,omitempty"`
+
+	Recurrence     *GraphPatternedRecurrence `json:"recurrence,omitempty" convert:"recurrence"`
+	ResponseStatus *GraphResponseStatus      `json:"responseStatus,omitempty"`
+	Sensitivity    GraphSensitivity          `json:"sensitivity,omitempty" convert:"visibility"`
+	ShowAs         string                    `json:"showAs,omitempty"`
+
+	// The event type: singleInstance, occurrence, exception, seriesMaster.
+	Type     string         `json:"type,omitempty"`
+	Location *GraphLocation `json:"location,omitempty" convert:"location"`
+
+	//Not to sync
+	Link string `json:"webLink,omitempty"`
+
+	//Not to sync and use
+	IsCancelled          bool   `json:"isCancelled,omitempty"`
+	IsOrganizer          bool   `json:"isOrganizer,omitempty"`
+	CreatedDateTime      string `json:"createdDateTime,omitempty"`
+	LastModifiedDateTime string `json:"lastModifiedDateTime,omitempty"`
+}
+
+type GraphRemoved struct {
+	// Why the event is not on the delta anymore: changed or deleted
+	Reason string `json:"reason,omitempty"`
+}
+
+// Properties of the attachments retrieved, leaving out the contents of the files
+const graphAttachmentsSelect = "name,contentType,size,isInline"
+
+type GraphAttachment struct {
+	ID string `json:"id,omitempty"`
+	// Kind of attachment: file, item or reference
+	OdataType            string `json:"@odata.type,omitempty"`
+	ContentType          string `json:"contentType,omitempty"`
+	IsInline             bool   `json:"isInline,omitempty"`
+	LastModifiedDateTime string `json:"lastModifiedDateTime,omitempty"`
+	Name                 string `json:"name,omitempty"`
+	Size                 int32  `json:"size,omitempty"`
+}
+
+type GraphAttachmentListResponse struct {
+	OdataContext string            `json:"@odata.context"`
+	Attachments  []GraphAttachment `json:"value"`
+}
+
+type GraphAttendee struct {
+	EmailAddress *GraphEmailAddress `json:"emailAddress,omitempty"`
+	Status       *GraphStatus       `json:"status,omitempty"`
+	// The type of attendee: required, optional, resource.
+	Type string `json:"type,omitempty"`
+}
+
+type GraphAttendees []GraphAttendee
+
+type GraphRecipient struct {
+	EmailAddress *GraphEmailAddress `json:"emailAddress,omitempty"`
+}
+
+type GraphStatus struct {
+	Response string `json:"response,omitempty"`
+	Time     string `json:"time,omitempty"`
+}
+
+type GraphItemBody struct {
+	ContentType string `json:"contentType,omitempty"`
+	Description string `json:"content,omitempty"`
+}
+
+// Same fields as OutlookDateTimeTimeZone, so the conversions of the dates are shared
+type GraphDateTimeTimeZone struct {
+	DateTime time.Time      `json:"dateTime,omitempty" convert:"dateTime"`
+	TimeZone *time.Location `json:"timeZone,omitempty" convert:"timeZone"`
+	IsAllDay bool           `json:"-" convert:"isAllDay"`
+}
+
+type GraphLocation struct {
+	Address              GraphPhysicalAddress `json:"address,omitempty"`
+	Coordinates          GraphGeoCoordinates  `json:"coordinates,omitempty"`
+	DisplayName          string               `json:"displayName,omitempty"`
+	LocationEmailAddress string               `json:"locationEmailAddress,omitempty"`
+}
+
+type GraphPhysicalAddress struct {
+	Street          string `json:"street,omitempty"`
+	City            string `json:"city,omitempty"`
+	State           string `json:"state,omitempty"`
+	CountryOrRegion string `json:"countryOrRegion,omitempty"`
+	PostalCode      string `json:"postalCode,omitempty"`
+}
+
+type GraphGeoCoordinates struct {
+	Altitude         float64 `json:"altitude,omitempty"`
+	Latitude         float64 `json:"latitude,omitempty"`
+	Longitude        float64 `json:"longitude,omitempty"`
+	Accuracy         float64 `json:"accuracy,omitempty"`
+	AltitudeAccuracy float64 `json:"altitudeAccuracy,omitempty"`
+}
+
+// Same fields as OutlookPatternedRecurrence, with the values of its enumerations in camelCase
+type GraphPatternedRecurrence struct {
+	Pattern            GraphRecurrencePattern `json:"pattern,omitempty"`
+	RecurrenceTimeZone string                 `json:"recurrenceTimeZone,omitempty"`
+	Range              GraphRecurrenceRange   `json:"range,omitempty"`
+	// Whether the event of the recurrence is all day
+	allDay bool
+	// End of a recurrence converted from a rule, whose day depends on the time zone of the event
+	until time.Time
+}
+
+type GraphRecurrencePattern struct {
+	// The recurrence pattern type: daily, weekly, absoluteMonthly, relativeMonthly, absoluteYearly, relativeYearly.
+	Type       string `json:"type,omitempty"`
+	Interval   int    `json:"interval,omitempty"`
+	DayOfMonth int    `json:"dayOfMonth,omitempty"`
+	Month      int    `json:"month,omitempty"`
+	// The day of the week: sunday, monday, tuesday, wednesday, thursday, friday, saturday.
+	DaysOfWeek     []string `json:"daysOfWeek,omitempty"`
+	FirstDayOfWeek string   `json:"firstDayOfWeek,omitempty"`
+	// The week index: first, second, third, fourth, last.
+	Index string `json:"index,omitempty"`
+}
+
+type GraphRecurrenceRange struct {
+	// The recurrence range: endDate, noEnd, numbered.
+	Type                string `json:"type,omitempty"`
+	StartDate           string `json:"startDate,omitempty"`
+	EndDate             string `json:"endDate,omitempty"`
+	NumberOfOccurrences int    `json:"numberOfOccurrences,omitempty"`
+}
+
+type GraphResponseStatus struct {
+	Response string `json:"response,omitempty"`
+	Time     string `json:"time,omitempty"`
+}
+
+// Indicates the level of privacy for the event: normal, personal, private, confidential.
+type GraphSensitivity string
+
+type GraphSubscription struct {
+	calendar        *GraphCalendar
+	Resource        string `json:"resource,omitempty"`
+	NotificationURL string `json:"notificationUrl,omitempty"`
+	// Where graph tells when the subscription must be reauthorized, has been removed or has missed notifications
+	LifecycleNotificationURL string `json:"lifecycleNotificationUrl,omitempty"`
+	//created,updated,deleted
+	ChangeType         string    `json:"changeType,omitempty"`
+	ID                 string    `json:"id,omitempty"`
+	ClientState        string    `json:"clientState,omitempty"`
+	ExpirationDateTime string    `json:"expirationDateTime,omitempty"`
+	Type               string    `json:"@odata.type,omitempty"`
+	Uuid               uuid.UUID `json:"-"`
+	expirationDate     time.Time
+}
+
+type GraphNotification struct {
+	Subscriptions []GraphSubscriptionNotification `json:"value"`
+}
+
+type GraphSubscriptionNotification struct {
+	SubscriptionID                 string            `json:"subscriptionId"`
+	SubscriptionExpirationDateTime string            `json:"subscriptionExpirationDateTime"`
+	ClientState                    string            `json:"clientState,omitempty"`
+	Resource                       string            `json:"resource,omitempty"`
+	Data                           GraphResourceData `json:"resourceData"`
+	//created,updated,deleted
+	ChangeType string `json:"changeType,omitempty"`
+	// Only given on lifecycle notifications: reauthorizationRequired, subscriptionRemoved, missed
+	LifecycleEvent string `json:"lifecycleEvent,omitempty"`
+}
+
+type GraphResourceData struct {
+	ID string `json:"id"`
+}
+
+// Error codes given by Graph when the item asked for does not exist
+var graphNotFoundErrors = map[string]bool{
+	"ErrorItemNotFound": true,
+	"ResourceNotFound":  true,
+	"itemNotFound":      true,
+}
+
+func createGraphResponseError(contents []byte) (err error) {
+	e := new(GraphError)
+	err = json.Unmarshal(contents, &e)
+	if err != nil {
+		return err
+	}
+	if len(e.Code) != 0 && len(e.Message) != 0 {
+		if graphNotFoundErrors[e.Code] {
+			return &customErrors.NotFoundError{Message: e.Message}
+		}
+		return e
+	}
+	return nil
+}
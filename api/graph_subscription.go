@@ -0,0 +1,238 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
+	log "github.com/TetAlius/GoSyncMyCalendars/logger"
+	"github.com/TetAlius/GoSyncMyCalendars/util"
+	"github.com/google/uuid"
+)
+
+const (
+	// Type stored for the graph subscriptions
+	graphSubscriptionType = "#microsoft.graph.subscription"
+	// Resource watched by the subscriptions, relative to the graph root
+	graphSubscriptionResource = "me/calendars/%s/events"
+	// Longest time a subscription to events can last before being renewed
+	graphSubscriptionDuration = 4230 * time.Minute
+)
+
+// Function that creates a new GraphSubscription
+func NewGraphSubscription() (subscription *GraphSubscription) {
+	subscription = new(GraphSubscription)
+	subscription.Type = graphSubscriptionType
+	subscription.Uuid = uuid.New()
+	return
+}
+
+// Function that returns a GraphSubscription given specific info
+func RetrieveGraphSubscription(ID string, uid uuid.UUID, calendar CalendarManager, typ string) (subscription *GraphSubscription) {
+	subscription = new(GraphSubscription)
+	subscription.ID = ID
+	subscription.Uuid = uid
+	subscription.calendar = calendar.(*GraphCalendar)
+	subscription.Type = typ
+	return
+}
+
+// Method that manages the data for a new subscription, also used to create again
+// the subscriptions retrieved from DB that graph has removed
+func (subscription *GraphSubscription) manageSubscriptionData(calendar CalendarManager) {
+	subscription.ID = ""
+	subscription.Type = graphSubscriptionType
+	subscription.NotificationURL = fmt.Sprintf("%s:8081/graph/watcher", os.Getenv("ENDPOINT"))
+	subscription.LifecycleNotificationURL = fmt.Sprintf("%s:8081/graph/lifecycle", os.Getenv("ENDPOINT"))
+	subscription.ChangeType = "created,updated,deleted"
+	subscription.Resource = fmt.Sprintf(graphSubscriptionResource, calendar.GetID())
+	subscription.ClientState = subscription.Uuid.String()
+	subscription.ExpirationDateTime = time.Now().UTC().Add(graphSubscriptionDuration).Format(time.RFC3339)
+}
+
+// Method that subscribes calendar for notifications
+//
+// POST https://graph.microsoft.com/v1.0/subscriptions
+func (subscription *GraphSubscription) Subscribe(calendar CalendarManager) (err error) {
+	return subscription.SubscribeContext(context.Background(), calendar)
+}
+
+// Method like Subscribe that is stopped once the given context is done
+func (subscription *GraphSubscription) SubscribeContext(ctx context.Context, calendar CalendarManager) (err error) {
+	if err = subscription.setCalendar(calendar); err != nil {
+		log.Errorf("kind of subscription and calender differs: %s", calendar.GetName())
+		return err
+	}
+	a := calendar.GetAccount()
+	log.Debugln("subscribe calendar graph")
+
+	route, err := util.GetRoute("graph/subscription")
+	if err != nil {
+		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+	subscription.manageSubscriptionData(calendar)
+	data, err := json.Marshal(subscription)
+	if err != nil {
+		return errors.New(fmt.Sprintf("error marshalling subscription data: %s", err.Error()))
+	}
+
+	headers := make(map[string]string)
+	headers["Authorization"] = a.AuthorizationRequest()
+
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodPost,
+		route,
+		bytes.NewBuffer(data),
+		headers, nil)
+	if err != nil {
+		return util.RequestError(err, fmt.Sprintf("error subscribing a calendar for email %s", a.Mail()))
+	}
+	err = createGraphResponseError(contents)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(contents, subscription)
+	subscription.setTime()
+	return
+}
+
+// Method that renews subscription.
+// Subscriptions that graph has already removed, or that were made on the outlook
+// endpoints before the account was moved to graph, are created again
+//
+// PATCH https://graph.microsoft.com/v1.0/subscriptions/{subscriptionId}
+func (subscription *GraphSubscription) Renew() (err error) {
+	return subscription.RenewContext(context.Background())
+}
+
+// Method like Renew that is stopped once the given context is done
+func (subscription *GraphSubscription) RenewContext(ctx context.Context) (err error) {
+	a := subscription.calendar.GetAccount()
+	log.Debugln("renew graph subscription")
+	if subscription.Type != graphSubscriptionType {
+		log.Warningf("subscription %s of type %s is not known by graph, subscribing again", subscription.GetID(), subscription.Type)
+		return subscription.SubscribeContext(ctx, subscription.calendar)
+	}
+
+	route, err := util.GetRoute("graph/subscription")
+	if err != nil {
+		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+
+	renewal := &GraphSubscription{ExpirationDateTime: time.Now().UTC().Add(graphSubscriptionDuration).Format(time.RFC3339)}
+	data, err := json.Marshal(renewal)
+	if err != nil {
+		return errors.New(fmt.Sprintf("error marshalling subscription data: %s", err.Error()))
+	}
+
+	headers := make(map[string]string)
+	headers["Authorization"] = a.AuthorizationRequest()
+
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodPatch,
+		fmt.Sprintf("%s/%s", route, subscription.GetID()),
+		bytes.NewBuffer(data),
+		headers, nil)
+	if _, ok := err.(*customErrors.NotFoundError); ok {
+		log.Warningf("graph subscription %s not found, subscribing again", subscription.GetID())
+		return subscription.SubscribeContext(ctx, subscription.calendar)
+	}
+	if err != nil {
+		return util.RequestError(err, fmt.Sprintf("error renewing a subscription for email %s", a.Mail()))
+	}
+	err = createGraphResponseError(contents)
+	if err != nil {
+		return err
+	}
+	subscription.ExpirationDateTime = renewal.ExpirationDateTime
+	subscription.setTime()
+	return
+}
+
+// Method that deletes subscription
+//
+// DELETE https://graph.microsoft.com/v1.0/subscriptions/{subscriptionId}
+func (subscription *GraphSubscription) Delete() (err error) {
+	return subscription.DeleteContext(context.Background())
+}
+
+// Method like Delete that is stopped once the given context is done
+func (subscription *GraphSubscription) DeleteContext(ctx context.Context) (err error) {
+	a := subscription.calendar.GetAccount()
+	log.Debugln("Delete graph subscription")
+	route, err := util.GetRoute("graph/subscription")
+	if err != nil {
+		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+
+	headers := make(map[string]string)
+	headers["Authorization"] = a.AuthorizationRequest()
+
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodDelete,
+		fmt.Sprintf("%s/%s", route, subscription.GetID()),
+		nil,
+		headers, nil)
+	if err != nil {
+		return util.RequestError(err, fmt.Sprintf("error deleting a subscription for email %s", a.Mail()))
+	}
+	if len(contents) != 0 {
+		return createGraphResponseError(contents)
+	}
+	return
+}
+
+// Method that returns the ID of the subscription
+func (subscription *GraphSubscription) GetID() string {
+	return subscription.ID
+}
+
+// Method that returns the UUID of the subscription
+func (subscription *GraphSubscription) GetUUID() uuid.UUID {
+	return subscription.Uuid
+}
+
+// Method that returns the account of the subscription
+func (subscription *GraphSubscription) GetAccount() AccountManager {
+	return subscription.calendar.account
+}
+
+// Method that returns the type of the subscription
+func (subscription *GraphSubscription) GetType() string {
+	return subscription.Type
+}
+
+// Method that sets the expiration time to the subscription.
+// It is kept a day earlier, as the subscriptions expired are renewed once a day
+func (subscription *GraphSubscription) setTime() {
+	expiration, err := time.Parse(time.RFC3339Nano, subscription.ExpirationDateTime)
+	if err != nil {
+		expiration = time.Now().Add(graphSubscriptionDuration)
+	}
+	subscription.expirationDate = expiration.AddDate(0, 0, -1)
+}
+
+// Method that sets the calendar to be watched by subscription
+func (subscription *GraphSubscription) setCalendar(calendar CalendarManager) (err error) {
+	switch calendar.(type) {
+	case *GraphCalendar:
+		subscription.calendar = calendar.(*GraphCalendar)
+	default:
+		return &customErrors.WrongKindError{Mail: calendar.GetName()}
+	}
+	return
+}
+
+// Method that returns the expiration date of the subscription
+func (subscription *GraphSubscription) GetExpirationDate() time.Time {
+	return subscription.expirationDate
+}
+
+// Method that returns the resourceID of the subscription
+func (subscription *GraphSubscription) GetResourceID() string {
+	return ""
+}
@@ -0,0 +1,102 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/TetAlius/GoSyncMyCalendars/api"
+)
+
+func TestGraphSubscription_SubscriptionLifeCycle(t *testing.T) {
+	var subscribed map[string]interface{}
+	var methods []string
+	_, teardown := setupStandIn(map[string]string{"graph/subscription": "/subscriptions"}, func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		body, _ := ioutil.ReadAll(r.Body)
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/subscriptions":
+			json.Unmarshal(body, &subscribed)
+			subscribed["id"] = fmt.Sprintf("subscription%d", len(methods))
+			json.NewEncoder(w).Encode(subscribed)
+		case r.Method == http.MethodPatch && r.URL.Path == "/subscriptions/removed":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":"ResourceNotFound","message":"The object was not found."}}`))
+		case r.Method == http.MethodPatch || r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusOK)
+			w.Write(body)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	defer teardown()
+	endpoint := os.Getenv("ENDPOINT")
+	os.Setenv("ENDPOINT", "https://example.com")
+	defer os.Setenv("ENDPOINT", endpoint)
+	calendar := api.RetrieveGraphCalendar("calendar", "", api.RetrieveGraphAccount("Bearer", "refresh", "travis@example.com", api.GRAPH, "token"))
+
+	// good call to subscribe watches the events of the calendar and the lifecycle of the subscription
+	subscription := api.NewGraphSubscription()
+	err := subscription.Subscribe(calendar)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if subscribed["resource"] != "me/calendars/calendar/events" || subscribed["changeType"] != "created,updated,deleted" || subscribed["clientState"] != subscription.GetUUID().String() {
+		t.Fatalf("something went wrong. Expected subscription to the events of the calendar found %v", subscribed)
+	}
+	if subscribed["@odata.type"] != "#microsoft.graph.subscription" {
+		t.Fatalf("something went wrong. Expected graph subscription type found %v", subscribed)
+	}
+	if subscribed["notificationUrl"] != "https://example.com:8081/graph/watcher" || subscribed["lifecycleNotificationUrl"] != "https://example.com:8081/graph/lifecycle" {
+		t.Fatalf("something went wrong. Expected watcher and lifecycle URLs found %v", subscribed)
+	}
+	if subscription.GetID() != "subscription1" || subscription.GetType() != "#microsoft.graph.subscription" {
+		t.Fatalf("something went wrong. Expected graph subscription with ID found %s", subscription.GetID())
+	}
+	// expiration is kept a day before the one given to graph, so it is renewed in time
+	if expiration := subscription.GetExpirationDate(); expiration.Before(time.Now().Add(24*time.Hour)) || expiration.After(time.Now().Add(48*time.Hour)) {
+		t.Fatalf("something went wrong. Expected expiration in 2 days found %s", expiration)
+	}
+
+	// good call to renew
+	err = subscription.Renew()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if subscription.GetID() != "subscription1" {
+		t.Fatalf("something went wrong. Expected same subscription found %s", subscription.GetID())
+	}
+
+	// renewing a subscription removed by graph subscribes again keeping its UUID
+	removed := api.RetrieveGraphSubscription("removed", subscription.GetUUID(), calendar, "#microsoft.graph.subscription")
+	err = removed.Renew()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if removed.GetID() != "subscription4" || removed.GetUUID() != subscription.GetUUID() || removed.GetType() != "#microsoft.graph.subscription" {
+		t.Fatalf("something went wrong. Expected new graph subscription found %s of type %s", removed.GetID(), removed.GetType())
+	}
+
+	// subscriptions of the outlook endpoints are subscribed again on graph without renewing them
+	moved := api.RetrieveGraphSubscription("outlook", subscription.GetUUID(), calendar, "#Microsoft.OutlookServices.PushSubscription")
+	err = moved.Renew()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if moved.GetID() != "subscription5" || moved.GetType() != "#microsoft.graph.subscription" || methods[4] != http.MethodPost {
+		t.Fatalf("something went wrong. Expected new graph subscription found %s of type %s", moved.GetID(), moved.GetType())
+	}
+
+	// good call to delete
+	err = removed.Delete()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(methods) != 6 || methods[5] != http.MethodDelete {
+		t.Fatalf("something went wrong. Expected 6 requests found %v", methods)
+	}
+}
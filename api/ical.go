@@ -0,0 +1,293 @@
+package api
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// Formats used by iCalendar (RFC 5545) for dates and date-times
+	icalDateFormat     = "20060102"
+	icalDateTimeFormat = "20060102T150405"
+	icalUTCFormat      = "20060102T150405Z"
+
+	// maximum length in octets of a content line before folding
+	icalLineLength = 75
+)
+
+// Property of an iCalendar component
+type icalProperty struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Component of an iCalendar object, such as VCALENDAR or VEVENT
+type icalComponent struct {
+	Name       string
+	Properties []icalProperty
+	Components []*icalComponent
+}
+
+// Method that returns the first property with the given name
+func (component *icalComponent) property(name string) (property icalProperty, ok bool) {
+	for _, p := range component.Properties {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return
+}
+
+// Method that returns all properties with the given name
+func (component *icalComponent) properties(name string) (properties []icalProperty) {
+	for _, p := range component.Properties {
+		if p.Name == name {
+			properties = append(properties, p)
+		}
+	}
+	return
+}
+
+// Method that returns the value of the first property with the given name
+func (component *icalComponent) value(name string) string {
+	property, _ := component.property(name)
+	return property.Value
+}
+
+// Method that returns all sub components with the given name
+func (component *icalComponent) components(name string) (components []*icalComponent) {
+	for _, c := range component.Components {
+		if c.Name == name {
+			components = append(components, c)
+		}
+	}
+	for _, c := range component.Components {
+		components = append(components, c.components(name)...)
+	}
+	return
+}
+
+// Method that adds a property to the component
+func (component *icalComponent) add(name string, value string, params map[string]string) {
+	component.Properties = append(component.Properties, icalProperty{Name: name, Params: params, Value: value})
+}
+
+// Function that parses an iCalendar stream into its components
+func parseICal(contents string) (calendars []*icalComponent, err error) {
+	var stack []*icalComponent
+	for _, line := range unfoldICal(contents) {
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		property, err := parseICalProperty(line)
+		if err != nil {
+			return nil, err
+		}
+		switch property.Name {
+		case "BEGIN":
+			stack = append(stack, &icalComponent{Name: strings.ToUpper(property.Value)})
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(property.Value) {
+				return nil, errors.New(fmt.Sprintf("unexpected END:%s on iCalendar", property.Value))
+			}
+			component := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				calendars = append(calendars, component)
+			} else {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, component)
+			}
+		default:
+			if len(stack) == 0 {
+				return nil, errors.New(fmt.Sprintf("property %s outside of any component", property.Name))
+			}
+			current := stack[len(stack)-1]
+			current.Properties = append(current.Properties, property)
+		}
+	}
+	if len(stack) != 0 {
+		return nil, errors.New(fmt.Sprintf("component %s was not closed", stack[len(stack)-1].Name))
+	}
+	return
+}
+
+// Function that joins all folded lines of an iCalendar stream
+func unfoldICal(contents string) (lines []string) {
+	scanner := bufio.NewScanner(strings.NewReader(contents))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && len(line) > 0 && (line[0] == ' ' || line[0] == '\t') {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return
+}
+
+// Function that parses a single content line
+func parseICalProperty(line string) (property icalProperty, err error) {
+	quoted := false
+	separator := -1
+	for i, c := range line {
+		if c == '"' {
+			quoted = !quoted
+		}
+		if c == ':' && !quoted {
+			separator = i
+			break
+		}
+	}
+	if separator == -1 {
+		return property, errors.New(fmt.Sprintf("malformed iCalendar line: %s", line))
+	}
+	property.Value = line[separator+1:]
+	params := strings.Split(line[:separator], ";")
+	property.Name = strings.ToUpper(params[0])
+	property.Params = make(map[string]string)
+	for _, param := range params[1:] {
+		if idx := strings.Index(param, "="); idx != -1 {
+			property.Params[strings.ToUpper(param[:idx])] = strings.Trim(param[idx+1:], "\"")
+		}
+	}
+	return
+}
+
+// Method that writes the component in iCalendar format
+func (component *icalComponent) String() string {
+	var builder strings.Builder
+	component.write(&builder)
+	return builder.String()
+}
+
+// Method that writes the component and all its sub components
+func (component *icalComponent) write(builder *strings.Builder) {
+	writeICalLine(builder, "BEGIN:"+component.Name)
+	for _, property := range component.Properties {
+		line := property.Name
+		for key, value := range property.Params {
+			if strings.ContainsAny(value, ":;,") {
+				value = fmt.Sprintf("\"%s\"", value)
+			}
+			line += fmt.Sprintf(";%s=%s", key, value)
+		}
+		writeICalLine(builder, line+":"+property.Value)
+	}
+	for _, c := range component.Components {
+		c.write(builder)
+	}
+	writeICalLine(builder, "END:"+component.Name)
+}
+
+// Function that writes a content line folding it when needed
+func writeICalLine(builder *strings.Builder, line string) {
+	for len(line) > icalLineLength {
+		cut := icalLineLength
+		// do not split a multi-byte character
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		builder.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+	}
+	builder.WriteString(line + "\r\n")
+}
+
+// Function that escapes a TEXT value
+func escapeICalText(text string) string {
+	text = strings.Replace(text, "\\", "\\\\", -1)
+	text = strings.Replace(text, ";", "\\;", -1)
+	text = strings.Replace(text, ",", "\\,", -1)
+	text = strings.Replace(text, "\r\n", "\\n", -1)
+	return strings.Replace(text, "\n", "\\n", -1)
+}
+
+// Function that unescapes a TEXT value
+func unescapeICalText(text string) string {
+	var builder strings.Builder
+	escaped := false
+	for _, c := range text {
+		if escaped {
+			switch c {
+			case 'n', 'N':
+				builder.WriteRune('\n')
+			default:
+				builder.WriteRune(c)
+			}
+			escaped = false
+			continue
+		}
+		if c == '\\' {
+			escaped = true
+			continue
+		}
+		builder.WriteRune(c)
+	}
+	return builder.String()
+}
+
+// Function that parses a DATE or DATE-TIME property.
+// Returns the time, whether it is a date and the location of the time
+func parseICalTime(property icalProperty) (t time.Time, isDate bool, location *time.Location, err error) {
+	value := property.Value
+	location = time.UTC
+	if property.Params["VALUE"] == "DATE" || len(value) == len(icalDateFormat) {
+		t, err = time.Parse(icalDateFormat, value)
+		return t.UTC(), true, location, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err = time.Parse(icalUTCFormat, value)
+		return t.UTC(), false, location, err
+	}
+	// time zones defined only by the calendar are read in UTC
+	if tzid, ok := property.Params["TZID"]; ok {
+		if loc, err := loadTimeZone(tzid); err == nil {
+			location = loc
+		}
+	}
+	t, err = time.ParseInLocation(icalDateTimeFormat, value, location)
+	return t.UTC(), false, location, err
+}
+
+// Function that parses a DURATION value such as P1D or PT1H30M
+func parseICalDuration(value string) (duration time.Duration, err error) {
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimLeft(value, "+-")
+	if !strings.HasPrefix(value, "P") {
+		return 0, errors.New(fmt.Sprintf("malformed duration: %s", value))
+	}
+	inTime := false
+	number := 0
+	for _, c := range value[1:] {
+		switch {
+		case c >= '0' && c <= '9':
+			number = number*10 + int(c-'0')
+			continue
+		case c == 'T':
+			inTime = true
+		case c == 'W':
+			duration += time.Duration(number) * 7 * 24 * time.Hour
+		case c == 'D':
+			duration += time.Duration(number) * 24 * time.Hour
+		case c == 'H' && inTime:
+			duration += time.Duration(number) * time.Hour
+		case c == 'M' && inTime:
+			duration += time.Duration(number) * time.Minute
+		case c == 'S' && inTime:
+			duration += time.Duration(number) * time.Second
+		default:
+			return 0, errors.New(fmt.Sprintf("malformed duration: %s", value))
+		}
+		number = 0
+	}
+	if negative {
+		duration = -duration
+	}
+	return
+}
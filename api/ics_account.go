@@ -0,0 +1,214 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
+	log "github.com/TetAlius/GoSyncMyCalendars/logger"
+	"github.com/TetAlius/GoSyncMyCalendars/util"
+	"github.com/google/uuid"
+)
+
+// ICS accounts are added with the URL of their feed and their calendars are polled
+func init() {
+	RegisterProvider(&Provider{
+		Kind: ICS,
+		Name: "ics",
+		RetrieveAccount: func(tokenType string, refreshToken string, email string, kind int, accessToken string, serverURL string, credential string) AccountManager {
+			return RetrieveICSAccount(tokenType, refreshToken, email, kind, accessToken)
+		},
+		RetrieveCalendar: func(ID string, uid string, account AccountManager) CalendarManager {
+			return RetrieveICSCalendar(ID, uid, account.(*ICSAccount))
+		},
+		NewEvent: func(ID string) EventManager {
+			return &ICSEvent{ID: ID}
+		},
+		NewSubscription: func() SubscriptionManager {
+			return NewPollingSubscription(uuid.New().String())
+		},
+		RetrieveSubscription: func(ID string, uid uuid.UUID, calendar CalendarManager, typ string, resourceID string) SubscriptionManager {
+			return RetrievePollingSubscription(ID, uid, calendar, typ)
+		},
+	})
+}
+
+// Function that creates an ICSAccount from the URL of a feed.
+// The feed is retrieved once to check that it is a valid iCalendar
+//
+// GET {feedURL}
+func NewICSAccount(feedURL string) (a *ICSAccount, err error) {
+	return NewICSAccountContext(context.Background(), feedURL)
+}
+
+// Function like NewICSAccount that stops the retrieval of the feed once the given context is done
+func NewICSAccountContext(ctx context.Context, feedURL string) (a *ICSAccount, err error) {
+	feedURL = strings.TrimSpace(feedURL)
+	if strings.HasPrefix(feedURL, "webcal://") {
+		feedURL = "https://" + strings.TrimPrefix(feedURL, "webcal://")
+	}
+	parsed, err := url.Parse(feedURL)
+	if err != nil || len(parsed.Host) == 0 {
+		return nil, errors.New(fmt.Sprintf("not a valid feed url: %s", feedURL))
+	}
+	a = RetrieveICSAccount("", feedURL, feedURL, ICS, "")
+	_, err = a.GetPrimaryCalendarContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Function that returns an ICSAccount given specific info
+func RetrieveICSAccount(tokenType string, refreshToken string, email string, kind int, accessToken string) (a *ICSAccount) {
+	a = new(ICSAccount)
+	a.TokenType = tokenType
+	a.FeedURL = refreshToken
+	a.Email = email
+	a.Kind = kind
+	a.AccessToken = accessToken
+	return
+}
+
+// Method that retrieves and parses the feed
+//
+// GET {feedURL}
+func (a *ICSAccount) getFeed(ctx context.Context) (calendars []*icalComponent, err error) {
+	headers := map[string]string{"Accept": "text/calendar"}
+	contents, status, _, err := util.DoRawRequestContext(ctx, http.MethodGet, a.FeedURL, nil, headers, nil)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error getting feed %s. %s", a.FeedURL, err.Error()))
+	}
+	if status >= http.StatusBadRequest {
+		return nil, errors.New(fmt.Sprintf("error getting feed %s. code: %d", a.FeedURL, status))
+	}
+	calendars, err = parseICal(string(contents))
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error parsing feed %s. %s", a.FeedURL, err.Error()))
+	}
+	if len(calendars) == 0 {
+		return nil, errors.New(fmt.Sprintf("feed %s has no calendar", a.FeedURL))
+	}
+	return
+}
+
+// Method to refresh the access to the ICS account.
+// Feeds are public, so there is nothing to refresh
+func (a *ICSAccount) Refresh() (err error) {
+	return a.RefreshContext(context.Background())
+}
+
+// Method like Refresh that is stopped once the given context is done
+func (a *ICSAccount) RefreshContext(ctx context.Context) (err error) {
+	if len(a.FeedURL) == 0 {
+		return errors.New(fmt.Sprintf("ics account %s has no feed", a.Mail()))
+	}
+	return
+}
+
+// Method that retrieves all calendars from account.
+// A feed always has a single calendar
+func (a *ICSAccount) GetAllCalendars() (calendars []CalendarManager, err error) {
+	return a.GetAllCalendarsContext(context.Background())
+}
+
+// Method like GetAllCalendars that is stopped once the given context is done
+func (a *ICSAccount) GetAllCalendarsContext(ctx context.Context) (calendars []CalendarManager, err error) {
+	log.Debugln("getAllCalendars ics")
+	calendar, err := a.GetPrimaryCalendarContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return []CalendarManager{calendar}, nil
+}
+
+// Method that retrieves one calendar given an ID
+func (a *ICSAccount) GetCalendar(calendarID string) (calendar CalendarManager, err error) {
+	return a.GetCalendarContext(context.Background(), calendarID)
+}
+
+// Method like GetCalendar that is stopped once the given context is done
+func (a *ICSAccount) GetCalendarContext(ctx context.Context, calendarID string) (calendar CalendarManager, err error) {
+	log.Debugln("getCalendar ics")
+	if calendarID != a.FeedURL {
+		return nil, &customErrors.NotFoundError{Message: fmt.Sprintf("calendar with id: %s not found", calendarID)}
+	}
+	return a.GetPrimaryCalendarContext(ctx)
+}
+
+// Method that returns the principal calendar from the account
+//
+// GET {feedURL}
+func (a *ICSAccount) GetPrimaryCalendar() (calendar CalendarManager, err error) {
+	return a.GetPrimaryCalendarContext(context.Background())
+}
+
+// Method like GetPrimaryCalendar that is stopped once the given context is done
+func (a *ICSAccount) GetPrimaryCalendarContext(ctx context.Context) (calendar CalendarManager, err error) {
+	log.Debugln("getPrimaryCalendar ics")
+	feed, err := a.getFeed(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cal := &ICSCalendar{ID: a.FeedURL, Name: unescapeICalText(feed[0].value("X-WR-CALNAME")), account: a}
+	if len(cal.Name) == 0 {
+		cal.Name = a.FeedURL
+	}
+	return cal, nil
+}
+
+// Method that format the authorization request.
+// Feeds do not need authorization
+func (a *ICSAccount) AuthorizationRequest() string {
+	return ""
+}
+
+// Method that returns the mail associated with the account
+func (a *ICSAccount) Mail() string {
+	return a.Email
+}
+
+// Method that sets which kind of account is
+func (a *ICSAccount) SetKind(kind int) {
+	a.Kind = kind
+}
+
+// Method that returns the token type
+func (a *ICSAccount) GetTokenType() string {
+	return a.TokenType
+}
+
+// Method that returns the refresh token.
+// For ICS this is the URL of the feed
+func (a *ICSAccount) GetRefreshToken() string {
+	return a.FeedURL
+}
+
+// Method that returns the kind of the account
+func (a *ICSAccount) GetKind() int {
+	return a.Kind
+}
+
+// Method that returns the access token
+func (a *ICSAccount) GetAccessToken() string {
+	return a.AccessToken
+}
+
+// Method that returns the internal ID given to the account on DB
+func (a *ICSAccount) GetInternalID() int {
+	return a.InternID
+}
+
+// Method that sets all synced calendars associated with the account
+func (a *ICSAccount) SetCalendars(calendars []CalendarManager) {
+	a.calendars = calendars
+}
+
+// Method that returns all synced calendars associated with the account
+func (a *ICSAccount) GetSyncCalendars() []CalendarManager {
+	return a.calendars
+}
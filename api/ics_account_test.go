@@ -0,0 +1,122 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TetAlius/GoSyncMyCalendars/api"
+)
+
+var icsFeed = strings.Replace(`BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Travis//Feed//EN
+X-WR-CALNAME:Holidays\, Spain
+BEGIN:VEVENT
+UID:new-year@travis
+DTSTAMP:20180101T000000Z
+LAST-MODIFIED:20171201T100000Z
+DTSTART;VALUE=DATE:20180101
+SUMMARY:New Year
+DESCRIPTION:A very long description that must be folded because it is longe
+ r than seventy five octets
+END:VEVENT
+BEGIN:VEVENT
+UID:meeting@travis
+DTSTAMP:20180101T000000Z
+DTSTART;TZID=Europe/Madrid:20180614T100000
+DURATION:PT1H30M
+SUMMARY:On call
+RRULE:FREQ=WEEKLY;BYDAY=TH
+END:VEVENT
+BEGIN:VEVENT
+UID:meeting@travis
+RECURRENCE-ID;TZID=Europe/Madrid:20180621T100000
+DTSTAMP:20180101T000000Z
+DTSTART;TZID=Europe/Madrid:20180621T120000
+DURATION:PT1H30M
+SUMMARY:On call moved
+END:VEVENT
+BEGIN:VEVENT
+UID:cancelled@travis
+DTSTAMP:20180101T000000Z
+DTSTART:20180614T100000Z
+STATUS:CANCELLED
+SUMMARY:Cancelled
+END:VEVENT
+END:VCALENDAR
+`, "\n", "\r\n", -1)
+
+// Function that starts a server serving the ICS feed
+func newICSFeed(feed string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/feed.ics" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/calendar")
+		w.Write([]byte(feed))
+	}))
+}
+
+func TestNewICSAccount(t *testing.T) {
+	server := newICSFeed(icsFeed)
+	defer server.Close()
+
+	// good call to add a feed
+	account, err := api.NewICSAccount(server.URL + "/feed.ics")
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if account.GetRefreshToken() != server.URL+"/feed.ics" || account.GetKind() != api.ICS {
+		t.Fatalf("something went wrong. Found feed %s of kind %d", account.GetRefreshToken(), account.GetKind())
+	}
+
+	// wrong call to a missing feed
+	_, err = api.NewICSAccount(server.URL + "/missing.ics")
+	if err == nil {
+		t.Fatal("something went wrong. Expected error found nil")
+	}
+	// wrong call to a not valid url
+	_, err = api.NewICSAccount("not a url")
+	if err == nil {
+		t.Fatal("something went wrong. Expected error found nil")
+	}
+
+	broken := newICSFeed("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nEND:VCALENDAR\r\n")
+	defer broken.Close()
+	// wrong call to a malformed feed
+	_, err = api.NewICSAccount(broken.URL + "/feed.ics")
+	if err == nil {
+		t.Fatal("something went wrong. Expected error found nil")
+	}
+}
+
+func TestICSAccount_GetCalendar(t *testing.T) {
+	server := newICSFeed(icsFeed)
+	defer server.Close()
+	account, err := api.NewICSAccount(server.URL + "/feed.ics")
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+
+	calendars, err := account.GetAllCalendars()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(calendars) != 1 || calendars[0].GetName() != "Holidays, Spain" {
+		t.Fatalf("something went wrong. Expected calendar 'Holidays, Spain' found %d calendars", len(calendars))
+	}
+
+	// good call to get calendar
+	_, err = account.GetCalendar(server.URL + "/feed.ics")
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	// wrong call to get calendar
+	_, err = account.GetCalendar("wrong")
+	if err == nil {
+		t.Fatal("something went wrong. Expected error found nil")
+	}
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
+	log "github.com/TetAlius/GoSyncMyCalendars/logger"
+)
+
+// Method that returns an ICSCalendar given specific info
+func RetrieveICSCalendar(ID string, uid string, account *ICSAccount) *ICSCalendar {
+	cal := new(ICSCalendar)
+	cal.ID = ID
+	cal.account = account
+	cal.uuid = uid
+	return cal
+}
+
+// Method that updates the calendar.
+// Feeds are read-only
+func (calendar *ICSCalendar) Update() (err error) {
+	return calendar.UpdateContext(context.Background())
+}
+
+// Method like Update that is stopped once the given context is done
+func (calendar *ICSCalendar) UpdateContext(ctx context.Context) (err error) {
+	return ReadOnlyError{ID: calendar.GetID()}
+}
+
+// Method that deletes the calendar.
+// Feeds are read-only
+func (calendar *ICSCalendar) Delete() (err error) {
+	return calendar.DeleteContext(context.Background())
+}
+
+// Method like Delete that is stopped once the given context is done
+func (calendar *ICSCalendar) DeleteContext(ctx context.Context) (err error) {
+	return ReadOnlyError{ID: calendar.GetID()}
+}
+
+// Method that creates the calendar.
+// Feeds are read-only
+func (calendar *ICSCalendar) Create() (err error) {
+	return calendar.CreateContext(context.Background())
+}
+
+// Method like Create that is stopped once the given context is done
+func (calendar *ICSCalendar) CreateContext(ctx context.Context) (err error) {
+	return ReadOnlyError{ID: calendar.GetID()}
+}
+
+// Method that returns all events inside the calendar
+//
+// GET {feedURL}
+func (calendar *ICSCalendar) GetAllEvents() (events []EventManager, err error) {
+	return calendar.GetAllEventsContext(context.Background())
+}
+
+// Method like GetAllEvents that is stopped once the given context is done
+func (calendar *ICSCalendar) GetAllEventsContext(ctx context.Context) (events []EventManager, err error) {
+	log.Debugln("getAllEvents ics")
+	feed, err := calendar.account.getFeed(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, cal := range feed {
+		for _, component := range cal.components("VEVENT") {
+			// only the master component of a recurring event is synced
+			if _, ok := component.property("RECURRENCE-ID"); ok {
+				continue
+			}
+			event, err := newICSEventFromComponent(component)
+			if err != nil {
+				log.Errorf("error parsing event of feed %s: %s", calendar.GetID(), err.Error())
+				continue
+			}
+			// ignore cancelled events
+			if event.Status == "CANCELLED" {
+				continue
+			}
+			event.calendar = calendar
+			events = append(events, event)
+		}
+	}
+	return
+}
+
+// Method that returns a single event given the ID
+//
+// GET {feedURL}
+func (calendar *ICSCalendar) GetEvent(eventID string) (event EventManager, err error) {
+	return calendar.GetEventContext(context.Background(), eventID)
+}
+
+// Method like GetEvent that is stopped once the given context is done
+func (calendar *ICSCalendar) GetEventContext(ctx context.Context, eventID string) (event EventManager, err error) {
+	log.Debugln("getEvent ics")
+	events, err := calendar.GetAllEventsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		if event.GetID() == eventID {
+			return event, nil
+		}
+	}
+	return nil, &customErrors.NotFoundError{Message: fmt.Sprintf("event with id: %s not found", eventID)}
+}
+
+// Method that sets the account which the calendar belongs
+func (calendar *ICSCalendar) SetAccount(a AccountManager) (err error) {
+	switch x := a.(type) {
+	case *ICSAccount:
+		calendar.account = x
+	default:
+		return errors.New(fmt.Sprintf("type of account not valid for ics: %T", x))
+	}
+	return
+}
+
+// Method that returns the ID formatted for a query request
+func (calendar *ICSCalendar) GetQueryID() string {
+	return calendar.GetID()
+}
+
+// Method that returns the ID of the calendar
+func (calendar *ICSCalendar) GetID() string {
+	return calendar.ID
+}
+
+// Method that returns the name of the calendar
+func (calendar *ICSCalendar) GetName() string {
+	return calendar.Name
+}
+
+// Method that returns the account
+func (calendar *ICSCalendar) GetAccount() AccountManager {
+	return calendar.account
+}
+
+// Method that returns the internal UUID given to the calendar
+func (calendar *ICSCalendar) GetUUID() string {
+	return calendar.uuid
+}
+
+// Method that sets the internal UUID for the calendar
+func (calendar *ICSCalendar) SetUUID(id string) {
+	calendar.uuid = id
+}
+
+// Method that sets the synced calendars
+func (calendar *ICSCalendar) SetCalendars(calendars []CalendarManager) {
+	calendar.calendars = calendars
+}
+
+// Method that returns the synced calendar
+func (calendar *ICSCalendar) GetCalendars() []CalendarManager {
+	return calendar.calendars
+}
+
+// Method that creates an empty event
+func (calendar *ICSCalendar) CreateEmptyEvent(ID string) EventManager {
+	return &ICSEvent{ID: ID, calendar: calendar}
+}
+
+// Method that sets the window of days whose events are synchronized
+func (calendar *ICSCalendar) SetSyncWindow(window SyncWindow) {
+	calendar.window = window
+}
+
+// Method that returns the window of days whose events are synchronized
+func (calendar *ICSCalendar) GetSyncWindow() SyncWindow {
+	return calendar.window
+}
+
+// Method that sets the options of the relation of the calendar
+func (calendar *ICSCalendar) SetSyncOptions(options SyncOptions) {
+	calendar.options = options
+}
+
+// Method that returns the options of the relation of the calendar
+func (calendar *ICSCalendar) GetSyncOptions() SyncOptions {
+	return calendar.options
+}
@@ -0,0 +1,88 @@
+package api_test
+
+import (
+	"testing"
+
+	"time"
+
+	"github.com/TetAlius/GoSyncMyCalendars/api"
+	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
+)
+
+func TestICSCalendar_GetAllEvents(t *testing.T) {
+	server := newICSFeed(icsFeed)
+	defer server.Close()
+	account, err := api.NewICSAccount(server.URL + "/feed.ics")
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	calendar, err := account.GetPrimaryCalendar()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+
+	events, err := calendar.GetAllEvents()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	// cancelled events and exceptions of recurring events are ignored
+	if len(events) != 2 {
+		t.Fatalf("something went wrong. Expected 2 events found %d", len(events))
+	}
+
+	newYear := events[0].(*api.ICSEvent)
+	if !newYear.IsAllDay || !newYear.End.DateTime.Equal(time.Date(2018, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("something went wrong. Expected all day event of one day found end %s", newYear.End.DateTime)
+	}
+	if newYear.Description != "A very long description that must be folded because it is longer than seventy five octets" {
+		t.Fatalf("something went wrong. Description was not unfolded: %q", newYear.Description)
+	}
+
+	meeting := events[1].(*api.ICSEvent)
+	if !meeting.Start.DateTime.Equal(time.Date(2018, 6, 14, 8, 0, 0, 0, time.UTC)) {
+		t.Fatalf("something went wrong. Expected start on 08:00 UTC found %s", meeting.Start.DateTime)
+	}
+	if !meeting.End.DateTime.Equal(meeting.Start.DateTime.Add(90 * time.Minute)) {
+		t.Fatalf("something went wrong. Expected duration of 90 minutes found end %s", meeting.End.DateTime)
+	}
+	if len(meeting.Recurrences) != 1 || meeting.Subject != "On call" {
+		t.Fatalf("something went wrong. Expected master recurring event found %s with %d recurrences", meeting.Subject, len(meeting.Recurrences))
+	}
+}
+
+func TestICSCalendar_GetEvent(t *testing.T) {
+	server := newICSFeed(icsFeed)
+	defer server.Close()
+	account, err := api.NewICSAccount(server.URL + "/feed.ics")
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	calendar, err := account.GetPrimaryCalendar()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+
+	// good call to get event
+	event, err := calendar.GetEvent("new-year@travis")
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if event.GetCalendar() != calendar {
+		t.Fatal("something went wrong. Expected event to have the calendar set")
+	}
+
+	// cancelled events are not found
+	_, err = calendar.GetEvent("cancelled@travis")
+	if _, ok := err.(*customErrors.NotFoundError); !ok {
+		t.Fatalf("something went wrong. Expected NotFoundError found %v", err)
+	}
+}
+
+func TestICSCalendar_ReadOnly(t *testing.T) {
+	calendar := api.RetrieveICSCalendar("https://example.com/feed.ics", "", api.RetrieveICSAccount("", "https://example.com/feed.ics", "https://example.com/feed.ics", api.ICS, ""))
+	for _, err := range []error{calendar.Create(), calendar.Update(), calendar.Delete()} {
+		if _, ok := err.(api.ReadOnlyError); !ok {
+			t.Fatalf("something went wrong. Expected ReadOnlyError found %v", err)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
+	"time"
+)
+
+// Function that creates an ICSEvent from a VEVENT component
+func newICSEventFromComponent(component *icalComponent) (event *ICSEvent, err error) {
+	parsed, err := newCalDAVEventFromComponent(component)
+	if err != nil {
+		return nil, err
+	}
+	event = &ICSEvent{
+		ID:           parsed.ID,
+		Subject:      parsed.Subject,
+		Description:  parsed.Description,
+		Start:        parsed.Start,
+		End:          parsed.End,
+		IsAllDay:     parsed.IsAllDay,
+		Status:       parsed.Status,
+		Location:     parsed.Location,
+		Recurrences:  parsed.Recurrences,
+		Class:        parsed.Class,
+		Transparency: parsed.Transparency,
+		Sequence:     parsed.Sequence,
+		contentHash:  icsContentHash(component),
+	}
+	return
+}
+
+// Properties of a VEVENT that feeds may change each time they are generated, even if the event did not change
+var icsVolatileProperties = map[string]bool{"DTSTAMP": true, "LAST-MODIFIED": true}
+
+// Function that returns a hash of the contents of a VEVENT, SEQUENCE included,
+// leaving out the properties that change each time the feed is generated
+func icsContentHash(component *icalComponent) uint64 {
+	hash := fnv.New64a()
+	writeICSContent(hash, component)
+	return hash.Sum64()
+}
+
+// Function that writes the contents of the component and its sub components to be hashed,
+// with the params in order so the same contents always give the same hash
+func writeICSContent(writer io.Writer, component *icalComponent) {
+	fmt.Fprintf(writer, "BEGIN:%s\n", component.Name)
+	for _, property := range component.Properties {
+		if icsVolatileProperties[property.Name] {
+			continue
+		}
+		var keys []string
+		for key := range property.Params {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fmt.Fprint(writer, property.Name)
+		for _, key := range keys {
+			fmt.Fprintf(writer, ";%s=%q", key, property.Params[key])
+		}
+		fmt.Fprintf(writer, ":%q\n", property.Value)
+	}
+	for _, c := range component.Components {
+		writeICSContent(writer, c)
+	}
+	fmt.Fprintf(writer, "END:%s\n", component.Name)
+}
+
+// Method that creates the event.
+// Feeds are read-only
+func (event *ICSEvent) Create() (err error) {
+	return event.CreateContext(context.Background())
+}
+
+// Method like Create that is stopped once the given context is done
+func (event *ICSEvent) CreateContext(ctx context.Context) (err error) {
+	return ReadOnlyError{ID: event.GetCalendar().GetID()}
+}
+
+// Method that updates the event.
+// Feeds are read-only
+func (event *ICSEvent) Update() (err error) {
+	return event.UpdateContext(context.Background())
+}
+
+// Method like Update that is stopped once the given context is done
+func (event *ICSEvent) UpdateContext(ctx context.Context) (err error) {
+	return ReadOnlyError{ID: event.GetCalendar().GetID()}
+}
+
+// Method that deletes the event.
+// Feeds are read-only
+func (event *ICSEvent) Delete() (err error) {
+	return event.DeleteContext(context.Background())
+}
+
+// Method like Delete that is stopped once the given context is done
+func (event *ICSEvent) DeleteContext(ctx context.Context) (err error) {
+	return ReadOnlyError{ID: event.GetCalendar().GetID()}
+}
+
+// Method that returns the ID of the event
+func (event *ICSEvent) GetID() string {
+	return event.ID
+}
+
+// Method that returns the calendar which have this event
+func (event *ICSEvent) GetCalendar() CalendarManager {
+	return event.calendar
+}
+
+// Method that returns the syncing events with this
+func (event *ICSEvent) GetRelations() []EventManager {
+	return event.relations
+}
+
+// Method that checks if the event can try sync again
+func (event *ICSEvent) CanProcessAgain() bool {
+	return event.exponentialBackoff < maxBackoff
+}
+
+// Method that returns the calendar which have this event
+func (event *ICSEvent) SetCalendar(calendar CalendarManager) (err error) {
+	switch x := calendar.(type) {
+	case *ICSCalendar:
+		event.calendar = x
+	default:
+		return errors.New(fmt.Sprintf("type of calendar not valid for ics: %T", x))
+	}
+	return
+}
+
+// Method that sets the events syncing with this
+func (event *ICSEvent) SetRelations(relations []EventManager) {
+	event.relations = relations
+}
+
+// Method that increments the number of failed attempts to sync
+func (event *ICSEvent) IncrementBackoff() {
+	event.exponentialBackoff += 1
+}
+
+// Method that sets the state of the event
+func (event *ICSEvent) SetState(stateInformed int) {
+	event.state = stateInformed
+}
+
+// Method that returns the state of the event
+func (event *ICSEvent) GetState() int {
+	return event.state
+}
+
+// Method that sets the internal ID generated on db
+func (event *ICSEvent) SetInternalID(internalID int) {
+	event.internalID = internalID
+}
+
+// Method that gets the internal ID of the event
+func (event *ICSEvent) GetInternalID() int {
+	return event.internalID
+}
+
+// Method that returns the start and end dates of the event.
+// The end is zero for recurring events
+func (event *ICSEvent) GetTimeRange() (start time.Time, end time.Time, err error) {
+	if event.Start == nil || event.End == nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("event %s has no dates", event.GetID())
+	}
+	return timeRange(event.Start.DateTime, event.End.DateTime, len(event.Recurrences) != 0)
+}
+
+// Method that returns the last update date.
+// Feeds may not send LAST-MODIFIED nor DTSTAMP, or set them to the time the feed is generated,
+// so they can not tell if the event changed. Instead, it returns a date made from the hash of
+// the contents and SEQUENCE of the event, which only changes when the event does
+func (event *ICSEvent) GetUpdatedAt() (t time.Time, err error) {
+	// seconds are kept below the year 2242 and the rest is given as microseconds, as stored on DB
+	seconds := int64(event.contentHash % (1 << 33))
+	micros := int64(event.contentHash>>33) % int64(time.Second/time.Microsecond)
+	return time.Unix(seconds, micros*int64(time.Microsecond)).UTC(), nil
+}
+
+// Method that returns the links to join the online meeting listed on the description
+func (event *ICSEvent) readMeetingLinks() []MeetingLink {
+	return meetingLinksFromDescription(event.Description)
+}
+
+// Method that returns the free/busy status of the event
+func (event *ICSEvent) readFreeBusy() (string, bool) {
+	return transparencyFreeBusy(event.Transparency), true
+}
+
+// Method that sets all day to the necessary attributes
+func (event *ICSEvent) setAllDay() {
+	if event.Start == nil && event.End == nil {
+		event.IsAllDay = false
+		return
+	}
+	event.IsAllDay = event.Start.IsAllDay
+}
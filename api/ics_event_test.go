@@ -0,0 +1,95 @@
+package api_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TetAlius/GoSyncMyCalendars/api"
+	"github.com/TetAlius/GoSyncMyCalendars/convert"
+)
+
+func TestICSEvent_ReadOnly(t *testing.T) {
+	server := newICSFeed(icsFeed)
+	defer server.Close()
+	account, err := api.NewICSAccount(server.URL + "/feed.ics")
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	calendar, err := account.GetPrimaryCalendar()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	event, err := calendar.GetEvent("meeting@travis")
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+
+	for _, err := range []error{event.Create(), event.Update(), event.Delete()} {
+		if _, ok := err.(api.ReadOnlyError); !ok {
+			t.Fatalf("something went wrong. Expected ReadOnlyError found %v", err)
+		}
+	}
+
+	// events of the feed can be written on other calendars
+	googleEvent := new(api.GoogleEvent)
+	err = convert.Convert(event, googleEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if googleEvent.Subject != "On call" || !googleEvent.Start.DateTime.Equal(event.(*api.ICSEvent).Start.DateTime) {
+		t.Fatalf("something went wrong converting to google. Found %s at %s", googleEvent.Subject, googleEvent.Start.DateTime)
+	}
+}
+
+// Function that returns the update date of the event with the given ID of the feed
+func icsUpdatedAt(t *testing.T, feed string, eventID string) time.Time {
+	server := newICSFeed(feed)
+	defer server.Close()
+	account, err := api.NewICSAccount(server.URL + "/feed.ics")
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	calendar, err := account.GetPrimaryCalendar()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	event, err := calendar.GetEvent(eventID)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	updatedAt, err := event.GetUpdatedAt()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	return updatedAt
+}
+
+func TestICSEvent_GetUpdatedAt(t *testing.T) {
+	updatedAt := icsUpdatedAt(t, icsFeed, "meeting@travis")
+	if !updatedAt.Equal(icsUpdatedAt(t, icsFeed, "meeting@travis")) {
+		t.Fatalf("something went wrong. Expected same update date for the same event found %s", updatedAt)
+	}
+	if updatedAt.Equal(icsUpdatedAt(t, icsFeed, "new-year@travis")) {
+		t.Fatalf("something went wrong. Expected different update date for other event found %s", updatedAt)
+	}
+	if !updatedAt.Equal(updatedAt.Truncate(time.Microsecond)) {
+		t.Fatalf("something went wrong. Expected update date that can be stored on DB found %s", updatedAt)
+	}
+
+	// feeds generated again give new dates to events that did not change
+	regenerated := strings.Replace(icsFeed, "DTSTAMP:20180101T000000Z", "DTSTAMP:20180301T000000Z\r\nLAST-MODIFIED:20180301T000000Z", -1)
+	if found := icsUpdatedAt(t, regenerated, "meeting@travis"); !found.Equal(updatedAt) {
+		t.Fatalf("something went wrong. Expected %s for regenerated feed found %s", updatedAt, found)
+	}
+
+	changes := map[string]string{
+		"contents": strings.Replace(icsFeed, "SUMMARY:On call\r\n", "SUMMARY:On call duty\r\n", 1),
+		"sequence": strings.Replace(icsFeed, "SUMMARY:On call\r\n", "SUMMARY:On call\r\nSEQUENCE:1\r\n", 1),
+	}
+	for change, feed := range changes {
+		if found := icsUpdatedAt(t, feed, "meeting@travis"); found.Equal(updatedAt) {
+			t.Fatalf("something went wrong. Expected new update date after change of %s found %s", change, found)
+		}
+	}
+}
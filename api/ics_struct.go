@@ -0,0 +1,50 @@
+package api
+
+type ICSAccount struct {
+	// ICS feeds are public, so no token is used
+	TokenType   string
+	AccessToken string
+	// ICS feeds have no refresh tokens, so it stores the URL of the feed
+	FeedURL   string
+	Email     string
+	Kind      int
+	InternID  int
+	calendars []CalendarManager
+}
+
+type ICSCalendar struct {
+	uuid      string
+	account   *ICSAccount
+	calendars []CalendarManager
+	window    SyncWindow
+	options   SyncOptions
+	// URL of the feed
+	ID   string
+	Name string `convert:"Name"`
+}
+
+type ICSEvent struct {
+	calendar           *ICSCalendar
+	relations          []EventManager
+	state              int
+	exponentialBackoff int
+	internalID         int
+
+	// UID of the event
+	ID string
+
+	Subject     string      `convert:"Subject"`
+	Description string      `convert:"Description"`
+	Start       *CalDAVTime `convert:"start"`
+	End         *CalDAVTime `convert:"end"`
+	IsAllDay    bool        `convert:"allDay"`
+
+	Status       string
+	Location     string
+	Recurrences  CalDAVRecurrence `convert:"recurrence"`
+	Class        CalDAVClass      `convert:"visibility"`
+	Transparency string
+	Sequence     int
+	// hash of the contents of the VEVENT, used to know if it changed
+	contentHash uint64
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Coordinates written at the end of the line of a location: (latitude, longitude)
+var locationCoordinates = regexp.MustCompile(`^(.*?)\s*\((-?[0-9]+(?:\.[0-9]+)?), (-?[0-9]+(?:\.[0-9]+)?)\)$`)
+
+// Location of an event, as given by the Deconverter of the location of each calendar
+type Location struct {
+	// Name shown of the location, like the name of a room or a building
+	DisplayName string
+	Street      string
+	City        string
+	State       string
+	PostalCode  string
+	Country     string
+	// Whether Latitude and Longitude are given
+	HasCoordinates bool
+	Latitude       float64
+	Longitude      float64
+}
+
+// Method that returns the address of the location in a single line:
+// Street, City, State PostalCode, Country
+func (location Location) address() string {
+	var parts []string
+	for _, part := range []string{location.Street, location.City, strings.TrimSpace(location.State + " " + location.PostalCode), location.Country} {
+		if len(part) != 0 {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Method that returns the location in a single readable line:
+// DisplayName, Street, City, State PostalCode, Country (Latitude, Longitude).
+// The display name is left out when it is already the address
+func (location Location) String() string {
+	var parts []string
+	address := location.address()
+	if len(location.DisplayName) != 0 && location.DisplayName != address {
+		parts = append(parts, location.DisplayName)
+	}
+	if len(address) != 0 {
+		parts = append(parts, address)
+	}
+	line := strings.Join(parts, ", ")
+	if location.HasCoordinates {
+		line = strings.TrimSpace(fmt.Sprintf("%s (%s, %s)", line, strconv.FormatFloat(location.Latitude, 'f', -1, 64), strconv.FormatFloat(location.Longitude, 'f', -1, 64)))
+	}
+	return line
+}
+
+// Function that parses a line written by Location.String back into the location.
+// The address is only parsed if the line has all the parts of it and a postal code,
+// otherwise the whole line is the display name
+func parseLocation(line string) (location Location) {
+	line = strings.TrimSpace(line)
+	if match := locationCoordinates.FindStringSubmatch(line); match != nil {
+		latitude, errLatitude := strconv.ParseFloat(match[2], 64)
+		longitude, errLongitude := strconv.ParseFloat(match[3], 64)
+		if errLatitude == nil && errLongitude == nil && latitude >= -90 && latitude <= 90 && longitude >= -180 && longitude <= 180 {
+			location.HasCoordinates = true
+			location.Latitude = latitude
+			location.Longitude = longitude
+			line = match[1]
+		}
+	}
+	location.DisplayName = line
+
+	parts := strings.Split(line, ", ")
+	if len(parts) < 4 {
+		return
+	}
+	n := len(parts)
+	fields := strings.Fields(parts[n-2])
+	if len(fields) == 0 || !strings.ContainsAny(fields[len(fields)-1], "0123456789") {
+		return
+	}
+	location.Country = parts[n-1]
+	location.PostalCode = fields[len(fields)-1]
+	location.State = strings.Join(fields[:len(fields)-1], " ")
+	location.City = parts[n-3]
+	location.Street = parts[n-4]
+	if n > 4 {
+		location.DisplayName = strings.Join(parts[:n-4], ", ")
+	} else {
+		location.DisplayName = location.address()
+	}
+	return
+}
+
+// Function that returns the location given by a Deconverter
+func locationFrom(m interface{}) (*Location, error) {
+	switch x := m.(type) {
+	case nil:
+		return nil, nil
+	case Location:
+		return &x, nil
+	default:
+		return nil, fmt.Errorf("incorrect type of field location: %T", x)
+	}
+}
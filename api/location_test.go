@@ -0,0 +1,112 @@
+package api_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/TetAlius/GoSyncMyCalendars/api"
+	"github.com/TetAlius/GoSyncMyCalendars/convert"
+)
+
+var outlookOffice = &api.OutlookLocation{
+	DisplayName: "Main office",
+	Address: api.OutlookPhysicalAddress{
+		Street:          "1 Main St",
+		City:            "Springfield",
+		State:           "IL",
+		PostalCode:      "62701",
+		CountryOrRegion: "United States",
+	},
+	Coordinates: api.OutlookGeoCoordinates{Latitude: 39.7817, Longitude: -89.6501},
+}
+
+func locationEvents() (*api.GoogleEvent, *api.OutlookEvent) {
+	googleEvent := &api.GoogleEvent{
+		Start: &api.GoogleTime{DateTime: recurrenceStart},
+		End:   &api.GoogleTime{DateTime: recurrenceStart},
+	}
+	outlookEvent := &api.OutlookEvent{
+		Start: &api.OutlookDateTimeTimeZone{DateTime: recurrenceStart},
+		End:   &api.OutlookDateTimeTimeZone{DateTime: recurrenceStart},
+		Body:  &api.OutlookItemBody{},
+	}
+	return googleEvent, outlookEvent
+}
+
+func TestLocation_OutlookToGoogle(t *testing.T) {
+	googleEvent, outlookEvent := locationEvents()
+	outlookEvent.Location = outlookOffice
+	err := convert.Convert(outlookEvent, googleEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	expected := api.GoogleLocation("Main office, 1 Main St, Springfield, IL 62701, United States (39.7817, -89.6501)")
+	if googleEvent.Location != expected {
+		t.Fatalf("something went wrong. Expected %s found %s", expected, googleEvent.Location)
+	}
+
+	// display names that are already the address are not repeated
+	outlookEvent.Location = &api.OutlookLocation{DisplayName: "1 Main St, Springfield, IL 62701, United States", Address: outlookOffice.Address}
+	err = convert.Convert(outlookEvent, googleEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if googleEvent.Location != "1 Main St, Springfield, IL 62701, United States" {
+		t.Fatalf("something went wrong. Expected only the address found %s", googleEvent.Location)
+	}
+
+	outlookEvent.Location = nil
+	err = convert.Convert(outlookEvent, googleEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(googleEvent.Location) != 0 {
+		t.Fatalf("something went wrong. Expected no location found %s", googleEvent.Location)
+	}
+}
+
+func TestLocation_GoogleToOutlook(t *testing.T) {
+	testCases := []struct {
+		location api.GoogleLocation
+		expected *api.OutlookLocation
+	}{
+		{"Main office, 1 Main St, Springfield, IL 62701, United States (39.7817, -89.6501)", outlookOffice},
+		{"Calle Mayor 1, Madrid, 28013, Spain", &api.OutlookLocation{
+			DisplayName: "Calle Mayor 1, Madrid, 28013, Spain",
+			Address:     api.OutlookPhysicalAddress{Street: "Calle Mayor 1", City: "Madrid", PostalCode: "28013", CountryOrRegion: "Spain"},
+		}},
+		// free text is kept as the name of the location
+		{"Room 4, second floor", &api.OutlookLocation{DisplayName: "Room 4, second floor"}},
+		{"Cafe, Main Street, Madrid, Spain", &api.OutlookLocation{DisplayName: "Cafe, Main Street, Madrid, Spain"}},
+		{"Beach (36.7, -4.4)", &api.OutlookLocation{DisplayName: "Beach", Coordinates: api.OutlookGeoCoordinates{Latitude: 36.7, Longitude: -4.4}}},
+		{"", nil},
+	}
+	for _, testCase := range testCases {
+		googleEvent, outlookEvent := locationEvents()
+		googleEvent.Location = testCase.location
+		err := convert.Convert(googleEvent, outlookEvent)
+		if err != nil {
+			t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+		}
+		if !reflect.DeepEqual(outlookEvent.Location, testCase.expected) {
+			t.Fatalf("something went wrong. Expected %+v found %+v", testCase.expected, outlookEvent.Location)
+		}
+	}
+}
+
+func TestLocation_RoundTrip(t *testing.T) {
+	googleEvent, outlookEvent := locationEvents()
+	outlookEvent.Location = outlookOffice
+	err := convert.Convert(outlookEvent, googleEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	_, outlookEvent = locationEvents()
+	err = convert.Convert(googleEvent, outlookEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if !reflect.DeepEqual(outlookEvent.Location, outlookOffice) {
+		t.Fatalf("something went wrong. Expected %+v found %+v", outlookOffice, outlookEvent.Location)
+	}
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Name of the block of the description where the links to join an online meeting are listed
+const meetingBlock = "Online meeting"
+
+// Kinds of link to join an online meeting
+const (
+	MeetingVideo = "video"
+	MeetingPhone = "phone"
+	MeetingSIP   = "sip"
+	MeetingMore  = "more"
+)
+
+// Link to join the online meeting of an event
+type MeetingLink struct {
+	Kind string
+	URI  string
+}
+
+// Method that returns the line that describes the link inside a list
+func (link MeetingLink) String() string {
+	return fmt.Sprintf("- %s: %s", strings.Title(link.Kind), link.URI)
+}
+
+// Interface for events whose online meeting links are written on the events synced with them
+type meetingReader interface {
+	// Method that returns the links to join the online meeting of the event
+	readMeetingLinks() []MeetingLink
+}
+
+// Interface for events that are written with the online meeting links of the events synced with them
+type meetingWriter interface {
+	// Method that writes the links to join the online meeting. No links removes the ones written before
+	writeMeetingLinks([]MeetingLink)
+}
+
+// Function that writes the online meeting links of an event on another one, if both have them
+func writeMeetingLinks(from EventManager, to EventManager) {
+	reader, ok := from.(meetingReader)
+	if !ok {
+		return
+	}
+	if writer, ok := to.(meetingWriter); ok {
+		writer.writeMeetingLinks(reader.readMeetingLinks())
+	}
+}
+
+// Function that returns the description with the block of the online meeting links.
+// The links replace the ones already listed, so a changed link is not repeated
+func describeMeetingLinks(description string, links []MeetingLink) string {
+	lines := make([]string, len(links))
+	for i, link := range links {
+		lines[i] = link.String()
+	}
+	return setDescriptionBlock(description, meetingBlock, strings.Join(lines, "\n"))
+}
+
+// Function that returns the online meeting links listed on the block of the description,
+// so links of calendars that can not hold them are passed on to the next ones
+func meetingLinksFromDescription(description string) (links []MeetingLink) {
+	for _, line := range strings.Split(descriptionBlock(description, meetingBlock), "\n") {
+		parts := strings.SplitN(strings.TrimPrefix(strings.TrimSpace(line), "- "), ": ", 2)
+		if len(parts) != 2 || len(parts[1]) == 0 {
+			continue
+		}
+		links = append(links, MeetingLink{Kind: strings.ToLower(parts[0]), URI: parts[1]})
+	}
+	return
+}
@@ -0,0 +1,84 @@
+package api_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/TetAlius/GoSyncMyCalendars/api"
+)
+
+func TestMeetingLinks_GoogleToOutlook(t *testing.T) {
+	googleEvent, outlookEvent := reminderEvents()
+	googleEvent.Description = "Agenda"
+	googleEvent.HangoutLink = "https://meet.google.com/abc-defg-hij"
+	googleEvent.ConferenceData = &api.GoogleConferenceData{EntryPoints: []api.GoogleEntryPoint{
+		{EntryPointType: "video", URI: "https://meet.google.com/abc-defg-hij"},
+		{EntryPointType: "phone", URI: "tel:+1-555-0100"},
+	}}
+	err := api.ConvertEvent(googleEvent, outlookEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	expected := "Agenda\n\n--- Online meeting ---\n- Video: https://meet.google.com/abc-defg-hij\n- Phone: tel:+1-555-0100\n--- End of online meeting ---"
+	if outlookEvent.Body.Description != expected {
+		t.Fatalf("something went wrong. Expected %q found %q", expected, outlookEvent.Body.Description)
+	}
+
+	// a changed link replaces the block instead of adding another one
+	googleEvent.Description = outlookEvent.Body.Description
+	googleEvent.HangoutLink = "https://meet.google.com/klm-nopq-rst"
+	googleEvent.ConferenceData = nil
+	_, outlookEvent = reminderEvents()
+	err = api.ConvertEvent(googleEvent, outlookEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	expected = "Agenda\n\n--- Online meeting ---\n- Video: https://meet.google.com/klm-nopq-rst\n--- End of online meeting ---"
+	if outlookEvent.Body.Description != expected {
+		t.Fatalf("something went wrong. Expected %q found %q", expected, outlookEvent.Body.Description)
+	}
+
+	// a removed link removes the block
+	googleEvent.HangoutLink = ""
+	googleEvent.Description = "Agenda"
+	_, outlookEvent = reminderEvents()
+	err = api.ConvertEvent(googleEvent, outlookEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if outlookEvent.Body.Description != "Agenda" {
+		t.Fatalf("something went wrong. Expected only the agenda found %q", outlookEvent.Body.Description)
+	}
+}
+
+func TestMeetingLinks_OutlookToGoogle(t *testing.T) {
+	googleEvent, outlookEvent := reminderEvents()
+	outlookEvent.Body.Description = "Agenda"
+	outlookEvent.OnlineMeetingUrl = "https://teams.microsoft.com/l/meetup-join/123"
+	err := api.ConvertEvent(outlookEvent, googleEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if strings.Count(googleEvent.Description, "https://teams.microsoft.com/l/meetup-join/123") != 1 || !strings.HasPrefix(googleEvent.Description, "Agenda\n\n--- Online meeting ---") {
+		t.Fatalf("something went wrong. Expected the link on the description found %q", googleEvent.Description)
+	}
+
+	// calendars without online meetings pass on the links of their description
+	caldavEvent := &api.CalDAVEvent{}
+	caldavEvent.SetCalendar(api.RetrieveCalDAVCalendar("calendar", "", &api.CalDAVAccount{}))
+	err = api.ConvertEvent(googleEvent, caldavEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if caldavEvent.Description != googleEvent.Description {
+		t.Fatalf("something went wrong. Expected %q found %q", googleEvent.Description, caldavEvent.Description)
+	}
+	_, outlookEvent = reminderEvents()
+	err = api.ConvertEvent(caldavEvent, outlookEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if outlookEvent.Body.Description != googleEvent.Description {
+		t.Fatalf("something went wrong. Expected %q found %q", googleEvent.Description, outlookEvent.Body.Description)
+	}
+}
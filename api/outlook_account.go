@@ -1,17 +1,41 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"strings"
 
 	log "github.com/TetAlius/GoSyncMyCalendars/logger"
 	"github.com/TetAlius/GoSyncMyCalendars/util"
+	"github.com/google/uuid"
 )
 
+// Outlook accounts are still read from DB, new ones are added as graph accounts
+func init() {
+	RegisterProvider(&Provider{
+		Kind: OUTLOOK,
+		Name: "outlook",
+		RetrieveAccount: func(tokenType string, refreshToken string, email string, kind int, accessToken string, serverURL string, credential string) AccountManager {
+			return RetrieveOutlookAccount(tokenType, refreshToken, email, kind, accessToken)
+		},
+		RetrieveCalendar: func(ID string, uid string, account AccountManager) CalendarManager {
+			return RetrieveOutlookCalendar(ID, uid, account.(*OutlookAccount))
+		},
+		NewEvent: func(ID string) EventManager {
+			return &OutlookEvent{ID: ID}
+		},
+		NewSubscription: func() SubscriptionManager {
+			return NewOutlookSubscription()
+		},
+		RetrieveSubscription: func(ID string, uid uuid.UUID, calendar CalendarManager, typ string, resourceID string) SubscriptionManager {
+			return RetrieveOutlookSubscription(ID, uid, calendar, typ)
+		},
+	})
+}
+
 // Function that parses the JSON of the request to a OutlookAccount
 func NewOutlookAccount(contents []byte) (a *OutlookAccount, err error) {
 	err = json.Unmarshal(contents, &a)
@@ -41,44 +65,33 @@ func RetrieveOutlookAccount(tokenType string, refreshToken string, email string,
 
 // Method to refresh the access to the outlook account
 func (a *OutlookAccount) Refresh() (err error) {
-	client := http.Client{}
+	return a.RefreshContext(context.Background())
+}
+
+// Method like Refresh that is stopped once the given context is done
+func (a *OutlookAccount) RefreshContext(ctx context.Context) (err error) {
 	//check if token is DEAD!!!
 
-	route, err := util.CallAPIRoot("outlook/token/uri")
+	route, err := util.GetRoute("outlook/token/uri")
 	log.Debugln(route)
 
 	if err != nil {
 		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
 	}
 
-	params, err := util.CallAPIRoot("outlook/token/refresh-params")
-	log.Debugf("Params: %s", fmt.Sprintf(params, a.RefreshToken))
+	params, err := util.GetParamsRoute("outlook/token/refresh-params", a.RefreshToken)
 	if err != nil {
 		return errors.New(fmt.Sprintf("error generating params: %s", err.Error()))
 	}
 
-	req, err := http.NewRequest(http.MethodPost,
+	headers := make(map[string]string)
+	headers["Content-Type"] = "application/x-www-form-urlencoded"
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodPost,
 		route,
-		strings.NewReader(fmt.Sprintf(params, a.RefreshToken)))
-
-	if err != nil {
-		return errors.New(fmt.Sprintf("error creating new request: %s", err.Error()))
-	}
-
-	req.Header.Set("Content-Type",
-		"application/x-www-form-urlencoded")
+		strings.NewReader(params),
+		headers, nil)
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return errors.New(fmt.Sprintf("error doing outlook request: %s", err.Error()))
-	}
-	defer resp.Body.Close()
-	contents, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return errors.New(fmt.Sprintf("error reading response body from outlook request: %s", err.Error()))
-	}
-
-	if resp.StatusCode != 201 && resp.StatusCode != 204 {
 		e := new(RefreshError)
 		_ = json.Unmarshal(contents, &e)
 		if len(e.Code) != 0 && len(e.Message) != 0 {
@@ -86,6 +99,7 @@ func (a *OutlookAccount) Refresh() (err error) {
 			log.Errorln(e.Message)
 			return e
 		}
+		return util.RequestError(err, "error doing outlook request")
 	}
 
 	log.Debugf("\nTokenType: %s\nExpiresIn: %d\nAccessToken: %s\nRefreshToken: %s\nTokenID: %s\nAnchorMailbox: %s\nPreferredUsername: %t",
@@ -102,9 +116,14 @@ func (a *OutlookAccount) Refresh() (err error) {
 //
 // GET https://outlook.office.com/api/v2.0/me/calendars
 func (a *OutlookAccount) GetAllCalendars() (calendars []CalendarManager, err error) {
+	return a.GetAllCalendarsContext(context.Background())
+}
+
+// Method like GetAllCalendars that is stopped once the given context is done
+func (a *OutlookAccount) GetAllCalendarsContext(ctx context.Context) (calendars []CalendarManager, err error) {
 	log.Debugln("getAllCalendars outlook")
 
-	route, err := util.CallAPIRoot("outlook/calendars")
+	route, err := util.GetRoute("outlook/calendars")
 	if err != nil {
 		log.Errorf("%s", err.Error())
 		return calendars, errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
@@ -115,39 +134,54 @@ func (a *OutlookAccount) GetAllCalendars() (calendars []CalendarManager, err err
 	headers["X-AnchorMailbox"] = a.Mail()
 	queryParams := map[string]string{"$filter": "CanEdit eq false"}
 
-	contents, err := util.DoRequest(http.MethodGet,
-		route,
-		nil,
-		headers, queryParams)
+	for {
+		contents, err := util.DoProviderRequestContext(ctx, http.MethodGet,
+			route,
+			nil,
+			headers, queryParams)
 
-	if err != nil {
-		return nil, errors.New(fmt.Sprintf("error getting all calendars for email %s. %s", a.AnchorMailbox, err.Error()))
-	}
-	err = createOutlookResponseError(contents)
-	if err != nil {
-		return nil, err
-	}
+		if err != nil {
+			return nil, util.RequestError(err, fmt.Sprintf("error getting all calendars for email %s", a.AnchorMailbox))
+		}
+		err = createOutlookResponseError(contents)
+		if err != nil {
+			return nil, err
+		}
 
-	calendarResponse := new(OutlookCalendarListResponse)
-	err = json.Unmarshal(contents, &calendarResponse)
+		calendarResponse := new(OutlookCalendarListResponse)
+		err = json.Unmarshal(contents, &calendarResponse)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("error unmarshalling calendars: %s", err.Error()))
+		}
 
-	for _, s := range calendarResponse.Calendars {
-		s.SetAccount(a)
-		calendars = append(calendars, s)
+		for _, s := range calendarResponse.Calendars {
+			s.SetAccount(a)
+			calendars = append(calendars, s)
+		}
+		if len(calendarResponse.OdataNextLink) == 0 {
+			return calendars, nil
+		}
+		// next links already carry all the query params
+		route = calendarResponse.OdataNextLink
+		queryParams = nil
 	}
-	return
 }
 
 // Method that retrieves one calendar given an ID
 //
 // GET https://outlook.office.com/api/v2.0/me/calendars/{calendarID}
 func (a *OutlookAccount) GetCalendar(calendarID string) (calendar CalendarManager, err error) {
+	return a.GetCalendarContext(context.Background(), calendarID)
+}
+
+// Method like GetCalendar that is stopped once the given context is done
+func (a *OutlookAccount) GetCalendarContext(ctx context.Context, calendarID string) (calendar CalendarManager, err error) {
 	if len(calendarID) == 0 {
 		return calendar, errors.New("no ID for calendar was given")
 	}
 	log.Debugln("getCalendar outlook")
 
-	route, err := util.CallAPIRoot("outlook/calendars/id")
+	route, err := util.GetRoute("outlook/calendars/id")
 	if err != nil {
 		log.Errorf("error generating URL: %s", err.Error())
 		return
@@ -157,13 +191,13 @@ func (a *OutlookAccount) GetCalendar(calendarID string) (calendar CalendarManage
 	headers["Authorization"] = a.AuthorizationRequest()
 	headers["X-AnchorMailbox"] = a.Mail()
 
-	contents, err := util.DoRequest(http.MethodGet,
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodGet,
 		fmt.Sprintf(route, calendarID),
 		nil,
 		headers, nil)
 
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("error getting a calendar for email %s. %s", a.AnchorMailbox, err.Error()))
+		return nil, util.RequestError(err, fmt.Sprintf("error getting a calendar for email %s", a.AnchorMailbox))
 	}
 	err = createOutlookResponseError(contents)
 	if err != nil {
@@ -181,9 +215,14 @@ func (a *OutlookAccount) GetCalendar(calendarID string) (calendar CalendarManage
 //
 // GET https://outlook.office.com/api/v2.0/me/calendar
 func (a *OutlookAccount) GetPrimaryCalendar() (calendar CalendarManager, err error) {
+	return a.GetPrimaryCalendarContext(context.Background())
+}
+
+// Method like GetPrimaryCalendar that is stopped once the given context is done
+func (a *OutlookAccount) GetPrimaryCalendarContext(ctx context.Context) (calendar CalendarManager, err error) {
 	log.Debugln("getPrimaryCalendar outlook")
 
-	route, err := util.CallAPIRoot("outlook/calendars/primary")
+	route, err := util.GetRoute("outlook/calendars/primary")
 	if err != nil {
 		log.Errorf("%s", err.Error())
 		return calendar, errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
@@ -193,14 +232,14 @@ func (a *OutlookAccount) GetPrimaryCalendar() (calendar CalendarManager, err err
 	headers["Authorization"] = a.AuthorizationRequest()
 	headers["X-AnchorMailbox"] = a.Mail()
 
-	contents, err := util.DoRequest(http.MethodGet,
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodGet,
 		route,
 		nil,
 		headers, nil)
 
 	if err != nil {
 		log.Errorf("%s", err.Error())
-		return calendar, errors.New(fmt.Sprintf("error getting primary calendar for email %s. %s", a.AnchorMailbox, err.Error()))
+		return calendar, util.RequestError(err, fmt.Sprintf("error getting primary calendar for email %s", a.AnchorMailbox))
 	}
 	err = createOutlookResponseError(contents)
 	if err != nil {
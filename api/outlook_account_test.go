@@ -2,6 +2,8 @@ package api_test
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/http"
 	"os"
 	"testing"
 
@@ -10,7 +12,7 @@ import (
 )
 
 func TestNewOutlookAccount(t *testing.T) {
-	setupApiRoot()
+	setupRoutes()
 	// Bad Info inside Json
 	b := []byte(`{"Name":"Bob","Food":"Pickle"}`)
 	_, err := api.NewOutlookAccount(b)
@@ -38,7 +40,7 @@ func TestNewOutlookAccount(t *testing.T) {
 }
 
 func TestOutlookAccount_Refresh(t *testing.T) {
-	setupApiRoot()
+	setupRoutes()
 	//Empty initialized info account
 	account := new(api.OutlookAccount)
 	err := account.Refresh()
@@ -54,7 +56,7 @@ func TestOutlookAccount_Refresh(t *testing.T) {
 		t.Fatalf("something went wrong. Expected nil found %s", err.Error())
 	}
 
-	os.Setenv("API_ROOT", "")
+	unreachableRoutes()
 	err = account.Refresh()
 	log.Debugln(err)
 
@@ -65,7 +67,7 @@ func TestOutlookAccount_Refresh(t *testing.T) {
 }
 
 func TestOutlookAccount_GetAllCalendars(t *testing.T) {
-	setupApiRoot()
+	setupRoutes()
 	account, _ := setup()
 	//Refresh previous petition in order to have tokens updated
 	account.Refresh()
@@ -75,7 +77,7 @@ func TestOutlookAccount_GetAllCalendars(t *testing.T) {
 		t.Fatalf("something went wrong. Expected nil found: %s", err.Error())
 	}
 
-	os.Setenv("API_ROOT", "")
+	unreachableRoutes()
 	// Bad calling to GetPrimaryCalendar
 	_, err = account.GetAllCalendars()
 	if err == nil {
@@ -84,8 +86,28 @@ func TestOutlookAccount_GetAllCalendars(t *testing.T) {
 
 }
 
+func TestOutlookAccount_GetAllCalendarsPaginated(t *testing.T) {
+	_, teardown := setupStandIn(map[string]string{"outlook/calendars": "/me/calendars"}, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("$skip") == "1" {
+			w.Write([]byte(`{"value":[{"Id":"second","Name":"Second"}]}`))
+			return
+		}
+		fmt.Fprintf(w, `{"@odata.nextLink":"http://%s%s?$skip=1","value":[{"Id":"first","Name":"First"}]}`, r.Host, r.URL.Path)
+	})
+	defer teardown()
+	account := &api.OutlookAccount{TokenType: "Bearer", AccessToken: "token", AnchorMailbox: "travis@example.com"}
+
+	calendars, err := account.GetAllCalendars()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(calendars) != 2 || calendars[1].GetID() != "second" {
+		t.Fatalf("something went wrong. Expected 2 calendars found %d", len(calendars))
+	}
+}
+
 func TestOutlookAccount_GetPrimaryCalendar(t *testing.T) {
-	setupApiRoot()
+	setupRoutes()
 	account, _ := setup()
 	//Refresh previous petition in order to have tokens updated
 	err := account.Refresh()
@@ -102,7 +124,7 @@ func TestOutlookAccount_GetPrimaryCalendar(t *testing.T) {
 	os.Setenv("OUTLOOK_CALENDAR_ID", calendar.GetID())
 	os.Setenv("OUTLOOK_CALENDAR_NAME", calendar.GetName())
 
-	os.Setenv("API_ROOT", "")
+	unreachableRoutes()
 	// Bad calling to GetPrimaryCalendar
 	_, err = account.GetPrimaryCalendar()
 	if err == nil {
@@ -112,7 +134,7 @@ func TestOutlookAccount_GetPrimaryCalendar(t *testing.T) {
 }
 
 func TestOutlookAccount_GetCalendar(t *testing.T) {
-	setupApiRoot()
+	setupRoutes()
 	account, _ := setup()
 	//Refresh previous petition in order to have tokens updated
 	account.Refresh()
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,11 +11,29 @@ import (
 
 	"strings"
 
+	"time"
+
 	"github.com/TetAlius/GoSyncMyCalendars/convert"
+	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
 	log "github.com/TetAlius/GoSyncMyCalendars/logger"
 	"github.com/TetAlius/GoSyncMyCalendars/util"
 )
 
+// Range of days, before and after the current date, that calendar views cover
+// when they are not limited by the sync window
+const outlookDeltaDays = 365
+
+// Range of days, around the original start of an instance, where it is looked for.
+// Instances moved further away from their original start are not found
+const outlookInstanceDays = 31
+
+// Error codes given by Outlook when a delta token can not be used anymore
+var outlookSyncStateErrors = map[string]bool{
+	"SyncStateNotFound":         true,
+	"SyncStateInvalid":          true,
+	"ErrorInvalidSyncStateData": true,
+}
+
 // Method that returns a OutlookCalendar given specific info
 func RetrieveOutlookCalendar(ID string, uid string, account *OutlookAccount) *OutlookCalendar {
 	cal := new(OutlookCalendar)
@@ -28,9 +47,14 @@ func RetrieveOutlookCalendar(ID string, uid string, account *OutlookAccount) *Ou
 //
 // POST https://outlook.office.com/api/v2.0/me/calendars
 func (calendar *OutlookCalendar) Create() (err error) {
+	return calendar.CreateContext(context.Background())
+}
+
+// Method like Create that is stopped once the given context is done
+func (calendar *OutlookCalendar) CreateContext(ctx context.Context) (err error) {
 	log.Debugln("createCalendars outlook")
 
-	route, err := util.CallAPIRoot("outlook/calendars")
+	route, err := util.GetRoute("outlook/calendars")
 	if err != nil {
 		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
 	}
@@ -44,13 +68,13 @@ func (calendar *OutlookCalendar) Create() (err error) {
 	headers["Authorization"] = calendar.GetAccount().AuthorizationRequest()
 	headers["X-AnchorMailbox"] = calendar.GetAccount().Mail()
 
-	contents, err := util.DoRequest(http.MethodPost,
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodPost,
 		route,
 		bytes.NewBuffer(data),
 		headers, nil)
 
 	if err != nil {
-		return errors.New(fmt.Sprintf("error creating a calendar for email %s. %s", calendar.GetAccount().Mail(), err.Error()))
+		return util.RequestError(err, fmt.Sprintf("error creating a calendar for email %s", calendar.GetAccount().Mail()))
 	}
 	err = createOutlookResponseError(contents)
 	if err != nil {
@@ -67,9 +91,14 @@ func (calendar *OutlookCalendar) Create() (err error) {
 //
 // PUT https://outlook.office.com/api/v2.0/me/calendars/{calendarID}
 func (calendar *OutlookCalendar) Update() error {
+	return calendar.UpdateContext(context.Background())
+}
+
+// Method like Update that is stopped once the given context is done
+func (calendar *OutlookCalendar) UpdateContext(ctx context.Context) error {
 	log.Debugln("updateCalendar outlook")
 
-	route, err := util.CallAPIRoot("outlook/calendars/id")
+	route, err := util.GetRoute("outlook/calendars/id")
 	if err != nil {
 		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
 	}
@@ -83,26 +112,26 @@ func (calendar *OutlookCalendar) Update() error {
 	headers["Authorization"] = calendar.GetAccount().AuthorizationRequest()
 	headers["X-AnchorMailbox"] = calendar.GetAccount().Mail()
 
-	contents, err := util.DoRequest(http.MethodPatch,
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodPatch,
 		fmt.Sprintf(route, calendar.GetID()),
 		bytes.NewBuffer(data),
 		headers, nil)
 
 	log.Debugf("contents: %s", contents)
-	if err != nil {
-		return errors.New(fmt.Sprintf("error updating a calendar for email %s. %s", calendar.GetAccount().Mail(), err.Error()))
-	}
-	err = createOutlookResponseError(contents)
 	// default outlook calendar cannot be renamed, so ignore this kind of error as the request is valid.
-	if err != nil && !strings.Contains(err.Error(), "default calendar cannot be renamed") {
-		return err
-	}
 	if err != nil && strings.Contains(err.Error(), "default calendar cannot be renamed") {
-		cal, err := calendar.GetAccount().GetCalendar(calendar.GetID())
+		cal, err := calendar.GetAccount().GetCalendarContext(ctx, calendar.GetID())
 		if err != nil {
 			return err
 		}
-		convert.Convert(cal, calendar)
+		return convert.Convert(cal, calendar)
+	}
+	if err != nil {
+		return util.RequestError(err, fmt.Sprintf("error updating a calendar for email %s", calendar.GetAccount().Mail()))
+	}
+	err = createOutlookResponseError(contents)
+	if err != nil {
+		return err
 	}
 
 	calendarResponse := OutlookCalendarResponse{OdataContext: "", OutlookCalendar: calendar}
@@ -116,12 +145,17 @@ func (calendar *OutlookCalendar) Update() error {
 //
 // DELETE https://outlook.office.com/api/v2.0/me/calendars/{calendarID}
 func (calendar *OutlookCalendar) Delete() (err error) {
+	return calendar.DeleteContext(context.Background())
+}
+
+// Method like Delete that is stopped once the given context is done
+func (calendar *OutlookCalendar) DeleteContext(ctx context.Context) (err error) {
 	log.Debugln("deleteCalendar outlook")
 	if len(calendar.GetID()) == 0 {
 		return errors.New("no ID for calendar was given")
 	}
 
-	route, err := util.CallAPIRoot("outlook/calendars/id")
+	route, err := util.GetRoute("outlook/calendars/id")
 	if err != nil {
 		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
 	}
@@ -130,13 +164,13 @@ func (calendar *OutlookCalendar) Delete() (err error) {
 	headers["Authorization"] = calendar.GetAccount().AuthorizationRequest()
 	headers["X-AnchorMailbox"] = calendar.GetAccount().Mail()
 
-	contents, err := util.DoRequest(http.MethodDelete,
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodDelete,
 		fmt.Sprintf(route, calendar.GetID()),
 		nil,
 		headers, nil)
 
 	if err != nil {
-		return errors.New(fmt.Sprintf("error deleting a calendar for email %s. %s", calendar.GetAccount().Mail(), err.Error()))
+		return util.RequestError(err, fmt.Sprintf("error deleting a calendar for email %s", calendar.GetAccount().Mail()))
 	}
 
 	if len(contents) != 0 {
@@ -151,54 +185,226 @@ func (calendar *OutlookCalendar) Delete() (err error) {
 //
 // GET https://outlook.office.com/api/v2.0/me/calendars/{calendarID}/events
 func (calendar *OutlookCalendar) GetAllEvents() (events []EventManager, err error) {
+	return calendar.GetAllEventsContext(context.Background())
+}
+
+// Method like GetAllEvents that is stopped once the given context is done
+func (calendar *OutlookCalendar) GetAllEventsContext(ctx context.Context) (events []EventManager, err error) {
 	log.Debugln("getAllEvents outlook")
-	route, err := util.CallAPIRoot("outlook/calendars/id/events")
+	err = calendar.ForEachEventPageContext(ctx, func(page []EventManager) error {
+		events = append(events, page...)
+		return nil
+	})
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+		return nil, err
+	}
+	return events, nil
+}
+
+// Method that calls the given function with every page of events of the calendar.
+// If the sync window is limited, the calendar view inside it is used
+//
+// GET https://outlook.office.com/api/v2.0/me/calendars/{calendarID}/events?$skip={skip}
+// GET https://outlook.office.com/api/v2.0/me/calendars/{calendarID}/calendarview?startDateTime={start}&endDateTime={end}
+func (calendar *OutlookCalendar) ForEachEventPage(fn func([]EventManager) error) (err error) {
+	return calendar.ForEachEventPageContext(context.Background(), fn)
+}
+
+// Method like ForEachEventPage that is stopped once the given context is done
+func (calendar *OutlookCalendar) ForEachEventPageContext(ctx context.Context, fn func([]EventManager) error) (err error) {
+	var queryParams map[string]string
+	routeName := "outlook/calendars/id/events"
+	if calendar.window.IsLimited() {
+		routeName = "outlook/calendars/id/calendarview"
+		queryParams = calendar.viewParams(time.Now())
 	}
+	route, err := util.GetRoute(routeName)
+	if err != nil {
+		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+	link := fmt.Sprintf(route, calendar.GetID())
 
 	headers := make(map[string]string)
 	headers["Authorization"] = calendar.GetAccount().AuthorizationRequest()
 	headers["X-AnchorMailbox"] = calendar.GetAccount().Mail()
 	headers["Prefer"] = "outlook.timezone=UTC, outlook.body-content-type=text"
 
-	contents, err := util.DoRequest(http.MethodGet,
-		fmt.Sprintf(route, calendar.GetID()),
-		nil,
-		headers, nil)
+	series := make(map[string]bool)
+	for {
+		contents, err := util.DoProviderRequestContext(ctx, http.MethodGet,
+			link,
+			nil,
+			headers, queryParams)
 
-	if err != nil {
-		return nil, errors.New(fmt.Sprintf("error getting all events of a calendar for email %s. %s", calendar.GetAccount().Mail(), err.Error()))
+		if err != nil {
+			return util.RequestError(err, fmt.Sprintf("error getting all events of a calendar for email %s", calendar.GetAccount().Mail()))
+		}
+
+		err = createOutlookResponseError(contents)
+		if err != nil {
+			return err
+		}
+		eventListResponse := new(OutlookEventListResponse)
+		err = json.Unmarshal(contents, &eventListResponse)
+		if err != nil {
+			return errors.New(fmt.Sprintf("error unmarshalling events: %s", err.Error()))
+		}
+
+		events, err := calendar.seriesMasters(ctx, eventListResponse.Events, series)
+		if err != nil {
+			return err
+		}
+		err = fn(events)
+		if err != nil || len(eventListResponse.OdataNextLink) == 0 {
+			return err
+		}
+		// next links already carry all the query params
+		link = eventListResponse.OdataNextLink
+		queryParams = nil
 	}
+}
 
-	err = createOutlookResponseError(contents)
-	if err != nil {
-		return nil, err
+// Method that returns the bounds of the calendar view. The sides not limited by the
+// sync window cover the default range of days
+func (calendar *OutlookCalendar) viewParams(now time.Time) map[string]string {
+	start := calendar.window.Start(now)
+	if start.IsZero() {
+		start = now.UTC().AddDate(0, 0, -outlookDeltaDays)
+	}
+	end := calendar.window.End(now)
+	if end.IsZero() {
+		end = now.UTC().AddDate(0, 0, outlookDeltaDays)
 	}
-	eventListResponse := new(OutlookEventListResponse)
-	err = json.Unmarshal(contents, &eventListResponse)
+	return map[string]string{
+		"startDateTime": start.Format(time.RFC3339),
+		"endDateTime":   end.Format(time.RFC3339),
+	}
+}
 
-	for _, s := range eventListResponse.Events {
-		s.SetCalendar(calendar)
-		if err != nil {
-			return
+// Method that replaces the occurrences given by calendar views with the master of
+// their series, so every series is synchronized once. Exceptions are kept after the
+// master, as they are instances changed on their own.
+// Series already given are stored on the map
+func (calendar *OutlookCalendar) seriesMasters(ctx context.Context, outlookEvents []*OutlookEvent, series map[string]bool) (events []EventManager, err error) {
+	for _, event := range outlookEvents {
+		event.SetCalendar(calendar)
+		if event.Type != "Occurrence" && event.Type != "Exception" || len(event.SeriesMasterID) == 0 {
+			if event.Type == "SeriesMaster" {
+				if series[event.ID] {
+					continue
+				}
+				series[event.ID] = true
+			}
+			if len(event.Reason) == 0 {
+				event.setAllDay()
+			}
+			events = append(events, event)
+			continue
+		}
+		if !series[event.SeriesMasterID] {
+			series[event.SeriesMasterID] = true
+			master, err := calendar.GetEventContext(ctx, event.SeriesMasterID)
+			if _, ok := err.(*customErrors.NotFoundError); ok {
+				// the series was removed after the view was given
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, master)
+		}
+		if event.Type == "Exception" {
+			event.setAllDay()
+			events = append(events, event)
 		}
-		s.setAllDay()
-		events = append(events, s)
 	}
 	return
 }
 
+// Method that returns the events changed since the given delta link and the delta link for the next call.
+// Removed events are returned with Deleted state
+//
+// GET https://outlook.office.com/api/v2.0/me/calendars/{calendarID}/calendarview/delta
+func (calendar *OutlookCalendar) GetChangedEvents(token string) (events []EventManager, nextToken string, err error) {
+	return calendar.GetChangedEventsContext(context.Background(), token)
+}
+
+// Method like GetChangedEvents that is stopped once the given context is done
+func (calendar *OutlookCalendar) GetChangedEventsContext(ctx context.Context, token string) (events []EventManager, nextToken string, err error) {
+	log.Debugln("getChangedEvents outlook")
+	link := token
+	var queryParams map[string]string
+	if len(link) == 0 {
+		route, err := util.GetRoute("outlook/calendars/id/calendarview/delta")
+		if err != nil {
+			return nil, "", errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+		}
+		link = fmt.Sprintf(route, calendar.GetID())
+		// the delta link keeps the bounds of the first call, which are the ones of the sync window
+		queryParams = calendar.viewParams(time.Now())
+	}
+
+	headers := make(map[string]string)
+	headers["Authorization"] = calendar.GetAccount().AuthorizationRequest()
+	headers["X-AnchorMailbox"] = calendar.GetAccount().Mail()
+	headers["Prefer"] = "odata.track-changes, outlook.timezone=UTC, outlook.body-content-type=text"
+
+	series := make(map[string]bool)
+	for {
+		contents, status, _, err := util.DoProviderRawRequestContext(ctx, http.MethodGet, link, nil, headers, queryParams)
+		if status == http.StatusGone {
+			return nil, "", &SyncTokenExpiredError{ID: calendar.GetID()}
+		}
+		// the expired sync states are also answered with bad requests
+		responseErr := createOutlookResponseError(contents)
+		if outlookErr, ok := responseErr.(*OutlookError); ok && outlookSyncStateErrors[outlookErr.Code] {
+			return nil, "", &SyncTokenExpiredError{ID: calendar.GetID()}
+		}
+		if err != nil {
+			return nil, "", util.RequestError(err, fmt.Sprintf("error getting changed events of a calendar for email %s", calendar.GetAccount().Mail()))
+		}
+		if responseErr != nil {
+			return nil, "", responseErr
+		}
+		eventListResponse := new(OutlookEventListResponse)
+		err = json.Unmarshal(contents, &eventListResponse)
+		if err != nil {
+			return nil, "", errors.New(fmt.Sprintf("error unmarshalling events: %s", err.Error()))
+		}
+		page, err := calendar.seriesMasters(ctx, eventListResponse.Events, series)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, event := range page {
+			if event.(*OutlookEvent).Reason == "deleted" {
+				event.SetState(Deleted)
+			}
+			events = append(events, event)
+		}
+		if len(eventListResponse.OdataNextLink) == 0 {
+			return events, eventListResponse.OdataDeltaLink, nil
+		}
+		// next links already carry all the query params
+		link = eventListResponse.OdataNextLink
+		queryParams = nil
+	}
+}
+
 // Method that returns a single event given the ID
 //
 // GET https://outlook.office.com/api/v2.0/me/events/{eventID}
 func (calendar *OutlookCalendar) GetEvent(ID string) (event EventManager, err error) {
+	return calendar.GetEventContext(context.Background(), ID)
+}
+
+// Method like GetEvent that is stopped once the given context is done
+func (calendar *OutlookCalendar) GetEventContext(ctx context.Context, ID string) (event EventManager, err error) {
 	log.Debugln("getEvent outlook")
 	if len(ID) == 0 {
 		return nil, errors.New("an ID for the event must be given")
 	}
 
-	route, err := util.CallAPIRoot("outlook/events/id")
+	route, err := util.GetRoute("outlook/events/id")
 	if err != nil {
 		return nil, errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
 	}
@@ -208,13 +414,13 @@ func (calendar *OutlookCalendar) GetEvent(ID string) (event EventManager, err er
 	headers["X-AnchorMailbox"] = calendar.GetAccount().Mail()
 	headers["Prefer"] = "outlook.timezone=UTC,outlook.body-content-type=text"
 
-	contents, err := util.DoRequest(http.MethodGet,
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodGet,
 		fmt.Sprintf(route, ID),
 		nil,
 		headers, nil)
 
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("error getting an event of a calendar for email %s. %s", calendar.GetAccount().Mail(), err.Error()))
+		return nil, util.RequestError(err, fmt.Sprintf("error getting an event of a calendar for email %s", calendar.GetAccount().Mail()))
 	}
 	err = createOutlookResponseError(contents)
 	if err != nil {
@@ -236,6 +442,91 @@ func (calendar *OutlookCalendar) GetEvent(ID string) (event EventManager, err er
 	return e, nil
 }
 
+// Method that returns the instance of the given series that originally started at the given time.
+// Instances are looked for in the days around their original start
+//
+// GET https://outlook.office.com/api/v2.0/me/events/{eventID}/instances?startDateTime={start}&endDateTime={end}
+func (calendar *OutlookCalendar) GetInstance(seriesID string, originalStart time.Time) (event EventManager, err error) {
+	return calendar.GetInstanceContext(context.Background(), seriesID, originalStart)
+}
+
+// Method like GetInstance that is stopped once the given context is done
+func (calendar *OutlookCalendar) GetInstanceContext(ctx context.Context, seriesID string, originalStart time.Time) (event EventManager, err error) {
+	log.Debugln("getInstance outlook")
+
+	route, err := util.GetRoute("outlook/events/id/instances")
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+	link := fmt.Sprintf(route, seriesID)
+
+	headers := make(map[string]string)
+	headers["Authorization"] = calendar.GetAccount().AuthorizationRequest()
+	headers["X-AnchorMailbox"] = calendar.GetAccount().Mail()
+	headers["Prefer"] = "outlook.timezone=UTC, outlook.body-content-type=text"
+
+	queryParams := map[string]string{
+		"startDateTime": originalStart.UTC().AddDate(0, 0, -outlookInstanceDays).Format(time.RFC3339),
+		"endDateTime":   originalStart.UTC().AddDate(0, 0, outlookInstanceDays).Format(time.RFC3339),
+	}
+	for {
+		contents, err := util.DoProviderRequestContext(ctx, http.MethodGet,
+			link,
+			nil,
+			headers, queryParams)
+		if err != nil {
+			return nil, util.RequestError(err, fmt.Sprintf("error getting an instance of a calendar for email %s", calendar.GetAccount().Mail()))
+		}
+		err = createOutlookResponseError(contents)
+		if err != nil {
+			return nil, err
+		}
+		eventListResponse := new(OutlookEventListResponse)
+		err = json.Unmarshal(contents, &eventListResponse)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("error unmarshalling events: %s", err.Error()))
+		}
+		for _, instance := range eventListResponse.Events {
+			if instance.GetOriginalStart().Equal(originalStart) {
+				instance.SetCalendar(calendar)
+				instance.setAllDay()
+				return instance, nil
+			}
+		}
+		if len(eventListResponse.OdataNextLink) == 0 {
+			break
+		}
+		// next links already carry all the query params
+		link = eventListResponse.OdataNextLink
+		queryParams = nil
+	}
+	return nil, &customErrors.NotFoundError{Message: fmt.Sprintf("instance of event with id: %s starting at %s not found", seriesID, originalStart)}
+}
+
+// Method that writes the requests of several events with a single batch request
+//
+// POST https://outlook.office.com/api/v2.0/$batch
+func (calendar *OutlookCalendar) doBatch(ctx context.Context, requests []util.BatchRequest) (responses []util.BatchResponse, err error) {
+	route, err := util.GetRoute("outlook/batch")
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+	headers := make(map[string]string)
+	headers["Authorization"] = calendar.GetAccount().AuthorizationRequest()
+	headers["X-AnchorMailbox"] = calendar.GetAccount().Mail()
+
+	responses, err = util.DoMultipartBatchContext(ctx, route, headers, requests)
+	if err != nil {
+		return nil, util.RequestError(err, fmt.Sprintf("error writing batch of events for email %s", calendar.GetAccount().Mail()))
+	}
+	return
+}
+
+// Method that returns the maximum number of requests inside a batch of outlook
+func (calendar *OutlookCalendar) batchSize() int {
+	return 20
+}
+
 // Method that sets the account which the calendar belongs
 func (calendar *OutlookCalendar) SetAccount(a AccountManager) (err error) {
 	switch x := a.(type) {
@@ -292,3 +583,29 @@ func (calendar *OutlookCalendar) GetCalendars() []CalendarManager {
 func (calendar *OutlookCalendar) CreateEmptyEvent(ID string) EventManager {
 	return &OutlookEvent{ID: ID, calendar: calendar}
 }
+
+// Method that sets the window of days whose events are synchronized
+func (calendar *OutlookCalendar) SetSyncWindow(window SyncWindow) {
+	calendar.window = window
+}
+
+// Method that returns the window of days whose events are synchronized
+func (calendar *OutlookCalendar) GetSyncWindow() SyncWindow {
+	return calendar.window
+}
+
+// Method that returns whether the changed events asked without a token are bounded.
+// They are always bounded, as the delta is only given on calendar views
+func (calendar *OutlookCalendar) HasBoundedChanges() bool {
+	return true
+}
+
+// Method that sets the options of the relation of the calendar
+func (calendar *OutlookCalendar) SetSyncOptions(options SyncOptions) {
+	calendar.options = options
+}
+
+// Method that returns the options of the relation of the calendar
+func (calendar *OutlookCalendar) GetSyncOptions() SyncOptions {
+	return calendar.options
+}
@@ -1,6 +1,8 @@
 package api_test
 
 import (
+	"net/http"
+	"net/url"
 	"testing"
 
 	"fmt"
@@ -8,10 +10,11 @@ import (
 	"time"
 
 	"github.com/TetAlius/GoSyncMyCalendars/api"
+	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
 )
 
 func TestOutlookCalendar_CalendarLifeCycle(t *testing.T) {
-	setupApiRoot()
+	setupRoutes()
 	account, _ := setup()
 	//Refresh previous petition in order to have tokens updated
 	account.Refresh()
@@ -74,7 +77,7 @@ func TestOutlookCalendar_CalendarLifeCycle(t *testing.T) {
 }
 
 func TestOutlookCalendar_GetAllEvents(t *testing.T) {
-	setupApiRoot()
+	setupRoutes()
 	account, _ := setup()
 	//Refresh previous petition in order to have tokens updated
 	account.Refresh()
@@ -101,8 +104,238 @@ func TestOutlookCalendar_GetAllEvents(t *testing.T) {
 
 }
 
+func TestOutlookCalendar_ForEachEventPage(t *testing.T) {
+	_, teardown := setupStandIn(map[string]string{"outlook/calendars/id/events": "/calendars/%s/events"}, func(w http.ResponseWriter, r *http.Request) {
+		link := fmt.Sprintf("http://%s%s", r.Host, r.URL.Path)
+		switch r.URL.Query().Get("$skip") {
+		case "":
+			fmt.Fprintf(w, `{"@odata.nextLink":"%s?$skip=1","value":[{"Id":"1","Start":{"DateTime":"2018-06-14T10:00:00","TimeZone":"UTC"},"End":{"DateTime":"2018-06-14T11:00:00","TimeZone":"UTC"}}]}`, link)
+		case "1":
+			fmt.Fprintf(w, `{"@odata.nextLink":"%s?$skip=2","value":[{"Id":"2","Start":{"DateTime":"2018-06-14T10:00:00","TimeZone":"UTC"},"End":{"DateTime":"2018-06-14T11:00:00","TimeZone":"UTC"}}]}`, link)
+		case "2":
+			w.Write([]byte(`{"value":[{"Id":"3","IsAllDay":true,"Start":{"DateTime":"2018-06-14T00:00:00","TimeZone":"UTC"},"End":{"DateTime":"2018-06-15T00:00:00","TimeZone":"UTC"}}]}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	defer teardown()
+	calendar := api.RetrieveOutlookCalendar("calendar", "", &api.OutlookAccount{TokenType: "Bearer", AccessToken: "token", AnchorMailbox: "travis@example.com"})
+
+	// good call follows all the next links
+	events, err := calendar.GetAllEvents()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(events) != 3 || events[2].GetID() != "3" {
+		t.Fatalf("something went wrong. Expected 3 events found %d", len(events))
+	}
+
+	// pages stop being retrieved once the function fails
+	pages := 0
+	err = calendar.ForEachEventPage(func(page []api.EventManager) error {
+		pages++
+		return fmt.Errorf("stop")
+	})
+	if err == nil || pages != 1 {
+		t.Fatalf("something went wrong. Expected error after 1 page found %d pages and error %v", pages, err)
+	}
+}
+
+func TestOutlookCalendar_SyncWindow(t *testing.T) {
+	var masterRequests int
+	_, teardown := setupStandIn(map[string]string{
+		"outlook/calendars/id/events":       "/calendars/%s/events",
+		"outlook/calendars/id/calendarview": "/calendars/%s/calendarview",
+		"outlook/events/id":                 "/events/%s",
+	}, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch r.URL.Path {
+		case "/calendars/calendar/events":
+			w.Write([]byte(`{"value":[]}`))
+		case "/calendars/calendar/calendarview":
+			if len(query.Get("startDateTime")) == 0 || len(query.Get("endDateTime")) == 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"value":[{"Id":"single","Type":"SingleInstance","Start":{"DateTime":"2018-06-14T10:00:00","TimeZone":"UTC"},"End":{"DateTime":"2018-06-14T11:00:00","TimeZone":"UTC"}},{"Id":"occurrence1","Type":"Occurrence","SeriesMasterId":"master","Start":{"DateTime":"2018-06-15T10:00:00","TimeZone":"UTC"},"End":{"DateTime":"2018-06-15T11:00:00","TimeZone":"UTC"}},{"Id":"occurrence2","Type":"Occurrence","SeriesMasterId":"master","Start":{"DateTime":"2018-06-16T10:00:00","TimeZone":"UTC"},"End":{"DateTime":"2018-06-16T11:00:00","TimeZone":"UTC"}}]}`))
+		case "/events/master":
+			masterRequests++
+			w.Write([]byte(`{"Id":"master","Type":"SeriesMaster","Start":{"DateTime":"2018-06-15T10:00:00","TimeZone":"UTC"},"End":{"DateTime":"2018-06-15T11:00:00","TimeZone":"UTC"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	defer teardown()
+	calendar := api.RetrieveOutlookCalendar("calendar", "", &api.OutlookAccount{TokenType: "Bearer", AccessToken: "token", AnchorMailbox: "travis@example.com"})
+
+	// no window lists the events of the calendar
+	events, err := calendar.GetAllEvents()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(events) != 0 {
+		t.Fatalf("something went wrong. Expected 0 events found %d", len(events))
+	}
+
+	// the window uses the calendar view, giving each series only once
+	calendar.SetSyncWindow(api.SyncWindow{DaysBefore: 30, DaysAfter: 365})
+	events, err = calendar.GetAllEvents()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(events) != 2 || events[0].GetID() != "single" || events[1].GetID() != "master" {
+		t.Fatalf("something went wrong. Expected single and master events found %d events", len(events))
+	}
+	if masterRequests != 1 {
+		t.Fatalf("something went wrong. Expected 1 request of the series master found %d", masterRequests)
+	}
+}
+
+func TestOutlookCalendar_GetChangedEvents(t *testing.T) {
+	var requests []string
+	_, teardown := setupStandIn(map[string]string{"outlook/calendars/id/calendarview/delta": "/calendars/%s/calendarview/delta"}, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		requests = append(requests, query.Encode())
+		if r.Header.Get("Prefer") == "" || r.Header.Get("X-AnchorMailbox") != "travis@example.com" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		link := fmt.Sprintf("http://%s%s", r.Host, r.URL.Path)
+		switch {
+		case query.Get("$deltatoken") == "expired":
+			w.WriteHeader(http.StatusGone)
+			w.Write([]byte(`{"error":{"code":"SyncStateNotFound","message":"The sync state is not valid."}}`))
+		case query.Get("$deltatoken") == "first":
+			fmt.Fprintf(w, `{"@odata.deltaLink":"%s?$deltatoken=second","value":[{"id":"1","reason":"deleted"},{"Id":"3","IsAllDay":true,"Start":{"DateTime":"2018-06-14T00:00:00","TimeZone":"UTC"},"End":{"DateTime":"2018-06-15T00:00:00","TimeZone":"UTC"}}]}`, link)
+		case query.Get("$skiptoken") == "page2":
+			fmt.Fprintf(w, `{"@odata.deltaLink":"%s?$deltatoken=first","value":[{"Id":"2","Start":{"DateTime":"2018-06-14T10:00:00","TimeZone":"UTC"},"End":{"DateTime":"2018-06-14T11:00:00","TimeZone":"UTC"}}]}`, link)
+		case len(query.Get("startDateTime")) != 0 && len(query.Get("endDateTime")) != 0:
+			fmt.Fprintf(w, `{"@odata.nextLink":"%s?$skiptoken=page2","value":[{"Id":"1","Start":{"DateTime":"2018-06-14T10:00:00","TimeZone":"UTC"},"End":{"DateTime":"2018-06-14T11:00:00","TimeZone":"UTC"}}]}`, link)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	defer teardown()
+	calendar := api.RetrieveOutlookCalendar("calendar", "", &api.OutlookAccount{TokenType: "Bearer", AccessToken: "token", AnchorMailbox: "travis@example.com"})
+
+	// good call without token retrieves all pages
+	events, token, err := calendar.GetChangedEvents("")
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(events) != 2 || len(token) == 0 {
+		t.Fatalf("something went wrong. Expected 2 events and a delta link found %d events and delta link %s", len(events), token)
+	}
+
+	// good call with token retrieves only changes, also the removed ones
+	events, token, err = calendar.GetChangedEvents(token)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(events) != 2 || len(token) == 0 {
+		t.Fatalf("something went wrong. Expected 2 events and a delta link found %d events and delta link %s", len(events), token)
+	}
+	if events[0].GetState() != api.Deleted || events[0].GetID() != "1" || events[1].GetState() == api.Deleted {
+		t.Fatal("something went wrong. Expected only removed event to be deleted")
+	}
+
+	// wrong call with a rejected token
+	_, _, err = calendar.GetChangedEvents(token[:len(token)-len("second")] + "expired")
+	if _, ok := err.(*api.SyncTokenExpiredError); !ok {
+		t.Fatalf("something went wrong. Expected SyncTokenExpiredError found %v", err)
+	}
+	if len(requests) != 4 {
+		t.Fatalf("something went wrong. Expected 4 requests found %d", len(requests))
+	}
+
+	// the first call is bounded by the sync window
+	window := api.SyncWindow{DaysBefore: 30, DaysAfter: 10}
+	calendar.SetSyncWindow(window)
+	_, _, err = calendar.GetChangedEvents("")
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	query, _ := url.ParseQuery(requests[4])
+	now := time.Now()
+	if query.Get("startDateTime") != window.Start(now).Format(time.RFC3339) || query.Get("endDateTime") != window.End(now).Format(time.RFC3339) {
+		t.Fatalf("something went wrong. Expected bounds of the sync window found %s", requests[4])
+	}
+	if !calendar.HasBoundedChanges() {
+		t.Fatal("something went wrong. Expected bounded changes")
+	}
+}
+
+func TestOutlookCalendar_GetInstance(t *testing.T) {
+	var pages int
+	_, teardown := setupStandIn(map[string]string{"outlook/events/id/instances": "/events/%s/instances"}, func(w http.ResponseWriter, r *http.Request) {
+		link := fmt.Sprintf("http://%s%s", r.Host, r.URL.Path)
+		pages++
+		if r.URL.Query().Get("page") == "2" {
+			w.Write([]byte(`{"value":[{"Id":"exception","Type":"Exception","SeriesMasterId":"master","OriginalStart":"2018-06-21T10:00:00Z","Start":{"DateTime":"2018-06-21T12:00:00","TimeZone":"UTC"},"End":{"DateTime":"2018-06-21T13:00:00","TimeZone":"UTC"}}]}`))
+			return
+		}
+		if len(r.URL.Query().Get("startDateTime")) == 0 || len(r.URL.Query().Get("endDateTime")) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(fmt.Sprintf(`{"value":[{"Id":"occurrence","Type":"Occurrence","SeriesMasterId":"master","OriginalStart":"2018-06-14T10:00:00Z","Start":{"DateTime":"2018-06-14T10:00:00","TimeZone":"UTC"},"End":{"DateTime":"2018-06-14T11:00:00","TimeZone":"UTC"}}],"@odata.nextLink":"%s?page=2"}`, link)))
+	})
+	defer teardown()
+	calendar := api.RetrieveOutlookCalendar("calendar", "", &api.OutlookAccount{TokenType: "Bearer", AccessToken: "token", AnchorMailbox: "travis@example.com"})
+
+	// instances are matched by their original start, following the pages
+	originalStart := time.Date(2018, 6, 21, 10, 0, 0, 0, time.UTC)
+	event, err := calendar.GetInstance("master", originalStart)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	instance := event.(api.InstanceEventManager)
+	if instance.GetID() != "exception" || instance.GetSeriesID() != "master" || !instance.GetOriginalStart().Equal(originalStart) {
+		t.Fatalf("something went wrong. Expected exception of master found %s of %s", instance.GetID(), instance.GetSeriesID())
+	}
+	if pages != 2 {
+		t.Fatalf("something went wrong. Expected 2 pages found %d", pages)
+	}
+
+	_, err = calendar.GetInstance("master", time.Date(2018, 6, 28, 10, 0, 0, 0, time.UTC))
+	if _, ok := err.(*customErrors.NotFoundError); !ok {
+		t.Fatalf("something went wrong. Expected NotFoundError found %v", err)
+	}
+}
+
+func TestOutlookCalendar_Exceptions(t *testing.T) {
+	_, teardown := setupStandIn(map[string]string{
+		"outlook/calendars/id/calendarview": "/calendars/%s/calendarview",
+		"outlook/events/id":                 "/events/%s",
+	}, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/calendars/calendar/calendarview":
+			w.Write([]byte(`{"value":[{"Id":"occurrence","Type":"Occurrence","SeriesMasterId":"master","OriginalStart":"2018-06-14T10:00:00Z","Start":{"DateTime":"2018-06-14T10:00:00","TimeZone":"UTC"},"End":{"DateTime":"2018-06-14T11:00:00","TimeZone":"UTC"}},{"Id":"exception","Type":"Exception","SeriesMasterId":"master","OriginalStart":"2018-06-21T10:00:00Z","Start":{"DateTime":"2018-06-21T12:00:00","TimeZone":"UTC"},"End":{"DateTime":"2018-06-21T13:00:00","TimeZone":"UTC"}}]}`))
+		case "/events/master":
+			w.Write([]byte(`{"Id":"master","Type":"SeriesMaster","Start":{"DateTime":"2018-06-14T10:00:00","TimeZone":"UTC"},"End":{"DateTime":"2018-06-14T11:00:00","TimeZone":"UTC"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	defer teardown()
+	calendar := api.RetrieveOutlookCalendar("calendar", "", &api.OutlookAccount{TokenType: "Bearer", AccessToken: "token", AnchorMailbox: "travis@example.com"})
+	calendar.SetSyncWindow(api.SyncWindow{DaysBefore: 30, DaysAfter: 365})
+
+	// occurrences are given as their master, exceptions are kept after it
+	events, err := calendar.GetAllEvents()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(events) != 2 || events[0].GetID() != "master" || events[1].GetID() != "exception" {
+		t.Fatalf("something went wrong. Expected master and exception found %d events", len(events))
+	}
+	if events[0].(api.InstanceEventManager).GetSeriesID() != "" || events[1].(api.InstanceEventManager).GetSeriesID() != "master" {
+		t.Fatal("something went wrong. Expected only the exception to be an instance")
+	}
+}
+
 func TestOutlookCalendar_GetEvent(t *testing.T) {
-	setupApiRoot()
+	setupRoutes()
 	account, _ := setup()
 	//Refresh previous petition in order to have tokens updated
 	account.Refresh()
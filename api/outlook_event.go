@@ -2,11 +2,13 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 
 	"net/http"
+	"strings"
 
 	"time"
 
@@ -17,17 +19,26 @@ import (
 	"github.com/TetAlius/GoSyncMyCalendars/util"
 )
 
-// Method that creates the event
+// Method that creates the event.
+// Attendees are only on the event, and so sent meeting requests, if the options of the relation say so
 //
 // POST https://outlook.office.com/api/v2.0/me/calendars/{calendarID}/events
 func (event *OutlookEvent) Create() (err error) {
+	return event.CreateContext(context.Background())
+}
+
+// Method like Create that is stopped once the given context is done
+func (event *OutlookEvent) CreateContext(ctx context.Context) (err error) {
 	a := event.GetCalendar().GetAccount()
 	log.Debugln("createEvent outlook")
-	route, err := util.CallAPIRoot("outlook/calendars/id/events")
+	route, err := util.GetRoute("outlook/calendars/id/events")
 	if err != nil {
 		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
 	}
 
+	if event.Recurrence != nil && event.Start != nil {
+		event.Recurrence.complete(event.Start.DateTime.In(timeZoneOrUTC(event.Start.TimeZone)))
+	}
 	data, err := json.Marshal(event)
 	if err != nil {
 		return errors.New(fmt.Sprintf("error marshalling event data: %s", err.Error()))
@@ -38,13 +49,13 @@ func (event *OutlookEvent) Create() (err error) {
 	headers["Authorization"] = a.AuthorizationRequest()
 	headers["X-AnchorMailbox"] = a.Mail()
 
-	contents, err := util.DoRequest(http.MethodPost,
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodPost,
 		fmt.Sprintf(route, event.GetCalendar().GetID()),
 		bytes.NewBuffer(data),
 		headers, nil)
 
 	if err != nil {
-		return errors.New(fmt.Sprintf("error creating event in a calendar for email %s. %s", a.Mail(), err.Error()))
+		return util.RequestError(err, fmt.Sprintf("error creating event in a calendar for email %s", a.Mail()))
 	}
 	err = createOutlookResponseError(contents)
 	if err != nil {
@@ -64,14 +75,22 @@ func (event *OutlookEvent) Create() (err error) {
 //
 // PATCH https://outlook.office.com/api/v2.0/me/events/{eventID}
 func (event *OutlookEvent) Update() (err error) {
+	return event.UpdateContext(context.Background())
+}
+
+// Method like Update that is stopped once the given context is done
+func (event *OutlookEvent) UpdateContext(ctx context.Context) (err error) {
 	a := event.GetCalendar().GetAccount()
 	log.Debugln("updateEvent outlook")
 
-	route, err := util.CallAPIRoot("outlook/events/id")
+	route, err := util.GetRoute("outlook/events/id")
 	if err != nil {
 		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
 	}
 	log.Debugln(route)
+	if event.Recurrence != nil && event.Start != nil {
+		event.Recurrence.complete(event.Start.DateTime.In(timeZoneOrUTC(event.Start.TimeZone)))
+	}
 	data, err := json.Marshal(event)
 	if err != nil {
 		return errors.New(fmt.Sprintf("error marshalling event data: %s", err.Error()))
@@ -82,13 +101,13 @@ func (event *OutlookEvent) Update() (err error) {
 	headers["Authorization"] = a.AuthorizationRequest()
 	headers["X-AnchorMailbox"] = a.Mail()
 
-	contents, err := util.DoRequest(http.MethodPatch,
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodPatch,
 		fmt.Sprintf(route, event.ID),
 		bytes.NewBuffer(data),
 		headers, nil)
 
 	if err != nil {
-		return errors.New(fmt.Sprintf("error updating event of a calendar for email %s. %s", a.Mail(), err.Error()))
+		return util.RequestError(err, fmt.Sprintf("error updating event of a calendar for email %s", a.Mail()))
 	}
 
 	err = createOutlookResponseError(contents)
@@ -109,10 +128,15 @@ func (event *OutlookEvent) Update() (err error) {
 //
 // DELETE https://outlook.office.com/api/v2.0/me/events/{eventID}
 func (event *OutlookEvent) Delete() (err error) {
+	return event.DeleteContext(context.Background())
+}
+
+// Method like Delete that is stopped once the given context is done
+func (event *OutlookEvent) DeleteContext(ctx context.Context) (err error) {
 	a := event.GetCalendar().GetAccount()
 	log.Debugln("deleteEvent outlook")
 
-	route, err := util.CallAPIRoot("outlook/events/id")
+	route, err := util.GetRoute("outlook/events/id")
 
 	if err != nil {
 		log.Errorf("error generating URL: %s", err.Error())
@@ -124,14 +148,14 @@ func (event *OutlookEvent) Delete() (err error) {
 	headers["Authorization"] = a.AuthorizationRequest()
 	headers["X-AnchorMailbox"] = a.Mail()
 
-	contents, err := util.DoRequest(http.MethodDelete,
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodDelete,
 		fmt.Sprintf(route, event.ID),
 		nil,
 		headers, nil)
 
 	if err != nil {
 		log.Errorf("error deleting event of a calendar for email %s. %s", a.Mail(), err.Error())
-		return errors.New(fmt.Sprintf("error deleting event of a calendar for email %s. %s", a.Mail(), err.Error()))
+		return util.RequestError(err, fmt.Sprintf("error deleting event of a calendar for email %s", a.Mail()))
 	}
 
 	if len(contents) != 0 {
@@ -142,6 +166,56 @@ func (event *OutlookEvent) Delete() (err error) {
 	return
 }
 
+// Method that returns the request that writes the event inside a batch as the operation says
+func (event *OutlookEvent) batchRequest(operation int) (request util.BatchRequest, err error) {
+	name := "outlook/events/id"
+	switch operation {
+	case Created:
+		name = "outlook/calendars/id/events"
+		request = util.BatchRequest{Method: http.MethodPost}
+	case Updated:
+		request = util.BatchRequest{Method: http.MethodPatch}
+	case Deleted:
+		request = util.BatchRequest{Method: http.MethodDelete}
+	default:
+		return request, errors.New(fmt.Sprintf("operation %d not supported for event %s", operation, event.ID))
+	}
+	route, err := util.GetRoute(name)
+	if err != nil {
+		return request, errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+	if operation == Created {
+		request.URL = fmt.Sprintf(route, event.GetCalendar().GetID())
+	} else {
+		request.URL = fmt.Sprintf(route, event.ID)
+	}
+	if operation == Deleted {
+		return
+	}
+	if event.Recurrence != nil && event.Start != nil {
+		event.Recurrence.complete(event.Start.DateTime.In(timeZoneOrUTC(event.Start.TimeZone)))
+	}
+	request.Body, err = json.Marshal(event)
+	if err != nil {
+		return request, errors.New(fmt.Sprintf("error marshalling event data: %s", err.Error()))
+	}
+	return
+}
+
+// Method that reads the event back from the response of its request inside a batch
+func (event *OutlookEvent) readBatchResponse(operation int, contents []byte) (err error) {
+	if operation == Deleted {
+		return
+	}
+	eventResponse := OutlookEventResponse{OdataContext: "", OutlookEvent: event}
+	err = json.Unmarshal(contents, &eventResponse)
+	if err != nil {
+		return err
+	}
+	event.setAllDay()
+	return
+}
+
 // Method that returns the ID of the event
 func (event *OutlookEvent) GetID() string {
 	return event.ID
@@ -203,6 +277,31 @@ func (event *OutlookEvent) GetState() int {
 	return event.state
 }
 
+// Method that returns the ID of the series master. It is empty if the event is not an instance
+func (event *OutlookEvent) GetSeriesID() string {
+	if event.Type != "Occurrence" && event.Type != "Exception" {
+		return ""
+	}
+	return event.SeriesMasterID
+}
+
+// Method that returns the start that the instance had inside its series
+func (event *OutlookEvent) GetOriginalStart() time.Time {
+	if event.OriginalStart == nil {
+		return time.Time{}
+	}
+	return event.OriginalStart.UTC()
+}
+
+// Method that returns the start and end dates of the event.
+// The end is zero for recurring events
+func (event *OutlookEvent) GetTimeRange() (start time.Time, end time.Time, err error) {
+	if event.Start == nil || event.End == nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("event %s has no dates", event.GetID())
+	}
+	return timeRange(event.Start.DateTime, event.End.DateTime, event.Recurrence != nil)
+}
+
 // Method that returns the last update date
 func (event *OutlookEvent) GetUpdatedAt() (t time.Time, err error) {
 	//format := time.RFC3339Nano
@@ -228,12 +327,12 @@ func (date *OutlookDateTimeTimeZone) UnmarshalJSON(b []byte) error {
 	}
 	tag, _ := parseTag(field.Tag.Get("json"))
 
-	location, err := time.LoadLocation(s[tag])
-	date.TimeZone = location
+	location, err := loadTimeZone(s[tag])
 	if err != nil {
-		log.Errorf("error getting location: %s")
+		log.Errorf("error getting location: %s", err.Error())
 		return err
 	}
+	date.TimeZone = location
 
 	field, ok = reflect.TypeOf(date).Elem().FieldByName("DateTime")
 	if !ok {
@@ -249,6 +348,8 @@ func (date *OutlookDateTimeTimeZone) UnmarshalJSON(b []byte) error {
 }
 
 // Method that converts from OutlookDateTimeTimeZone struct to a json.
+// Dates are written on the windows name of their time zone, so recurring events keep their time across DST.
+// All day dates are kept at midnight of their time zone.
 // This method implements Marshaler interface
 func (date *OutlookDateTimeTimeZone) MarshalJSON() (b []byte, err error) {
 	if date.DateTime.IsZero() {
@@ -261,7 +362,17 @@ func (date *OutlookDateTimeTimeZone) MarshalJSON() (b []byte, err error) {
 	tag, _ := parseTag(field.Tag.Get("json"))
 	buffer := bytes.NewBufferString("{")
 	//RFC3339Nano = "2006-01-02T15:04:05.999999999Z07:00"
-	_, err = buffer.WriteString(fmt.Sprintf(`"%s":"%s"`, tag, date.DateTime.UTC().Format("2006-01-02T15:04:05.999999999")))
+	timeZone := timeZoneOrUTC(date.TimeZone)
+	windows, err := windowsTimeZone(timeZone)
+	if err != nil {
+		return nil, err
+	}
+	dateTime := date.DateTime.In(timeZone)
+	if date.IsAllDay {
+		// midnight of the day on the time zone of the date
+		dateTime = floatingDate(date.DateTime, timeZone)
+	}
+	_, err = buffer.WriteString(fmt.Sprintf(`"%s":"%s"`, tag, dateTime.Format("2006-01-02T15:04:05.999999999")))
 	if err != nil {
 		return nil, err
 	}
@@ -276,7 +387,7 @@ func (date *OutlookDateTimeTimeZone) MarshalJSON() (b []byte, err error) {
 		return nil, fmt.Errorf("could not retrieve field TimeZone")
 	}
 	tag, _ = parseTag(field.Tag.Get("json"))
-	_, err = buffer.WriteString(fmt.Sprintf(`"%s":"%s"`, tag, date.TimeZone))
+	_, err = buffer.WriteString(fmt.Sprintf(`"%s":"%s"`, tag, windows))
 	if err != nil {
 		return nil, err
 	}
@@ -288,6 +399,7 @@ func (date *OutlookDateTimeTimeZone) MarshalJSON() (b []byte, err error) {
 }
 
 // Method that converts a OutlookDateTimeTimeZone struct to a interface{}.
+// All day dates are given as floating dates.
 // This method implements Deconverter interface
 func (date *OutlookDateTimeTimeZone) Deconvert() interface{} {
 	m := make(map[string]interface{})
@@ -297,6 +409,9 @@ func (date *OutlookDateTimeTimeZone) Deconvert() interface{} {
 	}
 	tag, _ := parseTag(field.Tag.Get("convert"))
 	m[tag] = date.DateTime.UTC()
+	if date.IsAllDay {
+		m[tag] = floatingDate(date.DateTime, date.TimeZone)
+	}
 
 	field, ok = reflect.TypeOf(date).Elem().FieldByName("IsAllDay")
 	if !ok {
@@ -310,7 +425,7 @@ func (date *OutlookDateTimeTimeZone) Deconvert() interface{} {
 		return nil
 	}
 	tag, _ = parseTag(field.Tag.Get("convert"))
-	m[tag] = date.TimeZone
+	m[tag] = timeZoneOrUTC(date.TimeZone)
 	return m
 }
 
@@ -347,12 +462,405 @@ func (*OutlookDateTimeTimeZone) Convert(m interface{}, tag string, opts string)
 	if !ok {
 		return nil, errors.New("incorrect type of field timeZone")
 	}
+	// outlook only writes the time zones that have a windows name
+	if _, err := windowsTimeZone(timeZone); err != nil {
+		return nil, err
+	}
 
 	return &OutlookDateTimeTimeZone{DateTime: dateTime, TimeZone: timeZone, IsAllDay: isAllDay}, nil
 }
 
+// Responses of Outlook and the ones they are converted to
+var outlookResponses = map[string]string{
+	"None":                ResponseNeedsAction,
+	"NotResponded":        ResponseNeedsAction,
+	"Organizer":           ResponseAccepted,
+	"Accepted":            ResponseAccepted,
+	"TentativelyAccepted": ResponseTentative,
+	"Declined":            ResponseDeclined,
+}
+
+// Responses given by the attendees and the ones they are written as on Outlook
+var outlookResponseTypes = map[string]string{
+	ResponseNeedsAction: "NotResponded",
+	ResponseAccepted:    "Accepted",
+	ResponseTentative:   "TentativelyAccepted",
+	ResponseDeclined:    "Declined",
+}
+
+// Method that converts a OutlookAttendees to a interface{}.
+// This method implements Deconverter interface
+func (outlookAttendees OutlookAttendees) Deconvert() interface{} {
+	var attendees []Attendee
+	for _, outlookAttendee := range outlookAttendees {
+		if outlookAttendee.EmailAddress == nil {
+			continue
+		}
+		attendee := Attendee{
+			Email:    outlookAttendee.EmailAddress.Address,
+			Name:     outlookAttendee.EmailAddress.Name,
+			Type:     strings.ToLower(outlookAttendee.Type),
+			Response: ResponseNeedsAction,
+		}
+		if attendee.Type != AttendeeOptional && attendee.Type != AttendeeResource {
+			attendee.Type = AttendeeRequired
+		}
+		if outlookAttendee.Status != nil {
+			if response, ok := outlookResponses[outlookAttendee.Status.Response]; ok {
+				attendee.Response = response
+			}
+			attendee.Organizer = outlookAttendee.Status.Response == "Organizer"
+		}
+		attendees = append(attendees, attendee)
+	}
+	return attendees
+}
+
+// Method that converts an interface{} to a OutlookAttendees.
+// The response status can not be written on Outlook, so it is kept only for reading.
+// This method implements Converter interface
+func (OutlookAttendees) Convert(m interface{}, tag string, opts string) (conv.Converter, error) {
+	attendees, err := attendeesFrom(m)
+	if err != nil {
+		return nil, err
+	}
+	var outlookAttendees OutlookAttendees
+	for _, attendee := range attendees {
+		response, ok := outlookResponseTypes[attendee.Response]
+		if !ok {
+			response = "None"
+		}
+		if attendee.Organizer {
+			response = "Organizer"
+		}
+		outlookAttendees = append(outlookAttendees, OutlookAttendee{
+			EmailAddress: &OutlookEmailAddress{Address: attendee.Email, Name: attendee.Name},
+			Status:       &OutlookStatus{Response: response},
+			Type:         strings.Title(attendee.Type),
+		})
+	}
+	return outlookAttendees, nil
+}
+
+// Method that sets whether the next writes of the event notify its attendees
+func (event *OutlookEvent) notifyAttendees(notify bool) {
+	event.notify = notify
+}
+
+// Method that writes the attendees already converted as the mode says.
+// Outlook sends meeting requests to every attendee of the events written, so
+// attendees are listed on the body instead of copied unless they have to be notified
+func (event *OutlookEvent) writeAttendees(mode AttendeesMode) {
+	if mode == AttendeesCopied && !event.notify {
+		mode = AttendeesInBody
+	}
+	if event.Body == nil {
+		event.Body = &OutlookItemBody{}
+	}
+	event.Body.Description = describeAttendees(event.Body.Description, event.Attendees.Deconvert().([]Attendee), mode)
+	if mode == AttendeesInBody {
+		event.Attendees = nil
+	}
+}
+
+// Free/busy statuses of outlook and the ones of the other calendars
+var outlookFreeBusy = map[OutlookFreeBusyStatus]string{
+	"Free":             FreeBusyFree,
+	"Busy":             FreeBusyBusy,
+	"Tentative":        FreeBusyTentative,
+	"Oof":              FreeBusyOutOfOffice,
+	"WorkingElsewhere": FreeBusyWorkingElsewhere,
+}
+
+// Method that returns the free/busy status of the event
+func (event *OutlookEvent) readFreeBusy() (string, bool) {
+	status, ok := outlookFreeBusy[event.ShowAs]
+	return status, ok
+}
+
+// Method that writes the free/busy status. Outlook has every status, so no fallback is needed
+func (event *OutlookEvent) writeFreeBusy(status string, fallbacks FreeBusyFallbacks) {
+	for showAs, s := range outlookFreeBusy {
+		if s == status {
+			event.ShowAs = showAs
+			return
+		}
+	}
+}
+
+// Method that returns the link to join the online meeting of the event.
+// Events without an online meeting of their own give the links listed on their body
+func (event *OutlookEvent) readMeetingLinks() []MeetingLink {
+	if len(event.OnlineMeetingUrl) != 0 {
+		return []MeetingLink{{Kind: MeetingVideo, URI: event.OnlineMeetingUrl}}
+	}
+	if event.Body == nil {
+		return nil
+	}
+	return meetingLinksFromDescription(event.Body.Description)
+}
+
+// Method that writes the links to join the online meeting on the body,
+// as the online meeting URL can not be written
+func (event *OutlookEvent) writeMeetingLinks(links []MeetingLink) {
+	if event.Body == nil {
+		event.Body = &OutlookItemBody{}
+	}
+	event.Body.Description = describeMeetingLinks(event.Body.Description, links)
+}
+
+// Method that returns the attachments of the event and the ones listed on its body.
+// Files attached are given as the link to the event, where they can be downloaded from
+func (event *OutlookEvent) readAttachments(ctx context.Context) (attachments []Attachment) {
+	if event.HasAttachments && event.Attachments == nil && event.calendar != nil {
+		err := event.getAttachments(ctx)
+		if err != nil {
+			log.Warningf("attachments of event %s not synced: %s", event.ID, err.Error())
+		}
+	}
+	for _, attachment := range event.Attachments {
+		if attachment.IsInline {
+			continue
+		}
+		// outlook gives no link to download the files without the token of the account,
+		// so they are only listed by their name. Shared files are given with their link
+		var link string
+		if attachment.OdataType == outlookReferenceAttachment {
+			link = attachment.SourceURL
+		}
+		attachments = append(attachments, Attachment{Name: attachment.Name, URL: link, ContentType: attachment.ContentType})
+	}
+	if event.Body != nil {
+		attachments = append(attachments, attachmentsFromDescription(event.Body.Description)...)
+	}
+	return
+}
+
+// Method that lists the attachments on the body, as outlook events are not written with attachments.
+// Files of the event itself, listed by their name, and links to the event are not written
+func (event *OutlookEvent) writeAttachments(ctx context.Context, attachments []Attachment) {
+	files := event.fileNames(ctx, attachments)
+	var listed []Attachment
+	for _, attachment := range attachments {
+		switch {
+		case len(attachment.URL) == 0 && files[attachment.Name]:
+		case len(attachment.URL) != 0 && attachment.URL == event.Link:
+		default:
+			listed = append(listed, attachment)
+		}
+	}
+	if event.Body == nil {
+		event.Body = &OutlookItemBody{}
+	}
+	event.Body.Description = describeAttachments(event.Body.Description, listed)
+}
+
+// Method that returns the names of the files attached to the event, retrieving them only if some
+// of the attachments given are listed by their name, as they may be the files of the event itself
+func (event *OutlookEvent) fileNames(ctx context.Context, attachments []Attachment) (names map[string]bool) {
+	names = make(map[string]bool)
+	for _, attachment := range attachments {
+		if len(attachment.URL) != 0 {
+			continue
+		}
+		if event.Attachments == nil && len(event.ID) != 0 && event.calendar != nil {
+			err := event.getAttachments(ctx)
+			if err != nil {
+				log.Warningf("attachments of event %s not retrieved: %s", event.ID, err.Error())
+			}
+		}
+		break
+	}
+	for _, attachment := range event.Attachments {
+		if !attachment.IsInline && attachment.OdataType != outlookReferenceAttachment {
+			names[attachment.Name] = true
+		}
+	}
+	return
+}
+
+// Method that retrieves the attachments of the event
+//
+// GET https://outlook.office.com/api/v2.0/me/events/{eventID}/attachments?$select=Name,ContentType,Size,IsInline,SourceUrl
+func (event *OutlookEvent) getAttachments(ctx context.Context) (err error) {
+	a := event.GetCalendar().GetAccount()
+	route, err := util.GetRoute("outlook/events/id/attachments")
+	if err != nil {
+		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
+	}
+
+	headers := make(map[string]string)
+	headers["Authorization"] = a.AuthorizationRequest()
+	headers["X-AnchorMailbox"] = a.Mail()
+
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodGet,
+		fmt.Sprintf(route, event.ID),
+		nil,
+		headers, map[string]string{"$select": outlookAttachmentsSelect})
+	if err != nil {
+		return util.RequestError(err, fmt.Sprintf("error getting attachments of an event for email %s", a.Mail()))
+	}
+	err = createOutlookResponseError(contents)
+	if err != nil {
+		return
+	}
+
+	attachmentsResponse := new(OutlookAttachmentListResponse)
+	err = json.Unmarshal(contents, &attachmentsResponse)
+	if err != nil {
+		return errors.New(fmt.Sprintf("error unmarshalling attachments: %s", err.Error()))
+	}
+	event.Attachments = attachmentsResponse.Attachments
+	return
+}
+
+// Method that returns the categories of the event
+func (event *OutlookEvent) readCategories(colors CategoryColors) ([]string, bool) {
+	return event.Categories, len(event.Categories) != 0
+}
+
+// Method that writes the categories of the event
+func (event *OutlookEvent) writeCategories(categories []string, colors CategoryColors) {
+	event.Categories = categories
+}
+
+// Method that converts a OutlookSensitivity to a interface{}.
+// Personal events are kept private on the other calendars.
+// This method implements Deconverter interface
+func (sensitivity OutlookSensitivity) Deconvert() interface{} {
+	switch sensitivity {
+	case "Personal", "Private":
+		return VisibilityPrivate
+	case "Confidential":
+		return VisibilityConfidential
+	default:
+		return VisibilityDefault
+	}
+}
+
+// Method that converts an interface{} to a OutlookSensitivity.
+// This method implements Converter interface
+func (OutlookSensitivity) Convert(m interface{}, tag string, opts string) (conv.Converter, error) {
+	switch visibilityFrom(m) {
+	case VisibilityPrivate:
+		return OutlookSensitivity("Private"), nil
+	case VisibilityConfidential:
+		return OutlookSensitivity("Confidential"), nil
+	case VisibilityDefault, VisibilityPublic:
+		return OutlookSensitivity("Normal"), nil
+	default:
+		return OutlookSensitivity(""), nil
+	}
+}
+
+// Method that returns the minutes before the start of the reminder of the event
+func (event *OutlookEvent) readReminders(ctx context.Context) ([]int, bool) {
+	if event.IsReminderOn == nil {
+		return nil, false
+	}
+	if !*event.IsReminderOn || event.ReminderMinutesBeforeStart == nil {
+		return []int{}, true
+	}
+	return []int{int(*event.ReminderMinutesBeforeStart)}, true
+}
+
+// Method that writes the reminders given as minutes before the start.
+// Outlook only has one reminder, so several ones are collapsed into the earliest one
+func (event *OutlookEvent) writeReminders(minutes []int) {
+	on := len(minutes) != 0
+	event.IsReminderOn = &on
+	event.ReminderMinutesBeforeStart = nil
+	if on {
+		earliest := int32(earliestReminder(minutes))
+		event.ReminderMinutesBeforeStart = &earliest
+	}
+}
+
+// Method that converts a OutlookLocation struct to a interface{}.
+// This method implements Deconverter interface
+func (location *OutlookLocation) Deconvert() interface{} {
+	coordinates := location.Coordinates
+	return Location{
+		DisplayName:    location.DisplayName,
+		Street:         location.Address.Street,
+		City:           location.Address.City,
+		State:          location.Address.State,
+		PostalCode:     location.Address.PostalCode,
+		Country:        location.Address.CountryOrRegion,
+		HasCoordinates: coordinates.Latitude != 0 || coordinates.Longitude != 0,
+		Latitude:       coordinates.Latitude,
+		Longitude:      coordinates.Longitude,
+	}
+}
+
+// Method that converts an interface{} to a OutlookLocation struct.
+// This method implements Converter interface
+func (*OutlookLocation) Convert(m interface{}, tag string, opts string) (conv.Converter, error) {
+	location, err := locationFrom(m)
+	if err != nil || location == nil {
+		return (*OutlookLocation)(nil), err
+	}
+	outlookLocation := &OutlookLocation{
+		DisplayName: location.DisplayName,
+		Address: OutlookPhysicalAddress{
+			Street:          location.Street,
+			City:            location.City,
+			State:           location.State,
+			PostalCode:      location.PostalCode,
+			CountryOrRegion: location.Country,
+		},
+	}
+	if location.HasCoordinates {
+		outlookLocation.Coordinates = OutlookGeoCoordinates{Latitude: location.Latitude, Longitude: location.Longitude}
+	}
+	return outlookLocation, nil
+}
+
+// Method that converts a OutlookPatternedRecurrence struct to a interface{}.
+// A recurrence that can not be expressed as RRULE lines is given as its error.
+// This method implements Deconverter interface
+func (recurrence *OutlookPatternedRecurrence) Deconvert() interface{} {
+	lines, err := recurrence.rules()
+	if err != nil {
+		return err
+	}
+	return lines
+}
+
+// Method that converts an interface{} to a OutlookPatternedRecurrence struct.
+// This method implements Converter interface
+func (*OutlookPatternedRecurrence) Convert(m interface{}, tag string, opts string) (conv.Converter, error) {
+	lines, err := recurrenceLines(m)
+	if err != nil {
+		return nil, err
+	}
+	return newOutlookRecurrence(lines)
+}
+
 // Method that sets all day to the necessary attributes
 func (event *OutlookEvent) setAllDay() {
 	event.Start.IsAllDay = event.IsAllDay
 	event.End.IsAllDay = event.IsAllDay
+	if event.Recurrence != nil {
+		event.Recurrence.allDay = event.IsAllDay
+	}
+	event.setOriginalTimeZones()
+}
+
+// Method that sets to the dates the time zones the event was created with,
+// as the dates are always asked in UTC. Dates with time zones that are not known,
+// like the custom ones, are kept in UTC and the error is logged
+func (event *OutlookEvent) setOriginalTimeZones() {
+	dates := []*OutlookDateTimeTimeZone{event.Start, event.End}
+	for i, name := range []string{event.OriginalStartTimeZone, event.OriginalEndTimeZone} {
+		if dates[i] == nil || len(name) == 0 {
+			continue
+		}
+		location, err := loadTimeZone(name)
+		if err != nil {
+			log.Errorf("time zone of event %s not kept: %s", event.ID, err.Error())
+			continue
+		}
+		dates[i].TimeZone = location
+	}
 }
@@ -1,13 +1,16 @@
 package api_test
 
 import (
+	"net/http"
 	"testing"
 
 	"encoding/json"
 	"time"
 
 	"github.com/TetAlius/GoSyncMyCalendars/api"
+	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
 	"github.com/TetAlius/GoSyncMyCalendars/logger"
+	"github.com/TetAlius/GoSyncMyCalendars/util"
 )
 
 var contOutlook = []byte(` {
@@ -119,7 +122,7 @@ func TestOutlookTime_JSON(t *testing.T) {
 }
 
 func TestOutlookEventCalendar_EventLifeCycle(t *testing.T) {
-	setupApiRoot()
+	setupRoutes()
 	account, _ := setup()
 	//Refresh previous petition in order to have tokens updated
 	account.Refresh()
@@ -169,3 +172,52 @@ func TestOutlookEventCalendar_EventLifeCycle(t *testing.T) {
 	}
 
 }
+
+func TestOutlookEvent_ResponseErrors(t *testing.T) {
+	var status, requests int
+	_, teardown := setupStandIn(map[string]string{"outlook/events/id": "/events/%s"}, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(status)
+		w.Write([]byte(`{"error":{"code":"ErrorCode","message":"Error message"}}`))
+	})
+	defer teardown()
+	event := &api.OutlookEvent{ID: "event"}
+	event.SetCalendar(api.RetrieveOutlookCalendar("calendar", "", &api.OutlookAccount{TokenType: "Bearer", AccessToken: "token", AnchorMailbox: "travis@example.com"}))
+
+	// errors that will not change are returned typed without retrying
+	for _, test := range []struct {
+		status int
+		check  func(error) bool
+	}{
+		{http.StatusUnauthorized, func(err error) bool { _, ok := err.(*customErrors.UnauthorizedError); return ok }},
+		{http.StatusNotFound, func(err error) bool { _, ok := err.(*customErrors.NotFoundError); return ok }},
+		{http.StatusConflict, func(err error) bool { _, ok := err.(*customErrors.ConflictError); return ok }},
+		{http.StatusPreconditionFailed, func(err error) bool { _, ok := err.(*customErrors.ConflictError); return ok }},
+		{http.StatusBadRequest, func(err error) bool { _, ok := err.(*customErrors.StatusError); return ok }},
+	} {
+		status, requests = test.status, 0
+		if err := event.Update(); !test.check(err) || requests != 1 {
+			t.Fatalf("something went wrong. Expected typed error for status %d after 1 request found %v after %d", status, err, requests)
+		}
+	}
+
+	// unavailable providers are retried until the retries run out
+	status, requests = http.StatusServiceUnavailable, 0
+	err := event.Delete()
+	if e, ok := err.(*customErrors.ServerError); !ok || e.StatusCode() != status || requests != util.DefaultProviderClient.MaxRetries+1 {
+		t.Fatalf("something went wrong. Expected ServerError after %d requests found %v after %d", util.DefaultProviderClient.MaxRetries+1, err, requests)
+	}
+
+	// waits longer than the allowed ones are not done
+	status, requests = http.StatusTooManyRequests, 0
+	_, teardown = setupStandIn(map[string]string{"outlook/events/id": "/events/%s"}, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(status)
+	})
+	defer teardown()
+	err = event.Update()
+	if e, ok := err.(*customErrors.RateLimitError); !ok || e.RetryAfter != time.Hour || requests != 1 {
+		t.Fatalf("something went wrong. Expected RateLimitError asking for 1h after 1 request found %v after %d", err, requests)
+	}
+}
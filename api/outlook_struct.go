@@ -41,8 +41,9 @@ type OutlookCalendarResponse struct {
 }
 
 type OutlookCalendarListResponse struct {
-	OdataContext string             `json:"@odata.context"`
-	Calendars    []*OutlookCalendar `json:"value"`
+	OdataContext  string             `json:"@odata.context"`
+	OdataNextLink string             `json:"@odata.nextLink,omitempty"`
+	Calendars     []*OutlookCalendar `json:"value"`
 }
 
 // CalendarInfo TODO
@@ -50,6 +51,8 @@ type OutlookCalendar struct {
 	uuid      string
 	account   *OutlookAccount
 	calendars []CalendarManager
+	window    SyncWindow
+	options   SyncOptions
 	OdataID   string `json:"@odata.id,omitempty"`
 
 	CalendarView        []OutlookEvent       `json:"CalendarView,omitempty"`
@@ -86,8 +89,10 @@ type OutlookEventResponse struct {
 }
 
 type OutlookEventListResponse struct {
-	OdataContext string          `json:"@odata.context"`
-	Events       []*OutlookEvent `json:"value"`
+	OdataContext   string          `json:"@odata.context"`
+	OdataNextLink  string          `json:"@odata.nextLink,omitempty"`
+	OdataDeltaLink string          `json:"@odata.deltaLink,omitempty"`
+	Events         []*OutlookEvent `json:"value"`
 }
 
 type OutlookEvent struct {
@@ -96,8 +101,12 @@ type OutlookEvent struct {
 	state              int
 	exponentialBackoff int
 	internalID         int
+	// Whether the writes of the event notify its attendees
+	notify bool
 
 	ID string `json:"Id"`
+	// Only given on delta responses for the events removed
+	Reason string `json:"reason,omitempty"`
 
 	Subject     string           `json:"Subject,omitempty" convert:"Subject"`
 	Description string           `json:"BodyPreview,omitempty"`
@@ -111,52 +120,76 @@ type OutlookEvent struct {
 	OnlineMeetingUrl           string                   `json:"OnlineMeetingUrl,omitempty"`
 	OriginalStartTimeZone      string                   `json:"OriginalStartTimeZone,omitempty"`
 	OriginalEndTimeZone        string                   `json:"OriginalEndTimeZone,omitempty"`
-	ReminderMinutesBeforeStart int32                    `json:"ReminderMinutesBeforeStart,omitempty"`
+	ReminderMinutesBeforeStart *int32                   `json:"ReminderMinutesBeforeStart,omitempty"`
 	ResponseRequested          bool                     `json:"ResponseRequested,omitempty"`
 	SeriesMasterID             string                   `json:"SeriesMasterId,omitempty"`
-
-	Organizer   *OutlookRecipient   `json:"Organizer,omitempty"`
-	Attachments []OutlookAttachment `json:"Attachments,omitempty"`
-	//Don't update
-	//This will go to Body to have a list
-	Attendees []OutlookAttendee `json:"Attendees,omitempty"`
-	Instances []OutlookEvent    `json:"Instances,omitempty"`
+	// Pointers, so turning off a reminder or setting it at the start is written
+	IsReminderOn *bool `json:"IsReminderOn,omitempty"`
+	// Start that an occurrence or exception had inside its series
+	OriginalStart *time.Time `json:"OriginalStart,omitempty"`
+
+	Organizer *OutlookRecipient `json:"Organizer,omitempty"`
+	// Retrieved on their own, HasAttachments says whether there are some to retrieve.
+	// They are not written back with the event
+	Attachments    []OutlookAttachment `json:"-"`
+	HasAttachments bool                `json:"HasAttachments,omitempty"`
+	// Copied or listed on the body depending on the options of the relation
+	Attendees OutlookAttendees `json:"Attendees,omitempty" convert:"attendees"`
+	Instances []OutlookEvent   `json:"Instances,omitempty"`
 
 	Importance OutlookImportance `json:"Importance,omitempty"`
 
-	Recurrence     *OutlookPatternedRecurrence `json:"Recurrence,omitempty"`
+	Recurrence     *OutlookPatternedRecurrence `json:"Recurrence,omitempty" convert:"recurrence"`
 	ResponseStatus *OutlookResponseStatus      `json:"ResponseStatus,omitempty"`
-	Sensitivity    OutlookSensitivity          `json:"Sensitivity,omitempty"`
+	Sensitivity    OutlookSensitivity          `json:"Sensitivity,omitempty" convert:"visibility"`
 	ShowAs         OutlookFreeBusyStatus       `json:"ShowAs,omitempty"`
 
-	Type OutlookEventType `json:"Type,omitempty"`
+	Type     OutlookEventType `json:"Type,omitempty"`
+	Location *OutlookLocation `json:"Location,omitempty" convert:"location"`
 
 	//Not to sync
 	Link string `json:"WebLink,omitempty"`
 
 	//Not to sync and use
-	Location             *OutlookLocation `json:"Location,omitempty"`
-	IsCancelled          bool             `json:"IsCancelled,omitempty"`
-	IsOrganizer          bool             `json:"IsOrganizer,omitempty"`
-	IsReminderOn         bool             `json:"IsReminderOn,omitempty"`
-	CreatedDateTime      string           `json:"CreatedDateTime,omitempty"`      //"2014-10-19T23:13:47.3959685Z"
-	LastModifiedDateTime string           `json:"LastModifiedDateTime,omitempty"` //"2014-10-19T23:13:47.6772234Z"
+	IsCancelled          bool   `json:"IsCancelled,omitempty"`
+	IsOrganizer          bool   `json:"IsOrganizer,omitempty"`
+	CreatedDateTime      string `json:"CreatedDateTime,omitempty"`      //"2014-10-19T23:13:47.3959685Z"
+	LastModifiedDateTime string `json:"LastModifiedDateTime,omitempty"` //"2014-10-19T23:13:47.6772234Z"
 }
 
+// Kind of the attachments that are links to a file shared instead of the file itself
+const outlookReferenceAttachment = "#Microsoft.OutlookServices.ReferenceAttachment"
+
+// Properties of the attachments retrieved, leaving out the contents of the files
+const outlookAttachmentsSelect = "Name,ContentType,Size,IsInline,SourceUrl"
+
 type OutlookAttachment struct {
+	ID string `json:"Id,omitempty"`
+	// Kind of attachment: file, item or reference
+	OdataType            string `json:"@odata.type,omitempty"`
 	ContentType          string `json:"ContentType,omitempty"`
 	IsInline             bool   `json:"IsInline,omitempty"`
 	LastModifiedDateTime string `json:"LastModifiedDateTime,omitempty"`
 	Name                 string `json:"Name,omitempty"`
 	Size                 int32  `json:"Size,omitempty"`
+	// Only given for reference attachments, the link to the file shared
+	SourceURL string `json:"SourceUrl,omitempty"`
+}
+
+type OutlookAttachmentListResponse struct {
+	OdataContext string              `json:"@odata.context"`
+	Attachments  []OutlookAttachment `json:"value"`
 }
 
 type OutlookAttendee struct {
-	Recipient *OutlookRecipient `json:"EmailAddress,omitempty"`
-	Status    *OutlookStatus    `json:"Status,omitempty"`
-	Type      string            `json:"Type,omitempty"`
+	EmailAddress *OutlookEmailAddress `json:"EmailAddress,omitempty"`
+	Status       *OutlookStatus       `json:"Status,omitempty"`
+	// The type of attendee: Required, Optional, Resource.
+	Type string `json:"Type,omitempty"`
 }
 
+type OutlookAttendees []OutlookAttendee
+
 type OutlookRecipient struct {
 	EmailAddress *OutlookEmailAddress `json:"EmailAddress,omitempty"`
 }
@@ -210,6 +243,10 @@ type OutlookPatternedRecurrence struct {
 	Pattern            OutlookRecurrencePattern `json:"Pattern,omitempty"`
 	RecurrenceTimeZone string                   `json:"RecurrenceTimeZone,omitempty"`
 	Range              OutlookRecurrenceRange   `json:"Range,omitempty"`
+	// Whether the event of the recurrence is all day
+	allDay bool
+	// End of a recurrence converted from a rule, whose day depends on the time zone of the event
+	until time.Time
 }
 
 type OutlookRecurrencePattern struct {
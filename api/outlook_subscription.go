@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -50,6 +51,11 @@ func manageRenewalData(subscription *OutlookSubscription) (data []byte, err erro
 //
 // POST https://outlook.office.com/api/v2.0/me/subscriptions
 func (subscription *OutlookSubscription) Subscribe(calendar CalendarManager) (err error) {
+	return subscription.SubscribeContext(context.Background(), calendar)
+}
+
+// Method like Subscribe that is stopped once the given context is done
+func (subscription *OutlookSubscription) SubscribeContext(ctx context.Context, calendar CalendarManager) (err error) {
 	if err = subscription.setCalendar(calendar); err != nil {
 		log.Errorf("kind of subscription and calender differs: %s", calendar.GetName())
 		return err
@@ -57,11 +63,11 @@ func (subscription *OutlookSubscription) Subscribe(calendar CalendarManager) (er
 	a := calendar.GetAccount()
 	log.Debugln("subscribe calendar outlook")
 
-	route, err := util.CallAPIRoot("outlook/subscription")
+	route, err := util.GetRoute("outlook/subscription")
 	if err != nil {
 		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
 	}
-	resource, err := util.CallAPIRoot("outlook/calendars/id/events")
+	resource, err := util.GetRoute("outlook/calendars/id/events")
 	if err != nil {
 		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
 	}
@@ -77,12 +83,15 @@ func (subscription *OutlookSubscription) Subscribe(calendar CalendarManager) (er
 	headers["Authorization"] = a.AuthorizationRequest()
 	headers["X-AnchorMailbox"] = a.Mail()
 
-	contents, err := util.DoRequest(http.MethodPost,
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodPost,
 		route,
 		bytes.NewBuffer(data),
 		headers, nil)
 
 	log.Warningf("RESPONSE: %s", contents)
+	if err != nil {
+		return util.RequestError(err, fmt.Sprintf("error subscribing a calendar for email %s", a.Mail()))
+	}
 
 	err = createOutlookResponseError(contents)
 	if err != nil {
@@ -99,11 +108,16 @@ func (subscription *OutlookSubscription) Subscribe(calendar CalendarManager) (er
 //
 // PATCH https://outlook.office.com/api/v2.0/me/subscriptions/{subscriptionId}
 func (subscription *OutlookSubscription) Renew() (err error) {
+	return subscription.RenewContext(context.Background())
+}
+
+// Method like Renew that is stopped once the given context is done
+func (subscription *OutlookSubscription) RenewContext(ctx context.Context) (err error) {
 	a := subscription.calendar.GetAccount()
 
 	log.Debugln("subscribe calendar outlook")
 
-	route, err := util.CallAPIRoot("outlook/subscription")
+	route, err := util.GetRoute("outlook/subscription")
 	if err != nil {
 		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
 	}
@@ -119,11 +133,14 @@ func (subscription *OutlookSubscription) Renew() (err error) {
 	headers["Authorization"] = a.AuthorizationRequest()
 	headers["X-AnchorMailbox"] = a.Mail()
 
-	contents, err := util.DoRequest(http.MethodPatch,
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodPatch,
 		route,
 		bytes.NewBuffer(data),
 		headers, nil)
 	log.Warningf("RESPONSE: %s", contents)
+	if err != nil {
+		return util.RequestError(err, fmt.Sprintf("error renewing a subscription for email %s", a.Mail()))
+	}
 	err = createOutlookResponseError(contents)
 	subscription.setTime()
 
@@ -134,9 +151,14 @@ func (subscription *OutlookSubscription) Renew() (err error) {
 //
 // DELETE https://outlook.office.com/api/v2.0/me/subscriptions('{subscriptionId}')
 func (subscription *OutlookSubscription) Delete() (err error) {
+	return subscription.DeleteContext(context.Background())
+}
+
+// Method like Delete that is stopped once the given context is done
+func (subscription *OutlookSubscription) DeleteContext(ctx context.Context) (err error) {
 	a := subscription.calendar.GetAccount()
 	log.Debugln("Delete outlook subscription")
-	route, err := util.CallAPIRoot("outlook/subscription")
+	route, err := util.GetRoute("outlook/subscription")
 	if err != nil {
 		return errors.New(fmt.Sprintf("error generating URL: %s", err.Error()))
 	}
@@ -146,11 +168,14 @@ func (subscription *OutlookSubscription) Delete() (err error) {
 	headers["Authorization"] = a.AuthorizationRequest()
 	headers["X-AnchorMailbox"] = a.Mail()
 
-	contents, err := util.DoRequest(http.MethodDelete,
+	contents, err := util.DoProviderRequestContext(ctx, http.MethodDelete,
 		route,
 		nil,
 		headers, nil)
 	log.Warningf("RESPONSE: %s", contents)
+	if err != nil {
+		return util.RequestError(err, fmt.Sprintf("error deleting a subscription for email %s", a.Mail()))
+	}
 	if len(contents) != 0 {
 		err = createOutlookResponseError(contents)
 		return err
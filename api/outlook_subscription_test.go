@@ -48,7 +48,7 @@ func setupNgrok(t *testing.T) {
 }
 
 func TestOutlookSubscription_SubscriptionLifeCycle(t *testing.T) {
-	setupApiRoot()
+	setupRoutes()
 	setupNgrok(t)
 	ngrokURL := fmt.Sprintf("%s/outlook/watcher", os.Getenv("NGROK_URI"))
 	account, _ := setup()
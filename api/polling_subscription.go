@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
+	log "github.com/TetAlius/GoSyncMyCalendars/logger"
+	"github.com/google/uuid"
+)
+
+// CalDAV servers and ICS feeds do not send notifications, so the subscription
+// is only used to know which calendars must be polled by the backend
+const pollingSubscriptionDuration = 7 * 24 * time.Hour
+
+type PollingSubscription struct {
+	calendar       CalendarManager
+	ID             string
+	Type           string
+	Uuid           uuid.UUID
+	expirationDate time.Time
+}
+
+// Function that creates a new PollingSubscription given specific info
+func NewPollingSubscription(ID string) (subscription *PollingSubscription) {
+	subscription = new(PollingSubscription)
+	subscription.Type = "polling"
+	subscription.ID = ID
+	subscription.Uuid = uuid.New()
+	return
+}
+
+// Function that returns a PollingSubscription given specific info
+func RetrievePollingSubscription(ID string, uid uuid.UUID, calendar CalendarManager, typ string) (subscription *PollingSubscription) {
+	subscription = new(PollingSubscription)
+	subscription.ID = ID
+	subscription.Uuid = uid
+	subscription.calendar = calendar
+	subscription.Type = typ
+	return
+}
+
+// Method that subscribes calendar for notifications.
+// Calendar will be polled until the subscription expires
+func (subscription *PollingSubscription) Subscribe(calendar CalendarManager) (err error) {
+	return subscription.SubscribeContext(context.Background(), calendar)
+}
+
+// Method like Subscribe that is stopped once the given context is done
+func (subscription *PollingSubscription) SubscribeContext(ctx context.Context, calendar CalendarManager) (err error) {
+	if err = subscription.setCalendar(calendar); err != nil {
+		log.Errorf("kind of subscription and calender differs: %s", calendar.GetName())
+		return err
+	}
+	log.Debugln("subscribe calendar polling")
+	subscription.expirationDate = time.Now().UTC().Add(pollingSubscriptionDuration)
+	return
+}
+
+// Method that renews subscription
+func (subscription *PollingSubscription) Renew() (err error) {
+	return subscription.RenewContext(context.Background())
+}
+
+// Method like Renew that is stopped once the given context is done
+func (subscription *PollingSubscription) RenewContext(ctx context.Context) (err error) {
+	log.Debugln("Renew polling subscription")
+	subscription.expirationDate = time.Now().UTC().Add(pollingSubscriptionDuration)
+	return
+}
+
+// Method that deletes subscription.
+// There is nothing to delete on the server
+func (subscription *PollingSubscription) Delete() (err error) {
+	return subscription.DeleteContext(context.Background())
+}
+
+// Method like Delete that is stopped once the given context is done
+func (subscription *PollingSubscription) DeleteContext(ctx context.Context) (err error) {
+	log.Debugln("Delete polling subscription")
+	return
+}
+
+// Method that sets the calendar to be watched by subscription.
+// Calendars of any provider can be polled
+func (subscription *PollingSubscription) setCalendar(calendar CalendarManager) (err error) {
+	if calendar == nil {
+		return &customErrors.WrongKindError{Mail: ""}
+	}
+	subscription.calendar = calendar
+	return
+}
+
+// Method that returns the ID of the subscription
+func (subscription *PollingSubscription) GetID() string {
+	return subscription.ID
+}
+
+// Method that returns the UUID of the subscription
+func (subscription *PollingSubscription) GetUUID() uuid.UUID {
+	return subscription.Uuid
+}
+
+// Method that returns the account of the subscription
+func (subscription *PollingSubscription) GetAccount() AccountManager {
+	return subscription.calendar.GetAccount()
+}
+
+// Method that returns the type of the subscription
+func (subscription *PollingSubscription) GetType() string {
+	return subscription.Type
+}
+
+// Method that returns the expiration date of the subscription
+func (subscription *PollingSubscription) GetExpirationDate() time.Time {
+	return subscription.expirationDate
+}
+
+// Method that returns the resourceID of the subscription
+func (subscription *PollingSubscription) GetResourceID() string {
+	return ""
+}
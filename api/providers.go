@@ -0,0 +1,86 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// Provider of calendars. Each provider registers itself with the functions needed
+// to build its accounts, calendars, events and subscriptions, so the rest of the
+// project does not need to know which providers exist
+type Provider struct {
+	// Kind of the accounts of the provider, as stored on DB
+	Kind int
+	// Name of the provider, used on logs
+	Name string
+	// Function that returns an account given the info stored on DB
+	RetrieveAccount func(tokenType string, refreshToken string, email string, kind int, accessToken string, serverURL string, credential string) AccountManager
+	// Function that returns a calendar of the given account
+	RetrieveCalendar func(ID string, uid string, account AccountManager) CalendarManager
+	// Function that returns an empty event, without calendar, given its ID
+	NewEvent func(ID string) EventManager
+	// Function that creates a new subscription for a calendar of the provider
+	NewSubscription func() SubscriptionManager
+	// Function that returns a subscription given the info stored on DB
+	RetrieveSubscription func(ID string, uid uuid.UUID, calendar CalendarManager, typ string, resourceID string) SubscriptionManager
+	// Endpoints used to authorize the accounts of the provider, nil if it does not use OAuth
+	OAuth *OAuthEndpoints
+}
+
+// Endpoints of the OAuth authorization of a provider
+type OAuthEndpoints struct {
+	// Path of the frontend that starts the authorization
+	SignInPath string
+	// Path of the frontend to which the provider redirects with the code
+	RedirectPath string
+	// Route of the route table with the login URL of the provider
+	LoginRoute string
+	// Route of the route table with the URL that gives the tokens
+	TokenRoute string
+	// Route of the route table with the params to request the tokens given a code
+	RequestParamsRoute string
+}
+
+// Providers registered, by kind
+var providers = make(map[int]*Provider)
+
+// Function that registers a provider.
+// It panics if there is already a provider with the same kind
+func RegisterProvider(provider *Provider) {
+	if _, ok := providers[provider.Kind]; ok {
+		panic(fmt.Sprintf("provider with kind %d already registered", provider.Kind))
+	}
+	providers[provider.Kind] = provider
+}
+
+// Function that returns the provider of a kind of account
+func GetProvider(kind int) (provider *Provider, ok bool) {
+	provider, ok = providers[kind]
+	return
+}
+
+// Function that returns the provider of a calendar, given by the kind of its account
+func ProviderOf(calendar CalendarManager) (provider *Provider, ok bool) {
+	return GetProvider(calendar.GetAccount().GetKind())
+}
+
+// Function that returns all providers registered, ordered by kind
+func Providers() (list []*Provider) {
+	for _, provider := range providers {
+		list = append(list, provider)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Kind < list[j].Kind })
+	return
+}
+
+// Function that returns a calendar with its account given the info stored on DB
+func RetrieveCalendar(kind int, ID string, uid string, tokenType string, refreshToken string, email string, accessToken string, serverURL string, credential string) (calendar CalendarManager, ok bool) {
+	provider, ok := GetProvider(kind)
+	if !ok {
+		return nil, false
+	}
+	account := provider.RetrieveAccount(tokenType, refreshToken, email, kind, accessToken, serverURL, credential)
+	return provider.RetrieveCalendar(ID, uid, account), true
+}
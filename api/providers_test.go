@@ -0,0 +1,71 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/TetAlius/GoSyncMyCalendars/api"
+	"github.com/google/uuid"
+)
+
+func TestProviders_Registered(t *testing.T) {
+	kinds := []int{api.GOOGLE, api.OUTLOOK, api.CALDAV, api.ICS, api.GRAPH}
+	providers := api.Providers()
+	if len(providers) != len(kinds) {
+		t.Fatalf("something went wrong. Expected %d providers found %d", len(kinds), len(providers))
+	}
+	for i, kind := range kinds {
+		if providers[i].Kind != i+1 {
+			t.Fatalf("something went wrong. Expected providers ordered by kind found %d on position %d", providers[i].Kind, i)
+		}
+		provider, ok := api.GetProvider(kind)
+		if !ok {
+			t.Fatalf("something went wrong. Expected provider with kind %d found none", kind)
+		}
+
+		// calendars are built with an account of the same kind
+		calendar, ok := api.RetrieveCalendar(kind, "calendar", "uuid", "Bearer", "refresh", "travis@example.com", "token", "", "")
+		if !ok || calendar.GetID() != "calendar" || calendar.GetUUID() != "uuid" || calendar.GetAccount().GetKind() != kind {
+			t.Fatalf("something went wrong. Expected calendar of kind %d found %v", kind, calendar)
+		}
+		if fromCalendar, _ := api.ProviderOf(calendar); fromCalendar != provider {
+			t.Fatalf("something went wrong. Expected provider %s for calendar found %v", provider.Name, fromCalendar)
+		}
+
+		// events and subscriptions of the provider accept its calendars
+		event := provider.NewEvent("event")
+		if err := event.SetCalendar(calendar); err != nil || event.GetID() != "event" {
+			t.Fatalf("something went wrong. Expected event of %s found error: %v", provider.Name, err)
+		}
+		if subscription := provider.NewSubscription(); subscription == nil || len(subscription.GetUUID().String()) == 0 {
+			t.Fatalf("something went wrong. Expected subscription of %s found %v", provider.Name, subscription)
+		}
+		subscription := provider.RetrieveSubscription("subscription", uuid.New(), calendar, "type", "resource")
+		if subscription.GetID() != "subscription" || subscription.GetAccount().GetKind() != kind {
+			t.Fatalf("something went wrong. Expected subscription of %s found %v", provider.Name, subscription)
+		}
+	}
+
+	// outlook accounts are only read, new ones are authorized as graph accounts
+	graph, _ := api.GetProvider(api.GRAPH)
+	outlook, _ := api.GetProvider(api.OUTLOOK)
+	if outlook.OAuth != nil || graph.OAuth == nil || graph.OAuth.SignInPath != "/SignInWithOutlook" || graph.OAuth.LoginRoute != "graph/login" {
+		t.Fatal("something went wrong. Expected OAuth endpoints of outlook accounts on graph")
+	}
+}
+
+func TestProviders_Unknown(t *testing.T) {
+	if _, ok := api.GetProvider(0); ok {
+		t.Fatal("something went wrong. Expected no provider for kind 0")
+	}
+	if calendar, ok := api.RetrieveCalendar(42, "calendar", "uuid", "", "", "", "", "", ""); ok || calendar != nil {
+		t.Fatalf("something went wrong. Expected no calendar for kind 42 found %v", calendar)
+	}
+
+	// kinds can only be registered once
+	defer func() {
+		if recover() == nil {
+			t.Fatal("something went wrong. Expected panic registering a kind twice")
+		}
+	}()
+	api.RegisterProvider(&api.Provider{Kind: api.GOOGLE, Name: "google"})
+}
@@ -0,0 +1,489 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/TetAlius/GoSyncMyCalendars/logger"
+)
+
+const (
+	// Format used by the dates of the recurrence rules
+	rruleDateFormat = "20060102"
+	// Format used by the dates of the Outlook recurrence ranges
+	outlookDateFormat = "2006-01-02"
+)
+
+// Days of the week as written on recurrence rules and on Outlook
+var rruleDays = map[string]string{
+	"SU": "Sunday",
+	"MO": "Monday",
+	"TU": "Tuesday",
+	"WE": "Wednesday",
+	"TH": "Thursday",
+	"FR": "Friday",
+	"SA": "Saturday",
+}
+
+// Week indexes of Outlook and the position they have on recurrence rules
+var outlookIndexes = map[string]int{
+	"First":  1,
+	"Second": 2,
+	"Third":  3,
+	"Fourth": 4,
+	"Last":   -1,
+}
+
+// Specific error for a recurrence that can not be expressed by the other calendar
+type RecurrenceError struct {
+	Rule   string
+	Reason string
+}
+
+// Method implementing error interface
+func (err RecurrenceError) Error() string {
+	return fmt.Sprintf("recurrence %s can not be converted: %s", err.Rule, err.Reason)
+}
+
+// Recurrence rule as defined on RFC 5545, with only the parts that can be converted
+type recurrenceRule struct {
+	Freq     string
+	Interval int
+	Count    int
+	Until    time.Time
+	// Whether UNTIL is a date-time in UTC instead of a date
+	UntilTime  bool
+	ByDay      []string
+	ByMonthDay []int
+	ByMonth    []int
+	BySetPos   []int
+	WeekStart  string
+}
+
+// Function that parses a RRULE line. Parts that can not be converted give a RecurrenceError
+func parseRecurrenceRule(line string) (rule recurrenceRule, err error) {
+	value := strings.TrimPrefix(line, "RRULE:")
+	rule.Interval = 1
+	for _, part := range strings.Split(value, ";") {
+		keyValue := strings.SplitN(part, "=", 2)
+		if len(keyValue) != 2 {
+			return rule, RecurrenceError{Rule: line, Reason: fmt.Sprintf("malformed part %s", part)}
+		}
+		key, val := strings.ToUpper(keyValue[0]), keyValue[1]
+		switch key {
+		case "FREQ":
+			rule.Freq = strings.ToUpper(val)
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(val)
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(val)
+		case "UNTIL":
+			rule.Until, err = parseRecurrenceDate(val)
+			rule.UntilTime = len(val) > len(rruleDateFormat)
+		case "BYDAY":
+			rule.ByDay = strings.Split(strings.ToUpper(val), ",")
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseRecurrenceInts(val)
+		case "BYMONTH":
+			rule.ByMonth, err = parseRecurrenceInts(val)
+		case "BYSETPOS":
+			rule.BySetPos, err = parseRecurrenceInts(val)
+		case "WKST":
+			rule.WeekStart = strings.ToUpper(val)
+		default:
+			return rule, RecurrenceError{Rule: line, Reason: fmt.Sprintf("%s is not supported", key)}
+		}
+		if err != nil {
+			return rule, RecurrenceError{Rule: line, Reason: fmt.Sprintf("wrong value of %s: %s", key, err.Error())}
+		}
+	}
+	if len(rule.Freq) == 0 {
+		return rule, RecurrenceError{Rule: line, Reason: "FREQ is missing"}
+	}
+	return
+}
+
+// Function that parses the date of an UNTIL, which can also have time
+func parseRecurrenceDate(value string) (time.Time, error) {
+	if len(value) > len(rruleDateFormat) {
+		return time.Parse(icalUTCFormat, value)
+	}
+	return time.Parse(rruleDateFormat, value)
+}
+
+// Function that parses a list of numbers separated by commas
+func parseRecurrenceInts(value string) (numbers []int, err error) {
+	for _, number := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return nil, err
+		}
+		numbers = append(numbers, n)
+	}
+	return
+}
+
+// Function that splits a BYDAY value into its position and its day
+func splitRecurrenceDay(value string) (position int, day string, err error) {
+	i := strings.IndexAny(value, "ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+	if i < 0 {
+		return 0, "", fmt.Errorf("day missing on %s", value)
+	}
+	day = value[i:]
+	if _, ok := rruleDays[day]; !ok {
+		return 0, "", fmt.Errorf("unknown day %s", day)
+	}
+	if i > 0 {
+		position, err = strconv.Atoi(value[:i])
+	}
+	return
+}
+
+// Method that returns the RRULE line of the rule
+func (rule recurrenceRule) String() string {
+	parts := []string{"FREQ=" + rule.Freq}
+	if rule.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", rule.Interval))
+	}
+	if rule.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", rule.Count))
+	}
+	if rule.UntilTime {
+		parts = append(parts, "UNTIL="+rule.Until.UTC().Format(icalUTCFormat))
+	} else if !rule.Until.IsZero() {
+		parts = append(parts, "UNTIL="+rule.Until.Format(rruleDateFormat))
+	}
+	if len(rule.ByMonth) != 0 {
+		parts = append(parts, "BYMONTH="+joinRecurrenceInts(rule.ByMonth))
+	}
+	if len(rule.ByMonthDay) != 0 {
+		parts = append(parts, "BYMONTHDAY="+joinRecurrenceInts(rule.ByMonthDay))
+	}
+	if len(rule.ByDay) != 0 {
+		parts = append(parts, "BYDAY="+strings.Join(rule.ByDay, ","))
+	}
+	if len(rule.BySetPos) != 0 {
+		parts = append(parts, "BYSETPOS="+joinRecurrenceInts(rule.BySetPos))
+	}
+	if len(rule.WeekStart) != 0 {
+		parts = append(parts, "WKST="+rule.WeekStart)
+	}
+	return "RRULE:" + strings.Join(parts, ";")
+}
+
+// Function that joins a list of numbers with commas
+func joinRecurrenceInts(numbers []int) string {
+	values := make([]string, len(numbers))
+	for i, n := range numbers {
+		values[i] = strconv.Itoa(n)
+	}
+	return strings.Join(values, ",")
+}
+
+// Function that returns the recurrence lines given by a Deconverter.
+// Recurrences that could not be deconverted are given as their error
+func recurrenceLines(m interface{}) ([]string, error) {
+	switch x := m.(type) {
+	case nil:
+		return nil, nil
+	case []string:
+		return x, nil
+	case error:
+		return nil, x
+	default:
+		return nil, errors.New(fmt.Sprintf("incorrect type of field recurrence: %T", x))
+	}
+}
+
+// Function that converts the recurrence lines of an event to an Outlook recurrence.
+// Only one RRULE can be expressed, and excluded or added dates can not be part of the pattern.
+// The dates that depend on the start of the event are completed when the event is written
+func newOutlookRecurrence(lines []string) (recurrence *OutlookPatternedRecurrence, err error) {
+	var rules []string
+	for _, line := range lines {
+		name := strings.ToUpper(strings.SplitN(line, ":", 2)[0])
+		switch {
+		case name == "RRULE":
+			rules = append(rules, line)
+		case strings.HasPrefix(name, "EXDATE"):
+			return nil, RecurrenceError{Rule: line, Reason: "excluded dates can not be expressed on an Outlook pattern"}
+		default:
+			return nil, RecurrenceError{Rule: line, Reason: "only RRULE is supported"}
+		}
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	if len(rules) > 1 {
+		return nil, RecurrenceError{Rule: strings.Join(rules, " "), Reason: "only one RRULE can be expressed"}
+	}
+	line := rules[0]
+	rule, err := parseRecurrenceRule(line)
+	if err != nil {
+		return nil, err
+	}
+	pattern := OutlookRecurrencePattern{Interval: rule.Interval}
+	if len(rule.WeekStart) != 0 {
+		pattern.FirstDayOfWeek = rruleDays[rule.WeekStart]
+		if len(pattern.FirstDayOfWeek) == 0 {
+			return nil, RecurrenceError{Rule: line, Reason: fmt.Sprintf("unknown day %s", rule.WeekStart)}
+		}
+	}
+	if len(rule.ByMonth) > 1 || len(rule.ByMonthDay) > 1 || len(rule.BySetPos) > 1 {
+		return nil, RecurrenceError{Rule: line, Reason: "only one month, day of month and position are supported"}
+	}
+	if len(rule.ByMonthDay) == 1 && len(rule.ByDay) != 0 {
+		return nil, RecurrenceError{Rule: line, Reason: "day of month and days of week can not be combined"}
+	}
+	if len(rule.ByMonthDay) == 1 && rule.ByMonthDay[0] < 1 {
+		return nil, RecurrenceError{Rule: line, Reason: "days of month counted from the end are not supported"}
+	}
+	position, days, err := recurrenceDays(rule)
+	if err != nil {
+		return nil, RecurrenceError{Rule: line, Reason: err.Error()}
+	}
+
+	switch rule.Freq {
+	case "DAILY":
+		if len(rule.ByMonth) != 0 || len(rule.ByMonthDay) != 0 || position != 0 {
+			return nil, RecurrenceError{Rule: line, Reason: "daily rules can only be limited by days of week"}
+		}
+		pattern.Type = "Daily"
+		if len(days) != 0 {
+			// every weekday is expressed as a weekly pattern on those days
+			if rule.Interval != 1 {
+				return nil, RecurrenceError{Rule: line, Reason: "days of week with an interval are not supported on daily rules"}
+			}
+			pattern.Type = "Weekly"
+			pattern.DaysOfWeek = days
+		}
+	case "WEEKLY":
+		if len(rule.ByMonth) != 0 || len(rule.ByMonthDay) != 0 || position != 0 {
+			return nil, RecurrenceError{Rule: line, Reason: "weekly rules can only be limited by days of week"}
+		}
+		pattern.Type = "Weekly"
+		pattern.DaysOfWeek = days
+	case "MONTHLY":
+		if len(rule.ByMonth) != 0 {
+			return nil, RecurrenceError{Rule: line, Reason: "monthly rules can not be limited by month"}
+		}
+		if len(days) != 0 {
+			pattern.Type = "RelativeMonthly"
+		} else {
+			pattern.Type = "AbsoluteMonthly"
+		}
+	case "YEARLY":
+		if len(days) != 0 {
+			pattern.Type = "RelativeYearly"
+		} else {
+			pattern.Type = "AbsoluteYearly"
+		}
+		if len(rule.ByMonth) == 1 {
+			pattern.Month = rule.ByMonth[0]
+		}
+	default:
+		return nil, RecurrenceError{Rule: line, Reason: fmt.Sprintf("frequency %s is not supported", rule.Freq)}
+	}
+	if len(rule.ByMonthDay) == 1 {
+		pattern.DayOfMonth = rule.ByMonthDay[0]
+	}
+	if strings.HasPrefix(pattern.Type, "Relative") {
+		if position == 0 {
+			return nil, RecurrenceError{Rule: line, Reason: "days of week need a position on monthly and yearly rules"}
+		}
+		pattern.DaysOfWeek = days
+		for index, value := range outlookIndexes {
+			if value == position {
+				pattern.Index = index
+			}
+		}
+		if len(pattern.Index) == 0 {
+			return nil, RecurrenceError{Rule: line, Reason: fmt.Sprintf("position %d is not supported", position)}
+		}
+	}
+
+	recurrenceRange := OutlookRecurrenceRange{Type: "NoEnd"}
+	switch {
+	case rule.Count > 0 && !rule.Until.IsZero():
+		return nil, RecurrenceError{Rule: line, Reason: "COUNT and UNTIL can not be combined"}
+	case rule.Count > 0:
+		recurrenceRange.Type = "Numbered"
+		recurrenceRange.NumberOfOccurrences = rule.Count
+	case !rule.Until.IsZero():
+		recurrenceRange.Type = "EndDate"
+		recurrenceRange.EndDate = rule.Until.Format(outlookDateFormat)
+	}
+	recurrence = &OutlookPatternedRecurrence{Pattern: pattern, Range: recurrenceRange}
+	if rule.UntilTime {
+		// the day of an UNTIL with time depends on the time zone of the series
+		recurrence.until = rule.Until
+	}
+	return recurrence, nil
+}
+
+// Function that returns the days of week of a rule and the position they have in the month.
+// The position can be given on each day or with BYSETPOS, but it must be the same for all
+func recurrenceDays(rule recurrenceRule) (position int, days []string, err error) {
+	for i, value := range rule.ByDay {
+		dayPosition, day, err := splitRecurrenceDay(value)
+		if err != nil {
+			return 0, nil, err
+		}
+		if i > 0 && dayPosition != position {
+			return 0, nil, fmt.Errorf("days of week with different positions are not supported")
+		}
+		position = dayPosition
+		days = append(days, rruleDays[day])
+	}
+	if len(rule.BySetPos) == 1 {
+		if position != 0 || len(days) == 0 {
+			return 0, nil, fmt.Errorf("BYSETPOS is only supported with days of week without position")
+		}
+		position = rule.BySetPos[0]
+	}
+	return
+}
+
+// Method that returns the recurrence as RRULE lines
+func (recurrence *OutlookPatternedRecurrence) rules() (lines []string, err error) {
+	pattern := recurrence.Pattern
+	rule := recurrenceRule{Interval: pattern.Interval}
+	if len(pattern.FirstDayOfWeek) != 0 {
+		rule.WeekStart, err = rruleDay(pattern.FirstDayOfWeek)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var byDay []string
+	for _, day := range pattern.DaysOfWeek {
+		value, err := rruleDay(day)
+		if err != nil {
+			return nil, err
+		}
+		byDay = append(byDay, value)
+	}
+	position, ok := outlookIndexes[pattern.Index]
+	if strings.HasPrefix(pattern.Type, "Relative") && !ok {
+		return nil, RecurrenceError{Rule: pattern.Type, Reason: fmt.Sprintf("unknown index %s", pattern.Index)}
+	}
+
+	switch pattern.Type {
+	case "Daily":
+		rule.Freq = "DAILY"
+	case "Weekly":
+		rule.Freq = "WEEKLY"
+		rule.ByDay = byDay
+	case "AbsoluteMonthly":
+		rule.Freq = "MONTHLY"
+		rule.ByMonthDay = []int{pattern.DayOfMonth}
+	case "RelativeMonthly":
+		rule.Freq = "MONTHLY"
+		rule.ByDay, rule.BySetPos = relativeDays(byDay, position)
+	case "AbsoluteYearly":
+		rule.Freq = "YEARLY"
+		rule.ByMonth = []int{pattern.Month}
+		rule.ByMonthDay = []int{pattern.DayOfMonth}
+	case "RelativeYearly":
+		rule.Freq = "YEARLY"
+		rule.ByMonth = []int{pattern.Month}
+		rule.ByDay, rule.BySetPos = relativeDays(byDay, position)
+	default:
+		return nil, RecurrenceError{Rule: pattern.Type, Reason: "unknown pattern type"}
+	}
+	// weeks only start on a different day for weekly rules
+	if rule.Freq != "WEEKLY" {
+		rule.WeekStart = ""
+	}
+
+	switch recurrence.Range.Type {
+	case "Numbered":
+		rule.Count = recurrence.Range.NumberOfOccurrences
+	case "EndDate":
+		rule.Until, err = recurrence.endDate()
+		if err != nil {
+			return nil, err
+		}
+		// UNTIL must have the same type as the start of the event
+		rule.UntilTime = !recurrence.allDay
+	case "NoEnd", "":
+	default:
+		return nil, RecurrenceError{Rule: recurrence.Range.Type, Reason: "unknown range type"}
+	}
+	return []string{rule.String()}, nil
+}
+
+// Method that returns the end date of the recurrence. On events that are not all day,
+// the end date is the last moment of that day on the time zone of the series
+func (recurrence *OutlookPatternedRecurrence) endDate() (time.Time, error) {
+	if recurrence.allDay {
+		until, err := time.Parse(outlookDateFormat, recurrence.Range.EndDate)
+		if err != nil {
+			return until, RecurrenceError{Rule: recurrence.Range.Type, Reason: fmt.Sprintf("wrong end date %s", recurrence.Range.EndDate)}
+		}
+		return until, nil
+	}
+	location, err := loadTimeZone(recurrence.RecurrenceTimeZone)
+	if err != nil {
+		log.Errorf("end date of recurrence given in UTC: %s", err.Error())
+		location = time.UTC
+	}
+	until, err := time.ParseInLocation(outlookDateFormat, recurrence.Range.EndDate, location)
+	if err != nil {
+		return until, RecurrenceError{Rule: recurrence.Range.Type, Reason: fmt.Sprintf("wrong end date %s", recurrence.Range.EndDate)}
+	}
+	return until.AddDate(0, 0, 1).Add(-time.Second).UTC(), nil
+}
+
+// Function that returns the days of week of a relative pattern. A single day
+// carries its position, many days use BYSETPOS to choose one of them
+func relativeDays(days []string, position int) ([]string, []int) {
+	if len(days) == 1 {
+		return []string{fmt.Sprintf("%d%s", position, days[0])}, nil
+	}
+	return days, []int{position}
+}
+
+// Function that returns the day of a recurrence rule from an Outlook day
+func rruleDay(day string) (string, error) {
+	for key, value := range rruleDays {
+		if strings.EqualFold(value, day) {
+			return key, nil
+		}
+	}
+	return "", RecurrenceError{Rule: day, Reason: "unknown day of week"}
+}
+
+// Method that completes the parts of the recurrence that depend on the start of the event,
+// given on the time zone of the event so the series keeps its time across DST
+func (recurrence *OutlookPatternedRecurrence) complete(start time.Time) {
+	if len(recurrence.RecurrenceTimeZone) == 0 {
+		if name, err := windowsTimeZone(start.Location()); err == nil {
+			recurrence.RecurrenceTimeZone = name
+		}
+	}
+	if len(recurrence.Range.StartDate) == 0 {
+		recurrence.Range.StartDate = start.Format(outlookDateFormat)
+	}
+	if !recurrence.until.IsZero() {
+		recurrence.Range.EndDate = recurrence.until.In(start.Location()).Format(outlookDateFormat)
+	}
+	if recurrence.Pattern.Interval == 0 {
+		recurrence.Pattern.Interval = 1
+	}
+	switch recurrence.Pattern.Type {
+	case "Weekly":
+		if len(recurrence.Pattern.DaysOfWeek) == 0 {
+			recurrence.Pattern.DaysOfWeek = []string{start.Weekday().String()}
+		}
+	case "AbsoluteMonthly", "AbsoluteYearly":
+		if recurrence.Pattern.DayOfMonth == 0 {
+			recurrence.Pattern.DayOfMonth = start.Day()
+		}
+	}
+	if strings.HasSuffix(recurrence.Pattern.Type, "Yearly") && recurrence.Pattern.Month == 0 {
+		recurrence.Pattern.Month = int(start.Month())
+	}
+}
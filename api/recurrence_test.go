@@ -0,0 +1,299 @@
+package api_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/TetAlius/GoSyncMyCalendars/api"
+	"github.com/TetAlius/GoSyncMyCalendars/convert"
+)
+
+var recurrenceStart = time.Date(2018, 6, 14, 10, 0, 0, 0, time.UTC)
+
+func recurringGoogleEvent(recurrences ...string) *api.GoogleEvent {
+	return &api.GoogleEvent{
+		Subject:     "Recurring",
+		Description: "Description",
+		Start:       &api.GoogleTime{DateTime: recurrenceStart, TimeZone: time.UTC},
+		End:         &api.GoogleTime{DateTime: recurrenceStart.Add(time.Hour), TimeZone: time.UTC},
+		Recurrences: recurrences,
+	}
+}
+
+func recurringOutlookEvent(recurrence *api.OutlookPatternedRecurrence) *api.OutlookEvent {
+	return &api.OutlookEvent{
+		Subject:    "Recurring",
+		Body:       &api.OutlookItemBody{Description: "Description"},
+		Start:      &api.OutlookDateTimeTimeZone{DateTime: recurrenceStart, TimeZone: time.UTC},
+		End:        &api.OutlookDateTimeTimeZone{DateTime: recurrenceStart.Add(time.Hour), TimeZone: time.UTC},
+		Recurrence: recurrence,
+	}
+}
+
+func TestRecurrence_GoogleToOutlook(t *testing.T) {
+	tests := []struct {
+		rule    string
+		pattern api.OutlookRecurrencePattern
+		ranges  api.OutlookRecurrenceRange
+	}{
+		{"RRULE:FREQ=DAILY;INTERVAL=2",
+			api.OutlookRecurrencePattern{Type: "Daily", Interval: 2},
+			api.OutlookRecurrenceRange{Type: "NoEnd"}},
+		{"RRULE:FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR;COUNT=10",
+			api.OutlookRecurrencePattern{Type: "Weekly", Interval: 1, DaysOfWeek: []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"}},
+			api.OutlookRecurrenceRange{Type: "Numbered", NumberOfOccurrences: 10}},
+		{"RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH;WKST=SU;UNTIL=20181231T235959Z",
+			api.OutlookRecurrencePattern{Type: "Weekly", Interval: 2, DaysOfWeek: []string{"Tuesday", "Thursday"}, FirstDayOfWeek: "Sunday"},
+			api.OutlookRecurrenceRange{Type: "EndDate", EndDate: "2018-12-31"}},
+		{"RRULE:FREQ=MONTHLY;BYMONTHDAY=14",
+			api.OutlookRecurrencePattern{Type: "AbsoluteMonthly", Interval: 1, DayOfMonth: 14},
+			api.OutlookRecurrenceRange{Type: "NoEnd"}},
+		{"RRULE:FREQ=MONTHLY;BYDAY=2TH",
+			api.OutlookRecurrencePattern{Type: "RelativeMonthly", Interval: 1, DaysOfWeek: []string{"Thursday"}, Index: "Second"},
+			api.OutlookRecurrenceRange{Type: "NoEnd"}},
+		{"RRULE:FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+			api.OutlookRecurrencePattern{Type: "RelativeMonthly", Interval: 1, DaysOfWeek: []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"}, Index: "Last"},
+			api.OutlookRecurrenceRange{Type: "NoEnd"}},
+		{"RRULE:FREQ=YEARLY;BYMONTH=6;BYMONTHDAY=14;UNTIL=20250614",
+			api.OutlookRecurrencePattern{Type: "AbsoluteYearly", Interval: 1, DayOfMonth: 14, Month: 6},
+			api.OutlookRecurrenceRange{Type: "EndDate", EndDate: "2025-06-14"}},
+		{"RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=4TH",
+			api.OutlookRecurrencePattern{Type: "RelativeYearly", Interval: 1, Month: 11, DaysOfWeek: []string{"Thursday"}, Index: "Fourth"},
+			api.OutlookRecurrenceRange{Type: "NoEnd"}},
+	}
+	for _, test := range tests {
+		outlookEvent := new(api.OutlookEvent)
+		err := convert.Convert(recurringGoogleEvent(test.rule), outlookEvent)
+		if err != nil {
+			t.Fatalf("something went wrong converting %s. Expected nil found error: %s", test.rule, err.Error())
+		}
+		if outlookEvent.Recurrence == nil {
+			t.Fatalf("something went wrong converting %s. Expected recurrence found nil", test.rule)
+		}
+		if !reflect.DeepEqual(outlookEvent.Recurrence.Pattern, test.pattern) {
+			t.Errorf("something went wrong converting %s. Expected pattern %+v found %+v", test.rule, test.pattern, outlookEvent.Recurrence.Pattern)
+		}
+		if !reflect.DeepEqual(outlookEvent.Recurrence.Range, test.ranges) {
+			t.Errorf("something went wrong converting %s. Expected range %+v found %+v", test.rule, test.ranges, outlookEvent.Recurrence.Range)
+		}
+	}
+}
+
+func TestRecurrence_OutlookToGoogle(t *testing.T) {
+	tests := []struct {
+		recurrence api.OutlookPatternedRecurrence
+		rule       string
+	}{
+		{api.OutlookPatternedRecurrence{
+			Pattern: api.OutlookRecurrencePattern{Type: "Daily", Interval: 3},
+			Range:   api.OutlookRecurrenceRange{Type: "NoEnd", StartDate: "2018-06-14"}},
+			"RRULE:FREQ=DAILY;INTERVAL=3"},
+		{api.OutlookPatternedRecurrence{
+			Pattern: api.OutlookRecurrencePattern{Type: "Weekly", Interval: 1, DaysOfWeek: []string{"Monday", "Friday"}, FirstDayOfWeek: "Monday"},
+			Range:   api.OutlookRecurrenceRange{Type: "Numbered", StartDate: "2018-06-14", NumberOfOccurrences: 5}},
+			"RRULE:FREQ=WEEKLY;COUNT=5;BYDAY=MO,FR;WKST=MO"},
+		{api.OutlookPatternedRecurrence{
+			Pattern: api.OutlookRecurrencePattern{Type: "AbsoluteMonthly", Interval: 2, DayOfMonth: 31, FirstDayOfWeek: "Sunday"},
+			Range:   api.OutlookRecurrenceRange{Type: "EndDate", StartDate: "2018-06-14", EndDate: "2019-06-14"}},
+			"RRULE:FREQ=MONTHLY;INTERVAL=2;UNTIL=20190614T235959Z;BYMONTHDAY=31"},
+		// the end date of a timed series is the end of that day on the time zone of the series
+		{api.OutlookPatternedRecurrence{
+			Pattern:            api.OutlookRecurrencePattern{Type: "Daily", Interval: 1},
+			RecurrenceTimeZone: "Eastern Standard Time",
+			Range:              api.OutlookRecurrenceRange{Type: "EndDate", StartDate: "2018-06-14", EndDate: "2018-06-20"}},
+			"RRULE:FREQ=DAILY;UNTIL=20180621T035959Z"},
+		{api.OutlookPatternedRecurrence{
+			Pattern: api.OutlookRecurrencePattern{Type: "RelativeMonthly", Interval: 1, DaysOfWeek: []string{"Sunday"}, Index: "First"},
+			Range:   api.OutlookRecurrenceRange{Type: "NoEnd"}},
+			"RRULE:FREQ=MONTHLY;BYDAY=1SU"},
+		{api.OutlookPatternedRecurrence{
+			Pattern: api.OutlookRecurrencePattern{Type: "RelativeMonthly", Interval: 1, DaysOfWeek: []string{"Saturday", "Sunday"}, Index: "Last"},
+			Range:   api.OutlookRecurrenceRange{Type: "NoEnd"}},
+			"RRULE:FREQ=MONTHLY;BYDAY=SA,SU;BYSETPOS=-1"},
+		{api.OutlookPatternedRecurrence{
+			Pattern: api.OutlookRecurrencePattern{Type: "AbsoluteYearly", Interval: 1, Month: 2, DayOfMonth: 29},
+			Range:   api.OutlookRecurrenceRange{Type: "NoEnd"}},
+			"RRULE:FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29"},
+		{api.OutlookPatternedRecurrence{
+			Pattern: api.OutlookRecurrencePattern{Type: "RelativeYearly", Interval: 1, Month: 5, DaysOfWeek: []string{"Monday"}, Index: "Last"},
+			Range:   api.OutlookRecurrenceRange{Type: "NoEnd"}},
+			"RRULE:FREQ=YEARLY;BYMONTH=5;BYDAY=-1MO"},
+	}
+	for _, test := range tests {
+		recurrence := test.recurrence
+		googleEvent := new(api.GoogleEvent)
+		err := convert.Convert(recurringOutlookEvent(&recurrence), googleEvent)
+		if err != nil {
+			t.Fatalf("something went wrong converting %s. Expected nil found error: %s", test.rule, err.Error())
+		}
+		if len(googleEvent.Recurrences) != 1 || googleEvent.Recurrences[0] != test.rule {
+			t.Errorf("something went wrong. Expected %s found %v", test.rule, googleEvent.Recurrences)
+		}
+	}
+}
+
+func TestRecurrence_RoundTrip(t *testing.T) {
+	rules := []string{
+		"RRULE:FREQ=DAILY",
+		"RRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=WE;WKST=SU",
+		"RRULE:FREQ=MONTHLY;UNTIL=20181231T235959Z;BYMONTHDAY=1",
+		"RRULE:FREQ=MONTHLY;BYDAY=3FR",
+		"RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=1SU",
+	}
+	for _, rule := range rules {
+		outlookEvent := new(api.OutlookEvent)
+		err := convert.Convert(recurringGoogleEvent(rule), outlookEvent)
+		if err != nil {
+			t.Fatalf("something went wrong converting %s. Expected nil found error: %s", rule, err.Error())
+		}
+		googleEvent := new(api.GoogleEvent)
+		err = convert.Convert(outlookEvent, googleEvent)
+		if err != nil {
+			t.Fatalf("something went wrong converting back %s. Expected nil found error: %s", rule, err.Error())
+		}
+		if len(googleEvent.Recurrences) != 1 || googleEvent.Recurrences[0] != rule {
+			t.Errorf("something went wrong. Expected %s found %v", rule, googleEvent.Recurrences)
+		}
+	}
+
+	// events without recurrence stay that way
+	outlookEvent := new(api.OutlookEvent)
+	err := convert.Convert(recurringGoogleEvent(), outlookEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if outlookEvent.Recurrence != nil {
+		t.Fatalf("something went wrong. Expected no recurrence found %+v", outlookEvent.Recurrence)
+	}
+}
+
+func TestRecurrence_Unsupported(t *testing.T) {
+	rules := [][]string{
+		{"RRULE:FREQ=HOURLY;INTERVAL=4"},
+		{"RRULE:FREQ=DAILY;BYHOUR=10,14"},
+		{"RRULE:FREQ=YEARLY;BYWEEKNO=20"},
+		{"RRULE:FREQ=MONTHLY;BYMONTHDAY=1,15"},
+		{"RRULE:FREQ=MONTHLY;BYMONTHDAY=-1"},
+		{"RRULE:FREQ=MONTHLY;BYDAY=1MO,3MO"},
+		{"RRULE:FREQ=MONTHLY;BYDAY=5FR"},
+		{"RRULE:FREQ=MONTHLY;BYDAY=FR"},
+		{"RRULE:FREQ=YEARLY;BYMONTH=1,7;BYMONTHDAY=1"},
+		{"RRULE:FREQ=WEEKLY;BYDAY=MO", "RRULE:FREQ=WEEKLY;BYDAY=FR"},
+		{"RDATE;VALUE=DATE:20180701"},
+	}
+	for _, lines := range rules {
+		outlookEvent := new(api.OutlookEvent)
+		err := convert.Convert(recurringGoogleEvent(lines...), outlookEvent)
+		if _, ok := err.(api.RecurrenceError); !ok {
+			t.Errorf("something went wrong converting %v. Expected recurrence error found %v", lines, err)
+		}
+	}
+
+	unknown := api.OutlookPatternedRecurrence{Pattern: api.OutlookRecurrencePattern{Type: "Hourly", Interval: 1}}
+	googleEvent := new(api.GoogleEvent)
+	err := convert.Convert(recurringOutlookEvent(&unknown), googleEvent)
+	if _, ok := err.(api.RecurrenceError); !ok {
+		t.Errorf("something went wrong. Expected recurrence error found %v", err)
+	}
+
+	// excluded dates can not be part of the pattern
+	outlookEvent := new(api.OutlookEvent)
+	err = convert.Convert(recurringGoogleEvent("RRULE:FREQ=DAILY", "EXDATE;VALUE=DATE:20180615"), outlookEvent)
+	if _, ok := err.(api.RecurrenceError); !ok {
+		t.Errorf("something went wrong. Expected recurrence error found %v", err)
+	}
+}
+
+func TestRecurrence_OutlookCreate(t *testing.T) {
+	var sent api.OutlookEvent
+	_, teardown := setupStandIn(map[string]string{
+		"outlook/calendars/id/events": "/calendars/%s/events",
+	}, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &sent)
+		w.Write([]byte(`{"Id":"created","Start":{"DateTime":"2018-06-14T10:00:00","TimeZone":"UTC"},"End":{"DateTime":"2018-06-14T11:00:00","TimeZone":"UTC"}}`))
+	})
+	defer teardown()
+	calendar := api.RetrieveOutlookCalendar("calendar", "", &api.OutlookAccount{TokenType: "Bearer", AccessToken: "token", AnchorMailbox: "travis@example.com"})
+
+	outlookEvent := new(api.OutlookEvent)
+	err := convert.Convert(recurringGoogleEvent("RRULE:FREQ=YEARLY"), outlookEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	outlookEvent.SetCalendar(calendar)
+	err = outlookEvent.Create()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	// the parts that depend on the start of the event are completed before sending it
+	expected := api.OutlookRecurrencePattern{Type: "AbsoluteYearly", Interval: 1, DayOfMonth: 14, Month: 6}
+	if sent.Recurrence == nil || !reflect.DeepEqual(sent.Recurrence.Pattern, expected) {
+		t.Fatalf("something went wrong. Expected pattern %+v found %+v", expected, sent.Recurrence)
+	}
+	if sent.Recurrence.Range.StartDate != "2018-06-14" {
+		t.Fatalf("something went wrong. Expected start date 2018-06-14 found %s", sent.Recurrence.Range.StartDate)
+	}
+}
+
+func TestRecurrence_EndDates(t *testing.T) {
+	var sent api.OutlookEvent
+	_, teardown := setupStandIn(map[string]string{
+		"outlook/calendars/id/events": "/calendars/%s/events",
+		"outlook/events/id":           "/events/%s",
+	}, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"Id":"allday","Subject":"All day","Body":{"ContentType":"Text","Content":"Description"},"IsAllDay":true,"Start":{"DateTime":"2018-06-14T00:00:00","TimeZone":"UTC"},"End":{"DateTime":"2018-06-15T00:00:00","TimeZone":"UTC"},"Recurrence":{"Pattern":{"Type":"Daily","Interval":1},"Range":{"Type":"EndDate","StartDate":"2018-06-14","EndDate":"2018-06-20"}}}`))
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &sent)
+		w.Write([]byte(`{"Id":"created","Start":{"DateTime":"2018-06-14T10:00:00","TimeZone":"UTC"},"End":{"DateTime":"2018-06-14T11:00:00","TimeZone":"UTC"}}`))
+	})
+	defer teardown()
+	calendar := api.RetrieveOutlookCalendar("calendar", "", &api.OutlookAccount{TokenType: "Bearer", AccessToken: "token", AnchorMailbox: "travis@example.com"})
+
+	// the day of an UNTIL with time is the one of the time zone of the series
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database not available: %s", err.Error())
+	}
+	start := time.Date(2018, 6, 14, 20, 0, 0, 0, location)
+	googleEvent := &api.GoogleEvent{
+		Subject:     "Recurring",
+		Start:       &api.GoogleTime{DateTime: start, TimeZone: location},
+		End:         &api.GoogleTime{DateTime: start.Add(time.Hour), TimeZone: location},
+		Recurrences: []string{"RRULE:FREQ=DAILY;UNTIL=20180621T000000Z"},
+	}
+	outlookEvent := new(api.OutlookEvent)
+	err = convert.Convert(googleEvent, outlookEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	outlookEvent.SetCalendar(calendar)
+	err = outlookEvent.Create()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if sent.Recurrence == nil || sent.Recurrence.Range.EndDate != "2018-06-20" {
+		t.Fatalf("something went wrong. Expected end date 2018-06-20 found %+v", sent.Recurrence)
+	}
+
+	// all day series keep an UNTIL without time
+	event, err := calendar.GetEvent("allday")
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	googleEvent = new(api.GoogleEvent)
+	err = convert.Convert(event, googleEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if len(googleEvent.Recurrences) != 1 || googleEvent.Recurrences[0] != "RRULE:FREQ=DAILY;UNTIL=20180620" {
+		t.Errorf("something went wrong. Expected RRULE:FREQ=DAILY;UNTIL=20180620 found %v", googleEvent.Recurrences)
+	}
+}
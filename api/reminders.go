@@ -0,0 +1,45 @@
+package api
+
+import "context"
+
+// Method used by the reminders written on Google, as Outlook reminders are popups
+const googleReminderMethod = "popup"
+
+// Interface for events whose reminders are written on the events synced with them
+type remindersReader interface {
+	// Method that returns the minutes before the start of each reminder of the event.
+	// Returns false if the reminders of the event are not known
+	readReminders(context.Context) ([]int, bool)
+}
+
+// Interface for events that are written with the reminders of the events synced with them
+type remindersWriter interface {
+	// Method that writes the reminders given as minutes before the start. No minutes turns the reminders off
+	writeReminders([]int)
+}
+
+// Function that collapses several reminders into the only one a calendar can have.
+// The earliest reminder is kept, so the event is never noticed later than on the calendar it comes from
+func earliestReminder(minutes []int) (earliest int) {
+	for i, m := range minutes {
+		if i == 0 || m > earliest {
+			earliest = m
+		}
+	}
+	return
+}
+
+// Function that writes the reminders of an event on another one, if both have them
+func writeReminders(ctx context.Context, from EventManager, to EventManager) {
+	reader, ok := from.(remindersReader)
+	if !ok {
+		return
+	}
+	writer, ok := to.(remindersWriter)
+	if !ok {
+		return
+	}
+	if minutes, known := reader.readReminders(ctx); known {
+		writer.writeReminders(minutes)
+	}
+}
@@ -0,0 +1,145 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/TetAlius/GoSyncMyCalendars/api"
+)
+
+func reminderEvents() (*api.GoogleEvent, *api.OutlookEvent) {
+	googleEvent, outlookEvent := locationEvents()
+	googleEvent.SetCalendar(api.RetrieveGoogleCalendar("primary", "", &api.GoogleAccount{TokenType: "Bearer", AccessToken: "token"}))
+	outlookEvent.SetCalendar(api.RetrieveOutlookCalendar("calendar", "", &api.OutlookAccount{}))
+	return googleEvent, outlookEvent
+}
+
+func TestReminders_GoogleToOutlook(t *testing.T) {
+	testCases := []struct {
+		reminders *api.GoogleEventReminder
+		on        *bool
+		minutes   *int32
+	}{
+		// the earliest reminder is kept
+		{&api.GoogleEventReminder{Overrides: []api.GoogleReminder{{Method: "popup", Minutes: 10}, {Method: "email", Minutes: 1440}, {Method: "popup", Minutes: 60}}}, newBool(true), newInt32(1440)},
+		{&api.GoogleEventReminder{Overrides: []api.GoogleReminder{{Method: "popup", Minutes: 0}}}, newBool(true), newInt32(0)},
+		{&api.GoogleEventReminder{}, newBool(false), nil},
+		// unknown reminders are not written
+		{nil, nil, nil},
+	}
+	for _, testCase := range testCases {
+		googleEvent, outlookEvent := reminderEvents()
+		googleEvent.Reminders = testCase.reminders
+		err := api.ConvertEvent(googleEvent, outlookEvent)
+		if err != nil {
+			t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+		}
+		if !reflect.DeepEqual(outlookEvent.IsReminderOn, testCase.on) || !reflect.DeepEqual(outlookEvent.ReminderMinutesBeforeStart, testCase.minutes) {
+			t.Fatalf("something went wrong. Expected reminder %v of %v minutes found %v of %v", testCase.on, testCase.minutes, outlookEvent.IsReminderOn, outlookEvent.ReminderMinutesBeforeStart)
+		}
+	}
+
+	// a reminder at the start is written
+	googleEvent, outlookEvent := reminderEvents()
+	googleEvent.Reminders = testCases[1].reminders
+	api.ConvertEvent(googleEvent, outlookEvent)
+	data, err := json.Marshal(outlookEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if !strings.Contains(string(data), `"ReminderMinutesBeforeStart":0`) || !strings.Contains(string(data), `"IsReminderOn":true`) {
+		t.Fatalf("something went wrong. Expected reminder at the start found %s", data)
+	}
+}
+
+func TestReminders_GoogleDefault(t *testing.T) {
+	var requests int
+	_, teardown := setupStandIn(map[string]string{"google/calendars/id": "/calendarList/%s", "google/calendars/id/events": "/calendars/%s/events"}, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if strings.HasPrefix(r.URL.Path, "/calendarList/") {
+			w.Write([]byte(`{"id":"primary","defaultReminders":[{"method":"popup","minutes":30},{"method":"email","minutes":10}]}`))
+			return
+		}
+		w.Write([]byte(`{"defaultReminders":[{"method":"popup","minutes":45}],"items":[{"id":"event","status":"confirmed","reminders":{"useDefault":true},"start":{"dateTime":"2018-06-14T15:00:00Z"},"end":{"dateTime":"2018-06-14T16:00:00Z"}}]}`))
+	})
+	defer teardown()
+
+	// defaults are retrieved once from the calendar
+	googleEvent, _ := reminderEvents()
+	googleEvent.Reminders = &api.GoogleEventReminder{UseDefault: true}
+	for i := 0; i < 2; i++ {
+		outlookEvent := &api.OutlookEvent{}
+		err := api.ConvertEvent(googleEvent, outlookEvent)
+		if err != nil {
+			t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+		}
+		if outlookEvent.ReminderMinutesBeforeStart == nil || *outlookEvent.ReminderMinutesBeforeStart != 30 {
+			t.Fatalf("something went wrong. Expected reminder of 30 minutes found %v", outlookEvent.ReminderMinutesBeforeStart)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("something went wrong. Expected 1 request found %d", requests)
+	}
+
+	// defaults given with the events are used
+	calendar := api.RetrieveGoogleCalendar("primary", "", &api.GoogleAccount{TokenType: "Bearer", AccessToken: "token"})
+	err := calendar.ForEachEventPage(func(events []api.EventManager) error {
+		for _, event := range events {
+			outlookEvent := &api.OutlookEvent{}
+			err := api.ConvertEvent(event, outlookEvent)
+			if err != nil {
+				return err
+			}
+			if outlookEvent.ReminderMinutesBeforeStart == nil || *outlookEvent.ReminderMinutesBeforeStart != 45 {
+				t.Fatalf("something went wrong. Expected reminder of 45 minutes found %v", outlookEvent.ReminderMinutesBeforeStart)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	if requests != 2 {
+		t.Fatalf("something went wrong. Expected 2 requests found %d", requests)
+	}
+}
+
+func TestReminders_OutlookToGoogle(t *testing.T) {
+	testCases := []struct {
+		on        *bool
+		minutes   *int32
+		reminders *api.GoogleEventReminder
+		data      string
+	}{
+		{newBool(true), newInt32(15), &api.GoogleEventReminder{Overrides: []api.GoogleReminder{{Method: "popup", Minutes: 15}}}, `{"useDefault":false,"overrides":[{"method":"popup","minutes":15}]}`},
+		{newBool(false), newInt32(15), &api.GoogleEventReminder{Overrides: []api.GoogleReminder{}}, `{"useDefault":false,"overrides":[]}`},
+		{nil, nil, nil, "null"},
+	}
+	for _, testCase := range testCases {
+		googleEvent, outlookEvent := reminderEvents()
+		outlookEvent.IsReminderOn = testCase.on
+		outlookEvent.ReminderMinutesBeforeStart = testCase.minutes
+		err := api.ConvertEvent(outlookEvent, googleEvent)
+		if err != nil {
+			t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+		}
+		if !reflect.DeepEqual(googleEvent.Reminders, testCase.reminders) {
+			t.Fatalf("something went wrong. Expected %+v found %+v", testCase.reminders, googleEvent.Reminders)
+		}
+		data, _ := json.Marshal(googleEvent.Reminders)
+		if string(data) != testCase.data {
+			t.Fatalf("something went wrong. Expected %s found %s", testCase.data, data)
+		}
+	}
+}
+
+func newBool(b bool) *bool {
+	return &b
+}
+
+func newInt32(i int32) *int32 {
+	return &i
+}
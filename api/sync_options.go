@@ -0,0 +1,88 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// How the attendees of an event are written on the events synced with it
+type AttendeesMode int
+
+const (
+	// Attendees are listed on the description of the synced events, so no invitation is sent
+	AttendeesInBody AttendeesMode = iota
+	// Attendees are copied as attendees of the synced events
+	AttendeesCopied
+)
+
+// Options of a relation of calendars about how its events are synchronized.
+// They are stored on the principal calendar of the relation, except the mapping of categories that is stored for the user
+type SyncOptions struct {
+	// How the attendees are written on the synced events
+	Attendees AttendeesMode
+	// Whether the attendees are notified of the writes on the synced events.
+	// By default the writes of the synchronization send no notification
+	NotifyAttendees bool
+	// Free/busy statuses written on calendars that do not have them
+	FreeBusyFallbacks FreeBusyFallbacks
+	// Mapping of the user between categories and colors. Nil means the default one
+	CategoryColors CategoryColors
+	// Limits of the attachments linked from the synced events
+	Attachments AttachmentLimits
+}
+
+// Free or busy, written instead of each status that only some calendars have.
+// Statuses not given fall back to busy, except working elsewhere that falls back to free
+type FreeBusyFallbacks struct {
+	Tentative        string
+	OutOfOffice      string
+	WorkingElsewhere string
+}
+
+// Method that returns the status written instead of the given one on calendars that are only free or busy
+func (fallbacks FreeBusyFallbacks) resolve(status string) string {
+	var fallback string
+	switch status {
+	case FreeBusyFree, FreeBusyBusy:
+		return status
+	case FreeBusyTentative:
+		fallback = fallbacks.Tentative
+	case FreeBusyOutOfOffice:
+		fallback = fallbacks.OutOfOffice
+	case FreeBusyWorkingElsewhere:
+		fallback = fallbacks.WorkingElsewhere
+		if len(fallback) == 0 {
+			fallback = FreeBusyFree
+		}
+	}
+	if fallback != FreeBusyFree {
+		return FreeBusyBusy
+	}
+	return fallback
+}
+
+// Interface for events whose attendees are written depending on the options of the relation
+type attendeesWriter interface {
+	// Method that writes the attendees already converted as the mode says
+	writeAttendees(AttendeesMode)
+}
+
+// Interface for events whose writes notify their attendees depending on the options of the relation
+type notificationsWriter interface {
+	// Method that sets whether the next writes of the event notify its attendees
+	notifyAttendees(bool)
+}
+
+// Function that parses a limit given by the user, like the days of a sync window or
+// the largest size of the attachments. Empty means not limited, and negative values are not valid
+func ParseLimit(value string) (limit int, err error) {
+	if len(value) == 0 {
+		return 0, nil
+	}
+	limit, err = strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return 0, errors.New(fmt.Sprintf("limit %q is not a number of 0 or more", value))
+	}
+	return
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+// Range of days, around the current date, whose events are synchronized
+// inside a relation of calendars. A bound with 0 days does not limit that side
+type SyncWindow struct {
+	// Number of days before the current date
+	DaysBefore int
+	// Number of days after the current date
+	DaysAfter int
+	// Whether the synced events that fall out of the window must be removed.
+	// Only the principal calendar of the relation prunes its events
+	Prune bool
+}
+
+// Method that returns whether the window limits the synchronization
+func (window SyncWindow) IsLimited() bool {
+	return window.DaysBefore != 0 || window.DaysAfter != 0
+}
+
+// Method that returns the start of the window. It is zero if not limited
+func (window SyncWindow) Start(now time.Time) time.Time {
+	if window.DaysBefore == 0 {
+		return time.Time{}
+	}
+	day := now.UTC().Truncate(24 * time.Hour)
+	return day.AddDate(0, 0, -window.DaysBefore)
+}
+
+// Method that returns the end of the window. It is zero if not limited
+func (window SyncWindow) End(now time.Time) time.Time {
+	if window.DaysAfter == 0 {
+		return time.Time{}
+	}
+	day := now.UTC().Truncate(24 * time.Hour)
+	return day.AddDate(0, 0, window.DaysAfter+1)
+}
+
+// Method that returns whether the event takes place inside the window.
+// Events whose dates can not be known are always inside
+func (window SyncWindow) ContainsEvent(event EventManager, now time.Time) bool {
+	if !window.IsLimited() {
+		return true
+	}
+	start, end, err := event.GetTimeRange()
+	if err != nil {
+		return true
+	}
+	windowStart := window.Start(now)
+	windowEnd := window.End(now)
+	if !windowStart.IsZero() && !end.IsZero() && !end.After(windowStart) {
+		return false
+	}
+	if !windowEnd.IsZero() && !start.Before(windowEnd) {
+		return false
+	}
+	return true
+}
+
+// Method that returns a human readable description of the window
+func (window SyncWindow) String() string {
+	before := "all past events"
+	if window.DaysBefore != 0 {
+		before = fmt.Sprintf("%d days before", window.DaysBefore)
+	}
+	after := "all future events"
+	if window.DaysAfter != 0 {
+		after = fmt.Sprintf("%d days after", window.DaysAfter)
+	}
+	return fmt.Sprintf("%s and %s", before, after)
+}
+
+// Function that returns the range of an event given its start and end.
+// Recurring events have no end, as their occurrences may go on
+func timeRange(start time.Time, end time.Time, recurring bool) (time.Time, time.Time, error) {
+	if start.IsZero() {
+		return time.Time{}, time.Time{}, fmt.Errorf("event has no start")
+	}
+	if recurring {
+		return start, time.Time{}, nil
+	}
+	return start, end, nil
+}
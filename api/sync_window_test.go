@@ -0,0 +1,74 @@
+package api_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TetAlius/GoSyncMyCalendars/api"
+)
+
+func TestSyncWindow_Bounds(t *testing.T) {
+	now := time.Date(2018, 6, 14, 15, 30, 0, 0, time.UTC)
+
+	// a window without days does not limit the synchronization
+	var window api.SyncWindow
+	if window.IsLimited() || !window.Start(now).IsZero() || !window.End(now).IsZero() {
+		t.Fatal("something went wrong. Expected window not limited")
+	}
+
+	window = api.SyncWindow{DaysBefore: 30, DaysAfter: 365}
+	if !window.IsLimited() {
+		t.Fatal("something went wrong. Expected window limited")
+	}
+	if start := window.Start(now); !start.Equal(time.Date(2018, 5, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("something went wrong. Expected start 2018-05-15 found %s", start)
+	}
+	if end := window.End(now); !end.Equal(time.Date(2019, 6, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("something went wrong. Expected end 2019-06-15 found %s", end)
+	}
+
+	// only one side limited
+	window = api.SyncWindow{DaysAfter: 10}
+	if !window.Start(now).IsZero() || window.End(now).IsZero() {
+		t.Fatal("something went wrong. Expected only end limited")
+	}
+}
+
+func TestSyncWindow_ContainsEvent(t *testing.T) {
+	now := time.Date(2018, 6, 14, 15, 30, 0, 0, time.UTC)
+	window := api.SyncWindow{DaysBefore: 30, DaysAfter: 365}
+	event := func(start time.Time, end time.Time) *api.CalDAVEvent {
+		return &api.CalDAVEvent{ID: "event", Start: &api.CalDAVTime{DateTime: start}, End: &api.CalDAVTime{DateTime: end}}
+	}
+
+	// event inside the window
+	if !window.ContainsEvent(event(now, now.Add(time.Hour)), now) {
+		t.Fatal("something went wrong. Expected event inside window")
+	}
+	// event that ended before the window
+	if window.ContainsEvent(event(now.AddDate(-1, 0, 0), now.AddDate(-1, 0, 0).Add(time.Hour)), now) {
+		t.Fatal("something went wrong. Expected past event out of window")
+	}
+	// event that starts after the window
+	if window.ContainsEvent(event(now.AddDate(2, 0, 0), now.AddDate(2, 0, 0).Add(time.Hour)), now) {
+		t.Fatal("something went wrong. Expected future event out of window")
+	}
+	// event that started before the window and ends inside it
+	if !window.ContainsEvent(event(now.AddDate(0, -2, 0), now.AddDate(0, 0, -1)), now) {
+		t.Fatal("something went wrong. Expected overlapping event inside window")
+	}
+	// recurring events have no end, so old series can still have occurrences inside
+	recurring := event(now.AddDate(-5, 0, 0), now.AddDate(-5, 0, 0).Add(time.Hour))
+	recurring.Recurrences = []string{"RRULE:FREQ=WEEKLY"}
+	if !window.ContainsEvent(recurring, now) {
+		t.Fatal("something went wrong. Expected recurring event inside window")
+	}
+	// events without dates can not be judged, so they are kept
+	if !window.ContainsEvent(&api.CalDAVEvent{ID: "event"}, now) {
+		t.Fatal("something went wrong. Expected event without dates inside window")
+	}
+	// any event is inside a window not limited
+	if !(api.SyncWindow{}).ContainsEvent(event(now.AddDate(-10, 0, 0), now.AddDate(-10, 0, 0)), now) {
+		t.Fatal("something went wrong. Expected event inside window not limited")
+	}
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+// Specific error for a time zone that is neither an IANA time zone nor a windows one that can be mapped to it
+type UnknownTimeZoneError struct {
+	Name string
+}
+
+// Method implementing error interface
+func (err UnknownTimeZoneError) Error() string {
+	return fmt.Sprintf("time zone %s is not an IANA time zone nor a windows time zone known", err.Name)
+}
+
+// Windows time zone, used by outlook, and the IANA time zones it is mapped to
+type cldrTimeZone struct {
+	Windows string
+	// The first one is the one of the territory 001, the windows time zone is loaded as it
+	IANA []string
+}
+
+// Mapping between windows and IANA time zones, bundled from windowsZones.xml of the
+// Unicode CLDR so time zones are translated without network access.
+// IANA names renamed since then are also given, so they are mapped to the same windows time zone
+var cldrTimeZones = []cldrTimeZone{
+	{"Dateline Standard Time", []string{"Etc/GMT+12"}},
+	{"UTC-11", []string{"Etc/GMT+11", "Pacific/Pago_Pago", "Pacific/Niue", "Pacific/Midway"}},
+	{"Aleutian Standard Time", []string{"America/Adak"}},
+	{"Hawaiian Standard Time", []string{"Pacific/Honolulu", "Pacific/Rarotonga", "Pacific/Tahiti", "Pacific/Johnston", "Etc/GMT+10"}},
+	{"Marquesas Standard Time", []string{"Pacific/Marquesas"}},
+	{"Alaskan Standard Time", []string{"America/Anchorage", "America/Juneau", "America/Metlakatla", "America/Nome", "America/Sitka", "America/Yakutat"}},
+	{"UTC-09", []string{"Etc/GMT+9", "Pacific/Gambier"}},
+	{"Pacific Standard Time (Mexico)", []string{"America/Tijuana", "America/Santa_Isabel"}},
+	{"UTC-08", []string{"Etc/GMT+8", "Pacific/Pitcairn"}},
+	{"Pacific Standard Time", []string{"America/Los_Angeles", "America/Vancouver", "PST8PDT"}},
+	{"US Mountain Standard Time", []string{"America/Phoenix", "America/Creston", "America/Dawson_Creek", "America/Fort_Nelson", "America/Hermosillo", "Etc/GMT+7"}},
+	{"Mountain Standard Time (Mexico)", []string{"America/Mazatlan"}},
+	{"Mountain Standard Time", []string{"America/Denver", "America/Edmonton", "America/Cambridge_Bay", "America/Inuvik", "America/Yellowknife", "America/Ciudad_Juarez", "America/Boise", "MST7MDT"}},
+	{"Yukon Standard Time", []string{"America/Whitehorse", "America/Dawson"}},
+	{"Central America Standard Time", []string{"America/Guatemala", "America/Belize", "America/Costa_Rica", "Pacific/Galapagos", "America/Tegucigalpa", "America/Managua", "America/El_Salvador", "Etc/GMT+6"}},
+	{"Central Standard Time", []string{"America/Chicago", "America/Winnipeg", "America/Rainy_River", "America/Rankin_Inlet", "America/Resolute", "America/Matamoros", "America/Ojinaga", "America/Indiana/Knox", "America/Indiana/Tell_City", "America/Menominee", "America/North_Dakota/Beulah", "America/North_Dakota/Center", "America/North_Dakota/New_Salem", "CST6CDT"}},
+	{"Easter Island Standard Time", []string{"Pacific/Easter"}},
+	{"Central Standard Time (Mexico)", []string{"America/Mexico_City", "America/Bahia_Banderas", "America/Merida", "America/Monterrey", "America/Chihuahua"}},
+	{"Canada Central Standard Time", []string{"America/Regina", "America/Swift_Current"}},
+	{"SA Pacific Standard Time", []string{"America/Bogota", "America/Rio_Branco", "America/Eirunepe", "America/Coral_Harbour", "America/Atikokan", "America/Guayaquil", "America/Jamaica", "America/Cayman", "America/Panama", "America/Lima", "Etc/GMT+5"}},
+	{"Eastern Standard Time (Mexico)", []string{"America/Cancun"}},
+	{"Eastern Standard Time", []string{"America/New_York", "America/Nassau", "America/Toronto", "America/Iqaluit", "America/Montreal", "America/Nipigon", "America/Pangnirtung", "America/Thunder_Bay", "America/Detroit", "America/Indiana/Petersburg", "America/Indiana/Vincennes", "America/Indiana/Winamac", "America/Kentucky/Monticello", "America/Louisville", "America/Kentucky/Louisville", "EST5EDT"}},
+	{"Haiti Standard Time", []string{"America/Port-au-Prince"}},
+	{"Cuba Standard Time", []string{"America/Havana"}},
+	{"US Eastern Standard Time", []string{"America/Indianapolis", "America/Indiana/Indianapolis", "America/Indiana/Marengo", "America/Indiana/Vevay"}},
+	{"Turks And Caicos Standard Time", []string{"America/Grand_Turk"}},
+	{"Paraguay Standard Time", []string{"America/Asuncion"}},
+	{"Atlantic Standard Time", []string{"America/Halifax", "Atlantic/Bermuda", "America/Glace_Bay", "America/Goose_Bay", "America/Moncton", "America/Thule"}},
+	{"Venezuela Standard Time", []string{"America/Caracas"}},
+	{"Central Brazilian Standard Time", []string{"America/Cuiaba", "America/Campo_Grande"}},
+	{"SA Western Standard Time", []string{"America/La_Paz", "America/Antigua", "America/Anguilla", "America/Aruba", "America/Barbados", "America/St_Barthelemy", "America/Kralendijk", "America/Manaus", "America/Boa_Vista", "America/Porto_Velho", "America/Blanc-Sablon", "America/Curacao", "America/Dominica", "America/Santo_Domingo", "America/Grenada", "America/Guadeloupe", "America/Guyana", "America/St_Kitts", "America/St_Lucia", "America/Marigot", "America/Martinique", "America/Montserrat", "America/Puerto_Rico", "America/Lower_Princes", "America/Port_of_Spain", "America/St_Vincent", "America/Tortola", "America/St_Thomas", "Etc/GMT+4"}},
+	{"Pacific SA Standard Time", []string{"America/Santiago"}},
+	{"Newfoundland Standard Time", []string{"America/St_Johns"}},
+	{"Tocantins Standard Time", []string{"America/Araguaina"}},
+	{"E. South America Standard Time", []string{"America/Sao_Paulo"}},
+	{"SA Eastern Standard Time", []string{"America/Cayenne", "Antarctica/Rothera", "Antarctica/Palmer", "America/Fortaleza", "America/Belem", "America/Maceio", "America/Recife", "America/Santarem", "Atlantic/Stanley", "America/Paramaribo", "Etc/GMT+3"}},
+	{"Argentina Standard Time", []string{"America/Buenos_Aires", "America/Argentina/Buenos_Aires", "America/Argentina/La_Rioja", "America/Argentina/Rio_Gallegos", "America/Argentina/Salta", "America/Argentina/San_Juan", "America/Argentina/San_Luis", "America/Argentina/Tucuman", "America/Argentina/Ushuaia", "America/Catamarca", "America/Argentina/Catamarca", "America/Cordoba", "America/Argentina/Cordoba", "America/Jujuy", "America/Argentina/Jujuy", "America/Mendoza", "America/Argentina/Mendoza"}},
+	{"Greenland Standard Time", []string{"America/Godthab", "America/Nuuk"}},
+	{"Montevideo Standard Time", []string{"America/Montevideo"}},
+	{"Magallanes Standard Time", []string{"America/Punta_Arenas"}},
+	{"Saint Pierre Standard Time", []string{"America/Miquelon"}},
+	{"Bahia Standard Time", []string{"America/Bahia"}},
+	{"UTC-02", []string{"Etc/GMT+2", "America/Noronha", "Atlantic/South_Georgia"}},
+	{"Azores Standard Time", []string{"Atlantic/Azores", "America/Scoresbysund"}},
+	{"Cape Verde Standard Time", []string{"Atlantic/Cape_Verde", "Etc/GMT+1"}},
+	{"UTC", []string{"Etc/UTC", "UTC", "Etc/GMT"}},
+	{"GMT Standard Time", []string{"Europe/London", "Atlantic/Canary", "Atlantic/Faeroe", "Atlantic/Faroe", "Europe/Guernsey", "Europe/Dublin", "Europe/Isle_of_Man", "Europe/Jersey", "Europe/Lisbon", "Atlantic/Madeira"}},
+	{"Greenwich Standard Time", []string{"Atlantic/Reykjavik", "Africa/Ouagadougou", "Africa/Abidjan", "Africa/Accra", "America/Danmarkshavn", "Africa/Banjul", "Africa/Conakry", "Africa/Bissau", "Africa/Monrovia", "Africa/Bamako", "Africa/Nouakchott", "Atlantic/St_Helena", "Africa/Freetown", "Africa/Dakar", "Africa/Lome"}},
+	{"Sao Tome Standard Time", []string{"Africa/Sao_Tome"}},
+	{"Morocco Standard Time", []string{"Africa/Casablanca", "Africa/El_Aaiun"}},
+	{"W. Europe Standard Time", []string{"Europe/Berlin", "Europe/Andorra", "Europe/Vienna", "Europe/Zurich", "Europe/Busingen", "Europe/Gibraltar", "Europe/Rome", "Europe/Vaduz", "Europe/Luxembourg", "Europe/Monaco", "Europe/Malta", "Europe/Amsterdam", "Europe/Oslo", "Europe/Stockholm", "Arctic/Longyearbyen", "Europe/San_Marino", "Europe/Vatican"}},
+	{"Central Europe Standard Time", []string{"Europe/Budapest", "Europe/Tirane", "Europe/Prague", "Europe/Podgorica", "Europe/Belgrade", "Europe/Ljubljana", "Europe/Bratislava"}},
+	{"Romance Standard Time", []string{"Europe/Paris", "Europe/Brussels", "Europe/Copenhagen", "Europe/Madrid", "Africa/Ceuta"}},
+	{"Central European Standard Time", []string{"Europe/Warsaw", "Europe/Sarajevo", "Europe/Zagreb", "Europe/Skopje"}},
+	{"W. Central Africa Standard Time", []string{"Africa/Lagos", "Africa/Luanda", "Africa/Porto-Novo", "Africa/Kinshasa", "Africa/Bangui", "Africa/Brazzaville", "Africa/Douala", "Africa/Algiers", "Africa/Libreville", "Africa/Malabo", "Africa/Niamey", "Africa/Ndjamena", "Africa/Tunis", "Etc/GMT-1"}},
+	{"Jordan Standard Time", []string{"Asia/Amman"}},
+	{"GTB Standard Time", []string{"Europe/Bucharest", "Asia/Nicosia", "Asia/Famagusta", "Europe/Athens"}},
+	{"Middle East Standard Time", []string{"Asia/Beirut"}},
+	{"Egypt Standard Time", []string{"Africa/Cairo"}},
+	{"E. Europe Standard Time", []string{"Europe/Chisinau"}},
+	{"Syria Standard Time", []string{"Asia/Damascus"}},
+	{"West Bank Standard Time", []string{"Asia/Hebron", "Asia/Gaza"}},
+	{"South Africa Standard Time", []string{"Africa/Johannesburg", "Africa/Bujumbura", "Africa/Gaborone", "Africa/Lubumbashi", "Africa/Maseru", "Africa/Blantyre", "Africa/Maputo", "Africa/Kigali", "Africa/Mbabane", "Africa/Lusaka", "Africa/Harare", "Etc/GMT-2"}},
+	{"FLE Standard Time", []string{"Europe/Kiev", "Europe/Kyiv", "Europe/Mariehamn", "Europe/Sofia", "Europe/Tallinn", "Europe/Helsinki", "Europe/Vilnius", "Europe/Riga", "Europe/Uzhgorod", "Europe/Zaporozhye"}},
+	{"Israel Standard Time", []string{"Asia/Jerusalem"}},
+	{"South Sudan Standard Time", []string{"Africa/Juba"}},
+	{"Kaliningrad Standard Time", []string{"Europe/Kaliningrad"}},
+	{"Sudan Standard Time", []string{"Africa/Khartoum"}},
+	{"Libya Standard Time", []string{"Africa/Tripoli"}},
+	{"Namibia Standard Time", []string{"Africa/Windhoek"}},
+	{"Arabic Standard Time", []string{"Asia/Baghdad"}},
+	{"Turkey Standard Time", []string{"Europe/Istanbul"}},
+	{"Arab Standard Time", []string{"Asia/Riyadh", "Asia/Bahrain", "Asia/Kuwait", "Asia/Qatar", "Asia/Aden"}},
+	{"Belarus Standard Time", []string{"Europe/Minsk"}},
+	{"Russian Standard Time", []string{"Europe/Moscow", "Europe/Kirov", "Europe/Simferopol"}},
+	{"E. Africa Standard Time", []string{"Africa/Nairobi", "Antarctica/Syowa", "Africa/Djibouti", "Africa/Asmera", "Africa/Asmara", "Africa/Addis_Ababa", "Indian/Comoro", "Indian/Antananarivo", "Africa/Mogadishu", "Africa/Dar_es_Salaam", "Africa/Kampala", "Indian/Mayotte", "Etc/GMT-3"}},
+	{"Volgograd Standard Time", []string{"Europe/Volgograd"}},
+	{"Iran Standard Time", []string{"Asia/Tehran"}},
+	{"Arabian Standard Time", []string{"Asia/Dubai", "Asia/Muscat", "Etc/GMT-4"}},
+	{"Astrakhan Standard Time", []string{"Europe/Astrakhan", "Europe/Ulyanovsk"}},
+	{"Azerbaijan Standard Time", []string{"Asia/Baku"}},
+	{"Russia Time Zone 3", []string{"Europe/Samara"}},
+	{"Mauritius Standard Time", []string{"Indian/Mauritius", "Indian/Reunion", "Indian/Mahe"}},
+	{"Saratov Standard Time", []string{"Europe/Saratov"}},
+	{"Georgian Standard Time", []string{"Asia/Tbilisi"}},
+	{"Caucasus Standard Time", []string{"Asia/Yerevan"}},
+	{"Afghanistan Standard Time", []string{"Asia/Kabul"}},
+	{"West Asia Standard Time", []string{"Asia/Tashkent", "Antarctica/Mawson", "Asia/Oral", "Asia/Aqtau", "Asia/Aqtobe", "Asia/Atyrau", "Indian/Maldives", "Indian/Kerguelen", "Asia/Dushanbe", "Asia/Ashgabat", "Asia/Samarkand", "Etc/GMT-5"}},
+	{"Ekaterinburg Standard Time", []string{"Asia/Yekaterinburg"}},
+	{"Pakistan Standard Time", []string{"Asia/Karachi"}},
+	{"Qyzylorda Standard Time", []string{"Asia/Qyzylorda"}},
+	{"India Standard Time", []string{"Asia/Calcutta", "Asia/Kolkata"}},
+	{"Sri Lanka Standard Time", []string{"Asia/Colombo"}},
+	{"Nepal Standard Time", []string{"Asia/Katmandu", "Asia/Kathmandu"}},
+	{"Central Asia Standard Time", []string{"Asia/Almaty", "Antarctica/Vostok", "Asia/Urumqi", "Indian/Chagos", "Asia/Bishkek", "Asia/Qostanay", "Etc/GMT-6"}},
+	{"Bangladesh Standard Time", []string{"Asia/Dhaka", "Asia/Thimphu"}},
+	{"Omsk Standard Time", []string{"Asia/Omsk"}},
+	{"Myanmar Standard Time", []string{"Asia/Rangoon", "Asia/Yangon", "Indian/Cocos"}},
+	{"SE Asia Standard Time", []string{"Asia/Bangkok", "Antarctica/Davis", "Indian/Christmas", "Asia/Jakarta", "Asia/Pontianak", "Asia/Phnom_Penh", "Asia/Vientiane", "Asia/Saigon", "Asia/Ho_Chi_Minh", "Etc/GMT-7"}},
+	{"Altai Standard Time", []string{"Asia/Barnaul"}},
+	{"W. Mongolia Standard Time", []string{"Asia/Hovd"}},
+	{"North Asia Standard Time", []string{"Asia/Krasnoyarsk", "Asia/Novokuznetsk"}},
+	{"N. Central Asia Standard Time", []string{"Asia/Novosibirsk"}},
+	{"Tomsk Standard Time", []string{"Asia/Tomsk"}},
+	{"China Standard Time", []string{"Asia/Shanghai", "Asia/Hong_Kong", "Asia/Macau"}},
+	{"North Asia East Standard Time", []string{"Asia/Irkutsk"}},
+	{"Singapore Standard Time", []string{"Asia/Singapore", "Asia/Brunei", "Asia/Makassar", "Asia/Kuala_Lumpur", "Asia/Kuching", "Asia/Manila", "Etc/GMT-8"}},
+	{"W. Australia Standard Time", []string{"Australia/Perth"}},
+	{"Taipei Standard Time", []string{"Asia/Taipei"}},
+	{"Ulaanbaatar Standard Time", []string{"Asia/Ulaanbaatar", "Asia/Choibalsan"}},
+	{"Aus Central W. Standard Time", []string{"Australia/Eucla"}},
+	{"Transbaikal Standard Time", []string{"Asia/Chita"}},
+	{"Tokyo Standard Time", []string{"Asia/Tokyo", "Asia/Jayapura", "Pacific/Palau", "Asia/Dili", "Etc/GMT-9"}},
+	{"North Korea Standard Time", []string{"Asia/Pyongyang"}},
+	{"Korea Standard Time", []string{"Asia/Seoul"}},
+	{"Yakutsk Standard Time", []string{"Asia/Yakutsk", "Asia/Khandyga"}},
+	{"Cen. Australia Standard Time", []string{"Australia/Adelaide", "Australia/Broken_Hill"}},
+	{"AUS Central Standard Time", []string{"Australia/Darwin"}},
+	{"E. Australia Standard Time", []string{"Australia/Brisbane", "Australia/Lindeman"}},
+	{"AUS Eastern Standard Time", []string{"Australia/Sydney", "Australia/Melbourne"}},
+	{"West Pacific Standard Time", []string{"Pacific/Port_Moresby", "Antarctica/DumontDUrville", "Pacific/Truk", "Pacific/Chuuk", "Pacific/Guam", "Pacific/Saipan", "Etc/GMT-10"}},
+	{"Tasmania Standard Time", []string{"Australia/Hobart", "Australia/Currie", "Antarctica/Macquarie"}},
+	{"Vladivostok Standard Time", []string{"Asia/Vladivostok", "Asia/Ust-Nera"}},
+	{"Lord Howe Standard Time", []string{"Australia/Lord_Howe"}},
+	{"Bougainville Standard Time", []string{"Pacific/Bougainville"}},
+	{"Russia Time Zone 10", []string{"Asia/Srednekolymsk"}},
+	{"Magadan Standard Time", []string{"Asia/Magadan"}},
+	{"Norfolk Standard Time", []string{"Pacific/Norfolk"}},
+	{"Sakhalin Standard Time", []string{"Asia/Sakhalin"}},
+	{"Central Pacific Standard Time", []string{"Pacific/Guadalcanal", "Antarctica/Casey", "Pacific/Ponape", "Pacific/Pohnpei", "Pacific/Kosrae", "Pacific/Noumea", "Pacific/Efate", "Etc/GMT-11"}},
+	{"Russia Time Zone 11", []string{"Asia/Kamchatka", "Asia/Anadyr"}},
+	{"New Zealand Standard Time", []string{"Pacific/Auckland", "Antarctica/McMurdo"}},
+	{"UTC+12", []string{"Etc/GMT-12", "Pacific/Tarawa", "Pacific/Majuro", "Pacific/Kwajalein", "Pacific/Nauru", "Pacific/Funafuti", "Pacific/Wake", "Pacific/Wallis"}},
+	{"Fiji Standard Time", []string{"Pacific/Fiji"}},
+	{"Chatham Islands Standard Time", []string{"Pacific/Chatham"}},
+	{"UTC+13", []string{"Etc/GMT-13", "Pacific/Enderbury", "Pacific/Kanton", "Pacific/Fakaofo"}},
+	{"Tonga Standard Time", []string{"Pacific/Tongatapu"}},
+	{"Samoa Standard Time", []string{"Pacific/Apia"}},
+	{"Line Islands Standard Time", []string{"Pacific/Kiritimati", "Etc/GMT-14"}},
+}
+
+// IANA time zone of each windows time zone, and windows time zone of each IANA one
+var (
+	ianaTimeZones    = make(map[string]string)
+	windowsTimeZones = make(map[string]string)
+)
+
+func init() {
+	for _, zone := range cldrTimeZones {
+		ianaTimeZones[zone.Windows] = zone.IANA[0]
+		for _, name := range zone.IANA {
+			windowsTimeZones[name] = zone.Windows
+		}
+	}
+}
+
+// Function that returns the location of the given IANA or windows time zone.
+// Empty time zones are UTC. Time zones that are not known give an UnknownTimeZoneError
+func loadTimeZone(name string) (*time.Location, error) {
+	if len(name) == 0 || name == "UTC" {
+		return time.UTC, nil
+	}
+	if iana, ok := ianaTimeZones[name]; ok {
+		name = iana
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		return nil, UnknownTimeZoneError{Name: name}
+	}
+	return location, nil
+}
+
+// Function that returns the windows time zone of the given location, which outlook writes events in.
+// Locations without windows time zone give an UnknownTimeZoneError
+func windowsTimeZone(location *time.Location) (string, error) {
+	name := timeZoneOrUTC(location).String()
+	if _, ok := ianaTimeZones[name]; ok {
+		return name, nil
+	}
+	if windows, ok := windowsTimeZones[name]; ok {
+		return windows, nil
+	}
+	return "", UnknownTimeZoneError{Name: name}
+}
+
+// Function that returns the given location, or UTC if not given
+func timeZoneOrUTC(location *time.Location) *time.Location {
+	if location == nil {
+		return time.UTC
+	}
+	return location
+}
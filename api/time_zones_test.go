@@ -0,0 +1,144 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TetAlius/GoSyncMyCalendars/api"
+)
+
+func TestTimeZones_GoogleToOutlook(t *testing.T) {
+	var created map[string]interface{}
+	_, teardown := setupStandIn(map[string]string{"outlook/calendars/id/events": "/calendars/%s/events"}, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &created)
+		w.Write(body)
+	})
+	defer teardown()
+
+	googleEvent := new(api.GoogleEvent)
+	err := json.Unmarshal([]byte(`{"id":"series","start":{"dateTime":"2018-03-08T10:00:00-05:00","timeZone":"America/New_York"},"end":{"dateTime":"2018-03-08T11:00:00-05:00","timeZone":"America/New_York"},"recurrence":["RRULE:FREQ=WEEKLY;COUNT=4"]}`), googleEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	googleEvent.SetCalendar(api.RetrieveGoogleCalendar("primary", "", &api.GoogleAccount{}))
+	outlookEvent := new(api.OutlookEvent)
+	outlookEvent.SetCalendar(api.RetrieveOutlookCalendar("calendar", "", &api.OutlookAccount{TokenType: "Bearer", AccessToken: "token", AnchorMailbox: "travis@example.com"}))
+	err = api.ConvertEvent(googleEvent, outlookEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	err = outlookEvent.Create()
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+
+	// the series is written on the time zone of the origin, so it does not shift after the change to DST
+	start := created["Start"].(map[string]interface{})
+	if start["DateTime"] != "2018-03-08T10:00:00" || start["TimeZone"] != "Eastern Standard Time" {
+		t.Fatalf("something went wrong. Expected 10:00 on Eastern Standard Time found %v", start)
+	}
+	recurrence := created["Recurrence"].(map[string]interface{})
+	if recurrence["RecurrenceTimeZone"] != "Eastern Standard Time" {
+		t.Fatalf("something went wrong. Expected recurrence on Eastern Standard Time found %v", recurrence["RecurrenceTimeZone"])
+	}
+}
+
+func TestTimeZones_OutlookToGoogle(t *testing.T) {
+	_, teardown := setupStandIn(map[string]string{"outlook/events/id": "/events/%s"}, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Prefer") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"Id":"event","Type":"SingleInstance","Subject":"Standup","Body":{"ContentType":"Text","Content":"Daily"},"OriginalStartTimeZone":"Romance Standard Time","OriginalEndTimeZone":"Romance Standard Time","Start":{"DateTime":"2018-06-14T08:00:00","TimeZone":"UTC"},"End":{"DateTime":"2018-06-14T09:00:00","TimeZone":"UTC"}}`))
+	})
+	defer teardown()
+	calendar := api.RetrieveOutlookCalendar("calendar", "", &api.OutlookAccount{TokenType: "Bearer", AccessToken: "token", AnchorMailbox: "travis@example.com"})
+	outlookEvent, err := calendar.GetEvent("event")
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	googleEvent := new(api.GoogleEvent)
+	googleEvent.SetCalendar(api.RetrieveGoogleCalendar("primary", "", &api.GoogleAccount{}))
+	err = api.ConvertEvent(outlookEvent, googleEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	contents, err := json.Marshal(googleEvent.Start)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	expected := `{"dateTime":"2018-06-14T10:00:00+02:00","timeZone":"Europe/Paris"}`
+	if string(contents) != expected {
+		t.Fatalf("something went wrong. Expected %s found %s", expected, contents)
+	}
+
+	// time zones not known are not guessed
+	googleEvent = new(api.GoogleEvent)
+	err = json.Unmarshal([]byte(`{"start":{"dateTime":"2018-06-14T10:00:00+02:00","timeZone":"Mars/Olympus_Mons"}}`), googleEvent)
+	if _, ok := err.(api.UnknownTimeZoneError); !ok {
+		t.Fatalf("something went wrong. Expected UnknownTimeZoneError found %v", err)
+	}
+}
+
+func TestTimeZones_Windows(t *testing.T) {
+	testCases := []struct {
+		windows  string
+		iana     string
+		dateTime string
+		utc      time.Time
+	}{
+		// after the end of DST on the US
+		{"Pacific Standard Time", "America/Los_Angeles", "2018-11-04T10:00:00", time.Date(2018, 11, 4, 18, 0, 0, 0, time.UTC)},
+		{"Romance Standard Time", "Europe/Paris", "2018-06-14T10:00:00", time.Date(2018, 6, 14, 8, 0, 0, 0, time.UTC)},
+		{"AUS Eastern Standard Time", "Australia/Sydney", "2018-06-14T10:00:00", time.Date(2018, 6, 14, 0, 0, 0, 0, time.UTC)},
+		{"UTC", "UTC", "2018-06-14T10:00:00", time.Date(2018, 6, 14, 10, 0, 0, 0, time.UTC)},
+	}
+	for _, testCase := range testCases {
+		date := new(api.OutlookDateTimeTimeZone)
+		data := fmt.Sprintf(`{"DateTime":"%s","TimeZone":"%s"}`, testCase.dateTime, testCase.windows)
+		err := json.Unmarshal([]byte(data), date)
+		if err != nil {
+			t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+		}
+		if date.TimeZone.String() != testCase.iana || !date.DateTime.Equal(testCase.utc) {
+			t.Fatalf("something went wrong. Expected %s on %s found %s on %s", testCase.utc, testCase.iana, date.DateTime, date.TimeZone)
+		}
+		contents, err := json.Marshal(date)
+		if err != nil {
+			t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+		}
+		if string(contents) != data {
+			t.Fatalf("something went wrong. Expected %s found %s", data, contents)
+		}
+	}
+
+	// IANA time zones are written by their windows name
+	googleEvent, outlookEvent := reminderEvents()
+	googleEvent.Start.TimeZone, _ = time.LoadLocation("Europe/Madrid")
+	err := api.ConvertEvent(googleEvent, outlookEvent)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	contents, _ := json.Marshal(outlookEvent.Start)
+	if !strings.Contains(string(contents), `"TimeZone":"Romance Standard Time"`) {
+		t.Fatalf("something went wrong. Expected Romance Standard Time found %s", contents)
+	}
+
+	// time zones without windows name can not be written on outlook
+	googleEvent, outlookEvent = reminderEvents()
+	googleEvent.Start.TimeZone, _ = time.LoadLocation("Antarctica/Troll")
+	err = api.ConvertEvent(googleEvent, outlookEvent)
+	if _, ok := err.(api.UnknownTimeZoneError); !ok {
+		t.Fatalf("something went wrong. Expected UnknownTimeZoneError found %v", err)
+	}
+	err = json.Unmarshal([]byte(`{"DateTime":"2018-06-14T10:00:00","TimeZone":"Olympus Mons Standard Time"}`), new(api.OutlookDateTimeTimeZone))
+	if _, ok := err.(api.UnknownTimeZoneError); !ok {
+		t.Fatalf("something went wrong. Expected UnknownTimeZoneError found %v", err)
+	}
+}
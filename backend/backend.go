@@ -25,6 +25,9 @@ import (
 	"github.com/getsentry/raven-go"
 )
 
+// Interval used to poll the calendars that can not notify changes
+const pollingInterval = 5 * time.Minute
+
 // Backend server
 type Server struct {
 	// IP of the server
@@ -36,7 +39,12 @@ type Server struct {
 	worker   *worker.Worker
 	database db.Database
 	ticker   *time.Ticker
-	sentry   *raven.Client
+	// ticker used for the calendars that can not notify changes
+	pollingTicker *time.Ticker
+	sentry        *raven.Client
+	// context of the requests to the providers, cancelled when the server stops
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // Method that process a requests to the server
@@ -47,9 +55,16 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // Function that creates a new backend given specific info
 func NewServer(ip string, port int, maxWorker int, database *sql.DB, sentry *raven.Client) *Server {
 	data := db.New(database, sentry)
-	server := Server{IP: net.ParseIP(ip), Port: port, mux: http.NewServeMux(), worker: worker.New(maxWorker, data), database: data, sentry: sentry}
+	server := Server{IP: net.ParseIP(ip), Port: port, mux: http.NewServeMux(), database: data, sentry: sentry}
+	server.ctx, server.cancel = context.WithCancel(context.Background())
+	// jobs of the worker are cancelled with the requests of the server
+	server.worker = worker.New(server.ctx, maxWorker, data)
+	// created before the server starts, so it can be stopped at any time
+	server.pollingTicker = time.NewTicker(pollingInterval)
 	server.mux.HandleFunc("/google/watcher", server.GoogleWatcherHandler)
 	server.mux.HandleFunc("/outlook/watcher", server.OutlookWatcherHandler)
+	server.mux.HandleFunc("/graph/watcher", server.GraphWatcherHandler)
+	server.mux.HandleFunc("/graph/lifecycle", server.GraphLifecycleHandler)
 	server.mux.HandleFunc("/accounts/", server.retrieveInfoHandler)
 	server.mux.HandleFunc("/subscribe/", server.subscribeCalendarHandler)
 	server.mux.HandleFunc("/refresh/", server.refreshHandler)
@@ -68,6 +83,7 @@ func (s *Server) Start() (err error) {
 	}
 	log.Infof("Backend server listening at %s", laddr)
 	go s.manageSubscriptions()
+	go s.managePolling()
 
 	err = s.server.ListenAndServe()
 	if err != nil && err != http.ErrServerClosed {
@@ -78,8 +94,11 @@ func (s *Server) Start() (err error) {
 
 // Method that stops the backend
 func (s *Server) Stop() (err error) {
-	ctx, _ := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
 	log.Debugf("Stopping backend with ctx: %s", ctx)
+	// requests to the providers and jobs of the worker are cancelled so the handlers waiting for them return
+	s.cancel()
 	var returnErr error
 	err = s.server.Shutdown(ctx)
 	if err != nil {
@@ -92,6 +111,7 @@ func (s *Server) Stop() (err error) {
 		returnErr = err
 	}
 	s.ticker.Stop()
+	s.pollingTicker.Stop()
 	err = s.database.Close()
 	if err != nil {
 		s.sentry.CaptureErrorAndWait(err, map[string]string{"stopping": "backend database"})
@@ -127,20 +147,24 @@ func (s *Server) subscribeCalendarHandler(w http.ResponseWriter, r *http.Request
 			return
 		}
 		log.Debugf("%s", calendar)
-		err = prepareSync(calendar)
+		ctx, cancel := s.requestContext(r)
+		defer cancel()
+		err = prepareSync(ctx, calendar)
 		if err != nil {
 			log.Errorf("error starting sync")
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
-		err = s.database.StartSync(calendar, userUUID)
+		err = s.database.StartSync(ctx, calendar, userUUID)
 		if err != nil {
 			log.Errorf("error trying to start sync: %s", calendar.GetUUID())
 			w.WriteHeader(http.StatusInternalServerError)
 		}
 	case http.MethodDelete:
 		log.Debugf("Getting method delete")
-		err := s.database.StopSync(param, email, userUUID)
+		ctx, cancel := s.requestContext(r)
+		defer cancel()
+		err := s.database.StopSync(ctx, param, email, userUUID)
 		if err != nil {
 			log.Errorf("error stopping sync: %s", err.Error())
 			w.WriteHeader(http.StatusInternalServerError)
@@ -173,7 +197,9 @@ func (s *Server) retrieveInfoHandler(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(err.Error()))
 		return
 	}
-	err = account.Refresh()
+	ctx, cancel := s.requestContext(r)
+	defer cancel()
+	err = account.RefreshContext(ctx)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(err.Error()))
@@ -185,7 +211,7 @@ func (s *Server) retrieveInfoHandler(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(err.Error()))
 		return
 	}
-	calendars, err := account.GetAllCalendars()
+	calendars, err := account.GetAllCalendarsContext(ctx)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(err.Error()))
@@ -211,7 +237,9 @@ func (s *Server) refreshHandler(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
-	err := s.database.UpdateAllCalendarsFromUser(userUUID, email)
+	ctx, cancel := s.requestContext(r)
+	defer cancel()
+	err := s.database.UpdateAllCalendarsFromUser(ctx, userUUID, email)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -219,6 +247,20 @@ func (s *Server) refreshHandler(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// Method that returns the context for the requests to the providers done while
+// serving the given request. It is cancelled when the client goes away or the server stops
+func (s *Server) requestContext(r *http.Request) (ctx context.Context, cancel context.CancelFunc) {
+	ctx, cancel = context.WithCancel(r.Context())
+	go func() {
+		select {
+		case <-s.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return
+}
+
 func manageCORS(w http.ResponseWriter, r http.Request, methods map[string]bool) (ok bool) {
 	ok = true
 	keys := make([]string, len(methods))
@@ -244,34 +286,98 @@ func manageCORS(w http.ResponseWriter, r http.Request, methods map[string]bool)
 }
 
 func (s *Server) manageSubscriptions() {
+	// subscriptions already expired, like the ones left by a migration, are renewed without waiting for the ticker
+	s.renewSubscriptions()
 	s.ticker = updateTicker()
 	for {
-		<-s.ticker.C
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.ticker.C:
+		}
 		log.Debugf("next ticking: %s")
-		subscriptions, err := s.database.GetExpiredSubscriptions()
+		s.renewSubscriptions()
+		s.reconcileSubscriptions()
+		s.rollSyncWindows()
+		s.ticker = updateTicker()
+	}
+}
+
+// Method that renews the subscriptions expired and stores them again
+func (s *Server) renewSubscriptions() {
+	subscriptions, err := s.database.GetExpiredSubscriptions()
+	if err != nil {
+		log.Errorf("error: %s", err.Error())
+		return
+	}
+	for _, subscription := range subscriptions {
+		acc := subscription.GetAccount()
+		if err := acc.RefreshContext(s.ctx); err != nil {
+			continue
+		}
+		if err = s.database.UpdateAccountFromSubscription(acc, subscription); err != nil {
+			log.Errorf("error updating account: %s", err.Error())
+		}
+		err = subscription.RenewContext(s.ctx)
+		if err != nil {
+			continue
+		}
+		err := s.database.UpdateSubscription(subscription)
+		if err != nil {
+			log.Errorf("error updating subscription: %s", err.Error())
+		}
+	}
+}
+
+func (s *Server) managePolling() {
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.pollingTicker.C:
+		}
+		IDs, err := s.database.GetPollingSubscriptionIDs()
 		if err != nil {
 			log.Errorf("error: %s", err.Error())
-			s.ticker = updateTicker()
 			continue
 		}
-		for _, subscription := range subscriptions {
-			acc := subscription.GetAccount()
-			if err := acc.Refresh(); err != nil {
-				continue
-			}
-			if err = s.database.UpdateAccountFromSubscription(acc, subscription); err != nil {
-				log.Errorf("error updating account: %s", err.Error())
-			}
-			err = subscription.Renew()
-			if err != nil {
-				continue
-			}
-			err := s.database.UpdateSubscription(subscription)
+		for _, subscriptionID := range IDs {
+			err = s.manageSynchronizationPolling(s.ctx, subscriptionID)
 			if err != nil {
-				log.Errorf("error updating subscription: %s", err.Error())
+				log.Errorf("error polling subscription ID: %s error: %s", subscriptionID, err.Error())
 			}
 		}
-		s.ticker = updateTicker()
+	}
+}
+
+// Method that looks for the changes lost by the subscriptions that are notified
+func (s *Server) reconcileSubscriptions() {
+	IDs, err := s.database.GetNotifiedSubscriptionIDs()
+	if err != nil {
+		log.Errorf("error: %s", err.Error())
+		return
+	}
+	for _, subscriptionID := range IDs {
+		err = s.manageReconciliation(s.ctx, subscriptionID)
+		if err != nil {
+			log.Errorf("error reconciling subscription ID: %s error: %s", subscriptionID, err.Error())
+		}
+	}
+}
+
+// Method that moves forward the sync windows, synchronizing the events that come into them
+// and removing the ones that fall out if the window prunes
+func (s *Server) rollSyncWindows() {
+	IDs, err := s.database.GetWindowedSubscriptionIDs()
+	if err != nil {
+		log.Errorf("error: %s", err.Error())
+		return
+	}
+	for _, subscriptionID := range IDs {
+		err = s.manageSynchronizationWindow(s.ctx, subscriptionID)
+		if err != nil {
+			log.Errorf("error rolling sync window of subscription ID: %s error: %s", subscriptionID, err.Error())
+		}
 	}
 }
 
@@ -286,14 +392,14 @@ func updateTicker() *time.Ticker {
 	return time.NewTicker(diff)
 }
 
-func prepareSync(calendar api.CalendarManager) (err error) {
-	err = calendar.GetAccount().Refresh()
+func prepareSync(ctx context.Context, calendar api.CalendarManager) (err error) {
+	err = calendar.GetAccount().RefreshContext(ctx)
 	if err != nil {
 		log.Errorf("error refreshing account: %s", err.Error())
 		return
 	}
 
-	cal, err := calendar.GetAccount().GetCalendar(calendar.GetID())
+	cal, err := calendar.GetAccount().GetCalendarContext(ctx, calendar.GetID())
 	convert.Convert(cal, calendar)
 	for _, calen := range calendar.GetCalendars() {
 		err := convert.Convert(calendar, calen)
@@ -302,12 +408,12 @@ func prepareSync(calendar api.CalendarManager) (err error) {
 			return err
 		}
 		log.Debugf("Name1: %s Name2: %s", calendar.GetName(), calen.GetName())
-		err = calen.GetAccount().Refresh()
+		err = calen.GetAccount().RefreshContext(ctx)
 		if err != nil {
 			log.Errorf("error refreshing account calendar: %s error: %s", calen.GetID(), err.Error())
 			return err
 		}
-		err = calen.Update()
+		err = calen.UpdateContext(ctx)
 
 		if err != nil {
 			log.Errorf("error updating calendar: %s error: %s", calen.GetID(), err.Error())
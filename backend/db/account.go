@@ -31,7 +31,9 @@ func (data Database) findAccountFromUser(userUUID string, internalID string) (ac
 	var tokenType string
 	var refreshToken string
 	var accessToken string
-	err = data.client.QueryRow("SELECT accounts.email,accounts.kind,accounts.id, accounts.token_type,accounts.refresh_token,accounts.access_token FROM accounts where user_uuid = $1 and id = $2", userUUID, internalID).Scan(&email, &kind, &id, &tokenType, &refreshToken, &accessToken)
+	var serverURL string
+	var credential string
+	err = data.client.QueryRow("SELECT accounts.email,accounts.kind,accounts.id, accounts.token_type,accounts.refresh_token,accounts.access_token,accounts.server_url,accounts.credential FROM accounts where user_uuid = $1 and id = $2", userUUID, internalID).Scan(&email, &kind, &id, &tokenType, &refreshToken, &accessToken, &serverURL, &credential)
 	switch {
 	case err == sql.ErrNoRows:
 		err = &customErrors.NotFoundError{Message: fmt.Sprintf("No account from user: %s with that id: %d.", userUUID, id)}
@@ -43,15 +45,12 @@ func (data Database) findAccountFromUser(userUUID string, internalID string) (ac
 		log.Debugf("error looking for account from user: %s with id: %d.", userUUID, id)
 		return
 	}
-	switch kind {
-	case api.GOOGLE:
-		account = api.RetrieveGoogleAccount(tokenType, refreshToken, email, kind, accessToken)
-	case api.OUTLOOK:
-		account = api.RetrieveOutlookAccount(tokenType, refreshToken, email, kind, accessToken)
-	default:
+	provider, ok := api.GetProvider(kind)
+	if !ok {
 		data.sentry.CaptureErrorAndWait(&customErrors.WrongKindError{Mail: email}, map[string]string{"database": "backend"})
 		return nil, &customErrors.WrongKindError{Mail: email}
 	}
+	account = provider.RetrieveAccount(tokenType, refreshToken, email, kind, accessToken, serverURL, credential)
 	return
 
 }
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -21,8 +22,8 @@ func (data Database) RetrieveCalendars(userEmail string, userUUID string, calend
 }
 
 // Method that updates all calendars from a user
-func (data Database) UpdateAllCalendarsFromUser(userUUID string, userEmail string) (err error) {
-	rows, err := data.client.Query("SELECT calendars.id, a.kind, a.token_type, a.refresh_token, a.email, a.access_token from calendars join accounts a on calendars.account_email = a.email join users u on a.user_uuid = u.uuid where u.uuid = $1 and u.email=$2", userUUID, userEmail)
+func (data Database) UpdateAllCalendarsFromUser(ctx context.Context, userUUID string, userEmail string) (err error) {
+	rows, err := data.client.Query("SELECT calendars.id, a.kind, a.token_type, a.refresh_token, a.email, a.access_token, a.server_url, a.credential from calendars join accounts a on calendars.account_email = a.email join users u on a.user_uuid = u.uuid where u.uuid = $1 and u.email=$2", userUUID, userEmail)
 	if err != nil {
 		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
 		log.Errorf("error querying get calendar: %s", err.Error())
@@ -36,22 +37,20 @@ func (data Database) UpdateAllCalendarsFromUser(userUUID string, userEmail strin
 		var email string
 		var kind int
 		var accessToken string
-		var account api.AccountManager
-		rows.Scan(&id, &kind, &tokenType, &refreshToken, &email, &accessToken)
-		switch kind {
-		case api.GOOGLE:
-			account = api.RetrieveGoogleAccount(tokenType, refreshToken, email, kind, accessToken)
-		case api.OUTLOOK:
-			account = api.RetrieveOutlookAccount(tokenType, refreshToken, email, kind, accessToken)
-		default:
+		var serverURL string
+		var credential string
+		rows.Scan(&id, &kind, &tokenType, &refreshToken, &email, &accessToken, &serverURL, &credential)
+		provider, ok := api.GetProvider(kind)
+		if !ok {
 			data.sentry.CaptureErrorAndWait(&customErrors.WrongKindError{Mail: email}, map[string]string{"database": "backend"})
 			log.Errorf("kind of calendar is not valid: %d", kind)
 			return &customErrors.WrongKindError{Mail: email}
 		}
+		account := provider.RetrieveAccount(tokenType, refreshToken, email, kind, accessToken, serverURL, credential)
 		//TODO: manage errors
-		account.Refresh()
+		account.RefreshContext(ctx)
 		data.UpdateAccountFromUser(account, userUUID)
-		calendar, err := account.GetCalendar(id)
+		calendar, err := account.GetCalendarContext(ctx, id)
 		if err != nil {
 			log.Errorf("error: %s", err.Error())
 		} else {
@@ -69,10 +68,12 @@ func (data Database) RetrieveCalendarFromSubscription(subscriptionID string) (ca
 	var email string
 	var kind int
 	var accessToken string
+	var serverURL string
+	var credential string
 	var calendarID string
 	var uid string
-	err = data.client.QueryRow("SELECT a.token_type, a.refresh_token,a.email,a.kind,a.access_token, calendars.id, calendars.uuid from calendars join subscriptions s2 on calendars.uuid = s2.calendar_uuid join accounts a on calendars.account_email = a.email where s2.id = $1", subscriptionID).
-		Scan(&tokenType, &refreshToken, &email, &kind, &accessToken, &calendarID, &uid)
+	err = data.client.QueryRow("SELECT a.token_type, a.refresh_token,a.email,a.kind,a.access_token, a.server_url, a.credential, calendars.id, calendars.uuid from calendars join subscriptions s2 on calendars.uuid = s2.calendar_uuid join accounts a on calendars.account_email = a.email where s2.id = $1", subscriptionID).
+		Scan(&tokenType, &refreshToken, &email, &kind, &accessToken, &serverURL, &credential, &calendarID, &uid)
 	switch {
 	case err == sql.ErrNoRows:
 		err = &customErrors.NotFoundError{Message: fmt.Sprintf("calendar from subscription with ID: %s not found", subscriptionID)}
@@ -84,17 +85,14 @@ func (data Database) RetrieveCalendarFromSubscription(subscriptionID string) (ca
 		log.Debugf("error getting calendar from subscription with ID: %s", subscriptionID)
 		return nil, err
 	}
-	switch kind {
-	case api.OUTLOOK:
-		account := api.RetrieveOutlookAccount(tokenType, refreshToken, email, kind, accessToken)
-		calendar = api.RetrieveOutlookCalendar(calendarID, uid, account)
-	case api.GOOGLE:
-		account := api.RetrieveGoogleAccount(tokenType, refreshToken, email, kind, accessToken)
-		calendar = api.RetrieveGoogleCalendar(calendarID, uid, account)
-	default:
+	calendar, ok := api.RetrieveCalendar(kind, calendarID, uid, tokenType, refreshToken, email, accessToken, serverURL, credential)
+	if !ok {
 		return nil, &customErrors.WrongKindError{Mail: fmt.Sprintf("error getting calendar with subscription ID: %s", subscriptionID)}
 	}
-
+	err = data.setSyncWindow(calendar)
+	if err != nil {
+		return nil, err
+	}
 	return
 }
 
@@ -106,8 +104,10 @@ func (data Database) findCalendarFromUser(userEmail string, userUUID string, cal
 	var email string
 	var kind int
 	var accessToken string
+	var serverURL string
+	var credential string
 	var uid string
-	err = data.client.QueryRow("SELECT calendars.id, calendars.uuid,a.kind, a.token_type, a.refresh_token, a.email, a.access_token from calendars join accounts a on calendars.account_email = a.email join users u on a.user_uuid = u.uuid where u.uuid = $1 and u.email=$2 and calendars.uuid =$3", userUUID, userEmail, calendarUUID).Scan(&id, &uid, &kind, &tokenType, &refreshToken, &email, &accessToken)
+	err = data.client.QueryRow("SELECT calendars.id, calendars.uuid,a.kind, a.token_type, a.refresh_token, a.email, a.access_token, a.server_url, a.credential from calendars join accounts a on calendars.account_email = a.email join users u on a.user_uuid = u.uuid where u.uuid = $1 and u.email=$2 and calendars.uuid =$3", userUUID, userEmail, calendarUUID).Scan(&id, &uid, &kind, &tokenType, &refreshToken, &email, &accessToken, &serverURL, &credential)
 	switch {
 	case err == sql.ErrNoRows:
 		err = &customErrors.NotFoundError{Message: fmt.Sprintf("No account from user: %s with that uuid: %s.", userUUID, calendarUUID)}
@@ -119,17 +119,17 @@ func (data Database) findCalendarFromUser(userEmail string, userUUID string, cal
 		log.Debugf("error looking for account from user: %s with id: %d.", userUUID, id)
 		return
 	}
-	switch kind {
-	case api.GOOGLE:
-		calendar = api.RetrieveGoogleCalendar(id, uid, api.RetrieveGoogleAccount(tokenType, refreshToken, email, kind, accessToken))
-	case api.OUTLOOK:
-		calendar = api.RetrieveOutlookCalendar(id, uid, api.RetrieveOutlookAccount(tokenType, refreshToken, email, kind, accessToken))
-	default:
+	calendar, ok := api.RetrieveCalendar(kind, id, uid, tokenType, refreshToken, email, accessToken, serverURL, credential)
+	if !ok {
 		data.sentry.CaptureErrorAndWait(&customErrors.WrongKindError{Mail: email}, map[string]string{"database": "backend"})
 		log.Errorf("kind of calendar is not valid: %d", kind)
 		return nil, &customErrors.WrongKindError{Mail: email}
 	}
 	calendar.SetUUID(calendarUUID)
+	err = data.setSyncWindow(calendar)
+	if err != nil {
+		return nil, err
+	}
 	calendars, err := data.getSynchronizedCalendars(calendar)
 	if err != nil {
 		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
@@ -142,7 +142,7 @@ func (data Database) findCalendarFromUser(userEmail string, userUUID string, cal
 
 // Returns all calendars that are related to given one
 func (data Database) getSynchronizedCalendars(calendar api.CalendarManager) (calendars []api.CalendarManager, err error) {
-	rows, err := data.client.Query("select calendars.id, calendars.uuid, a.kind, a.token_type, a.refresh_token, a.email, a.access_token from calendars join accounts a on calendars.account_email = a.email where (calendars.parent_calendar_uuid = (Select calendars.parent_calendar_uuid from calendars where calendars.uuid = $1) OR calendars.uuid = (select calendars.parent_calendar_uuid from calendars where calendars.uuid = $1) OR calendars.parent_calendar_uuid = $1) AND calendars.uuid != $1", calendar.GetUUID())
+	rows, err := data.client.Query("select calendars.id, calendars.uuid, a.kind, a.token_type, a.refresh_token, a.email, a.access_token, a.server_url, a.credential from calendars join accounts a on calendars.account_email = a.email where (calendars.parent_calendar_uuid = (Select calendars.parent_calendar_uuid from calendars where calendars.uuid = $1) OR calendars.uuid = (select calendars.parent_calendar_uuid from calendars where calendars.uuid = $1) OR calendars.parent_calendar_uuid = $1) AND calendars.uuid != $1", calendar.GetUUID())
 	if err != nil {
 		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
 		log.Errorf("error selecting setSynchronizedCalendars: %s", err.Error())
@@ -157,17 +157,14 @@ func (data Database) getSynchronizedCalendars(calendar api.CalendarManager) (cal
 		var refreshToken string
 		var email string
 		var accessToken string
-		var calendar api.CalendarManager
+		var serverURL string
+		var credential string
 		var kind int
-		err = rows.Scan(&id, &uid, &kind, &tokenType, &refreshToken, &email, &accessToken)
-		switch kind {
-		case api.GOOGLE:
-			calendar = api.RetrieveGoogleCalendar(id, uid, api.RetrieveGoogleAccount(tokenType, refreshToken, email, kind, accessToken))
-		case api.OUTLOOK:
-			calendar = api.RetrieveOutlookCalendar(id, uid, api.RetrieveOutlookAccount(tokenType, refreshToken, email, kind, accessToken))
-		default:
-			data.sentry.CaptureErrorAndWait(&customErrors.WrongKindError{Mail: calendar.GetName()}, map[string]string{"database": "backend"})
-			return nil, &customErrors.WrongKindError{Mail: calendar.GetName()}
+		err = rows.Scan(&id, &uid, &kind, &tokenType, &refreshToken, &email, &accessToken, &serverURL, &credential)
+		calendar, ok := api.RetrieveCalendar(kind, id, uid, tokenType, refreshToken, email, accessToken, serverURL, credential)
+		if !ok {
+			data.sentry.CaptureErrorAndWait(&customErrors.WrongKindError{Mail: email}, map[string]string{"database": "backend"})
+			return nil, &customErrors.WrongKindError{Mail: email}
 		}
 		calendars = append(calendars, calendar)
 	}
@@ -212,6 +209,107 @@ func (data Database) updateCalendarFromUser(calendar api.CalendarManager, userUU
 
 }
 
+// Method that returns the token of the last incremental synchronization of a calendar
+func (data Database) GetSyncToken(calendar api.CalendarManager) (token string, err error) {
+	err = data.client.QueryRow("select calendars.sync_token from calendars where calendars.uuid = $1", calendar.GetUUID()).Scan(&token)
+	switch {
+	case err == sql.ErrNoRows:
+		err = &customErrors.NotFoundError{Message: fmt.Sprintf("calendar with uuid: %s not found", calendar.GetUUID())}
+		log.Debugf("calendar with uuid: %s not found", calendar.GetUUID())
+		return
+	case err != nil:
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+		log.Errorf("error getting sync token of calendar with uuid: %s", calendar.GetUUID())
+		return
+	}
+	return
+}
+
+// Method that stores the token of the last incremental synchronization of a calendar
+func (data Database) UpdateSyncToken(calendar api.CalendarManager, token string) (err error) {
+	stmt, err := data.client.Prepare("update calendars set sync_token = $1 where calendars.uuid = $2")
+	if err != nil {
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+		log.Errorf("error preparing query: %s", err.Error())
+		return
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(token, calendar.GetUUID())
+	if err != nil {
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+		log.Errorf("error executing query: %s", err.Error())
+		return
+	}
+
+	affect, err := res.RowsAffected()
+	if err != nil {
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+		log.Errorf("error retrieving rows affected: %s", err.Error())
+		return
+	}
+	if affect != 1 {
+		err = errors.New(fmt.Sprintf("could not update sync token of calendar with uuid: %s", calendar.GetUUID()))
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+		return
+	}
+	return
+}
+
+// Method that sets to the calendar the sync window and options of its relation,
+// which are stored on the principal calendar
+func (data Database) setSyncWindow(calendar api.CalendarManager) (err error) {
+	var window api.SyncWindow
+	var options api.SyncOptions
+	var principal bool
+	var contentTypes string
+	err = data.client.QueryRow("select calendars.sync_days_before, calendars.sync_days_after, calendars.sync_window_prune, calendars.attendees_mode, calendars.notify_attendees, calendars.tentative_fallback, calendars.out_of_office_fallback, calendars.working_elsewhere_fallback, calendars.attachments_content_types, calendars.uuid = $1 from calendars where calendars.uuid = coalesce((select c.parent_calendar_uuid from calendars as c where c.uuid = $1), $1)", calendar.GetUUID()).
+		Scan(&window.DaysBefore, &window.DaysAfter, &window.Prune, &options.Attendees, &options.NotifyAttendees, &options.FreeBusyFallbacks.Tentative, &options.FreeBusyFallbacks.OutOfOffice, &options.FreeBusyFallbacks.WorkingElsewhere, &contentTypes, &principal)
+	switch {
+	case err == sql.ErrNoRows:
+		err = &customErrors.NotFoundError{Message: fmt.Sprintf("calendar with uuid: %s not found", calendar.GetUUID())}
+		log.Debugf("calendar with uuid: %s not found", calendar.GetUUID())
+		return
+	case err != nil:
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+		log.Errorf("error getting sync window of calendar with uuid: %s", calendar.GetUUID())
+		return
+	}
+	// only the principal calendar removes the events synced from it
+	window.Prune = window.Prune && principal
+	options.Attachments = api.AttachmentLimits{ContentTypes: api.ParseContentTypes(contentTypes)}
+	options.CategoryColors, err = data.getCategoryColors(calendar)
+	if err != nil {
+		return
+	}
+	calendar.SetSyncWindow(window)
+	calendar.SetSyncOptions(options)
+	return
+}
+
+// Method that returns the mapping between categories and colors of the user of the calendar.
+// Returns nil if the user has not set one
+func (data Database) getCategoryColors(calendar api.CalendarManager) (colors api.CategoryColors, err error) {
+	rows, err := data.client.Query("select category_colors.category, category_colors.color_id from category_colors join accounts a on category_colors.user_uuid = a.user_uuid join calendars c on a.email = c.account_email where c.uuid = $1 order by category_colors.position", calendar.GetUUID())
+	if err != nil {
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+		log.Errorf("error getting category colors of calendar with uuid: %s", calendar.GetUUID())
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var color api.CategoryColor
+		err = rows.Scan(&color.Category, &color.ColorID)
+		if err != nil {
+			data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+			log.Errorf("error scanning category colors of calendar with uuid: %s", calendar.GetUUID())
+			return
+		}
+		colors = append(colors, color)
+	}
+	return colors, rows.Err()
+}
+
 // Method that saves a subscription to DB
 func (data Database) saveSubscription(transaction *sql.Tx, subscription api.SubscriptionManager, calendar api.CalendarManager) (err error) {
 	stmt, err := transaction.Prepare("insert into subscriptions(uuid,calendar_uuid,id, type, expiration_date, resource_id) values ($1,$2,$3,$4,$5,$6)")
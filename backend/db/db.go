@@ -1,13 +1,14 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"time"
 
 	"github.com/TetAlius/GoSyncMyCalendars/api"
-	"github.com/TetAlius/GoSyncMyCalendars/convert"
+	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
 	log "github.com/TetAlius/GoSyncMyCalendars/logger"
 	"github.com/getsentry/raven-go"
-	"github.com/google/uuid"
 	_ "github.com/lib/pq"
 )
 
@@ -33,31 +34,27 @@ func (data Database) Close() error {
 
 // Method that starts the sync of a calendar, creating the events and storing them
 // on DB. Also creating subscription and storing them
-func (data Database) StartSync(calendar api.CalendarManager, userUUID string) (err error) {
+func (data Database) StartSync(ctx context.Context, calendar api.CalendarManager, userUUID string) (err error) {
 	var subscriptions []api.SubscriptionManager
 	var subs api.SubscriptionManager
 	var eventsCreated []api.EventManager
-	var events []api.EventManager
+	var instances []api.InstanceEventManager
+	now := time.Now()
 	transaction, err := data.client.Begin()
 	if err != nil {
 		log.Errorf("error creating transaction: %s", err.Error())
 		return
 	}
 	data.UpdateAccountFromUser(calendar.GetAccount(), userUUID)
-	events, err = calendar.GetAllEvents()
+	data.UpdateCalendarFromUser(calendar, userUUID)
+	// events stored are retrieved again on the first synchronization
+	data.UpdateSyncToken(calendar, "")
+	subs, err = newSubscription(calendar)
 	if err != nil {
 		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
-		log.Errorf("error creating subscription for calendar: %s, error: %s", calendar.GetUUID(), err.Error())
 		goto End
 	}
-	data.UpdateCalendarFromUser(calendar, userUUID)
-	switch calendar.(type) {
-	case *api.GoogleCalendar:
-		subs = api.NewGoogleSubscription(uuid.New().String())
-	case *api.OutlookCalendar:
-		subs = api.NewOutlookSubscription()
-	}
-	err = subs.Subscribe(calendar)
+	err = subs.SubscribeContext(ctx, calendar)
 	if err != nil {
 		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
 		log.Errorf("error creating subscription for calendar: %s, error: %s", calendar.GetUUID(), err.Error())
@@ -65,58 +62,42 @@ func (data Database) StartSync(calendar api.CalendarManager, userUUID string) (e
 	}
 	subscriptions = append(subscriptions, subs)
 	data.saveSubscription(transaction, subs, calendar)
-	err = data.savePrincipalEvents(transaction, events)
-	if err != nil {
-		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
-		log.Errorf("error creating subscription for calendar: %s, error: %s", calendar.GetUUID(), err.Error())
-		goto End
-	}
 
 	for _, cal := range calendar.GetCalendars() {
 		data.UpdateAccountFromUser(cal.GetAccount(), userUUID)
 		data.UpdateCalendarFromUser(cal, userUUID)
-		var subscript api.SubscriptionManager
-		switch cal.(type) {
-		case *api.GoogleCalendar:
-			subscript = api.NewGoogleSubscription(uuid.New().String())
-		case *api.OutlookCalendar:
-			subscript = api.NewOutlookSubscription()
-		}
-		for _, event := range events {
-			var toEvent api.EventManager
-			switch cal.(type) {
-			case *api.GoogleCalendar:
-				toEvent = &api.GoogleEvent{}
-			case *api.OutlookCalendar:
-				toEvent = &api.OutlookEvent{}
-			}
-			convert.Convert(event, toEvent)
-			err = toEvent.SetCalendar(cal)
-			if err != nil {
-				data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
-				log.Errorf("error converting event for calendar: %s, error: %s", cal.GetUUID(), err.Error())
-				goto End
-			}
-			err = toEvent.Create()
-			if err != nil {
-				data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
-				log.Errorf("error creating event for calendar: %s, error: %s", cal.GetUUID(), err.Error())
-				goto End
+		data.UpdateSyncToken(cal, "")
+	}
+	// events are synced page by page so they are not all kept in memory
+	err = api.ForEachEventPageContext(ctx, calendar, func(page []api.EventManager) error {
+		var events []api.EventManager
+		for _, event := range page {
+			if !calendar.GetSyncWindow().ContainsEvent(event, now) {
+				continue
 			}
-			eventsCreated = append(eventsCreated, toEvent)
-			err = data.saveEventsRelation(transaction, event, toEvent)
-			if err != nil {
-				data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
-				log.Errorf("error saving relation on database: %s, error: %s", event.GetID(), err.Error())
-				goto End
+			if instance, ok := event.(api.InstanceEventManager); ok && len(instance.GetSeriesID()) != 0 {
+				instances = append(instances, instance)
+				continue
 			}
+			events = append(events, event)
 		}
+		created, err := data.startSyncEvents(ctx, transaction, calendar, events)
+		eventsCreated = append(eventsCreated, created...)
+		return err
+	})
+	if err != nil {
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+		log.Errorf("error syncing events for calendar: %s, error: %s", calendar.GetUUID(), err.Error())
+		goto End
+	}
+	for _, cal := range calendar.GetCalendars() {
+		var subscript api.SubscriptionManager
+		subscript, err = newSubscription(cal)
 		if err != nil {
 			data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
-			log.Errorln("some error saving events")
 			goto End
 		}
-		err := subscript.Subscribe(cal)
+		err = subscript.SubscribeContext(ctx, cal)
 		if err != nil {
 			data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
 			log.Errorf("error creating subscription for calendar: %s, error: %s", calendar.GetUUID(), err.Error())
@@ -133,22 +114,129 @@ func (data Database) StartSync(calendar api.CalendarManager, userUUID string) (e
 End:
 	if err != nil {
 		transaction.Rollback()
+		// what was created is removed even if the sync was cancelled
 		for _, subscription := range subscriptions {
 			subscription.Delete()
 		}
 
-		for _, event := range eventsCreated {
-			event.Delete()
-		}
+		api.WriteEvents(context.Background(), api.Deleted, eventsCreated)
 		return
 	}
 	transaction.Commit()
+	// instances are synced once all series are stored, as they can be given before them. Each one is stored
+	// on its own transaction, so none is kept open while they are retrieved from the providers.
+	// The ones that fail are synchronized again with the next changes of the calendar
+	for _, instance := range instances {
+		instanceErr := data.startSyncInstance(ctx, instance)
+		if instanceErr != nil {
+			data.sentry.CaptureErrorAndWait(instanceErr, map[string]string{"database": "backend"})
+			log.Errorf("error syncing instance: %s for calendar: %s, error: %s", instance.GetID(), calendar.GetUUID(), instanceErr.Error())
+		}
+	}
 	return
 }
 
+// Method that stores a page of events of the principal calendar and creates them
+// on the synced calendars, with batch requests when the calendars take them.
+// The relation of each event created is stored. Returns the events created even if an error happened
+func (data Database) startSyncEvents(ctx context.Context, transaction *sql.Tx, calendar api.CalendarManager, events []api.EventManager) (eventsCreated []api.EventManager, err error) {
+	err = data.savePrincipalEvents(transaction, events)
+	if err != nil {
+		log.Errorf("error saving events for calendar: %s, error: %s", calendar.GetUUID(), err.Error())
+		return
+	}
+	for _, cal := range calendar.GetCalendars() {
+		provider, ok := api.ProviderOf(cal)
+		if !ok {
+			return eventsCreated, &customErrors.WrongKindError{Mail: cal.GetAccount().Mail()}
+		}
+		toEvents := make([]api.EventManager, len(events))
+		for i := range events {
+			toEvents[i] = provider.NewEvent("")
+			err = toEvents[i].SetCalendar(cal)
+			if err != nil {
+				log.Errorf("error converting event for calendar: %s, error: %s", cal.GetUUID(), err.Error())
+				return
+			}
+		}
+		errs := api.SyncEvents(ctx, api.Created, events, toEvents)
+		// every event created keeps its relation, so it is removed if the sync is rolled back
+		for i, event := range events {
+			switch errs[i].(type) {
+			case nil:
+			case api.RecurrenceError, api.ReadOnlyError:
+				// as on the worker, events that can not be written on the calendar do not stop the sync
+				log.Warningf("event: %s not synchronized with calendar: %s, error: %s", event.GetID(), cal.GetUUID(), errs[i].Error())
+				continue
+			default:
+				log.Errorf("error creating event for calendar: %s, error: %s", cal.GetUUID(), errs[i].Error())
+				if err == nil {
+					err = errs[i]
+				}
+				continue
+			}
+			eventsCreated = append(eventsCreated, toEvents[i])
+			saveErr := data.saveEventsRelation(transaction, event, toEvents[i])
+			if saveErr != nil {
+				log.Errorf("error saving relation on database: %s, error: %s", event.GetID(), saveErr.Error())
+				if err == nil {
+					err = saveErr
+				}
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Method that updates, on the synced calendars, the instances that match an instance
+// of the principal calendar changed on its own, and stores them on DB
+func (data Database) startSyncInstance(ctx context.Context, instance api.InstanceEventManager) (err error) {
+	seriesInternalID, series, found, err := data.findSeries(instance)
+	if err != nil || !found {
+		return
+	}
+	instances, err := data.findSeriesInstances(ctx, instance, series)
+	if err != nil {
+		return
+	}
+	for i, synced := range instances {
+		toEvent := synced.event.GetCalendar().CreateEmptyEvent(synced.event.GetID())
+		err = api.ConvertEventContext(ctx, instance, toEvent)
+		if err != nil {
+			log.Errorf("error converting instance for calendar: %s, error: %s", synced.event.GetCalendar().GetUUID(), err.Error())
+			return
+		}
+		err = toEvent.UpdateContext(ctx)
+		if err != nil {
+			log.Errorf("error updating instance for calendar: %s, error: %s", synced.event.GetCalendar().GetUUID(), err.Error())
+			return
+		}
+		instances[i].event = toEvent
+	}
+	updatedAt, err := instance.GetUpdatedAt()
+	if err != nil {
+		log.Errorf("error getting updated at for event: %s", instance.GetID())
+		return
+	}
+	transaction, err := data.client.Begin()
+	if err != nil {
+		log.Errorf("error starting transaction: %s", err.Error())
+		return
+	}
+	err = data.saveSeriesInstance(transaction, instance, updatedAt, seriesInternalID, instances)
+	if err != nil {
+		transaction.Rollback()
+		return
+	}
+	return transaction.Commit()
+}
+
 // Method that stops the sync from a calendar, deleting all events on db and stopping
 // subscription and deleting them
-func (data Database) StopSync(principalSubscriptionUUID string, userEmail string, userUUID string) (err error) {
+func (data Database) StopSync(ctx context.Context, principalSubscriptionUUID string, userEmail string, userUUID string) (err error) {
 	subscriptions, err := data.RetrieveAllSubscriptionsFromUser(principalSubscriptionUUID, userEmail, userUUID)
 	transaction, err := data.client.Begin()
 	if err != nil {
@@ -159,11 +247,11 @@ func (data Database) StopSync(principalSubscriptionUUID string, userEmail string
 	for _, subscription := range subscriptions {
 		acc := subscription.GetAccount()
 		//TODO: manage when account access is refused
-		if err = acc.Refresh(); err != nil {
+		if err = acc.RefreshContext(ctx); err != nil {
 			continue
 		}
 		go func() { data.UpdateAccountFromUser(acc, userUUID) }()
-		err = subscription.Delete()
+		err = subscription.DeleteContext(ctx)
 		if err != nil {
 			data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
 			log.Errorf("error deleting subscription: %s", err.Error())
@@ -187,3 +275,12 @@ func (data Database) StopSync(principalSubscriptionUUID string, userEmail string
 	transaction.Commit()
 	return
 }
+
+// Function that creates a new subscription for a calendar, given by the provider of its account
+func newSubscription(calendar api.CalendarManager) (subscription api.SubscriptionManager, err error) {
+	provider, ok := api.ProviderOf(calendar)
+	if !ok {
+		return nil, &customErrors.WrongKindError{Mail: calendar.GetAccount().Mail()}
+	}
+	return provider.NewSubscription(), nil
+}
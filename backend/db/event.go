@@ -1,10 +1,12 @@
 package db
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
 	"database/sql"
+	"time"
 
 	"github.com/TetAlius/GoSyncMyCalendars/api"
 	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
@@ -47,7 +49,7 @@ func (data Database) RetrieveSyncedEventsWithSubscription(eventID string, subscr
 
 // Returns all events related to a given event
 func (data Database) getSynchronizedEventsFromEvent(principalEventID int, eventID string) (events []api.EventManager, err error) {
-	stmt, err := data.client.Prepare("select events.id, a.kind, a.token_type, a.refresh_token, a.email, a.access_token, c2.id, c2.uuid from events join calendars c2 on events.calendar_uuid = c2.uuid join accounts a on c2.account_email = a.email where events.internal_id = $1 or events.parent_event_internal_id=$1 and events.id!=$2")
+	stmt, err := data.client.Prepare("select events.id, a.kind, a.token_type, a.refresh_token, a.email, a.access_token, a.server_url, a.credential, c2.id, c2.uuid from events join calendars c2 on events.calendar_uuid = c2.uuid join accounts a on c2.account_email = a.email where events.internal_id = $1 or events.parent_event_internal_id=$1 and events.id!=$2")
 	if err != nil {
 		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
 		log.Errorf("error getting synced events from principalID: %d", principalEventID)
@@ -68,31 +70,22 @@ func (data Database) getSynchronizedEventsFromEvent(principalEventID int, eventI
 		var refreshToken string
 		var email string
 		var accessToken string
+		var serverURL string
+		var credential string
 		var calendarID string
 		var calendarUUID string
-		err = rows.Scan(&id, &kind, &tokenType, &refreshToken, &email, &accessToken, &calendarID, &calendarUUID)
+		err = rows.Scan(&id, &kind, &tokenType, &refreshToken, &email, &accessToken, &serverURL, &credential, &calendarID, &calendarUUID)
 		if err != nil {
 			data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
 			log.Errorf("error scanning synced events from principalID: %d", principalEventID)
 			return nil, err
 		}
 		var eventSync api.EventManager
-		var calendar api.CalendarManager
-		switch kind {
-		case api.GOOGLE:
-			account := api.RetrieveGoogleAccount(tokenType, refreshToken, email, kind, accessToken)
-			calendar = api.RetrieveGoogleCalendar(calendarID, calendarUUID, account)
-			eventSync = &api.GoogleEvent{ID: id}
-		case api.OUTLOOK:
-			account := api.RetrieveOutlookAccount(tokenType, refreshToken, email, kind, accessToken)
-			calendar = api.RetrieveOutlookCalendar(calendarID, calendarUUID, account)
-			eventSync = &api.OutlookEvent{ID: id}
-		default:
-			err = &customErrors.WrongKindError{Mail: fmt.Sprintf("wrong kind of account for events with parent ID: %d", principalEventID)}
+		eventSync, err = newSyncedEvent(kind, id, tokenType, refreshToken, email, accessToken, serverURL, credential, calendarID, calendarUUID)
+		if _, ok := err.(*customErrors.WrongKindError); ok {
 			data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
 			return nil, err
 		}
-		err = eventSync.SetCalendar(calendar)
 		if err != nil {
 			data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
 			log.Errorf("error setting calendar for event ID: %s", eventID)
@@ -102,6 +95,18 @@ func (data Database) getSynchronizedEventsFromEvent(principalEventID int, eventI
 	return
 }
 
+// Function that returns an event stored on DB with its calendar and account
+func newSyncedEvent(kind int, id string, tokenType string, refreshToken string, email string, accessToken string, serverURL string, credential string, calendarID string, calendarUUID string) (event api.EventManager, err error) {
+	provider, ok := api.GetProvider(kind)
+	if !ok {
+		return nil, &customErrors.WrongKindError{Mail: fmt.Sprintf("wrong kind of account for event ID: %s", id)}
+	}
+	account := provider.RetrieveAccount(tokenType, refreshToken, email, kind, accessToken, serverURL, credential)
+	event = provider.NewEvent(id)
+	err = event.SetCalendar(provider.RetrieveCalendar(calendarID, calendarUUID, account))
+	return
+}
+
 // Saves principal event to db. Principal event is the event which was first created
 func (data Database) SavePrincipalEvent(event api.EventManager) (err error) {
 	transaction, err := data.client.Begin()
@@ -355,9 +360,10 @@ func (data Database) ExistsEvent(event api.EventManager) bool {
 	return exists
 }
 
-// Returns all event IDs that are associated with a subscription
+// Returns all event IDs that are associated with a subscription.
+// Instances of a series are left out, as they are not always listed by the calendars
 func (data Database) GetEventIDs(subscriptionID string) (eventIDs []string, err error) {
-	stmt, err := data.client.Prepare("select events.id from events join calendars c2 on events.calendar_uuid = c2.uuid join subscriptions s2 on c2.uuid = s2.calendar_uuid where s2.id=$1")
+	stmt, err := data.client.Prepare("select events.id from events join calendars c2 on events.calendar_uuid = c2.uuid join subscriptions s2 on c2.uuid = s2.calendar_uuid where s2.id=$1 and events.series_internal_id is null")
 	if err != nil {
 		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
 		log.Errorf("error getting stored events from subscriptionID: %s", subscriptionID)
@@ -383,3 +389,153 @@ func (data Database) GetEventIDs(subscriptionID string) (eventIDs []string, err
 	}
 	return
 }
+
+// Instance of a series on a synced calendar and the row of its series master
+type seriesInstance struct {
+	event            api.EventManager
+	seriesInternalID int
+}
+
+// Saves an instance of a recurring series that is not on DB along the instances that match it
+// on the calendars synced with its series. Returns false if its series is not synced
+func (data Database) SaveSeriesInstance(ctx context.Context, event api.InstanceEventManager) (found bool, err error) {
+	// the instances are retrieved from the providers before the transaction starts, so it is not kept open while waiting for them
+	seriesInternalID, series, found, err := data.findSeries(event)
+	if err != nil || !found {
+		return
+	}
+	instances, err := data.findSeriesInstances(ctx, event, series)
+	if err != nil {
+		return false, err
+	}
+	transaction, err := data.client.Begin()
+	if err != nil {
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+		log.Errorf("error starting transaction: %s", err.Error())
+		return false, err
+	}
+	// the instance is stored without update date, so its changes are always synchronized
+	err = data.saveSeriesInstance(transaction, event, time.Time{}, seriesInternalID, instances)
+	if err != nil {
+		transaction.Rollback()
+		return false, err
+	}
+	transaction.Commit()
+	return
+}
+
+// Returns the row of the series master of an instance and the series masters synced with it.
+// Returns false if the series is not synced
+func (data Database) findSeries(event api.InstanceEventManager) (seriesInternalID int, series []seriesInstance, found bool, err error) {
+	var principalEventID int
+	err = data.client.QueryRow("select events.internal_id, COALESCE(events.parent_event_internal_id, events.internal_id) from events where events.id = $1 and events.calendar_uuid = $2", event.GetSeriesID(), event.GetCalendar().GetUUID()).Scan(&seriesInternalID, &principalEventID)
+	switch {
+	case err == sql.ErrNoRows:
+		log.Warningf("series with ID: %s of instance ID: %s not found", event.GetSeriesID(), event.GetID())
+		return 0, nil, false, nil
+	case err != nil:
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+		log.Errorf("error getting series from instance ID: %s", event.GetID())
+		return 0, nil, false, err
+	}
+
+	rows, err := data.client.Query("select events.internal_id, events.id, a.kind, a.token_type, a.refresh_token, a.email, a.access_token, a.server_url, a.credential, c2.id, c2.uuid from events join calendars c2 on events.calendar_uuid = c2.uuid join accounts a on c2.account_email = a.email where (events.internal_id = $1 or events.parent_event_internal_id = $1) and events.internal_id != $2", principalEventID, seriesInternalID)
+	if err != nil {
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+		log.Errorf("error getting synced series from principalID: %d", principalEventID)
+		return 0, nil, false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var internalID int
+		var id string
+		var kind int
+		var tokenType string
+		var refreshToken string
+		var email string
+		var accessToken string
+		var serverURL string
+		var credential string
+		var calendarID string
+		var calendarUUID string
+		err = rows.Scan(&internalID, &id, &kind, &tokenType, &refreshToken, &email, &accessToken, &serverURL, &credential, &calendarID, &calendarUUID)
+		if err != nil {
+			data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+			log.Errorf("error scanning synced series from principalID: %d", principalEventID)
+			return 0, nil, false, err
+		}
+		master, err := newSyncedEvent(kind, id, tokenType, refreshToken, email, accessToken, serverURL, credential, calendarID, calendarUUID)
+		if err != nil {
+			data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+			return 0, nil, false, err
+		}
+		series = append(series, seriesInstance{event: master, seriesInternalID: internalID})
+	}
+	return seriesInternalID, series, true, nil
+}
+
+// Returns the instances that match an instance on the series masters given, retrieving them from
+// the providers. Calendars whose series can not have instances changed on their own are left out
+func (data Database) findSeriesInstances(ctx context.Context, event api.InstanceEventManager, series []seriesInstance) (instances []seriesInstance, err error) {
+	for _, master := range series {
+		calendar, ok := master.event.GetCalendar().(api.InstanceCalendarManager)
+		if !ok {
+			log.Warningf("instance ID: %s can not be synchronized with calendar: %s", event.GetID(), master.event.GetCalendar().GetUUID())
+			continue
+		}
+		err = calendar.GetAccount().RefreshContext(ctx)
+		if err != nil {
+			data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+			log.Errorf("error refreshing account: %s", calendar.GetAccount().Mail())
+			return nil, err
+		}
+		go data.UpdateAccount(calendar.GetAccount())
+		instance, err := calendar.GetInstanceContext(ctx, master.event.GetID(), event.GetOriginalStart())
+		if _, ok := err.(*customErrors.NotFoundError); ok {
+			log.Warningf("instance of series ID: %s starting at %s not found on calendar: %s", master.event.GetID(), event.GetOriginalStart(), calendar.GetUUID())
+			continue
+		}
+		if err != nil {
+			data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+			log.Errorf("error getting instance of series ID: %s", master.event.GetID())
+			return nil, err
+		}
+		instances = append(instances, seriesInstance{event: instance, seriesInternalID: master.seriesInternalID})
+	}
+	return
+}
+
+// Saves an instance of a series and the instances that match it on the synced calendars
+func (data Database) saveSeriesInstance(transaction *sql.Tx, event api.InstanceEventManager, updatedAt time.Time, seriesInternalID int, instances []seriesInstance) (err error) {
+	lastInsertId := 0
+	err = transaction.QueryRow("INSERT INTO events (calendar_uuid, id, updated_at, series_internal_id, original_start) VALUES($1, $2, $3, $4, $5) RETURNING internal_id", event.GetCalendar().GetUUID(), event.GetID(), updatedAt, seriesInternalID, event.GetOriginalStart()).Scan(&lastInsertId)
+	if err != nil {
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+		log.Errorf("error insert instance with id: %s and calendar UUID: %s", event.GetID(), event.GetCalendar().GetUUID())
+		return err
+	}
+	event.SetInternalID(lastInsertId)
+
+	stmt, err := transaction.Prepare("insert into events(calendar_uuid, id, parent_event_internal_id, updated_at, series_internal_id, original_start) values ($1,$2,$3,$4,$5,$6)")
+	if err != nil {
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+		log.Errorf("error preparing query: %s", err.Error())
+		return
+	}
+	defer stmt.Close()
+	for _, instance := range instances {
+		instanceUpdatedAt, err := instance.event.GetUpdatedAt()
+		if err != nil {
+			data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+			log.Errorf("error getting updated at for event: %s", instance.event.GetID())
+			return err
+		}
+		_, err = stmt.Exec(instance.event.GetCalendar().GetUUID(), instance.event.GetID(), lastInsertId, instanceUpdatedAt, instance.seriesInternalID, event.GetOriginalStart())
+		if err != nil {
+			data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+			log.Errorf("error executing query: %s", err.Error())
+			return err
+		}
+	}
+	return
+}
@@ -112,6 +112,72 @@ func (data Database) GetExpiredSubscriptions() (subscriptions []api.Subscription
 
 }
 
+// Retrieves the IDs of all subscriptions whose calendars must be polled
+func (data Database) GetPollingSubscriptionIDs() (IDs []string, err error) {
+	rows, err := data.client.Query("select subscriptions.id from subscriptions where subscriptions.type = 'polling'")
+	if err != nil {
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+		log.Errorf("error retrieving all polling subscriptions: %s", err.Error())
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ID string
+		err = rows.Scan(&ID)
+		if err != nil {
+			data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+			log.Errorf("error scanning results: %s", err.Error())
+			return nil, err
+		}
+		IDs = append(IDs, ID)
+	}
+	return
+}
+
+// Retrieves the IDs of all subscriptions whose calendars belong to a relation with a sync window
+func (data Database) GetWindowedSubscriptionIDs() (IDs []string, err error) {
+	rows, err := data.client.Query("select subscriptions.id from subscriptions join calendars c on subscriptions.calendar_uuid = c.uuid left outer join calendars p on c.parent_calendar_uuid = p.uuid where coalesce(p.sync_days_before, c.sync_days_before) <> 0 or coalesce(p.sync_days_after, c.sync_days_after) <> 0")
+	if err != nil {
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+		log.Errorf("error retrieving all windowed subscriptions: %s", err.Error())
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ID string
+		err = rows.Scan(&ID)
+		if err != nil {
+			data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+			log.Errorf("error scanning results: %s", err.Error())
+			return nil, err
+		}
+		IDs = append(IDs, ID)
+	}
+	return
+}
+
+// Retrieves the IDs of all subscriptions whose calendars notify their changes
+func (data Database) GetNotifiedSubscriptionIDs() (IDs []string, err error) {
+	rows, err := data.client.Query("select subscriptions.id from subscriptions where subscriptions.type <> 'polling'")
+	if err != nil {
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+		log.Errorf("error retrieving all notified subscriptions: %s", err.Error())
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ID string
+		err = rows.Scan(&ID)
+		if err != nil {
+			data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+			log.Errorf("error scanning results: %s", err.Error())
+			return nil, err
+		}
+		IDs = append(IDs, ID)
+	}
+	return
+}
+
 // Method that updates the info of a subscription
 func (data Database) UpdateSubscription(subscription api.SubscriptionManager) (err error) {
 	stmt, err := data.client.Prepare("update subscriptions set id = $1, type = $2, expiration_date = $3, resource_id = $4 where uuid = $5")
@@ -162,15 +228,12 @@ func (data Database) getSubscription(subscriptionUUID string, userEmail string,
 		return
 	}
 	calendar, _ := data.findCalendarFromUser(userEmail, userUUID, calendarUUID)
-	switch kind {
-	case api.GOOGLE:
-		subscription = api.RetrieveGoogleSubscription(id, uid, calendar, resourceID)
-	case api.OUTLOOK:
-		subscription = api.RetrieveOutlookSubscription(id, uid, calendar, typ)
-	default:
+	provider, ok := api.GetProvider(kind)
+	if !ok {
 		data.sentry.CaptureErrorAndWait(&customErrors.WrongKindError{Mail: subscriptionUUID}, map[string]string{"database": "backend"})
 		return nil, &customErrors.WrongKindError{Mail: subscriptionUUID}
 	}
+	subscription = provider.RetrieveSubscription(id, uid, calendar, typ, resourceID)
 	return
 }
 
@@ -189,3 +252,22 @@ func (data Database) ExistsSubscriptionFromID(ID string) (ok bool, err error) {
 	}
 	return
 }
+
+// Method that retrieves a subscription given the ID that the cloud gave to it
+func (data Database) RetrieveSubscriptionFromID(ID string) (subscription api.SubscriptionManager, err error) {
+	var subscriptionUUID string
+	var userEmail string
+	var userUUID string
+	err = data.client.QueryRow("select subscriptions.uuid, u.email, u.uuid from subscriptions join calendars c2 on subscriptions.calendar_uuid = c2.uuid join accounts a on c2.account_email = a.email join users u on a.user_uuid = u.uuid where subscriptions.id = $1", ID).Scan(&subscriptionUUID, &userEmail, &userUUID)
+	switch {
+	case err == sql.ErrNoRows:
+		err = &customErrors.NotFoundError{Message: fmt.Sprintf("No subscription with id: %s", ID)}
+		log.Debugf("No subscription with id: %s", ID)
+		return nil, err
+	case err != nil:
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "backend"})
+		log.Errorf("error looking for subscription with id: %s", ID)
+		return nil, err
+	}
+	return data.getSubscription(subscriptionUUID, userEmail, userUUID)
+}
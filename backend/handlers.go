@@ -29,7 +29,9 @@ func (s *Server) GoogleWatcherHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		err := s.manageSynchronizationGoogle(channelID)
+		ctx, cancel := s.requestContext(r)
+		defer cancel()
+		err := s.manageSynchronizationGoogle(ctx, channelID)
 		var status int
 		if err != nil {
 			status = http.StatusInternalServerError
@@ -71,7 +73,9 @@ func (s *Server) OutlookWatcherHandler(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 			log.Warningf("OUTLOOK SUB: %s", contents)
-			err = s.manageSynchronizationOutlook(notification.Subscriptions)
+			ctx, cancel := s.requestContext(r)
+			defer cancel()
+			err = s.manageSynchronizationOutlook(ctx, notification.Subscriptions)
 			var status int
 			if err != nil {
 				status = http.StatusInternalServerError
@@ -88,6 +92,94 @@ func (s *Server) OutlookWatcherHandler(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// Method that process the request of a graph notification to our server
+func (s *Server) GraphWatcherHandler(w http.ResponseWriter, r *http.Request) {
+	if s.worker.IsClosed() {
+		serverError(w)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		validationToken := r.FormValue("validationToken")
+		if len(validationToken) > 0 {
+			log.Debugf("ValidationToken: %s", validationToken)
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, "%s", validationToken)
+		} else {
+			contents, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				serverError(w)
+				return
+			}
+			notification := new(api.GraphNotification)
+			err = json.Unmarshal(contents, &notification)
+			if err != nil {
+				serverError(w)
+				return
+			}
+			ctx, cancel := s.requestContext(r)
+			defer cancel()
+			err = s.manageSynchronizationGraph(ctx, notification.Subscriptions)
+			var status int
+			if err != nil {
+				status = http.StatusInternalServerError
+			} else {
+				status = http.StatusOK
+			}
+			w.WriteHeader(status)
+		}
+		return
+	default:
+		notFound(w)
+		return
+	}
+}
+
+// Method that process the request of a graph lifecycle notification, telling about the state of a subscription
+func (s *Server) GraphLifecycleHandler(w http.ResponseWriter, r *http.Request) {
+	if s.worker.IsClosed() {
+		serverError(w)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		validationToken := r.FormValue("validationToken")
+		if len(validationToken) > 0 {
+			log.Debugf("ValidationToken: %s", validationToken)
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, "%s", validationToken)
+		} else {
+			contents, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				serverError(w)
+				return
+			}
+			notification := new(api.GraphNotification)
+			err = json.Unmarshal(contents, &notification)
+			if err != nil {
+				serverError(w)
+				return
+			}
+			ctx, cancel := s.requestContext(r)
+			defer cancel()
+			err = s.manageLifecycleGraph(ctx, notification.Subscriptions)
+			var status int
+			if err != nil {
+				status = http.StatusInternalServerError
+			} else {
+				status = http.StatusOK
+			}
+			w.WriteHeader(status)
+		}
+		return
+	default:
+		notFound(w)
+		return
+	}
+}
+
 func notFound(w http.ResponseWriter) {
 	contents, err := ioutil.ReadFile("./frontend/resources/html/404.html")
 	if err != nil {
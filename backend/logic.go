@@ -1,34 +1,41 @@
 package backend
 
 import (
+	"context"
 	"fmt"
 
+	"time"
+
 	"github.com/TetAlius/GoSyncMyCalendars/api"
 	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
 	log "github.com/TetAlius/GoSyncMyCalendars/logger"
 )
 
-func (s *Server) manageSynchronizationOutlook(notifications []api.OutlookSubscriptionNotification) (err error) {
+func (s *Server) manageSynchronizationOutlook(ctx context.Context, notifications []api.OutlookSubscriptionNotification) (err error) {
 	tags := map[string]string{"sync": "outlook"}
 	for _, subscription := range notifications {
-		calendar, err := s.retrieveCalendar(subscription.SubscriptionID, tags)
+		calendar, err := s.retrieveCalendar(ctx, subscription.SubscriptionID, tags)
 		if err != nil {
 			return err
 		}
 		if calendar == nil && err == nil {
 			return nil
 		}
-		calendar.GetAccount().Refresh()
+		calendar.GetAccount().RefreshContext(ctx)
 		go s.database.UpdateAccount(calendar.GetAccount())
 		tags["event"] = subscription.ChangeType
 		if subscription.ChangeType == "Missed" {
-			err = s.manageByCalendar(calendar, subscription.SubscriptionID, tags)
+			if incremental, ok := calendar.(api.IncrementalCalendarManager); ok {
+				err = s.manageByToken(ctx, incremental, subscription.SubscriptionID, tags)
+			} else {
+				err = s.manageByCalendar(ctx, calendar, subscription.SubscriptionID, tags)
+			}
 			if err != nil {
 				return err
 			}
 			continue
 		}
-		err = s.manageSubscription(calendar, subscription.SubscriptionID, subscription.Data.ID, tags)
+		err = s.manageSubscription(ctx, calendar, subscription.SubscriptionID, subscription.Data.ID, tags)
 		if err != nil {
 			log.Errorf("error managing outlook subscription ID: %s", subscription.SubscriptionID)
 			return err
@@ -37,9 +44,93 @@ func (s *Server) manageSynchronizationOutlook(notifications []api.OutlookSubscri
 	return err
 }
 
-func (s *Server) manageByCalendar(calendar api.CalendarManager, subscriptionID string, tags map[string]string) (err error) {
+func (s *Server) manageSynchronizationGraph(ctx context.Context, notifications []api.GraphSubscriptionNotification) (err error) {
+	tags := map[string]string{"sync": "graph"}
+	for _, subscription := range notifications {
+		calendar, err := s.retrieveCalendar(ctx, subscription.SubscriptionID, tags)
+		if err != nil {
+			return err
+		}
+		if calendar == nil && err == nil {
+			return nil
+		}
+		go s.database.UpdateAccount(calendar.GetAccount())
+		tags["event"] = subscription.ChangeType
+		err = s.manageSubscription(ctx, calendar, subscription.SubscriptionID, subscription.Data.ID, tags)
+		if err != nil {
+			log.Errorf("error managing graph subscription ID: %s", subscription.SubscriptionID)
+			return err
+		}
+	}
+	return err
+}
+
+// Method that manages the lifecycle notifications of the graph subscriptions:
+// the notifications missed are synchronized and the subscriptions removed or
+// needing a new authorization are renewed
+func (s *Server) manageLifecycleGraph(ctx context.Context, notifications []api.GraphSubscriptionNotification) (err error) {
+	tags := map[string]string{"sync": "graph-lifecycle"}
+	for _, notification := range notifications {
+		tags["event"] = notification.LifecycleEvent
+		switch notification.LifecycleEvent {
+		case "missed":
+			calendar, err := s.retrieveCalendar(ctx, notification.SubscriptionID, tags)
+			if err != nil {
+				return err
+			}
+			if calendar == nil && err == nil {
+				continue
+			}
+			go s.database.UpdateAccount(calendar.GetAccount())
+			if incremental, ok := calendar.(api.IncrementalCalendarManager); ok {
+				err = s.manageByToken(ctx, incremental, notification.SubscriptionID, tags)
+			} else {
+				err = s.manageByCalendar(ctx, calendar, notification.SubscriptionID, tags)
+			}
+			if err != nil {
+				return err
+			}
+		case "reauthorizationRequired", "subscriptionRemoved":
+			subscription, err := s.database.RetrieveSubscriptionFromID(notification.SubscriptionID)
+			if _, ok := err.(*customErrors.NotFoundError); ok {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			account := subscription.GetAccount()
+			if err = account.RefreshContext(ctx); err != nil {
+				s.sentry.CaptureErrorAndWait(err, tags)
+				return err
+			}
+			if err = s.database.UpdateAccountFromSubscription(account, subscription); err != nil {
+				log.Errorf("error updating account: %s", err.Error())
+			}
+			// subscriptions already removed by graph are created again
+			if err = subscription.RenewContext(ctx); err != nil {
+				s.sentry.CaptureErrorAndWait(err, tags)
+				return err
+			}
+			if err = s.database.UpdateSubscription(subscription); err != nil {
+				return err
+			}
+			if notification.LifecycleEvent == "subscriptionRemoved" {
+				// changes made while the subscription was removed have not been notified
+				err = s.manageReconciliation(ctx, subscription.GetID())
+				if err != nil {
+					return err
+				}
+			}
+		default:
+			log.Warningf("lifecycle event not managed: %s for graph subscription ID: %s", notification.LifecycleEvent, notification.SubscriptionID)
+		}
+	}
+	return
+}
+
+func (s *Server) manageByCalendar(ctx context.Context, calendar api.CalendarManager, subscriptionID string, tags map[string]string) (err error) {
 	eventIDs := make(map[string]string)
-	events, err := calendar.GetAllEvents()
+	events, err := calendar.GetAllEventsContext(ctx)
 	if err != nil {
 		log.Errorf("error getting all events from cloud: %s", err.Error())
 		s.sentry.CaptureErrorAndWait(err, tags)
@@ -58,7 +149,7 @@ func (s *Server) manageByCalendar(calendar api.CalendarManager, subscriptionID s
 		eventIDs[eventID] = eventID
 	}
 	for eventID := range eventIDs {
-		err = s.manageSubscription(calendar, subscriptionID, eventID, tags)
+		err = s.manageSubscription(ctx, calendar, subscriptionID, eventID, tags)
 		if err != nil {
 			log.Errorf("error managing subscription ID: %s", subscriptionID)
 			return err
@@ -67,23 +158,234 @@ func (s *Server) manageByCalendar(calendar api.CalendarManager, subscriptionID s
 	return
 }
 
-func (s *Server) manageSynchronizationGoogle(subscriptionID string) (err error) {
+func (s *Server) manageSynchronizationGoogle(ctx context.Context, subscriptionID string) (err error) {
 	tags := map[string]string{"sync": "google"}
-	calendar, err := s.retrieveCalendar(subscriptionID, tags)
+	calendar, err := s.retrieveCalendar(ctx, subscriptionID, tags)
+	if err != nil {
+		return err
+	}
+	if calendar == nil && err == nil {
+		return nil
+	}
+	calendar.GetAccount().RefreshContext(ctx)
+	go s.database.UpdateAccount(calendar.GetAccount())
+	if incremental, ok := calendar.(api.IncrementalCalendarManager); ok {
+		return s.manageByToken(ctx, incremental, subscriptionID, tags)
+	}
+	return s.manageByCalendar(ctx, calendar, subscriptionID, tags)
+}
+
+// Method that retrieves only the events changed since the last synchronization.
+// If there is no token stored, or it is no longer valid, all events are compared
+func (s *Server) manageByToken(ctx context.Context, calendar api.IncrementalCalendarManager, subscriptionID string, tags map[string]string) (err error) {
+	token, err := s.database.GetSyncToken(calendar)
+	if err != nil {
+		return err
+	}
+	events, nextToken, err := calendar.GetChangedEventsContext(ctx, token)
+	if _, ok := err.(*api.SyncTokenExpiredError); ok {
+		log.Warningf("sync token expired for calendar: %s, doing a full synchronization", calendar.GetUUID())
+		token = ""
+		events, nextToken, err = calendar.GetChangedEventsContext(ctx, token)
+	}
+	if err != nil {
+		log.Errorf("error getting changed events from cloud: %s", err.Error())
+		s.sentry.CaptureErrorAndWait(err, tags)
+		return err
+	}
+	if len(token) == 0 {
+		err = s.manageChangedEvents(ctx, calendar, subscriptionID, events, tags)
+	} else {
+		var batch []api.EventManager
+		for _, event := range events {
+			var ready bool
+			ready, err = s.prepareEvent(ctx, calendar, subscriptionID, event, event.GetState() != api.Deleted, tags)
+			if err != nil {
+				log.Errorf("error managing subscription ID: %s", subscriptionID)
+				break
+			}
+			if ready {
+				batch = append(batch, event)
+			}
+		}
+		s.sendBatch(batch)
+	}
+	if err != nil {
+		return err
+	}
+	return s.database.UpdateSyncToken(calendar, nextToken)
+}
+
+// Method that compares the changed events given without a token with the ones stored on DB.
+// If they are bounded by dates, the events missing from them may still exist: with a sync window
+// they are checked on their own, and without it all the events of the calendar are compared
+func (s *Server) manageChangedEvents(ctx context.Context, calendar api.IncrementalCalendarManager, subscriptionID string, events []api.EventManager, tags map[string]string) (err error) {
+	bounded, ok := calendar.(api.BoundedIncrementalCalendarManager)
+	if !ok || !bounded.HasBoundedChanges() {
+		return s.manageAllEvents(ctx, calendar, subscriptionID, events, false, tags)
+	}
+	if calendar.GetSyncWindow().IsLimited() {
+		return s.manageAllEvents(ctx, calendar, subscriptionID, events, true, tags)
+	}
+	events, err = calendar.GetAllEventsContext(ctx)
+	if err != nil {
+		log.Errorf("error getting all events from cloud: %s", err.Error())
+		s.sentry.CaptureErrorAndWait(err, tags)
+		return err
+	}
+	return s.manageAllEvents(ctx, calendar, subscriptionID, events, false, tags)
+}
+
+// Method that synchronizes the changes of a calendar that may have been lost from its notifications
+func (s *Server) manageReconciliation(ctx context.Context, subscriptionID string) (err error) {
+	tags := map[string]string{"sync": "reconciliation"}
+	calendar, err := s.retrieveCalendar(ctx, subscriptionID, tags)
 	if err != nil {
 		return err
 	}
 	if calendar == nil && err == nil {
 		return nil
 	}
-	calendar.GetAccount().Refresh()
+	incremental, ok := calendar.(api.IncrementalCalendarManager)
+	if !ok {
+		return nil
+	}
 	go s.database.UpdateAccount(calendar.GetAccount())
-	return s.manageByCalendar(calendar, subscriptionID, tags)
+	return s.manageByToken(ctx, incremental, subscriptionID, tags)
 }
 
-func (s *Server) manageSubscription(calendar api.CalendarManager, subscriptionID string, eventID string, tags map[string]string) (err error) {
+// Method that lists again the events inside the sync window of a calendar,
+// synchronizing the ones that have come into the window
+func (s *Server) manageSynchronizationWindow(ctx context.Context, subscriptionID string) (err error) {
+	tags := map[string]string{"sync": "window"}
+	calendar, err := s.retrieveCalendar(ctx, subscriptionID, tags)
+	if err != nil {
+		return err
+	}
+	if calendar == nil && err == nil {
+		return nil
+	}
+	go s.database.UpdateAccount(calendar.GetAccount())
+	return s.manageByPolling(ctx, calendar, subscriptionID, tags)
+}
+
+func (s *Server) manageSynchronizationPolling(ctx context.Context, subscriptionID string) (err error) {
+	tags := map[string]string{"sync": "polling"}
+	calendar, err := s.retrieveCalendar(ctx, subscriptionID, tags)
+	if err != nil {
+		return err
+	}
+	if calendar == nil && err == nil {
+		return nil
+	}
+	go s.database.UpdateAccount(calendar.GetAccount())
+	return s.manageByPolling(ctx, calendar, subscriptionID, tags)
+}
+
+// Method that compares all events of a calendar that does not notify changes
+// with the ones stored on DB. Events are retrieved only once from the cloud
+func (s *Server) manageByPolling(ctx context.Context, calendar api.CalendarManager, subscriptionID string, tags map[string]string) (err error) {
+	events, err := calendar.GetAllEventsContext(ctx)
+	if err != nil {
+		log.Errorf("error getting all events from cloud: %s", err.Error())
+		s.sentry.CaptureErrorAndWait(err, tags)
+		return err
+	}
+	return s.manageAllEvents(ctx, calendar, subscriptionID, events, false, tags)
+}
+
+// Method that compares all the events given, already retrieved from the cloud,
+// with the ones stored on DB. The ones changed are sent to the worker as a single batch.
+// If the events given are bounded, the ones missing are never removed without being checked on their own
+func (s *Server) manageAllEvents(ctx context.Context, calendar api.CalendarManager, subscriptionID string, events []api.EventManager, bounded bool, tags map[string]string) (err error) {
+	var batch []api.EventManager
+	defer func() { s.sendBatch(batch) }()
+	cloudEvents := make(map[string]api.EventManager)
+	outOfWindow := make(map[string]api.EventManager)
+	window := calendar.GetSyncWindow()
+	now := time.Now()
+	for _, event := range events {
+		if event.GetState() == api.Deleted {
+			continue
+		}
+		if window.ContainsEvent(event, now) {
+			cloudEvents[event.GetID()] = event
+		} else {
+			outOfWindow[event.GetID()] = event
+		}
+	}
+	IDs, err := s.database.GetEventIDs(subscriptionID)
+	if err != nil {
+		log.Errorf("error getting all events from db: %s", err.Error())
+		s.sentry.CaptureErrorAndWait(err, tags)
+		return err
+	}
+	for _, eventID := range IDs {
+		if _, ok := cloudEvents[eventID]; ok {
+			continue
+		}
+		if event, ok := outOfWindow[eventID]; ok {
+			err = s.manageOutOfWindow(ctx, calendar, subscriptionID, event, tags)
+		} else if bounded || window.IsLimited() {
+			// the event may be just out of the range given, so it is checked on its own
+			err = s.manageWindowEvent(ctx, calendar, subscriptionID, eventID, tags)
+		} else {
+			event := calendar.CreateEmptyEvent(eventID)
+			var ready bool
+			ready, err = s.prepareEvent(ctx, calendar, subscriptionID, event, false, tags)
+			if ready {
+				batch = append(batch, event)
+			}
+		}
+		if err != nil {
+			log.Errorf("error managing subscription ID: %s", subscriptionID)
+			return err
+		}
+	}
+	for _, event := range cloudEvents {
+		ready, err := s.prepareEvent(ctx, calendar, subscriptionID, event, true, tags)
+		if err != nil {
+			log.Errorf("error managing subscription ID: %s", subscriptionID)
+			return err
+		}
+		if ready {
+			batch = append(batch, event)
+		}
+	}
+	return
+}
+
+// Method that manages an event stored on DB that was not given inside the sync window.
+// It is retrieved on its own to know whether it has been deleted or it is out of the window
+func (s *Server) manageWindowEvent(ctx context.Context, calendar api.CalendarManager, subscriptionID string, eventID string, tags map[string]string) (err error) {
+	event, err := calendar.GetEventContext(ctx, eventID)
+	if _, ok := err.(*customErrors.NotFoundError); ok {
+		return s.manageEvent(ctx, calendar, subscriptionID, calendar.CreateEmptyEvent(eventID), false, tags)
+	}
+	if err != nil {
+		s.sentry.CaptureErrorAndWait(err, tags)
+		log.Errorf("error retrieving event from account: %s", err.Error())
+		return err
+	}
+	if calendar.GetSyncWindow().ContainsEvent(event, time.Now()) {
+		return s.manageEvent(ctx, calendar, subscriptionID, event, true, tags)
+	}
+	return s.manageOutOfWindow(ctx, calendar, subscriptionID, event, tags)
+}
+
+// Method that manages an event stored on DB that is out of the sync window.
+// If the window prunes, its synced events are removed. Otherwise they are kept as they are
+func (s *Server) manageOutOfWindow(ctx context.Context, calendar api.CalendarManager, subscriptionID string, event api.EventManager, tags map[string]string) (err error) {
+	if !calendar.GetSyncWindow().Prune {
+		return nil
+	}
+	log.Debugf("event with id: %s out of sync window, removing synced events", event.GetID())
+	return s.manageEvent(ctx, calendar, subscriptionID, event, false, tags)
+}
+
+func (s *Server) manageSubscription(ctx context.Context, calendar api.CalendarManager, subscriptionID string, eventID string, tags map[string]string) (err error) {
 	onCloud := true
-	event, err := calendar.GetEvent(eventID)
+	event, err := calendar.GetEventContext(ctx, eventID)
 	if _, ok := err.(*customErrors.NotFoundError); ok {
 		onCloud = false
 		err = nil
@@ -94,18 +396,56 @@ func (s *Server) manageSubscription(calendar api.CalendarManager, subscriptionID
 		log.Errorf("error retrieving event from account: %s", err.Error())
 		return err
 	}
+	return s.manageEvent(ctx, calendar, subscriptionID, event, onCloud, tags)
+}
+
+// Method that sends the event to the worker if it has changed since the last synchronization
+func (s *Server) manageEvent(ctx context.Context, calendar api.CalendarManager, subscriptionID string, event api.EventManager, onCloud bool, tags map[string]string) (err error) {
+	ready, err := s.prepareEvent(ctx, calendar, subscriptionID, event, onCloud, tags)
+	if err != nil || !ready {
+		return
+	}
+	if !s.worker.Send(event) {
+		log.Warningf("worker stopped, event: %s not synchronized", event.GetID())
+	}
+	return
+}
+
+// Method that sends the events prepared to the worker as a single job,
+// so the ones synced with the same calendar are written together
+func (s *Server) sendBatch(events []api.EventManager) {
+	if len(events) > 0 && !s.worker.SendBatch(events) {
+		log.Warningf("worker stopped, %d events not synchronized", len(events))
+	}
+}
+
+// Method that prepares the event to be synchronized by the worker, with its relations and state.
+// Returns false if it has not changed since the last synchronization
+func (s *Server) prepareEvent(ctx context.Context, calendar api.CalendarManager, subscriptionID string, event api.EventManager, onCloud bool, tags map[string]string) (ready bool, err error) {
+	eventID := event.GetID()
 	events, onDB, err := s.database.RetrieveSyncedEventsWithSubscription(eventID, subscriptionID, calendar)
 	if err != nil {
 		s.sentry.CaptureErrorAndWait(err, tags)
 		log.Errorf("error retrieving events synced: %s", err.Error())
-		return err
+		return false, err
+	}
+	if instance, ok := event.(api.InstanceEventManager); ok && !onDB && len(instance.GetSeriesID()) != 0 {
+		// the first change of an instance is applied on the matching instance of the synced series
+		events, onDB, err = s.manageInstance(ctx, calendar, subscriptionID, instance, tags)
+		if err != nil {
+			return false, err
+		}
+		if !onDB {
+			log.Warningf("instance with id: %s of a series not synchronized, ignoring it", eventID)
+			return false, nil
+		}
 	}
 	if !onCloud && !onDB {
 		log.Warningf("event with id: %s already deleted", eventID)
-		return nil
+		return false, nil
 	}
 	if onCloud && onDB && s.database.EventAlreadyUpdated(event) {
-		return nil
+		return false, nil
 	}
 
 	event.SetRelations(events)
@@ -113,15 +453,34 @@ func (s *Server) manageSubscription(calendar api.CalendarManager, subscriptionID
 	if state == 0 {
 		err = fmt.Errorf("synchronization not supported for event: %s", eventID)
 		s.sentry.CaptureErrorAndWait(err, tags)
-		return err
+		return false, err
 	}
 
 	event.SetState(state)
-	s.worker.Events <- event
+	return true, nil
+}
+
+// Method that stores an instance of a series, changed on its own for the first time,
+// along the instances that match it on the synced calendars, and returns them
+func (s *Server) manageInstance(ctx context.Context, calendar api.CalendarManager, subscriptionID string, instance api.InstanceEventManager, tags map[string]string) (events []api.EventManager, found bool, err error) {
+	found, err = s.database.SaveSeriesInstance(ctx, instance)
+	if err != nil {
+		s.sentry.CaptureErrorAndWait(err, tags)
+		log.Errorf("error saving instance: %s", err.Error())
+		return nil, false, err
+	}
+	if !found {
+		return nil, false, nil
+	}
+	events, found, err = s.database.RetrieveSyncedEventsWithSubscription(instance.GetID(), subscriptionID, calendar)
+	if err != nil {
+		s.sentry.CaptureErrorAndWait(err, tags)
+		log.Errorf("error retrieving events synced: %s", err.Error())
+	}
 	return
 }
 
-func (s *Server) retrieveCalendar(subscriptionID string, tags map[string]string) (calendar api.CalendarManager, err error) {
+func (s *Server) retrieveCalendar(ctx context.Context, subscriptionID string, tags map[string]string) (calendar api.CalendarManager, err error) {
 	ok, err := s.database.ExistsSubscriptionFromID(subscriptionID)
 	if err != nil && ok {
 		//Ignore this subscription
@@ -139,7 +498,7 @@ func (s *Server) retrieveCalendar(subscriptionID string, tags map[string]string)
 		return nil, err
 	}
 	recoveredPanic, sentryID := s.sentry.CapturePanicAndWait(func() {
-		err = calendar.GetAccount().Refresh()
+		err = calendar.GetAccount().RefreshContext(ctx)
 	}, tags)
 
 	if recoveredPanic != nil {
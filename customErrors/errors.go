@@ -1,6 +1,10 @@
 package customErrors
 
-import "fmt"
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
 
 // AccError provides error for when an account given
 // to the database is not supported.
@@ -72,3 +76,87 @@ type AccountAlreadyUsed struct {
 func (err *AccountAlreadyUsed) Error() string {
 	return fmt.Sprintf("account with email: %s is already in used", err.Mail)
 }
+
+// ResponseError is implemented by the errors of the responses of the providers,
+// so callers can tell them apart from errors doing the request
+type ResponseError interface {
+	error
+	StatusCode() int
+}
+
+func (err *NotFoundError) StatusCode() int {
+	return http.StatusNotFound
+}
+
+// UnauthorizedError provides error for when the provider does not accept
+// the credentials of the account
+type UnauthorizedError struct {
+	Message string
+}
+
+func (err *UnauthorizedError) Error() string {
+	return err.Message
+}
+
+func (err *UnauthorizedError) StatusCode() int {
+	return http.StatusUnauthorized
+}
+
+// RateLimitError provides error for when the provider rejects requests
+// because too many were done, with the time it asked to wait if given
+type RateLimitError struct {
+	Status     int
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (err *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded, retry after %s: %s", err.RetryAfter, err.Message)
+}
+
+func (err *RateLimitError) StatusCode() int {
+	return err.Status
+}
+
+// ConflictError provides error for when the resource changed on the provider
+// since it was retrieved
+type ConflictError struct {
+	Status  int
+	Message string
+}
+
+func (err *ConflictError) Error() string {
+	return err.Message
+}
+
+func (err *ConflictError) StatusCode() int {
+	return err.Status
+}
+
+// ServerError provides error for when the provider failed answering the request
+type ServerError struct {
+	Status  int
+	Message string
+}
+
+func (err *ServerError) Error() string {
+	return err.Message
+}
+
+func (err *ServerError) StatusCode() int {
+	return err.Status
+}
+
+// StatusError provides error for the rest of error statuses answered by the provider
+type StatusError struct {
+	Status  int
+	Message string
+}
+
+func (err *StatusError) Error() string {
+	return err.Message
+}
+
+func (err *StatusError) StatusCode() int {
+	return err.Status
+}
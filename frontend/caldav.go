@@ -0,0 +1,72 @@
+package frontend
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"github.com/TetAlius/GoSyncMyCalendars/api"
+	"github.com/TetAlius/GoSyncMyCalendars/frontend/db"
+	log "github.com/TetAlius/GoSyncMyCalendars/logger"
+)
+
+func (s *Server) caldavSignInHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser, ok := s.manageSession(w, r)
+	if !ok {
+		return
+	}
+	data := PageInfo{
+		PageTitle: "Add CalDAV Account",
+		User:      *currentUser,
+	}
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		account, err := api.NewCalDAVAccountContext(r.Context(), r.FormValue("server"), r.FormValue("username"), r.FormValue("password"))
+		if err != nil {
+			log.Errorf("error adding caldav account: %s", err.Error())
+			data.Error = err.Error()
+			break
+		}
+		credential, err := account.GetCredential()
+		if err != nil {
+			log.Errorf("error encrypting caldav credential: %s", err.Error())
+			serverError(w, err)
+			return
+		}
+		acc := db.Account{
+			User:         currentUser,
+			TokenType:    account.GetTokenType(),
+			RefreshToken: account.GetRefreshToken(),
+			Email:        account.Mail(),
+			AccessToken:  account.GetAccessToken(),
+			ServerURL:    account.GetServerURL(),
+			Credential:   credential,
+			Kind:         api.CALDAV,
+		}
+		id, err := s.database.AddAccount(currentUser, acc)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+		http.Redirect(w, r, fmt.Sprintf("/accounts/%d", id), http.StatusFound)
+		return
+	default:
+		notFound(w)
+		return
+	}
+
+	t, err := template.New("layout.html").Funcs(funcMap).ParseFiles(root+"/html/shared/layout.html", root+"/html/accounts/caldav.html")
+	if err != nil {
+		log.Errorf("error parsing files: %s", err.Error())
+		serverError(w, err)
+		return
+	}
+
+	err = t.Execute(w, data)
+	if err != nil {
+		log.Errorf("error executing templates: %s", err.Error())
+		serverError(w, err)
+		return
+	}
+}
@@ -26,6 +26,10 @@ type Account struct {
 	Kind int
 	// AccessToken of the account
 	AccessToken string
+	// URL of the server of the account, for the providers that are not fixed
+	ServerURL string
+	// Encrypted credential of the account, for the providers without tokens
+	Credential string
 	// InternalID of the account
 	ID int
 	// Whether if the account is the principal one
@@ -90,8 +94,8 @@ func (data Database) FindCalendars(account *Account) (err error) {
 
 // Saves an account to the db
 func (data Database) save(account Account) (id int, err error) {
-	err = data.client.QueryRow("insert into accounts(user_uuid,token_type,refresh_token,email,kind,access_token, principal) values ($1,$2,$3,$4,$5,$6,$7) RETURNING id",
-		account.User.UUID, account.TokenType, account.RefreshToken, account.Email, account.Kind, account.AccessToken, account.Principal).Scan(&id)
+	err = data.client.QueryRow("insert into accounts(user_uuid,token_type,refresh_token,email,kind,access_token, principal, server_url, credential) values ($1,$2,$3,$4,$5,$6,$7,$8,$9) RETURNING id",
+		account.User.UUID, account.TokenType, account.RefreshToken, account.Email, account.Kind, account.AccessToken, account.Principal, account.ServerURL, account.Credential).Scan(&id)
 	if pgerr, ok := err.(*pq.Error); ok && pgerr.Code == uniqueViolationError {
 		log.Warningf("account already used: %s", account.Email)
 		return 0, &customErrors.AccountAlreadyUsed{Mail: account.Email}
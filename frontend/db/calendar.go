@@ -1,6 +1,7 @@
 package db
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 
@@ -26,6 +27,22 @@ type Calendar struct {
 	SubscriptionUUID uuid.UUID
 	// List of calendars that are related to this one
 	Calendars []Calendar
+	// Days before the current date synchronized by the relation, 0 if not limited
+	SyncDaysBefore int
+	// Days after the current date synchronized by the relation, 0 if not limited
+	SyncDaysAfter int
+	// Whether the synced events that fall out of the window are removed
+	SyncWindowPrune bool
+	// How attendees are written on the synced events: 0 listed on the description, 1 copied
+	AttendeesMode int
+	// Whether the attendees of the synced events are notified of the writes of the synchronization
+	NotifyAttendees bool
+	// Free or busy, written for the tentative, out of office and working elsewhere events on calendars without those statuses
+	TentativeFallback        string
+	OutOfOfficeFallback      string
+	WorkingElsewhereFallback string
+	// Content types of the attachments linked from the synced events separated by commas, empty for all of them
+	AttachmentsContentTypes string
 }
 
 // Function that creates a new instance of the calendar given specific info
@@ -43,7 +60,7 @@ func newCalendar(id string, name string, uid uuid.UUID, accountEmail string, acc
 
 // Method that finds all calendars related to an account
 func (data Database) findCalendars(account *Account) (err error) {
-	rows, err := data.client.Query("select calendars.id, calendars.name, calendars.uuid, s2.uuid from calendars join accounts a on calendars.account_email = a.email left outer join subscriptions s2 on calendars.uuid = s2.calendar_uuid where a.id=$1 order by calendars.name ASC", account.ID)
+	rows, err := data.client.Query("select calendars.id, calendars.name, calendars.uuid, s2.uuid, calendars.sync_days_before, calendars.sync_days_after, calendars.sync_window_prune, calendars.attendees_mode, calendars.notify_attendees, calendars.tentative_fallback, calendars.out_of_office_fallback, calendars.working_elsewhere_fallback, calendars.attachments_content_types from calendars join accounts a on calendars.account_email = a.email left outer join subscriptions s2 on calendars.uuid = s2.calendar_uuid where a.id=$1 order by calendars.name ASC", account.ID)
 	if err != nil {
 		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "frontend"})
 		log.Errorln("error selecting findCalendarsFromAccount")
@@ -57,13 +74,31 @@ func (data Database) findCalendars(account *Account) (err error) {
 		var name string
 		var uid uuid.UUID
 		var subscription uuid.UUID
-		err = rows.Scan(&id, &name, &uid, &subscription)
+		var daysBefore int
+		var daysAfter int
+		var prune bool
+		var attendeesMode int
+		var notify bool
+		var tentative string
+		var outOfOffice string
+		var workingElsewhere string
+		var contentTypes string
+		err = rows.Scan(&id, &name, &uid, &subscription, &daysBefore, &daysAfter, &prune, &attendeesMode, &notify, &tentative, &outOfOffice, &workingElsewhere, &contentTypes)
 		if err != nil {
 			//TODO
 			data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "frontend"})
 			continue
 		}
 		calendar := newCalendar(id, name, uid, account.Email, *account, subscription)
+		calendar.SyncDaysBefore = daysBefore
+		calendar.SyncDaysAfter = daysAfter
+		calendar.SyncWindowPrune = prune
+		calendar.AttendeesMode = attendeesMode
+		calendar.NotifyAttendees = notify
+		calendar.TentativeFallback = tentative
+		calendar.OutOfOfficeFallback = outOfOffice
+		calendar.WorkingElsewhereFallback = workingElsewhere
+		calendar.AttachmentsContentTypes = contentTypes
 
 		data.setSynchronizedCalendars(&calendar, account.Principal)
 		calendars = append(calendars, calendar)
@@ -135,6 +170,66 @@ func (data Database) updateCalendarFromUser(user *User, calendarUUID string, par
 	return
 }
 
+// Method that updates the sync window of a calendar from user
+func (data Database) updateSyncWindowFromUser(transaction *sql.Tx, user *User, calendarUUID string, daysBefore int, daysAfter int, prune bool) (err error) {
+	stmt, err := transaction.Prepare("update calendars set sync_days_before = $1, sync_days_after = $2, sync_window_prune = $3 from accounts where calendars.account_email = accounts.email and accounts.user_uuid = $4 and calendars.uuid = $5;")
+	if err != nil {
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "frontend"})
+		log.Errorf("error preparing query: %s", err.Error())
+		return
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(daysBefore, daysAfter, prune, user.UUID, calendarUUID)
+	if err != nil {
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "frontend"})
+		log.Errorf("error executing query: %s", err.Error())
+		return
+	}
+
+	affect, err := res.RowsAffected()
+	if err != nil {
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "frontend"})
+		log.Errorf("error retrieving rows affected: %s", err.Error())
+		return
+	}
+	if affect != 1 {
+		data.sentry.CaptureErrorAndWait(errors.New(fmt.Sprintf("could not update sync window of calendar with UUID: %s", calendarUUID)), map[string]string{"database": "frontend"})
+		return errors.New(fmt.Sprintf("could not update sync window of calendar with UUID: %s", calendarUUID))
+	}
+	return
+}
+
+// Method that updates the sync options of a calendar from user
+func (data Database) updateSyncOptionsFromUser(transaction *sql.Tx, user *User, calendarUUID string, attendeesMode int, notify bool, tentative string, outOfOffice string, workingElsewhere string, contentTypes string) (err error) {
+	stmt, err := transaction.Prepare("update calendars set attendees_mode = $1, notify_attendees = $2, tentative_fallback = $3, out_of_office_fallback = $4, working_elsewhere_fallback = $5, attachments_content_types = $6 from accounts where calendars.account_email = accounts.email and accounts.user_uuid = $7 and calendars.uuid = $8;")
+	if err != nil {
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "frontend"})
+		log.Errorf("error preparing query: %s", err.Error())
+		return
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(attendeesMode, notify, tentative, outOfOffice, workingElsewhere, contentTypes, user.UUID, calendarUUID)
+	if err != nil {
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "frontend"})
+		log.Errorf("error executing query: %s", err.Error())
+		return
+	}
+
+	affect, err := res.RowsAffected()
+	if err != nil {
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "frontend"})
+		log.Errorf("error retrieving rows affected: %s", err.Error())
+		return
+	}
+	if affect != 1 {
+		data.sentry.CaptureErrorAndWait(errors.New(fmt.Sprintf("could not update sync options of calendar with UUID: %s", calendarUUID)), map[string]string{"database": "frontend"})
+		return errors.New(fmt.Sprintf("could not update sync options of calendar with UUID: %s", calendarUUID))
+	}
+	return
+}
+
 // Method that retrieves all related calendar to a given one
 func (data Database) setSynchronizedCalendars(calendar *Calendar, principal bool) (err error) {
 	var query string
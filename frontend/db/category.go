@@ -0,0 +1,69 @@
+package db
+
+import (
+	log "github.com/TetAlius/GoSyncMyCalendars/logger"
+)
+
+// Category of outlook events and the color of google events with that category, mapped from db
+type CategoryColor struct {
+	Category string
+	ColorID  string
+}
+
+// Method that retrieves the mapping between categories and colors of the user, in order of preference.
+// Returns none if the user has not set one
+func (data Database) FindCategoryColors(user *User) (colors []CategoryColor, err error) {
+	rows, err := data.client.Query("select category_colors.category, category_colors.color_id from category_colors where category_colors.user_uuid = $1 order by category_colors.position", user.UUID)
+	if err != nil {
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "frontend"})
+		log.Errorf("error selecting category colors: %s", err.Error())
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var color CategoryColor
+		err = rows.Scan(&color.Category, &color.ColorID)
+		if err != nil {
+			data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "frontend"})
+			log.Errorf("error scanning category colors: %s", err.Error())
+			return
+		}
+		colors = append(colors, color)
+	}
+	return colors, rows.Err()
+}
+
+// Method that replaces the mapping between categories and colors of the user
+func (data Database) UpdateCategoryColors(user *User, colors []CategoryColor) (err error) {
+	tx, err := data.client.Begin()
+	if err != nil {
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "frontend"})
+		log.Errorf("error starting transaction: %s", err.Error())
+		return
+	}
+	_, err = tx.Exec("delete from category_colors where category_colors.user_uuid = $1", user.UUID)
+	if err != nil {
+		tx.Rollback()
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "frontend"})
+		log.Errorf("error deleting category colors: %s", err.Error())
+		return
+	}
+	stmt, err := tx.Prepare("insert into category_colors(user_uuid, position, category, color_id) values ($1, $2, $3, $4)")
+	if err != nil {
+		tx.Rollback()
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "frontend"})
+		log.Errorf("error preparing query: %s", err.Error())
+		return
+	}
+	defer stmt.Close()
+	for position, color := range colors {
+		_, err = stmt.Exec(user.UUID, position, color.Category, color.ColorID)
+		if err != nil {
+			tx.Rollback()
+			data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "frontend"})
+			log.Errorf("error inserting category color: %s", err.Error())
+			return
+		}
+	}
+	return tx.Commit()
+}
@@ -205,9 +205,36 @@ func (data Database) DeleteCalendar(user *User, id string) (err error) {
 	return data.deleteFromUser(calendar, user)
 }
 
+// Method that relates the given calendars to a principal one and stores the sync window and options
+// of the relation, given by the calendar, on a single transaction so a failure leaves all of them as they were
+func (data Database) SaveCalendarsRelation(user *User, parentCalendarUUID string, calendarIDs []string, options Calendar) (err error) {
+	if options.SyncDaysBefore < 0 || options.SyncDaysAfter < 0 {
+		return errors.New(fmt.Sprintf("days of the sync window can not be negative: %d, %d", options.SyncDaysBefore, options.SyncDaysAfter))
+	}
+	transaction, err := data.client.Begin()
+	if err != nil {
+		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "frontend"})
+		log.Errorf("error starting transaction: %s", err.Error())
+		return
+	}
+	err = data.addCalendarsRelation(transaction, user, parentCalendarUUID, calendarIDs)
+	if err == nil {
+		err = data.updateSyncWindowFromUser(transaction, user, parentCalendarUUID, options.SyncDaysBefore, options.SyncDaysAfter, options.SyncWindowPrune)
+	}
+	if err == nil {
+		err = data.updateSyncOptionsFromUser(transaction, user, parentCalendarUUID, options.AttendeesMode, options.NotifyAttendees,
+			options.TentativeFallback, options.OutOfOfficeFallback, options.WorkingElsewhereFallback, options.AttachmentsContentTypes)
+	}
+	if err != nil {
+		transaction.Rollback()
+		return
+	}
+	return transaction.Commit()
+}
+
 // Method that creates the relations between calendars
-func (data Database) AddCalendarsRelation(user *User, parentCalendarUUID string, calendarIDs []string) (err error) {
-	stmt, err := data.client.Prepare("update calendars set (parent_calendar_uuid) = ($1) from accounts as a where calendars.uuid = $2 and calendars.account_email = a.email and a.user_uuid = $3")
+func (data Database) addCalendarsRelation(transaction *sql.Tx, user *User, parentCalendarUUID string, calendarIDs []string) (err error) {
+	stmt, err := transaction.Prepare("update calendars set (parent_calendar_uuid) = ($1) from accounts as a where calendars.uuid = $2 and calendars.account_email = a.email and a.user_uuid = $3")
 	if err != nil {
 		data.sentry.CaptureErrorAndWait(err, map[string]string{"database": "frontend"})
 		log.Errorf("error preparing query: %s", err.Error())
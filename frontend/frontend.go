@@ -21,6 +21,7 @@ import (
 
 	"os"
 
+	"github.com/TetAlius/GoSyncMyCalendars/api"
 	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
 	"github.com/TetAlius/GoSyncMyCalendars/frontend/db"
 	log "github.com/TetAlius/GoSyncMyCalendars/logger"
@@ -43,11 +44,13 @@ type Server struct {
 // Struct in which the different info that must be showed to the user
 // is stored
 type PageInfo struct {
-	PageTitle string
-	User      db.User
-	Account   db.Account
-	Calendars []db.Calendar
-	Error     string
+	PageTitle      string
+	User           db.User
+	Account        db.Account
+	Calendars      []db.Calendar
+	CategoryColors []db.CategoryColor
+	Colors         []api.GoogleEventColor
+	Error          string
 }
 
 var root string
@@ -89,16 +92,16 @@ func NewServer(ip string, port int, dir string, database *sql.DB, sentry *raven.
 	mux.Handle("/images/", imagesFileServer)
 	mux.HandleFunc("/", server.indexHandler)
 
-	mux.HandleFunc("/SignInWithGoogle", server.googleSignInHandler)
-	mux.HandleFunc("/google", server.googleTokenHandler)
+	server.handleOAuthProviders(mux)
 
-	mux.HandleFunc("/SignInWithOutlook", server.outlookSignInHandler)
-	mux.HandleFunc("/outlook", server.outlookTokenHandler)
+	mux.HandleFunc("/SignInWithCalDAV", server.caldavSignInHandler)
+	mux.HandleFunc("/AddICSFeed", server.icsFeedHandler)
 
 	mux.HandleFunc("/calendars", server.calendarListHandler)
 	mux.HandleFunc("/calendars/", server.calendarHandler)
 	mux.HandleFunc("/accounts", server.accountListHandler)
 	mux.HandleFunc("/accounts/", server.accountHandler)
+	mux.HandleFunc("/categories", server.categoriesHandler)
 	mux.HandleFunc("/user", server.userHandler)
 	server.mux = AddContext(mux)
 
@@ -299,7 +302,33 @@ func (s *Server) calendarHandler(w http.ResponseWriter, r *http.Request) {
 		calendarIDs := r.Form["calendars"]
 		log.Debugf("calendar ids: %s", calendarIDs)
 
-		err := s.database.AddCalendarsRelation(currentUser, id, calendarIDs)
+		// limits are checked before storing anything, so a wrong form changes nothing
+		daysBefore, err := api.ParseLimit(r.FormValue("days_before"))
+		if err != nil {
+			badRequest(w, err)
+			return
+		}
+		daysAfter, err := api.ParseLimit(r.FormValue("days_after"))
+		if err != nil {
+			badRequest(w, err)
+			return
+		}
+		attendees := api.AttendeesInBody
+		if r.FormValue("attendees") == "copy" {
+			attendees = api.AttendeesCopied
+		}
+		options := db.Calendar{
+			SyncDaysBefore:           daysBefore,
+			SyncDaysAfter:            daysAfter,
+			SyncWindowPrune:          r.FormValue("prune") == "on",
+			AttendeesMode:            int(attendees),
+			NotifyAttendees:          r.FormValue("notify") == "on",
+			TentativeFallback:        formFreeBusy(r.FormValue("tentative")),
+			OutOfOfficeFallback:      formFreeBusy(r.FormValue("out_of_office")),
+			WorkingElsewhereFallback: formFreeBusy(r.FormValue("working_elsewhere")),
+			AttachmentsContentTypes:  strings.Join(api.ParseContentTypes(r.FormValue("attachments_content_types")), ", "),
+		}
+		err = s.database.SaveCalendarsRelation(currentUser, id, calendarIDs, options)
 		if err != nil {
 			serverError(w, err)
 			return
@@ -342,14 +371,92 @@ func (s *Server) userHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (s *Server) categoriesHandler(w http.ResponseWriter, r *http.Request) {
+	currentUser, ok := s.manageSession(w, r)
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		r.ParseForm()
+		err := s.database.UpdateCategoryColors(currentUser, formCategoryColors(r.Form["category"], r.Form["color"]))
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+		http.Redirect(w, r, "/categories", http.StatusFound)
+		return
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	colors, err := s.database.FindCategoryColors(currentUser)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+	if len(colors) == 0 {
+		for _, color := range api.DefaultCategoryColors {
+			colors = append(colors, db.CategoryColor{Category: color.Category, ColorID: color.ColorID})
+		}
+	}
+
+	data := PageInfo{
+		PageTitle:      "Categories",
+		User:           *currentUser,
+		CategoryColors: colors,
+		Colors:         api.GoogleEventColors,
+	}
+	t, err := template.New("layout.html").Funcs(funcMap).ParseFiles(root+"/html/shared/layout.html", root+"/html/categories/list.html")
+	if err != nil {
+		log.Errorf("error parsing files: %s", err.Error())
+		serverError(w, err)
+		return
+	}
+
+	err = t.Execute(w, data)
+	if err != nil {
+		log.Errorf("error executing templates: %s", err.Error())
+		serverError(w, err)
+		return
+	}
+}
+
+// Function that returns the mapping between categories and colors given on a form.
+// Rows without category or with an unknown color are left out
+func formCategoryColors(categories []string, colorIDs []string) (colors []db.CategoryColor) {
+	for i, category := range categories {
+		category = strings.TrimSpace(category)
+		if len(category) == 0 || i >= len(colorIDs) {
+			continue
+		}
+		for _, color := range api.GoogleEventColors {
+			if color.ID == colorIDs[i] {
+				colors = append(colors, db.CategoryColor{Category: category, ColorID: color.ID})
+				break
+			}
+		}
+	}
+	return
+}
+
+// Function that parses a free/busy fallback given on a form. Anything but free is busy
+func formFreeBusy(value string) string {
+	if value == api.FreeBusyFree {
+		return api.FreeBusyFree
+	}
+	return api.FreeBusyBusy
+}
+
 func notFound(w http.ResponseWriter) {
 	t, err := template.New("layout.html").Funcs(funcMap).ParseFiles(root+"/html/shared/layout.html", root+"/html/404.html")
 	if err != nil {
 		serverError(w, err)
 		return
 	}
-	w.WriteHeader(http.StatusNotFound)
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusNotFound)
 	data := PageInfo{
 		PageTitle: "Not found :(",
 	}
@@ -359,13 +466,32 @@ func notFound(w http.ResponseWriter) {
 	}
 }
 
+// Function that shows the error of a request with values that are not valid
+func badRequest(w http.ResponseWriter, error error) {
+	t, err := template.New("layout.html").Funcs(funcMap).ParseFiles(root+"/html/shared/layout.html", root+"/html/500.html")
+	if err != nil {
+		panic(err)
+	}
+	// headers are only sent if set before the status
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusBadRequest)
+	data := PageInfo{
+		PageTitle: "Wrong values :(",
+		Error:     error.Error(),
+	}
+	err = t.Execute(w, data)
+	if err != nil {
+		log.Errorln(err)
+	}
+}
+
 func serverError(w http.ResponseWriter, error error) {
 	t, err := template.New("layout.html").Funcs(funcMap).ParseFiles(root+"/html/shared/layout.html", root+"/html/500.html")
 	if err != nil {
 		panic(err)
 	}
-	w.WriteHeader(http.StatusInternalServerError)
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
 	data := PageInfo{
 		PageTitle: "Something went wrong :(",
 		Error:     error.Error(),
@@ -1 +1,165 @@
 package frontend_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/TetAlius/GoSyncMyCalendars/frontend"
+)
+
+// Driver of a database that knows a single user and stores nothing.
+// Updates of the sync options change no calendar, so they fail
+type userDriver struct {
+	writes *int
+	ends   *[]string
+}
+
+type userConn struct {
+	writes *int
+	ends   *[]string
+}
+
+type userStmt struct {
+	query  string
+	writes *int
+}
+
+type userTx struct {
+	ends *[]string
+}
+
+type userRows struct {
+	done bool
+}
+
+func (d userDriver) Open(name string) (driver.Conn, error) {
+	return userConn{writes: d.writes, ends: d.ends}, nil
+}
+
+func (c userConn) Prepare(query string) (driver.Stmt, error) {
+	return userStmt{query: query, writes: c.writes}, nil
+}
+
+func (c userConn) Close() error {
+	return nil
+}
+
+func (c userConn) Begin() (driver.Tx, error) {
+	if c.ends == nil {
+		return nil, errors.New("transactions not supported")
+	}
+	return userTx{ends: c.ends}, nil
+}
+
+func (tx userTx) Commit() error {
+	*tx.ends = append(*tx.ends, "commit")
+	return nil
+}
+
+func (tx userTx) Rollback() error {
+	*tx.ends = append(*tx.ends, "rollback")
+	return nil
+}
+
+func (s userStmt) Close() error {
+	return nil
+}
+
+func (s userStmt) NumInput() int {
+	return -1
+}
+
+func (s userStmt) Exec(args []driver.Value) (driver.Result, error) {
+	*s.writes++
+	if strings.Contains(s.query, "attendees_mode") {
+		return driver.RowsAffected(0), nil
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s userStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if !strings.Contains(s.query, "from users") {
+		*s.writes++
+	}
+	return &userRows{}, nil
+}
+
+func (r *userRows) Columns() []string {
+	return []string{"uuid", "name", "email"}
+}
+
+func (r *userRows) Close() error {
+	return nil
+}
+
+func (r *userRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	dest[1] = "Travis"
+	dest[2] = "travis@example.com"
+	return nil
+}
+
+func TestCalendarHandler_NegativeLimits(t *testing.T) {
+	var writes int
+	sql.Register("frontend-user", userDriver{writes: &writes})
+	database, err := sql.Open("frontend-user", "")
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	server := frontend.NewServer("127.0.0.1", 0, "resources", database, nil)
+
+	for _, field := range []string{"days_before", "days_after"} {
+		form := url.Values{"calendars": {"calendar"}, field: {"-1"}}
+		req := httptest.NewRequest(http.MethodPost, "/calendars/principal", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: "session", Value: "6ba7b810-9dad-11d1-80b4-00c04fd430c8"})
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("something went wrong. Expected status %d for negative %s found %d", http.StatusBadRequest, field, w.Code)
+		}
+		if contentType := w.Header().Get("Content-Type"); contentType != "text/html; charset=utf-8" {
+			t.Fatalf("something went wrong. Expected HTML content type found %s", contentType)
+		}
+	}
+	// nothing is stored from the wrong forms
+	if writes != 0 {
+		t.Fatalf("something went wrong. Expected no writes on DB found %d", writes)
+	}
+}
+
+func TestCalendarHandler_RelationRolledBack(t *testing.T) {
+	var writes int
+	var ends []string
+	sql.Register("frontend-relation", userDriver{writes: &writes, ends: &ends})
+	database, err := sql.Open("frontend-relation", "")
+	if err != nil {
+		t.Fatalf("something went wrong. Expected nil found error: %s", err.Error())
+	}
+	server := frontend.NewServer("127.0.0.1", 0, "resources", database, nil)
+
+	form := url.Values{"calendars": {"6ba7b811-9dad-11d1-80b4-00c04fd430c8"}, "days_before": {"30"}, "days_after": {"30"}}
+	req := httptest.NewRequest(http.MethodPost, "/calendars/principal", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "6ba7b810-9dad-11d1-80b4-00c04fd430c8"})
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("something went wrong. Expected status %d found %d", http.StatusInternalServerError, w.Code)
+	}
+	// the relation and the sync window written before the failure are rolled back with it
+	if writes != 3 || len(ends) != 1 || ends[0] != "rollback" {
+		t.Fatalf("something went wrong. Expected 3 writes rolled back found %d writes ended with %v", writes, ends)
+	}
+}
@@ -0,0 +1,137 @@
+package frontend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/TetAlius/GoSyncMyCalendars/api"
+	"github.com/TetAlius/GoSyncMyCalendars/frontend/db"
+	log "github.com/TetAlius/GoSyncMyCalendars/logger"
+	"github.com/TetAlius/GoSyncMyCalendars/util"
+)
+
+// Method that adds the handlers of the OAuth authorization of all providers that use it
+func (s *Server) handleOAuthProviders(mux *http.ServeMux) {
+	for _, provider := range api.Providers() {
+		if provider.OAuth == nil {
+			continue
+		}
+		mux.HandleFunc(provider.OAuth.SignInPath, s.oauthSignInHandler(provider))
+		mux.HandleFunc(provider.OAuth.RedirectPath, s.oauthTokenHandler(provider))
+	}
+}
+
+// Method that returns the handler that redirects to the login of the provider
+func (s *Server) oauthSignInHandler(provider *api.Provider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debugf("Starting %s petition", provider.Name)
+		route, err := util.GetRoute(provider.OAuth.LoginRoute)
+		if err != nil {
+			log.Errorf("Error generating URL: %s", err.Error())
+			serverError(w, err)
+			return
+		}
+		http.Redirect(w, r, route, http.StatusFound)
+	}
+}
+
+// Method that returns the handler that exchanges the code given by the provider
+// for the tokens of the account, and stores the account
+func (s *Server) oauthTokenHandler(provider *api.Provider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if len(query.Get("error")) > 0 {
+			log.Errorf("%s authorization with error: %s", provider.Name, query.Get("error"))
+			http.Redirect(w, r, "/accounts", http.StatusPermanentRedirect)
+			return
+		}
+		currentUser, ok := s.manageSession(w, r)
+		if !ok {
+			return
+		}
+		route, err := util.GetRoute(provider.OAuth.TokenRoute)
+		log.Debugln(route)
+		if err != nil {
+			log.Errorf("error generating URL: %s", err.Error())
+			serverError(w, err)
+			return
+		}
+		// TODO: Know how to send state
+		//state := query.Get("state")
+
+		code := query.Get("code")
+		params, err := util.GetParamsRoute(provider.OAuth.RequestParamsRoute, code)
+		if err != nil {
+			log.Errorf("error generating URL: %s", err.Error())
+			serverError(w, err)
+			return
+		}
+
+		client := &http.Client{
+			Timeout: time.Second * 30,
+		}
+		req, err := http.NewRequest("POST",
+			route,
+			strings.NewReader(params))
+
+		if err != nil {
+			log.Errorf("error creating new %s request: %s", provider.Name, err.Error())
+			serverError(w, err)
+			return
+		}
+
+		req.Header.Set("Content-Type",
+			"application/x-www-form-urlencoded")
+
+		// the exchange is cancelled if the user goes away
+		resp, err := client.Do(req.WithContext(r.Context()))
+		if err != nil {
+			log.Errorf("error doing %s request: %s", provider.Name, err.Error())
+			serverError(w, err)
+			return
+		}
+
+		defer resp.Body.Close()
+		contents, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			log.Errorf("error reading response body from %s request: %s", provider.Name, err.Error())
+			serverError(w, err)
+			return
+		}
+
+		//TODO: DB to implement
+		var objmap map[string]interface{}
+		err = json.Unmarshal(contents, &objmap)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		// preferred is ignored
+		email, _, err := util.MailFromToken(strings.Split(objmap["id_token"].(string), "."))
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+		acc := db.Account{
+			User:         currentUser,
+			TokenType:    objmap["token_type"].(string),
+			RefreshToken: objmap["refresh_token"].(string),
+			Email:        email,
+			AccessToken:  objmap["access_token"].(string),
+			Kind:         provider.Kind,
+		}
+		id, err := s.database.AddAccount(currentUser, acc)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+
+		//This is so that users cannot read the response
+		http.Redirect(w, r, fmt.Sprintf("/accounts/%d", id), http.StatusPermanentRedirect)
+	}
+}
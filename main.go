@@ -12,6 +12,7 @@ import (
 	"github.com/TetAlius/GoSyncMyCalendars/backend"
 	"github.com/TetAlius/GoSyncMyCalendars/frontend"
 	"github.com/TetAlius/GoSyncMyCalendars/logger"
+	"github.com/TetAlius/GoSyncMyCalendars/util"
 	"github.com/getsentry/raven-go"
 )
 
@@ -51,6 +52,10 @@ func init() {
 		log.Fatalf("missing ORIGIN variable")
 		missing = true
 	}
+	if len(os.Getenv("CREDENTIALS_KEY")) <= 0 {
+		log.Fatalf("missing CREDENTIALS_KEY variable")
+		missing = true
+	}
 	if missing {
 		os.Exit(1)
 	}
@@ -65,6 +70,14 @@ func main() {
 	sentry.SetEnvironment(os.Getenv("ENVIRONMENT"))
 	sentry.SetRelease(os.Getenv("RELEASE"))
 
+	if routes := os.Getenv("ROUTES_FILE"); len(routes) > 0 {
+		err = util.LoadRoutes(routes)
+		if err != nil {
+			logger.Errorf("error loading routes: %s", err.Error())
+			os.Exit(1)
+		}
+	}
+
 	dbInfo := fmt.Sprintf("host=%s user=%s password=%s dbname=%s sslmode=disable",
 		host, user, password, name)
 	frontendDB, err := sql.Open("postgres", dbInfo)
@@ -0,0 +1,2 @@
+-- Token used to retrieve only the events changed since the last synchronization
+ALTER TABLE calendars ADD COLUMN IF NOT EXISTS sync_token TEXT NOT NULL DEFAULT '';
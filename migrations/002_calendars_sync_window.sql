@@ -0,0 +1,6 @@
+-- Window of days around the current date whose events are synchronized.
+-- It is stored on the principal calendar of the relation, 0 does not limit that side
+ALTER TABLE calendars ADD COLUMN IF NOT EXISTS sync_days_before INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE calendars ADD COLUMN IF NOT EXISTS sync_days_after INTEGER NOT NULL DEFAULT 0;
+-- Whether the synced events that fall out of the window are removed
+ALTER TABLE calendars ADD COLUMN IF NOT EXISTS sync_window_prune BOOLEAN NOT NULL DEFAULT FALSE;
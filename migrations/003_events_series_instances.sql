@@ -0,0 +1,4 @@
+-- Instances of a recurring series changed or cancelled on their own are stored along
+-- the row of their series master on the same calendar, and the start they had inside it
+ALTER TABLE events ADD COLUMN IF NOT EXISTS series_internal_id INTEGER REFERENCES events(internal_id) ON DELETE CASCADE;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS original_start TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,3 @@
+-- How the attendees are written on the synced events of the relation:
+-- 0 lists them on the description, 1 copies them as attendees
+ALTER TABLE calendars ADD COLUMN IF NOT EXISTS attendees_mode INTEGER NOT NULL DEFAULT 0;
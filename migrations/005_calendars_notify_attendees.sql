@@ -0,0 +1,3 @@
+-- Whether the attendees of the synced events of the relation are notified of
+-- the writes of the synchronization. They are not by default
+ALTER TABLE calendars ADD COLUMN IF NOT EXISTS notify_attendees BOOLEAN NOT NULL DEFAULT FALSE;
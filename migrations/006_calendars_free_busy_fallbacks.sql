@@ -0,0 +1,5 @@
+-- Status, free or busy, written for the tentative, out of office and working
+-- elsewhere events of the relation on calendars that do not have those statuses
+ALTER TABLE calendars ADD COLUMN IF NOT EXISTS tentative_fallback VARCHAR(10) NOT NULL DEFAULT 'busy';
+ALTER TABLE calendars ADD COLUMN IF NOT EXISTS out_of_office_fallback VARCHAR(10) NOT NULL DEFAULT 'busy';
+ALTER TABLE calendars ADD COLUMN IF NOT EXISTS working_elsewhere_fallback VARCHAR(10) NOT NULL DEFAULT 'free';
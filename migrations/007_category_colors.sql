@@ -0,0 +1,9 @@
+-- Mapping of each user between the categories of outlook and the colors of
+-- google, in order of preference. Users without rows use the default mapping
+CREATE TABLE IF NOT EXISTS category_colors (
+  user_uuid UUID         NOT NULL REFERENCES users (uuid) ON DELETE CASCADE,
+  position  INTEGER      NOT NULL,
+  category  VARCHAR(255) NOT NULL,
+  color_id  VARCHAR(2)   NOT NULL,
+  PRIMARY KEY (user_uuid, position)
+);
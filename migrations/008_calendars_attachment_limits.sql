@@ -0,0 +1,4 @@
+-- Limits of the attachments linked from the synced events of the relation:
+-- the content types synced separated by commas, like application/pdf or image/*,
+-- empty for all of them
+ALTER TABLE calendars ADD COLUMN IF NOT EXISTS attachments_content_types VARCHAR(255) NOT NULL DEFAULT '';
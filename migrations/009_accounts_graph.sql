@@ -0,0 +1,12 @@
+-- Outlook accounts are moved to the Microsoft Graph provider. The events keep
+-- their IDs, but the delta links of the outlook endpoints are not valid on
+-- graph, so their calendars are synchronized again from the start
+UPDATE calendars SET sync_token = '' WHERE account_email IN (SELECT email FROM accounts WHERE kind = 2);
+-- Outlook subscriptions are not known by graph. They keep their type and are
+-- marked as expired, so the backend subscribes them again on graph as soon as
+-- it starts. The outlook subscriptions are not removed, as the tokens of graph
+-- are not valid on the outlook endpoints: they expire on their own, and their
+-- notifications are ignored once their IDs are replaced
+UPDATE subscriptions SET expiration_date = current_date
+WHERE calendar_uuid IN (SELECT calendars.uuid FROM calendars JOIN accounts ON calendars.account_email = accounts.email WHERE accounts.kind = 2);
+UPDATE accounts SET kind = 5 WHERE kind = 2;
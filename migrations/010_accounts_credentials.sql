@@ -0,0 +1,9 @@
+-- Accounts without fixed servers, like CalDAV ones, keep the URL of their
+-- server and their credential, encrypted with the CREDENTIALS_KEY variable,
+-- on their own columns instead of the ones of the tokens
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS server_url VARCHAR(1024) NOT NULL DEFAULT '';
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS credential TEXT NOT NULL DEFAULT '';
+UPDATE accounts SET server_url = refresh_token WHERE kind = 3;
+-- The credentials of CalDAV accounts were stored without encryption, so they
+-- are removed and the accounts must be added again to sync their calendars
+UPDATE accounts SET refresh_token = '', access_token = '' WHERE kind = 3;
@@ -0,0 +1,235 @@
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Request written inside a batch request
+type BatchRequest struct {
+	Method string
+	// URL used to do the request on its own
+	URL    string
+	Params map[string]string
+	// Body given as JSON, nil if the request has none
+	Body []byte
+}
+
+// Response of a request written inside a batch request
+type BatchResponse struct {
+	Status   int
+	Contents []byte
+	// Typed error of the response, nil if the request succeeded
+	Err error
+	// If the request can succeed doing it again
+	Retry bool
+}
+
+// Body of a JSON batch request, like the $batch of graph
+type jsonBatch struct {
+	Requests []jsonBatchRequest `json:"requests"`
+}
+
+// Request inside a JSON batch request
+type jsonBatchRequest struct {
+	ID      string            `json:"id"`
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// Body of the response of a JSON batch request
+type jsonBatchResult struct {
+	Responses []jsonBatchResponse `json:"responses"`
+}
+
+// Response inside the response of a JSON batch request
+type jsonBatchResponse struct {
+	ID      string            `json:"id"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// Function that does the requests given as a single multipart/mixed batch request, as google
+// and outlook take them. Each part has a request with the path of its URL. The responses are
+// returned in the same order as the requests
+func DoMultipartBatchContext(ctx context.Context, batchURL string, headers map[string]string, requests []BatchRequest) (responses []BatchResponse, err error) {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	for i, request := range requests {
+		requestURL, err := batchRequestURL(request)
+		if err != nil {
+			return nil, err
+		}
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type": {"application/http"},
+			"Content-ID":   {fmt.Sprintf("<item-%d>", i+1)},
+		})
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("error writing batch request: %s", err.Error()))
+		}
+		fmt.Fprintf(part, "%s %s HTTP/1.1\r\nHost: %s\r\n", request.Method, requestURL.RequestURI(), requestURL.Host)
+		if request.Body != nil {
+			fmt.Fprintf(part, "Content-Type: application/json\r\nContent-Length: %d\r\n\r\n", len(request.Body))
+			part.Write(request.Body)
+		} else {
+			fmt.Fprint(part, "\r\n")
+		}
+	}
+	writer.Close()
+
+	batchHeaders := map[string]string{"Content-Type": "multipart/mixed; boundary=" + writer.Boundary()}
+	for key, value := range headers {
+		batchHeaders[key] = value
+	}
+	contents, _, header, err := DefaultProviderClient.DoContext(ctx, http.MethodPost, batchURL, body, batchHeaders, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		return nil, errors.New(fmt.Sprintf("batch response is not multipart: %s", header.Get("Content-Type")))
+	}
+	responses = make([]BatchResponse, len(requests))
+	answered := make([]bool, len(requests))
+	reader := multipart.NewReader(bytes.NewReader(contents), params["boundary"])
+	for position := 0; ; position++ {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+		// responses are matched by the ID given to their request, or else by their position
+		i := batchItemIndex(part.Header.Get("Content-ID"), position)
+		if i < 0 || i >= len(requests) {
+			continue
+		}
+		response, err := http.ReadResponse(bufio.NewReader(part), nil)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("error reading batch response: %s", err.Error()))
+		}
+		data, err := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("error reading batch response: %s", err.Error()))
+		}
+		responses[i] = batchResponse(requests[i], response.StatusCode, response.Header, data)
+		answered[i] = true
+	}
+	notAnswered(requests, responses, answered)
+	return
+}
+
+// Function that does the requests given as a single JSON batch request, as graph takes them.
+// URLs of the requests are given relative to the one of the batch. The responses are
+// returned in the same order as the requests
+func DoJSONBatchContext(ctx context.Context, batchURL string, headers map[string]string, requests []BatchRequest) (responses []BatchResponse, err error) {
+	root := batchURL[:strings.LastIndex(batchURL, "/")]
+	batch := jsonBatch{}
+	for i, request := range requests {
+		requestURL, err := batchRequestURL(request)
+		if err != nil {
+			return nil, err
+		}
+		item := jsonBatchRequest{ID: strconv.Itoa(i + 1), Method: request.Method, URL: strings.TrimPrefix(requestURL.String(), root)}
+		if request.Body != nil {
+			item.Headers = map[string]string{"Content-Type": "application/json"}
+			item.Body = request.Body
+		}
+		batch.Requests = append(batch.Requests, item)
+	}
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error marshalling batch request: %s", err.Error()))
+	}
+	contents, _, _, err := DefaultProviderClient.DoContext(ctx, http.MethodPost, batchURL, bytes.NewReader(data), headers, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := new(jsonBatchResult)
+	err = json.Unmarshal(contents, result)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error unmarshalling batch response: %s", err.Error()))
+	}
+	responses = make([]BatchResponse, len(requests))
+	answered := make([]bool, len(requests))
+	for position, response := range result.Responses {
+		i := batchItemIndex(response.ID, position)
+		if i < 0 || i >= len(requests) {
+			continue
+		}
+		header := make(http.Header)
+		for key, value := range response.Headers {
+			header.Set(key, value)
+		}
+		responses[i] = batchResponse(requests[i], response.Status, header, response.Body)
+		answered[i] = true
+	}
+	notAnswered(requests, responses, answered)
+	return
+}
+
+// Function that returns the URL of a request inside a batch, with its params
+func batchRequestURL(request BatchRequest) (requestURL *url.URL, err error) {
+	requestURL, err = url.Parse(request.URL)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error parsing url of batch request %s: %s", request.URL, err.Error()))
+	}
+	if len(request.Params) > 0 {
+		q := requestURL.Query()
+		for key, value := range request.Params {
+			q.Add(key, value)
+		}
+		requestURL.RawQuery = q.Encode()
+	}
+	return
+}
+
+// Function that returns the position of the request of a response given its ID, like
+// "<response-item-2>" or "2". Responses without ID are taken in the position they come
+func batchItemIndex(ID string, position int) int {
+	ID = strings.Trim(ID, "<>")
+	if len(ID) == 0 {
+		return position
+	}
+	ID = strings.TrimPrefix(strings.TrimPrefix(ID, "response-"), "item-")
+	i, err := strconv.Atoi(ID)
+	if err != nil {
+		return -1
+	}
+	return i - 1
+}
+
+// Function that returns the response of a request inside a batch with its typed error
+func batchResponse(request BatchRequest, status int, header http.Header, contents []byte) BatchResponse {
+	retry, err := classifyResponse(request.Method, request.URL, status, header, contents)
+	return BatchResponse{Status: status, Contents: contents, Err: err, Retry: retry}
+}
+
+// Function that gives an error to the requests that had no response inside the batch.
+// They are only repeated if doing them twice does not change the result
+func notAnswered(requests []BatchRequest, responses []BatchResponse, answered []bool) {
+	for i, ok := range answered {
+		if !ok {
+			responses[i] = BatchResponse{
+				Err:   errors.New(fmt.Sprintf("no response inside batch for %s %s", requests[i].Method, requests[i].URL)),
+				Retry: idempotentMethods[requests[i].Method],
+			}
+		}
+	}
+}
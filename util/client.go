@@ -0,0 +1,235 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TetAlius/GoSyncMyCalendars/customErrors"
+	log "github.com/TetAlius/GoSyncMyCalendars/logger"
+)
+
+// Client used for the requests to the providers. Responses with an error status are
+// returned as typed errors, and the ones that can succeed later are retried, waiting
+// what the provider asks on Retry-After or an exponential backoff with jitter
+type ProviderClient struct {
+	// Client that does each attempt
+	HTTPClient *http.Client
+	// Attempts done after the first one
+	MaxRetries int
+	// Wait before the first retry, doubled on each retry
+	BaseDelay time.Duration
+	// Longest wait between attempts. If the provider asks for a longer one the error is returned
+	MaxDelay time.Duration
+}
+
+// Client used by all providers
+var DefaultProviderClient = NewProviderClient()
+
+// Methods that can be repeated without changing the result.
+// Updates set whole fields, so repeating them gives the same event
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+}
+
+// Function that returns a ProviderClient with the default retries
+func NewProviderClient() *ProviderClient {
+	return &ProviderClient{
+		HTTPClient: &http.Client{Timeout: time.Second * 30},
+		MaxRetries: 4,
+		BaseDelay:  time.Second,
+		MaxDelay:   time.Minute,
+	}
+}
+
+// Function that does a request to a provider with the default client
+func DoProviderRequest(method string, url string, body io.Reader, headers map[string]string, params map[string]string) (contents []byte, err error) {
+	return DoProviderRequestContext(context.Background(), method, url, body, headers, params)
+}
+
+// Function like DoProviderRequest that stops the request and its retries once the given context is done
+func DoProviderRequestContext(ctx context.Context, method string, url string, body io.Reader, headers map[string]string, params map[string]string) (contents []byte, err error) {
+	contents, _, _, err = DefaultProviderClient.DoContext(ctx, method, url, body, headers, params)
+	return
+}
+
+// Function that does a request to a provider with the default client, also returning
+// the status code and the headers of the response
+func DoProviderRawRequest(method string, url string, body io.Reader, headers map[string]string, params map[string]string) (contents []byte, status int, header http.Header, err error) {
+	return DefaultProviderClient.DoContext(context.Background(), method, url, body, headers, params)
+}
+
+// Function like DoProviderRawRequest that stops the request and its retries once the given context is done
+func DoProviderRawRequestContext(ctx context.Context, method string, url string, body io.Reader, headers map[string]string, params map[string]string) (contents []byte, status int, header http.Header, err error) {
+	return DefaultProviderClient.DoContext(ctx, method, url, body, headers, params)
+}
+
+// Function that returns the error of a request to a provider with the message given.
+// Errors of the responses are returned as they are, so callers can check their type
+func RequestError(err error, message string) error {
+	if _, ok := err.(customErrors.ResponseError); ok {
+		return err
+	}
+	return errors.New(fmt.Sprintf("%s. %s", message, err.Error()))
+}
+
+// Method that does a request by the info given, retrying it while the provider answers
+// with an error that can succeed later. The contents of the last response are returned
+// along with its error
+func (client *ProviderClient) Do(method string, url string, body io.Reader, headers map[string]string, params map[string]string) (contents []byte, status int, header http.Header, err error) {
+	return client.DoContext(context.Background(), method, url, body, headers, params)
+}
+
+// Method like Do that stops once the given context is done, cancelling the attempt
+// in flight or the wait before the next one
+func (client *ProviderClient) DoContext(ctx context.Context, method string, url string, body io.Reader, headers map[string]string, params map[string]string) (contents []byte, status int, header http.Header, err error) {
+	// the body is read once so it can be sent again on each attempt
+	var data []byte
+	if body != nil {
+		data, err = ioutil.ReadAll(body)
+		if err != nil {
+			return nil, 0, nil, errors.New(fmt.Sprintf("error reading request body: %s", err.Error()))
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		contents, status, header, retry, err = client.attempt(ctx, method, url, data, body != nil, headers, params)
+		if err == nil || !retry || attempt >= client.MaxRetries || ctx.Err() != nil {
+			return
+		}
+		wait := client.backoff(attempt)
+		if rateLimit, ok := err.(*customErrors.RateLimitError); ok && rateLimit.RetryAfter > 0 {
+			if rateLimit.RetryAfter > client.MaxDelay {
+				return
+			}
+			wait = rateLimit.RetryAfter
+		}
+		log.Warningf("retrying %s %s in %s after attempt %d: %s", method, url, wait, attempt+1, err.Error())
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return contents, status, header, errors.New(fmt.Sprintf("request %s %s cancelled: %s. %s", method, url, ctx.Err().Error(), err.Error()))
+		case <-timer.C:
+		}
+	}
+}
+
+// Method that does a single attempt of a request, returning if it can be retried
+func (client *ProviderClient) attempt(ctx context.Context, method string, url string, data []byte, hasBody bool, headers map[string]string, params map[string]string) (contents []byte, status int, header http.Header, retry bool, err error) {
+	var body io.Reader
+	if hasBody {
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, 0, nil, false, errors.New(fmt.Sprintf("error creating new request: %s", err.Error()))
+	}
+	req = req.WithContext(ctx)
+
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	// If body is given and no Content-Type was set, has to put a content-Type json on the header
+	if hasBody && len(req.Header.Get("Content-Type")) == 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if len(params) > 0 {
+		q := req.URL.Query()
+		for key, value := range params {
+			q.Add(key, value)
+		}
+		req.URL.RawQuery = q.Encode()
+	}
+
+	resp, err := client.HTTPClient.Do(req)
+	if err != nil {
+		// the request may have arrived, so it is only repeated if that does not change the result
+		return nil, 0, nil, idempotentMethods[method], errors.New(fmt.Sprintf("error doing request: %s", err.Error()))
+	}
+	defer resp.Body.Close()
+
+	contents, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return contents, resp.StatusCode, resp.Header, idempotentMethods[method], errors.New(fmt.Sprintf("error reading response body: %s", err.Error()))
+	}
+
+	retry, err = classifyResponse(method, url, resp.StatusCode, resp.Header, contents)
+	return contents, resp.StatusCode, resp.Header, retry, err
+}
+
+// Function that returns the typed error of a response and if the request can be retried
+func classifyResponse(method string, url string, status int, header http.Header, contents []byte) (retry bool, err error) {
+	message := fmt.Sprintf("%s %s answered %d: %s", method, url, status, bytes.TrimSpace(contents))
+	switch {
+	case status < http.StatusBadRequest:
+		return false, nil
+	case status == http.StatusUnauthorized:
+		return false, &customErrors.UnauthorizedError{Message: message}
+	case status == http.StatusTooManyRequests,
+		status == http.StatusForbidden && isRateLimited(contents):
+		// the request was rejected before doing anything, so it can always be repeated
+		return true, &customErrors.RateLimitError{Status: status, Message: message, RetryAfter: retryAfter(header)}
+	case status == http.StatusNotFound:
+		return false, &customErrors.NotFoundError{Message: message}
+	case status == http.StatusConflict || status == http.StatusPreconditionFailed:
+		return false, &customErrors.ConflictError{Status: status, Message: message}
+	case status >= http.StatusInternalServerError:
+		// unavailable services did not process the request
+		retry = idempotentMethods[method] || status == http.StatusServiceUnavailable
+		return retry, &customErrors.ServerError{Status: status, Message: message}
+	default:
+		return false, &customErrors.StatusError{Status: status, Message: message}
+	}
+}
+
+// Function that returns if the body of a forbidden response is because of the rate limits,
+// like the rateLimitExceeded and userRateLimitExceeded reasons of google
+func isRateLimited(contents []byte) bool {
+	return strings.Contains(strings.ToLower(string(contents)), "ratelimitexceeded")
+}
+
+// Function that returns the wait asked by the Retry-After header, given in seconds or as a date
+func retryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if len(value) == 0 {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(time.Now()) {
+		return time.Until(date)
+	}
+	return 0
+}
+
+// Method that returns the wait before the retry after the given attempt:
+// a random time between the half and the whole of the exponential backoff
+func (client *ProviderClient) backoff(attempt int) time.Duration {
+	delay := client.BaseDelay << uint(attempt)
+	if delay <= 0 || delay > client.MaxDelay {
+		delay = client.MaxDelay
+	}
+	half := int64(delay / 2)
+	if half <= 0 {
+		return delay
+	}
+	return time.Duration(half + rand.Int63n(half+1))
+}
@@ -0,0 +1,66 @@
+package util
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Variable with the secret used to encrypt the credentials stored on DB
+const credentialsKeyVariable = "CREDENTIALS_KEY"
+
+// Function that encrypts a credential, like the password of a CalDAV account, so it is not
+// stored as it is. It is returned in base64, with the nonce used before the encrypted data
+func EncryptCredential(credential string) (encrypted string, err error) {
+	aead, err := credentialsCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("error generating nonce: %s", err.Error()))
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(credential), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Function that decrypts a credential encrypted by EncryptCredential
+func DecryptCredential(encrypted string) (credential string, err error) {
+	aead, err := credentialsCipher()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("error decoding credential: %s", err.Error()))
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("credential too short to be decrypted")
+	}
+	data, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("error decrypting credential: %s", err.Error()))
+	}
+	return string(data), nil
+}
+
+// Function that returns the AES-GCM cipher of the credentials.
+// The key is derived from the secret with SHA-256, so a secret of any length can be given
+func credentialsCipher() (aead cipher.AEAD, err error) {
+	secret := os.Getenv(credentialsKeyVariable)
+	if len(secret) == 0 {
+		return nil, errors.New(fmt.Sprintf("missing %s variable to encrypt the credentials", credentialsKeyVariable))
+	}
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error creating cipher: %s", err.Error()))
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,59 @@
+package util_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/TetAlius/GoSyncMyCalendars/util"
+)
+
+func TestCredentials(t *testing.T) {
+	key := os.Getenv("CREDENTIALS_KEY")
+	defer os.Setenv("CREDENTIALS_KEY", key)
+	os.Setenv("CREDENTIALS_KEY", "credentials-secret")
+	credential := "user@example.com:pass:word"
+
+	encrypted, err := util.EncryptCredential(credential)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected no error found %s", err.Error())
+	}
+	if encrypted == credential {
+		t.Fatalf("something went wrong. Expected credential encrypted found %s", encrypted)
+	}
+	other, err := util.EncryptCredential(credential)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected no error found %s", err.Error())
+	}
+	if other == encrypted {
+		t.Fatalf("something went wrong. Expected a different nonce for each encryption found %s twice", encrypted)
+	}
+
+	decrypted, err := util.DecryptCredential(encrypted)
+	if err != nil {
+		t.Fatalf("something went wrong. Expected no error found %s", err.Error())
+	}
+	if decrypted != credential {
+		t.Fatalf("something went wrong. Expected %s found %s", credential, decrypted)
+	}
+
+	_, err = util.DecryptCredential("not a credential")
+	if err == nil {
+		t.Fatalf("something went wrong. Expected error decoding credential found nil")
+	}
+
+	os.Setenv("CREDENTIALS_KEY", "other-secret")
+	_, err = util.DecryptCredential(encrypted)
+	if err == nil {
+		t.Fatalf("something went wrong. Expected error decrypting with other key found nil")
+	}
+
+	os.Setenv("CREDENTIALS_KEY", "")
+	_, err = util.EncryptCredential(credential)
+	if err == nil {
+		t.Fatalf("something went wrong. Expected error without key found nil")
+	}
+	_, err = util.DecryptCredential(encrypted)
+	if err == nil {
+		t.Fatalf("something went wrong. Expected error without key found nil")
+	}
+}
@@ -0,0 +1,203 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Route of the API of a provider: a path relative to the base URL of the provider,
+// or, without base, the params of a request
+type route struct {
+	base     string
+	template string
+}
+
+// Base URLs of the APIs of the providers
+var defaultBases = map[string]string{
+	"google":          "https://www.googleapis.com/calendar/v3",
+	"google-accounts": "https://accounts.google.com/o/oauth2/v2",
+	"google-token":    "https://www.googleapis.com/oauth2/v4",
+	"google-batch":    "https://www.googleapis.com/batch/calendar/v3",
+	"outlook":         "https://outlook.office.com/api/v2.0",
+	"graph":           "https://graph.microsoft.com/v1.0",
+	"microsoft-login": "https://login.microsoftonline.com/common/oauth2/v2.0",
+}
+
+// Scopes asked for the accounts of each provider, already escaped
+const (
+	googleScopes  = "openid%20email%20https%3A%2F%2Fwww.googleapis.com%2Fauth%2Fcalendar"
+	outlookScopes = "openid%20email%20offline_access%20https%3A%2F%2Foutlook.office.com%2Fcalendars.readwrite"
+	graphScopes   = "openid%20email%20offline_access%20https%3A%2F%2Fgraph.microsoft.com%2FCalendars.ReadWrite"
+)
+
+// Routes used by the providers. Templates are formatted with the IDs of each request,
+// and the variables of the environment written as ${NAME} are replaced by their escaped value
+var defaultRoutes = map[string]route{
+	"google/login":                            {"google-accounts", "/auth?client_id=${GOOGLE_CLIENT_ID}&redirect_uri=${ENDPOINT}%2Fgoogle&response_type=code&access_type=offline&prompt=consent&scope=" + googleScopes},
+	"google/token/uri":                        {"google-token", "/token"},
+	"google/token/request-params":             {"", "client_id=${GOOGLE_CLIENT_ID}&client_secret=${GOOGLE_CLIENT_SECRET}&redirect_uri=${ENDPOINT}%2Fgoogle&grant_type=authorization_code&code=%s"},
+	"google/token/refresh-params":             {"", "client_id=${GOOGLE_CLIENT_ID}&client_secret=${GOOGLE_CLIENT_SECRET}&grant_type=refresh_token&refresh_token=%s"},
+	"google/calendar-list":                    {"google", "/users/me/calendarList"},
+	"google/calendars":                        {"google", "/calendars"},
+	"google/calendars/id":                     {"google", "/users/me/calendarList/%s"},
+	"google/calendars/primary":                {"google", "/calendars/primary"},
+	"google/calendars/id/events":              {"google", "/calendars/%s/events"},
+	"google/calendars/id/events/id":           {"google", "/calendars/%s/events/%s"},
+	"google/calendars/id/events/id/instances": {"google", "/calendars/%s/events/%s/instances"},
+	"google/calendars/subscription":           {"google", "/calendars/%s/events/watch"},
+	"google/calendars/subscription/stop":      {"google", "/channels/stop"},
+	"google/batch":                            {"google-batch", ""},
+
+	"outlook/token/uri":                       {"microsoft-login", "/token"},
+	"outlook/token/refresh-params":            {"", "client_id=${MICROSOFT_CLIENT_ID}&client_secret=${MICROSOFT_CLIENT_SECRET}&grant_type=refresh_token&scope=" + outlookScopes + "&refresh_token=%s"},
+	"outlook/calendars":                       {"outlook", "/me/calendars"},
+	"outlook/calendars/id":                    {"outlook", "/me/calendars/%s"},
+	"outlook/calendars/primary":               {"outlook", "/me/calendar"},
+	"outlook/calendars/id/events":             {"outlook", "/me/calendars/%s/events"},
+	"outlook/calendars/id/calendarview":       {"outlook", "/me/calendars/%s/calendarview"},
+	"outlook/calendars/id/calendarview/delta": {"outlook", "/me/calendars/%s/calendarview/delta"},
+	"outlook/events/id":                       {"outlook", "/me/events/%s"},
+	"outlook/events/id/instances":             {"outlook", "/me/events/%s/instances"},
+	"outlook/events/id/attachments":           {"outlook", "/me/events/%s/attachments"},
+	"outlook/subscription":                    {"outlook", "/me/subscriptions"},
+	"outlook/batch":                           {"outlook", "/$batch"},
+
+	"graph/login":                           {"microsoft-login", "/authorize?client_id=${MICROSOFT_CLIENT_ID}&redirect_uri=${ENDPOINT}%2Foutlook&response_type=code&response_mode=query&scope=" + graphScopes},
+	"graph/token/uri":                       {"microsoft-login", "/token"},
+	"graph/token/request-params":            {"", "client_id=${MICROSOFT_CLIENT_ID}&client_secret=${MICROSOFT_CLIENT_SECRET}&redirect_uri=${ENDPOINT}%2Foutlook&grant_type=authorization_code&scope=" + graphScopes + "&code=%s"},
+	"graph/token/refresh-params":            {"", "client_id=${MICROSOFT_CLIENT_ID}&client_secret=${MICROSOFT_CLIENT_SECRET}&grant_type=refresh_token&scope=" + graphScopes + "&refresh_token=%s"},
+	"graph/calendars":                       {"graph", "/me/calendars"},
+	"graph/calendars/id":                    {"graph", "/me/calendars/%s"},
+	"graph/calendars/primary":               {"graph", "/me/calendar"},
+	"graph/calendars/id/events":             {"graph", "/me/calendars/%s/events"},
+	"graph/calendars/id/calendarview":       {"graph", "/me/calendars/%s/calendarView"},
+	"graph/calendars/id/calendarview/delta": {"graph", "/me/calendars/%s/calendarView/delta"},
+	"graph/events/id":                       {"graph", "/me/events/%s"},
+	"graph/events/id/instances":             {"graph", "/me/events/%s/instances"},
+	"graph/events/id/attachments":           {"graph", "/me/events/%s/attachments"},
+	"graph/subscription":                    {"graph", "/subscriptions"},
+	"graph/batch":                           {"graph", "/$batch"},
+}
+
+// Overrides of the base URLs and of whole routes, given by a config file or by the tests
+var (
+	routesMutex    sync.RWMutex
+	baseOverrides  = make(map[string]string)
+	routeOverrides = make(map[string]string)
+)
+
+// Variables of the environment used inside the templates
+var routeVariable = regexp.MustCompile(`\$\{([A-Za-z0-9_]+)\}`)
+
+// Config file that overrides the routes
+type RoutesConfig struct {
+	// Base URLs by provider, like "graph": "http://localhost:8080/v1.0"
+	Bases map[string]string `json:"bases"`
+	// Whole routes by name, like "graph/subscription": "http://localhost:8080/subscriptions"
+	Routes map[string]string `json:"routes"`
+}
+
+// Function that returns the route with the given name, to be formatted with the IDs of the request
+func GetRoute(name string) (string, error) {
+	routesMutex.RLock()
+	defer routesMutex.RUnlock()
+	if template, ok := routeOverrides[name]; ok {
+		return expandRoute(template), nil
+	}
+	r, ok := defaultRoutes[name]
+	if !ok {
+		return "", errors.New(fmt.Sprintf("no route with name: %s", name))
+	}
+	if len(r.base) == 0 {
+		return expandRoute(r.template), nil
+	}
+	base, ok := baseOverrides[r.base]
+	if !ok {
+		base = defaultBases[r.base]
+	}
+	return expandRoute(base + r.template), nil
+}
+
+// Function that returns the params route with the given name with the value given, like the code
+// or the refresh token, escaped on its place. The value is not formatted with fmt, as the escaped
+// characters of the route would be taken as verbs
+func GetParamsRoute(name string, value string) (string, error) {
+	params, err := GetRoute(name)
+	if err != nil {
+		return "", err
+	}
+	if !strings.Contains(params, "%s") {
+		return "", errors.New(fmt.Sprintf("no place for the value on params route with name: %s", name))
+	}
+	return strings.Replace(params, "%s", url.QueryEscape(value), 1), nil
+}
+
+// Function that replaces the variables of the environment inside a route with their escaped value
+func expandRoute(template string) string {
+	return routeVariable.ReplaceAllStringFunc(template, func(variable string) string {
+		return url.QueryEscape(os.Getenv(routeVariable.FindStringSubmatch(variable)[1]))
+	})
+}
+
+// Function that reads the JSON config file given and overrides the routes with it
+func LoadRoutes(path string) (err error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.New(fmt.Sprintf("error reading routes file %s: %s", path, err.Error()))
+	}
+	config := new(RoutesConfig)
+	err = json.Unmarshal(contents, config)
+	if err != nil {
+		return errors.New(fmt.Sprintf("error parsing routes file %s: %s", path, err.Error()))
+	}
+	for base, baseURL := range config.Bases {
+		if _, ok := defaultBases[base]; !ok {
+			return errors.New(fmt.Sprintf("no base with name: %s on routes file %s", base, path))
+		}
+		SetBaseURL(base, baseURL)
+	}
+	for name, template := range config.Routes {
+		if _, ok := defaultRoutes[name]; !ok {
+			return errors.New(fmt.Sprintf("no route with name: %s on routes file %s", name, path))
+		}
+		SetRoute(name, template)
+	}
+	return
+}
+
+// Function that overrides the base URL of a provider, used by all its routes
+func SetBaseURL(base string, baseURL string) {
+	routesMutex.Lock()
+	defer routesMutex.Unlock()
+	baseOverrides[base] = baseURL
+}
+
+// Function that overrides a whole route
+func SetRoute(name string, template string) {
+	routesMutex.Lock()
+	defer routesMutex.Unlock()
+	routeOverrides[name] = template
+}
+
+// Function that removes all overrides, going back to the routes of the providers
+func ResetRoutes() {
+	routesMutex.Lock()
+	defer routesMutex.Unlock()
+	baseOverrides = make(map[string]string)
+	routeOverrides = make(map[string]string)
+}
+
+// Function that returns the names of the bases of the routes
+func RouteBases() (bases []string) {
+	for base := range defaultBases {
+		bases = append(bases, base)
+	}
+	return
+}
@@ -0,0 +1,53 @@
+package util_test
+
+import (
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/TetAlius/GoSyncMyCalendars/util"
+)
+
+func TestGetParamsRoute(t *testing.T) {
+	os.Setenv("ENDPOINT", "https://localhost:8081")
+	os.Setenv("GOOGLE_CLIENT_ID", "google-id")
+	os.Setenv("GOOGLE_CLIENT_SECRET", "google%secret")
+	os.Setenv("MICROSOFT_CLIENT_ID", "microsoft-id")
+	os.Setenv("MICROSOFT_CLIENT_SECRET", "microsoft:secret/%20")
+	// values that look like escaped text, or like verbs of fmt
+	value := "4/0A%3A%s+token=="
+
+	routes := map[string]map[string]string{
+		"google/token/request-params": {"client_id": "google-id", "client_secret": "google%secret", "redirect_uri": "https://localhost:8081/google", "grant_type": "authorization_code", "code": value},
+		"google/token/refresh-params": {"client_id": "google-id", "client_secret": "google%secret", "grant_type": "refresh_token", "refresh_token": value},
+		"outlook/token/refresh-params": {"client_id": "microsoft-id", "client_secret": "microsoft:secret/%20", "grant_type": "refresh_token", "refresh_token": value,
+			"scope": "openid email offline_access https://outlook.office.com/calendars.readwrite"},
+		"graph/token/request-params": {"client_id": "microsoft-id", "client_secret": "microsoft:secret/%20", "redirect_uri": "https://localhost:8081/outlook", "grant_type": "authorization_code", "code": value,
+			"scope": "openid email offline_access https://graph.microsoft.com/Calendars.ReadWrite"},
+		"graph/token/refresh-params": {"client_id": "microsoft-id", "client_secret": "microsoft:secret/%20", "grant_type": "refresh_token", "refresh_token": value,
+			"scope": "openid email offline_access https://graph.microsoft.com/Calendars.ReadWrite"},
+	}
+	for name, expected := range routes {
+		params, err := util.GetParamsRoute(name, value)
+		if err != nil {
+			t.Fatalf("something went wrong. Expected no error on %s found %s", name, err.Error())
+		}
+		query, err := url.ParseQuery(params)
+		if err != nil {
+			t.Fatalf("something went wrong. Expected params of %s found %s with error %s", name, params, err.Error())
+		}
+		if len(query) != len(expected) {
+			t.Fatalf("something went wrong. Expected %d params on %s found %s", len(expected), name, params)
+		}
+		for key, value := range expected {
+			if query.Get(key) != value {
+				t.Fatalf("something went wrong. Expected %s=%s on %s found %s", key, value, name, query.Get(key))
+			}
+		}
+	}
+
+	_, err := util.GetParamsRoute("google/calendars", value)
+	if err == nil {
+		t.Fatalf("something went wrong. Expected error on route without place for the value found nil")
+	}
+}
@@ -1,6 +1,7 @@
 package util
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -8,7 +9,6 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
-	"os"
 	"reflect"
 	"strings"
 
@@ -64,51 +64,40 @@ func MailFromToken(tokens []string) (email string, preferred bool, err error) {
 	return
 }
 
-// Function that calls AWS API Gateway in order to retrieve the request URI
-func CallAPIRoot(route string) (apiRoute string, err error) {
-	root := os.Getenv("API_ROOT")
-	if len(root) == 0 {
-		return "", errors.New("API_ROOT not given on environment")
-	}
-	client := &http.Client{
-		Timeout: time.Second * 30,
-	}
-	req, err := http.NewRequest("GET", root+route, nil)
-
-	if err != nil {
-		return "", errors.New(fmt.Sprintf("Error creating API request: %s", err.Error()))
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", errors.New(fmt.Sprintf("Error doing API request: %s", err.Error()))
-	}
+// Function that manages all requests by the info given
+func DoRequest(method string, url string, body io.Reader, headers map[string]string, params map[string]string) (contents []byte, err error) {
+	return DoRequestContext(context.Background(), method, url, body, headers, params)
+}
 
-	defer resp.Body.Close()
-	contents, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return "", errors.New(fmt.Sprintf("Error reading response body from API request: %s", err.Error()))
-	}
+// Function like DoRequest that cancels the request once the given context is done
+func DoRequestContext(ctx context.Context, method string, url string, body io.Reader, headers map[string]string, params map[string]string) (contents []byte, err error) {
+	contents, _, _, err = DoRawRequestContext(ctx, method, url, body, headers, params)
+	return
+}
 
-	return strings.Replace(string(contents[:]), "\"", "", -1), nil
+// Function that manages all requests by the info given, also returning
+// the status code and the headers of the response
+func DoRawRequest(method string, url string, body io.Reader, headers map[string]string, params map[string]string) (contents []byte, status int, header http.Header, err error) {
+	return DoRawRequestContext(context.Background(), method, url, body, headers, params)
 }
 
-// Function that manages all requests by the info given
-func DoRequest(method string, url string, body io.Reader, headers map[string]string, params map[string]string) (contents []byte, err error) {
+// Function like DoRawRequest that cancels the request once the given context is done
+func DoRawRequestContext(ctx context.Context, method string, url string, body io.Reader, headers map[string]string, params map[string]string) (contents []byte, status int, header http.Header, err error) {
 	client := &http.Client{
 		Timeout: time.Second * 30,
 	}
 	req, err := http.NewRequest(method, url, body)
 	if err != nil {
-		return contents, errors.New(fmt.Sprintf("error creating new request: %s", err.Error()))
+		return contents, 0, nil, errors.New(fmt.Sprintf("error creating new request: %s", err.Error()))
 	}
+	req = req.WithContext(ctx)
 
 	for key, value := range headers {
 		req.Header.Set(key, value)
 	}
 
-	// If body is given, has to put a content-Type json on the header
-	if body != nil {
+	// If body is given and no Content-Type was set, has to put a content-Type json on the header
+	if body != nil && len(req.Header.Get("Content-Type")) == 0 {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
@@ -122,16 +111,16 @@ func DoRequest(method string, url string, body io.Reader, headers map[string]str
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return contents, errors.New(fmt.Sprintf("error doing request: %s", err.Error()))
+		return contents, 0, nil, errors.New(fmt.Sprintf("error doing request: %s", err.Error()))
 	}
 
-	log.Warningf("RESPONSE CODE: %s", resp.StatusCode)
+	log.Warningf("RESPONSE CODE: %d", resp.StatusCode)
 	defer resp.Body.Close()
 	//TODO parse errors and content
 	contents, err = ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return contents, errors.New(fmt.Sprintf("error reading response body: %s", err.Error()))
+		return contents, resp.StatusCode, resp.Header, errors.New(fmt.Sprintf("error reading response body: %s", err.Error()))
 	}
 
-	return
+	return contents, resp.StatusCode, resp.Header, nil
 }
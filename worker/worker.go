@@ -1,13 +1,16 @@
 package worker
 
 import (
+	"context"
 	"fmt"
 
 	"reflect"
+	"sync"
+
+	"time"
 
 	"github.com/TetAlius/GoSyncMyCalendars/api"
 	"github.com/TetAlius/GoSyncMyCalendars/backend/db"
-	"github.com/TetAlius/GoSyncMyCalendars/convert"
 	log "github.com/TetAlius/GoSyncMyCalendars/logger"
 )
 
@@ -16,24 +19,62 @@ const (
 	stateQuit
 )
 
+// Longest time given to synchronize an event with one of its relations
+const jobTimeout = 5 * time.Minute
+
 // Object that manages the different kinds of synchronization
 type Worker struct {
-	Events   chan api.EventManager
-	state    int
-	database db.Database
+	Events chan api.EventManager
+	// events synchronized together, writing the ones of the same calendar with batch requests
+	Batches chan []api.EventManager
+	state   int
+	// lock of the state, so no job is sent once the channels are closed
+	stateMutex sync.RWMutex
+	database   db.Database
+	// context of all synchronizations, cancelled when the worker or its server stops
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
-// Function that returns a new worker from given info
-func New(maxWorkers int, database db.Database) (worker *Worker) {
-	worker = &Worker{Events: make(chan api.EventManager), state: stateInitial, database: database}
+// Function that returns a new worker from given info.
+// Its synchronizations are cancelled once the given context is done or the worker stops
+func New(ctx context.Context, maxWorkers int, database db.Database) (worker *Worker) {
+	worker = &Worker{Events: make(chan api.EventManager), Batches: make(chan []api.EventManager), state: stateInitial, database: database}
+	worker.ctx, worker.cancel = context.WithCancel(ctx)
 	return
 }
 
 // Method that returns whether the channel is closed
 func (worker *Worker) IsClosed() bool {
+	worker.stateMutex.RLock()
+	defer worker.stateMutex.RUnlock()
 	return worker.state == stateQuit
 }
 
+// Method that sends an event to be synchronized by the worker.
+// Returns false if the worker is stopped and the event was not sent
+func (worker *Worker) Send(event api.EventManager) bool {
+	worker.stateMutex.RLock()
+	defer worker.stateMutex.RUnlock()
+	if worker.state == stateQuit {
+		return false
+	}
+	worker.Events <- event
+	return true
+}
+
+// Method that sends several events to be synchronized by the worker as a single job.
+// Returns false if the worker is stopped and the events were not sent
+func (worker *Worker) SendBatch(events []api.EventManager) bool {
+	worker.stateMutex.RLock()
+	defer worker.stateMutex.RUnlock()
+	if worker.state == stateQuit {
+		return false
+	}
+	worker.Batches <- events
+	return true
+}
+
 // Method that starts the processing of the worker
 func (worker *Worker) Start() {
 	worker.Process()
@@ -41,23 +82,37 @@ func (worker *Worker) Start() {
 
 // Method that stops the processing of the worker
 func (worker *Worker) Stop() (err error) {
-	worker.state = stateQuit
 	log.Debugln("closing workers")
+	// requests in flight are cancelled instead of waiting for the providers
+	worker.cancel()
+	// jobs being sent are received before the channels are closed
+	worker.stateMutex.Lock()
+	defer worker.stateMutex.Unlock()
+	worker.state = stateQuit
 	close(worker.Events)
+	close(worker.Batches)
 	log.Debugln("close workers")
 	return
 }
 
 // Method that process all requests of sync
 func (worker *Worker) Process() {
-	var event api.EventManager
-	for worker.state != stateQuit {
-		event = <-worker.Events
-		worker.processSynchronization(event)
-
-	}
-	for event = range worker.Events {
-		worker.processSynchronization(event)
+	events, batches := worker.Events, worker.Batches
+	for events != nil || batches != nil {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			worker.processSynchronization(event)
+		case batch, ok := <-batches:
+			if !ok {
+				batches = nil
+				continue
+			}
+			worker.processBatch(batch)
+		}
 	}
 }
 
@@ -74,11 +129,66 @@ func (err SynchronizeError) Error() string {
 
 // Method that process a specific request of sync
 func (worker *Worker) processSynchronization(event api.EventManager) {
-	if event.GetState() == api.Updated && worker.database.EventAlreadyUpdated(event) {
+	if !worker.prepareSynchronization(event) {
 		return
 	}
+	for _, toSync := range event.GetRelations() {
+		err := worker.synchronizeJob(event, toSync)
+		worker.manageError(event, toSync, err)
+	}
+	return
+}
+
+// Method that process several requests of sync at once. Events synced with the same calendar
+// are written together, and the result of each one is stored on its relation
+func (worker *Worker) processBatch(events []api.EventManager) {
+	ctx, cancel := context.WithTimeout(worker.ctx, jobTimeout)
+	defer cancel()
+	from := make(map[int][]api.EventManager)
+	to := make(map[int][]api.EventManager)
+	refreshed := make(map[string]bool)
+	for _, event := range events {
+		if !worker.prepareSynchronization(event) {
+			continue
+		}
+		for _, toSync := range event.GetRelations() {
+			if event.GetState() == api.Deleted && !worker.database.ExistsEvent(toSync) {
+				continue
+			}
+			account := toSync.GetCalendar().GetAccount()
+			if !refreshed[account.Mail()] {
+				refreshed[account.Mail()] = true
+				account.RefreshContext(ctx)
+				go worker.database.UpdateAccount(account)
+			}
+			from[event.GetState()] = append(from[event.GetState()], event)
+			to[event.GetState()] = append(to[event.GetState()], toSync)
+		}
+	}
+	for _, state := range []int{api.Created, api.Updated, api.Deleted} {
+		errs := api.SyncEvents(ctx, state, from[state], to[state])
+		for i, err := range errs {
+			if err != nil {
+				worker.manageError(from[state][i], to[state][i], err)
+				continue
+			}
+			// the event is already written, so only its storing is done again
+			worker.manageSaveError(from[state][i], to[state][i], worker.saveSynchronization(from[state][i], to[state][i]))
+		}
+	}
+}
+
+// Method that checks if the event has to be synchronized and stores its change on DB
+func (worker *Worker) prepareSynchronization(event api.EventManager) bool {
+	if event.GetState() == api.Updated && worker.database.EventAlreadyUpdated(event) {
+		return false
+	}
 	if event.GetState() == api.Deleted && !worker.database.ExistsEvent(event) {
-		return
+		return false
+	}
+	if event.GetState() != api.Deleted && !event.GetCalendar().GetSyncWindow().ContainsEvent(event, time.Now()) {
+		log.Debugf("event: %s out of sync window, ignoring it", event.GetID())
+		return false
 	}
 	switch event.GetState() {
 	case api.Created:
@@ -88,48 +198,100 @@ func (worker *Worker) processSynchronization(event api.EventManager) {
 	case api.Deleted:
 		worker.database.DeleteEvent(event)
 	}
-	for _, toSync := range event.GetRelations() {
-		err := toSync.GetCalendar().GetAccount().Refresh()
-		go worker.database.UpdateAccount(toSync.GetCalendar().GetAccount())
-		err = worker.synchronizeEvents(event, toSync)
-		if err != nil && reflect.TypeOf(err).Kind() != reflect.TypeOf(SynchronizeError{}).Kind() {
-			go func() {
-				for toSync.CanProcessAgain() {
-					toSync.IncrementBackoff()
-					err := worker.synchronizeEvents(event, toSync)
-					if err != nil {
-						continue
-					} else {
-						//Synchronized correctly
-						break
-					}
+	return true
+}
+
+// Method that manages the error synchronizing an event with another one,
+// trying it again later if it can succeed
+func (worker *Worker) manageError(event api.EventManager, toSync api.EventManager, err error) {
+	switch err.(type) {
+	case nil:
+		return
+	case api.ReadOnlyError, api.RecurrenceError:
+		// read-only calendars are a one-way source and recurrences that can not be
+		// expressed on the other calendar will not change, trying again will not help
+		log.Warningf("event: %s not synchronized: %s", event.GetID(), err.Error())
+		return
+	}
+	if reflect.TypeOf(err).Kind() != reflect.TypeOf(SynchronizeError{}).Kind() {
+		go func() {
+			for toSync.CanProcessAgain() && worker.ctx.Err() == nil {
+				toSync.IncrementBackoff()
+				err := worker.synchronizeJob(event, toSync)
+				if err != nil {
+					continue
+				} else {
+					//Synchronized correctly
+					break
 				}
-			}()
-		}
+			}
+		}()
+	}
+}
 
+// Method that manages the error storing on DB the synchronization of an event already written,
+// storing it again later without writing the event another time
+func (worker *Worker) manageSaveError(event api.EventManager, toSync api.EventManager, err error) {
+	if err == nil {
+		return
 	}
-	return
+	log.Errorf("error storing synchronization of event: %s, from event: %s: %s", toSync.GetID(), event.GetID(), err.Error())
+	go func() {
+		for toSync.CanProcessAgain() && worker.ctx.Err() == nil {
+			toSync.IncrementBackoff()
+			if worker.saveSynchronization(event, toSync) == nil {
+				break
+			}
+		}
+	}()
+}
+
+// Method that refreshes the account of the event to sync and synchronizes both events,
+// cancelling the requests if they take longer than jobTimeout or the worker stops
+func (worker *Worker) synchronizeJob(from api.EventManager, to api.EventManager) (err error) {
+	ctx, cancel := context.WithTimeout(worker.ctx, jobTimeout)
+	defer cancel()
+	to.GetCalendar().GetAccount().RefreshContext(ctx)
+	go worker.database.UpdateAccount(to.GetCalendar().GetAccount())
+	return worker.synchronizeEvents(ctx, from, to)
 }
 
 // Method that synchronize to events. If the request gets here, all database checks have passed
-func (worker *Worker) synchronizeEvents(from api.EventManager, to api.EventManager) (err error) {
+func (worker *Worker) synchronizeEvents(ctx context.Context, from api.EventManager, to api.EventManager) (err error) {
 	switch from.GetState() {
 	case api.Created:
-		err = worker.createEvent(from, to)
+		err = worker.createEvent(ctx, from, to)
 	case api.Updated:
-		err = worker.updateEvent(from, to)
+		err = worker.updateEvent(ctx, from, to)
 	case api.Deleted:
-		err = worker.deleteEvent(from, to)
+		err = worker.deleteEvent(ctx, from, to)
 	default:
 		return SynchronizeError{State: from.GetState(), ID: from.GetID()}
 	}
 	return
 }
 
+// Method that stores on DB the relation of two events already synchronized
+func (worker *Worker) saveSynchronization(from api.EventManager, to api.EventManager) (err error) {
+	switch from.GetState() {
+	case api.Created:
+		return worker.database.SaveEventsRelation(from, to)
+	case api.Updated:
+		return worker.database.UpdateModificationDate(to)
+	case api.Deleted:
+		return worker.database.DeleteEvent(to)
+	}
+	return SynchronizeError{State: from.GetState(), ID: from.GetID()}
+}
+
 // Method that manages an update
-func (worker *Worker) updateEvent(from api.EventManager, to api.EventManager) (err error) {
-	convert.Convert(from, to)
-	err = to.Update()
+func (worker *Worker) updateEvent(ctx context.Context, from api.EventManager, to api.EventManager) (err error) {
+	err = api.ConvertEventContext(ctx, from, to)
+	if err != nil {
+		log.Errorf("error converting event: %s, from event: %s", to.GetID(), from.GetID())
+		return err
+	}
+	err = to.UpdateContext(ctx)
 	if err != nil {
 		log.Errorf("error updating event: %s, from event: %s", to.GetID(), from.GetID())
 		return err
@@ -139,9 +301,13 @@ func (worker *Worker) updateEvent(from api.EventManager, to api.EventManager) (e
 }
 
 // Method that manages a creation
-func (worker *Worker) createEvent(from api.EventManager, to api.EventManager) (err error) {
-	convert.Convert(from, to)
-	err = to.Create()
+func (worker *Worker) createEvent(ctx context.Context, from api.EventManager, to api.EventManager) (err error) {
+	err = api.ConvertEventContext(ctx, from, to)
+	if err != nil {
+		log.Errorf("error converting event from event: %s", from.GetID())
+		return err
+	}
+	err = to.CreateContext(ctx)
 	if err != nil {
 		log.Errorf("error updating event: %s, from event: %s", to.GetID(), from.GetID())
 		return err
@@ -152,11 +318,11 @@ func (worker *Worker) createEvent(from api.EventManager, to api.EventManager) (e
 }
 
 // Method that manages a deletion
-func (worker *Worker) deleteEvent(from api.EventManager, to api.EventManager) (err error) {
+func (worker *Worker) deleteEvent(ctx context.Context, from api.EventManager, to api.EventManager) (err error) {
 	if !worker.database.ExistsEvent(to) {
 		return nil
 	}
-	err = to.Delete()
+	err = api.DeleteEventContext(ctx, from, to)
 	if err != nil {
 		log.Errorf("error updating event: %s, from event: %s", to.GetID(), from.GetID())
 		return err